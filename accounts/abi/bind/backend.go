@@ -39,6 +39,10 @@ var (
 	// This error is returned by WaitDeployed if contract creation leaves an
 	// empty contract behind.
 	ErrNoCodeAfterDeploy = errors.New("no contract code after deployment")
+
+	// This error is returned by WaitDeployed if the receipt of the contract
+	// creation reports a failed execution status (e.g. a reverted constructor).
+	ErrTransactionFailed = errors.New("transaction execution failed")
 )
 
 // ContractCaller defines the methods needed to allow operating with contract on a read
@@ -29,6 +29,10 @@ import (
 
 // WaitMined waits for tx to be mined on the blockchain.
 // It stops waiting when the context is canceled.
+//
+// Being mined does not imply successful execution: receipts of transactions
+// included after the Byzantium fork carry a Status field which callers should
+// check against types.ReceiptStatusSuccessful.
 func WaitMined(ctx context.Context, b DeployBackend, tx *types.Transaction) (*types.Receipt, error) {
 	queryTicker := time.NewTicker(1 * time.Second)
 	defer queryTicker.Stop()
@@ -65,6 +69,10 @@ func WaitDeployed(ctx context.Context, b DeployBackend, tx *types.Transaction) (
 	if receipt.ContractAddress == (common.Address{}) {
 		return common.Address{}, fmt.Errorf("zero address")
 	}
+	// Post-Byzantium receipts report whether the constructor ran successfully
+	if len(receipt.PostState) == 0 && receipt.Status == types.ReceiptStatusFailed {
+		return receipt.ContractAddress, ErrTransactionFailed
+	}
 	// Check that code has indeed been deployed at the address.
 	// This matters on pre-Homestead chains: OOG in the constructor
 	// could leave an empty account behind.
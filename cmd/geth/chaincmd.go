@@ -17,7 +17,10 @@
 package main
 
 import (
+	"bufio"
+	"encoding/json"
 	"fmt"
+	"math/big"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -31,11 +34,12 @@ import (
 	"github.com/daxxcoin/daxxcore/core"
 	"github.com/daxxcoin/daxxcore/core/state"
 	"github.com/daxxcoin/daxxcore/core/types"
+	"github.com/daxxcoin/daxxcore/crypto"
 	"github.com/daxxcoin/daxxcore/daxxdb"
 	"github.com/daxxcoin/daxxcore/logger"
 	"github.com/daxxcoin/daxxcore/logger/glog"
+	"github.com/daxxcoin/daxxcore/params"
 	"github.com/daxxcoin/daxxcore/trie"
-	"github.com/syndtr/goleveldb/leveldb/util"
 	"gopkg.in/urfave/cli.v1"
 )
 
@@ -95,6 +99,26 @@ TODO: Please write this
 TODO: Please write this
 `,
 	}
+	dumpCommandStartFlag = cli.StringFlag{
+		Name:  "start",
+		Usage: "Account address or address hash to start the dump at",
+	}
+	dumpCommandLimitFlag = cli.Uint64Flag{
+		Name:  "limit",
+		Usage: "Maximum number of accounts to dump (0 = unlimited)",
+	}
+	dumpCommandNoCodeFlag = cli.BoolFlag{
+		Name:  "nocode",
+		Usage: "Exclude contract code from the dump",
+	}
+	dumpCommandNoStorageFlag = cli.BoolFlag{
+		Name:  "nostorage",
+		Usage: "Exclude storage entries from the dump",
+	}
+	dumpCommandOnlyWithStorageFlag = cli.BoolFlag{
+		Name:  "onlywithstorage",
+		Usage: "Only dump accounts with non-empty storage",
+	}
 	dumpCommand = cli.Command{
 		Action:    dump,
 		Name:      "dump",
@@ -104,6 +128,31 @@ TODO: Please write this
 		Description: `
 The arguments are interpreted as block numbers or hashes.
 Use "daxxcoin dump 0" to dump the genesis block.
+
+The state is streamed as JSON lines ordered by the hash of the account addresses:
+a first line with the state root, followed by one line per account. If the dump
+stops at --limit, a last line holds the hash of the next account, which can be
+passed to --start to continue.
+`,
+		Flags: []cli.Flag{
+			dumpCommandStartFlag,
+			dumpCommandLimitFlag,
+			dumpCommandNoCodeFlag,
+			dumpCommandNoStorageFlag,
+			dumpCommandOnlyWithStorageFlag,
+		},
+	}
+	forksCommand = cli.Command{
+		Action:    forks,
+		Name:      "forks",
+		Usage:     "Print the fork schedule of the chain",
+		ArgsUsage: " ",
+		Category:  "BLOCKCHAIN COMMANDS",
+		Description: `
+The forks command prints the chain configuration the node would run with, listing
+every protocol fork along with its activation block and whether the local chain
+has already passed it. Pending configuration upgrades requiring a rewind of the
+local chain are reported too.
 `,
 	}
 )
@@ -116,20 +165,26 @@ func initGenesis(ctx *cli.Context) error {
 		utils.Fatalf("must supply path to genesis JSON file")
 	}
 
-	stack := makeFullNode(ctx)
-	chaindb := utils.MakeChainDatabase(ctx, stack)
-
 	genesisFile, err := os.Open(genesisPath)
 	if err != nil {
 		utils.Fatalf("failed to read genesis file: %v", err)
 	}
 	defer genesisFile.Close()
 
-	block, err := core.WriteGenesisBlock(chaindb, genesisFile)
+	genesis := new(core.Genesis)
+	if err := json.NewDecoder(genesisFile).Decode(genesis); err != nil {
+		utils.Fatalf("invalid genesis file: %v", err)
+	}
+
+	stack := makeFullNode(ctx)
+	chaindb := utils.MakeChainDatabase(ctx, stack)
+	defer chaindb.Close()
+
+	_, hash, err := core.SetupGenesisBlock(chaindb, genesis)
 	if err != nil {
 		utils.Fatalf("failed to write genesis block: %v", err)
 	}
-	glog.V(logger.Info).Infof("successfully wrote genesis block and/or chain rule set: %x", block.Hash())
+	glog.V(logger.Info).Infof("successfully wrote genesis block and/or chain rule set: %x", hash)
 	return nil
 }
 
@@ -161,12 +216,11 @@ func importChain(ctx *cli.Context) error {
 	if err := utils.ImportChain(chain, ctx.Args().First()); err != nil {
 		utils.Fatalf("Import error: %v", err)
 	}
+	chain.Stop()
 	fmt.Printf("Import done in %v.\n\n", time.Since(start))
 
 	// Output pre-compaction stats mostly to see the import trashing
-	db := chainDb.(*ethdb.LDBDatabase)
-
-	stats, err := db.LDB().GetProperty("leveldb.stats")
+	stats, err := chainDb.Stat("leveldb.stats")
 	if err != nil {
 		utils.Fatalf("Failed to read database stats: %v", err)
 	}
@@ -186,12 +240,12 @@ func importChain(ctx *cli.Context) error {
 	// Compact the entire database to more accurately measure disk io and print the stats
 	start = time.Now()
 	fmt.Println("Compacting entire database...")
-	if err = db.LDB().CompactRange(util.Range{}); err != nil {
+	if err = chainDb.Compact(nil, nil); err != nil {
 		utils.Fatalf("Compaction failed: %v", err)
 	}
 	fmt.Printf("Compaction done in %v.\n\n", time.Since(start))
 
-	stats, err = db.LDB().GetProperty("leveldb.stats")
+	stats, err = chainDb.Stat("leveldb.stats")
 	if err != nil {
 		utils.Fatalf("Failed to read database stats: %v", err)
 	}
@@ -281,6 +335,7 @@ func upgradeDB(ctx *cli.Context) error {
 	chain, chainDb = utils.MakeChain(ctx, stack)
 	core.WriteBlockChainVersion(chainDb, core.BlockChainVersion)
 	err := utils.ImportChain(chain, exportFile)
+	chain.Stop()
 	chainDb.Close()
 	if err != nil {
 		utils.Fatalf("Import error %v (a backup is made in %s, use the import command to import it)", err, exportFile)
@@ -292,7 +347,9 @@ func upgradeDB(ctx *cli.Context) error {
 }
 
 func dbDirectory(db ethdb.Database) string {
-	ldb, ok := db.(*ethdb.LDBDatabase)
+	ldb, ok := db.(interface {
+		Path() string
+	})
 	if !ok {
 		return ""
 	}
@@ -302,6 +359,27 @@ func dbDirectory(db ethdb.Database) string {
 func dump(ctx *cli.Context) error {
 	stack := makeFullNode(ctx)
 	chain, chainDb := utils.MakeChain(ctx, stack)
+	defer chainDb.Close()
+
+	conf := state.DumpConfig{
+		SkipCode:        ctx.Bool(dumpCommandNoCodeFlag.Name),
+		SkipStorage:     ctx.Bool(dumpCommandNoStorageFlag.Name),
+		OnlyWithStorage: ctx.Bool(dumpCommandOnlyWithStorageFlag.Name),
+		Max:             ctx.Uint64(dumpCommandLimitFlag.Name),
+	}
+	if start := ctx.String(dumpCommandStartFlag.Name); start != "" {
+		switch blob := common.FromHex(start); len(blob) {
+		case common.AddressLength:
+			conf.Start = crypto.Keccak256(blob)
+		case common.HashLength:
+			conf.Start = blob
+		default:
+			utils.Fatalf("Invalid --%s, want address or hash: %q", dumpCommandStartFlag.Name, start)
+		}
+	}
+	out := bufio.NewWriter(os.Stdout)
+	defer out.Flush()
+
 	for _, arg := range ctx.Args() {
 		var block *types.Block
 		if hashish(arg) {
@@ -311,17 +389,74 @@ func dump(ctx *cli.Context) error {
 			block = chain.GetBlockByNumber(uint64(num))
 		}
 		if block == nil {
-			fmt.Println("{}")
 			utils.Fatalf("block not found")
-		} else {
-			state, err := state.New(block.Root(), chainDb)
-			if err != nil {
-				utils.Fatalf("could not create new state: %v", err)
-			}
-			fmt.Printf("%s\n", state.Dump())
+		}
+		statedb, err := chain.StateAt(block.Root())
+		if err != nil {
+			utils.Fatalf("could not create new state: %v", err)
+		}
+		if _, err := statedb.IterativeDump(conf, out); err != nil {
+			utils.Fatalf("could not dump state: %v", err)
 		}
 	}
-	chainDb.Close()
+	return nil
+}
+
+// forks prints the effective fork schedule of the configured chain.
+func forks(ctx *cli.Context) error {
+	stack := makeFullNode(ctx)
+	chainDb := utils.MakeChainDatabase(ctx, stack)
+	defer chainDb.Close()
+
+	config, genesis, err := core.SetupGenesisBlock(chainDb, utils.MakeGenesis(ctx))
+	compat, _ := err.(*params.ConfigCompatError)
+	if err != nil && compat == nil {
+		utils.Fatalf("Could not load chain configuration: %v", err)
+	}
+	var head uint64
+	if hash := core.GetHeadHeaderHash(chainDb); hash != (common.Hash{}) {
+		if header := core.GetHeader(chainDb, hash, core.GetBlockNumber(chainDb, hash)); header != nil {
+			head = header.Number.Uint64()
+		}
+	}
+	engine := "daxxhash"
+	if config.Clique != nil {
+		engine = fmt.Sprintf("clique (period %d, epoch %d)", config.Clique.Period, config.Clique.Epoch)
+	}
+	fmt.Printf("Genesis:   %x\n", genesis)
+	fmt.Printf("Chain ID:  %v\n", config.ChainId)
+	fmt.Printf("Consensus: %s\n", engine)
+	fmt.Printf("Head:      #%d\n\n", head)
+
+	dao := "DAO (opposed)"
+	if config.DAOForkSupport {
+		dao = "DAO (supported)"
+	}
+	schedule := []struct {
+		name  string
+		block *big.Int
+	}{
+		{"Homestead", config.HomesteadBlock},
+		{dao, config.DAOForkBlock},
+		{"EIP150", config.EIP150Block},
+		{"EIP155", config.EIP155Block},
+		{"EIP158", config.EIP158Block},
+		{"Byzantium", config.ByzantiumBlock},
+		{"Constantinople", config.ConstantinopleBlock},
+	}
+	for _, fork := range schedule {
+		switch {
+		case fork.block == nil:
+			fmt.Printf("%-16s %12s  disabled\n", fork.name, "-")
+		case fork.block.Uint64() <= head:
+			fmt.Printf("%-16s %12v  active\n", fork.name, fork.block)
+		default:
+			fmt.Printf("%-16s %12v  pending\n", fork.name, fork.block)
+		}
+	}
+	if compat != nil {
+		fmt.Printf("\nWARNING: %v\nThe chain will be rewound to block #%d on the next start.\n", compat, compat.RewindTo)
+	}
 	return nil
 }
 
@@ -0,0 +1,206 @@
+// Copyright 2017 The daxxcoreAuthors
+// This file is part of daxxCore.
+//
+// daxxcoreis free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// daxxcoreis distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with daxxCore. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/daxxcoin/daxxcore/cmd/utils"
+	"github.com/daxxcoin/daxxcore/common"
+	"github.com/daxxcoin/daxxcore/common/hexutil"
+	"github.com/daxxcoin/daxxcore/core"
+	"github.com/daxxcoin/daxxcore/daxxdb"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	dbCommand = cli.Command{
+		Name:      "db",
+		Usage:     "Low level chain database operations",
+		ArgsUsage: "",
+		Category:  "BLOCKCHAIN COMMANDS",
+		Description: `
+The db commands operate directly on the key-value store of the chain database,
+bypassing the blockchain logic. They are meant for diagnosing and maintaining
+the database of a stopped node; modifying entries may corrupt the chain.
+`,
+		Subcommands: []cli.Command{
+			{
+				Action:    dbInspect,
+				Name:      "inspect",
+				Usage:     "Print the number and size of the entries of each data type",
+				ArgsUsage: " ",
+				Description: `
+The inspect command iterates over the entire database, grouping the entries by
+the schema prefixes they were stored under (headers, bodies, receipts, trie
+nodes, etc) and prints the number of entries and the data size of each group.
+`,
+			},
+			{
+				Action:    dbGet,
+				Name:      "get",
+				Usage:     "Print the value stored under a database key",
+				ArgsUsage: "<hex-encoded key>",
+			},
+			{
+				Action:    dbDelete,
+				Name:      "delete",
+				Usage:     "Delete the value stored under a database key",
+				ArgsUsage: "<hex-encoded key>",
+				Description: `
+The delete command removes a single entry from the database. This is a
+dangerous operation, only use it if you know what you are doing.
+`,
+			},
+			{
+				Action:    dbCompact,
+				Name:      "compact",
+				Usage:     "Compact the entire database",
+				ArgsUsage: " ",
+				Description: `
+The compact command runs a full compaction of the database, discarding deleted
+and overwritten entries and reclaiming the disk space they used.
+`,
+			},
+			{
+				Action:    dbRecompress,
+				Name:      "recompress",
+				Usage:     "Convert the block bodies and receipts to the configured compression",
+				ArgsUsage: " ",
+				Description: `
+The recompress command rewrites the stored block bodies and receipts in place,
+converting them to the algorithms selected by the global --db.compression,
+--db.compression.bodies and --db.compression.receipts flags. Tables without
+compression configured are decompressed.
+The database is compacted afterwards to reclaim the space of the old values.
+`,
+			},
+		},
+	}
+)
+
+// openDatabase opens the chain database of the node configured on the command line.
+func openDatabase(ctx *cli.Context) ethdb.Database {
+	stack := makeFullNode(ctx)
+	return utils.MakeChainDatabase(ctx, stack)
+}
+
+// parseKeyArg decodes the single hex encoded database key passed to a command.
+func parseKeyArg(ctx *cli.Context) []byte {
+	if len(ctx.Args()) != 1 {
+		utils.Fatalf("This command requires a single key argument.")
+	}
+	key, err := hexutil.Decode(ctx.Args().First())
+	if err != nil {
+		utils.Fatalf("Invalid key %q: %v", ctx.Args().First(), err)
+	}
+	return key
+}
+
+func dbInspect(ctx *cli.Context) error {
+	db := openDatabase(ctx)
+	defer db.Close()
+
+	start := time.Now()
+	stats, err := core.InspectDatabase(db)
+	if err != nil {
+		utils.Fatalf("Failed to inspect database: %v", err)
+	}
+	var (
+		count uint64
+		size  common.StorageSize
+	)
+	fmt.Printf("%-20s %12s %12s\n", "Category", "Entries", "Size")
+	for _, stat := range stats {
+		count += stat.Count
+		size += stat.Size
+		fmt.Printf("%-20s %12d %12v\n", stat.Name, stat.Count, stat.Size)
+	}
+	fmt.Printf("%-20s %12d %12v\n\n", "Total", count, size)
+	fmt.Printf("Inspection done in %v\n", time.Since(start))
+	return nil
+}
+
+func dbGet(ctx *cli.Context) error {
+	key := parseKeyArg(ctx)
+
+	db := openDatabase(ctx)
+	defer db.Close()
+
+	value, err := db.Get(key)
+	if err != nil {
+		utils.Fatalf("Failed to retrieve key %x: %v", key, err)
+	}
+	fmt.Println(hexutil.Encode(value))
+	return nil
+}
+
+func dbDelete(ctx *cli.Context) error {
+	key := parseKeyArg(ctx)
+
+	db := openDatabase(ctx)
+	defer db.Close()
+
+	if ok, err := db.Has(key); err != nil {
+		utils.Fatalf("Failed to retrieve key %x: %v", key, err)
+	} else if !ok {
+		utils.Fatalf("Key %x not found", key)
+	}
+	if err := db.Delete(key); err != nil {
+		utils.Fatalf("Failed to delete key %x: %v", key, err)
+	}
+	fmt.Printf("Deleted key %x\n", key)
+	return nil
+}
+
+func dbCompact(ctx *cli.Context) error {
+	db := openDatabase(ctx)
+	defer db.Close()
+
+	start := time.Now()
+	fmt.Println("Compacting entire database...")
+	if err := db.Compact(nil, nil); err != nil {
+		utils.Fatalf("Compaction failed: %v", err)
+	}
+	fmt.Printf("Compaction done in %v\n", time.Since(start))
+	return nil
+}
+
+func dbRecompress(ctx *cli.Context) error {
+	db := openDatabase(ctx)
+	defer db.Close()
+
+	cdb, ok := db.(*ethdb.CompressedDatabase)
+	if !ok {
+		utils.Fatalf("The chain database doesn't support compression")
+	}
+	start := time.Now()
+	count, err := cdb.Recompress()
+	if err != nil {
+		utils.Fatalf("Recompression failed after %d entries: %v", count, err)
+	}
+	fmt.Printf("Recompressed %d entries in %v\n", count, time.Since(start))
+
+	start = time.Now()
+	fmt.Println("Compacting entire database...")
+	if err := db.Compact(nil, nil); err != nil {
+		utils.Fatalf("Compaction failed: %v", err)
+	}
+	fmt.Printf("Compaction done in %v\n", time.Since(start))
+	return nil
+}
@@ -0,0 +1,185 @@
+// Copyright 2017 The daxxcoreAuthors
+// This file is part of daxxCore.
+//
+// daxxcoreis free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// daxxcoreis distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with daxxCore. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/daxxcoin/daxxcore/cmd/utils"
+	"github.com/daxxcoin/daxxcore/common"
+	"github.com/daxxcoin/daxxcore/core/types"
+	"github.com/daxxcoin/daxxcore/daxxdb"
+	"github.com/daxxcoin/daxxcore/rlp"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	freezerCommand = cli.Command{
+		Name:      "freezer",
+		Usage:     "Inspect the ancient chain data store",
+		ArgsUsage: "",
+		Category:  "BLOCKCHAIN COMMANDS",
+		Description: `
+Immutable chain data (headers, bodies, receipts and total difficulties) older
+than the finality threshold is moved out of the key-value database into a set of
+append-only flat files, the ancient store. It's located in the "ancient" folder
+of the chain database, unless pointed elsewhere with --datadir.ancient.
+`,
+		Subcommands: []cli.Command{
+			{
+				Action:    freezerInspect,
+				Name:      "inspect",
+				Usage:     "Print the number of frozen blocks and the size of each table",
+				ArgsUsage: " ",
+				Description: `
+The inspect command prints the location of the ancient store, the number of
+blocks frozen into it and the disk space used by each of its data tables.
+`,
+			},
+			{
+				Action:    freezerVerify,
+				Name:      "verify",
+				Usage:     "Check the integrity of all the frozen chain data",
+				ArgsUsage: " ",
+				Description: `
+The verify command decodes every frozen block, checking that the headers link up
+into a chain matching the stored hashes, that the bodies and receipts match the
+roots committed to in the headers and that the total difficulties add up.
+`,
+			},
+		},
+	}
+)
+
+// openFreezer opens the chain database and retrieves the ancient store backing it.
+func openFreezer(ctx *cli.Context) (ethdb.Database, *ethdb.Freezer) {
+	stack := makeFullNode(ctx)
+	chainDb := utils.MakeChainDatabase(ctx, stack)
+
+	freezer := ethdb.AncientStore(chainDb)
+	if freezer == nil {
+		chainDb.Close()
+		utils.Fatalf("The chain database has no ancient store")
+	}
+	return chainDb, freezer
+}
+
+func freezerInspect(ctx *cli.Context) error {
+	chainDb, freezer := openFreezer(ctx)
+	defer chainDb.Close()
+
+	fmt.Printf("Location: %s\n", freezer.Path())
+	fmt.Printf("Blocks:   %d\n\n", freezer.Ancients())
+
+	var total uint64
+	for _, table := range ethdb.FreezerTables {
+		size, err := freezer.AncientSize(table)
+		if err != nil {
+			utils.Fatalf("Failed to retrieve %s table size: %v", table, err)
+		}
+		total += size
+		fmt.Printf("%-10s %v\n", table, common.StorageSize(size))
+	}
+	fmt.Printf("%-10s %v\n", "total", common.StorageSize(total))
+	return nil
+}
+
+func freezerVerify(ctx *cli.Context) error {
+	chainDb, freezer := openFreezer(ctx)
+	defer chainDb.Close()
+
+	var (
+		start  = time.Now()
+		frozen = freezer.Ancients()
+		parent common.Hash
+		td     = new(big.Int)
+	)
+	for number := uint64(0); number < frozen; number++ {
+		if err := verifyAncient(freezer, number, &parent, td); err != nil {
+			utils.Fatalf("Ancient block #%d is corrupt: %v", number, err)
+		}
+		if number > 0 && number%10000 == 0 {
+			fmt.Printf("Verified %d/%d blocks (%v elapsed)\n", number, frozen, time.Since(start))
+		}
+	}
+	fmt.Printf("Verified %d frozen blocks in %v\n", frozen, time.Since(start))
+	return nil
+}
+
+// verifyAncient checks the integrity of a single frozen block, linking it to the
+// previously verified parent and accumulating the total difficulty.
+func verifyAncient(freezer *ethdb.Freezer, number uint64, parent *common.Hash, td *big.Int) error {
+	blobs := make(map[string][]byte)
+	for _, table := range ethdb.FreezerTables {
+		blob, err := freezer.Ancient(table, number)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %v", table, err)
+		}
+		blobs[table] = blob
+	}
+	header := new(types.Header)
+	if err := rlp.DecodeBytes(blobs[ethdb.FreezerHeaderTable], header); err != nil {
+		return fmt.Errorf("invalid header: %v", err)
+	}
+	hash := common.BytesToHash(blobs[ethdb.FreezerHashTable])
+	if header.Hash() != hash {
+		return fmt.Errorf("header hash mismatch: have %x, want %x", header.Hash(), hash)
+	}
+	if header.Number.Uint64() != number {
+		return fmt.Errorf("header number mismatch: have %d", header.Number)
+	}
+	if number > 0 && header.ParentHash != *parent {
+		return fmt.Errorf("parent hash mismatch: have %x, want %x", header.ParentHash, *parent)
+	}
+	body := new(types.Body)
+	if err := rlp.DecodeBytes(blobs[ethdb.FreezerBodiesTable], body); err != nil {
+		return fmt.Errorf("invalid body: %v", err)
+	}
+	if root := types.DeriveSha(types.Transactions(body.Transactions)); root != header.TxHash {
+		return fmt.Errorf("transaction root mismatch: have %x, want %x", root, header.TxHash)
+	}
+	if uncles := types.CalcUncleHash(body.Uncles); uncles != header.UncleHash {
+		return fmt.Errorf("uncle hash mismatch: have %x, want %x", uncles, header.UncleHash)
+	}
+	var stored []*types.ReceiptForStorage
+	if err := rlp.DecodeBytes(blobs[ethdb.FreezerReceiptTable], &stored); err != nil {
+		return fmt.Errorf("invalid receipts: %v", err)
+	}
+	if len(stored) != len(body.Transactions) {
+		return fmt.Errorf("receipt count mismatch: have %d, want %d", len(stored), len(body.Transactions))
+	}
+	receipts := make(types.Receipts, len(stored))
+	for i, receipt := range stored {
+		receipts[i] = (*types.Receipt)(receipt)
+	}
+	if root := types.DeriveSha(receipts); root != header.ReceiptHash {
+		return fmt.Errorf("receipt root mismatch: have %x, want %x", root, header.ReceiptHash)
+	}
+	have := new(big.Int)
+	if err := rlp.DecodeBytes(blobs[ethdb.FreezerDifficultyTable], have); err != nil {
+		return fmt.Errorf("invalid total difficulty: %v", err)
+	}
+	if number == 0 {
+		td.Set(have)
+	} else if td.Add(td, header.Difficulty); td.Cmp(have) != 0 {
+		return fmt.Errorf("total difficulty mismatch: have %v, want %v", have, td)
+	}
+	*parent = hash
+	return nil
+}
@@ -69,6 +69,12 @@ func init() {
 		upgradedbCommand,
 		removedbCommand,
 		dumpCommand,
+		forksCommand,
+		freezerCommand,
+		// See dbcmd.go:
+		dbCommand,
+		// See snapshotcmd.go:
+		snapshotCommand,
 		// See monitorcmd.go:
 		monitorCommand,
 		// See accountcmd.go:
@@ -91,13 +97,31 @@ func init() {
 		utils.BootnodesFlag,
 		utils.DataDirFlag,
 		utils.KeyStoreDirFlag,
+		utils.AncientFlag,
+		utils.DatabaseCompressionFlag,
+		utils.DatabaseBodyCompressionFlag,
+		utils.DatabaseReceiptCompressionFlag,
 		utils.FastSyncFlag,
 		utils.LightModeFlag,
 		utils.LightServFlag,
 		utils.LightPeersFlag,
 		utils.LightKDFFlag,
+		utils.TxPoolLocalsFlag,
+		utils.TxPoolJournalFlag,
+		utils.TxPoolRejournalFlag,
+		utils.TxPoolAccountSlotsFlag,
+		utils.TxPoolGlobalSlotsFlag,
+		utils.TxPoolAccountQueueFlag,
+		utils.TxPoolGlobalQueueFlag,
+		utils.TxPoolPriceBumpFlag,
+		utils.TxPoolLifetimeFlag,
 		utils.CacheFlag,
 		utils.TrieCacheGenFlag,
+		utils.GCModeFlag,
+		utils.TrieCacheFlag,
+		utils.TrieFlushFlag,
+		utils.SnapshotCacheFlag,
+		utils.TxLookupLimitFlag,
 		utils.JSpathFlag,
 		utils.ListenPortFlag,
 		utils.MaxPeersFlag,
@@ -106,6 +130,12 @@ func init() {
 		utils.GasPriceFlag,
 		utils.MinerThreadsFlag,
 		utils.MiningEnabledFlag,
+		utils.StratumEnabledFlag,
+		utils.StratumListenAddrFlag,
+		utils.StratumPortFlag,
+		utils.StratumPasswordFlag,
+		utils.StratumDifficultyFlag,
+		utils.StratumMaxSessionsFlag,
 		utils.AutoDAGFlag,
 		utils.TargetGasLimitFlag,
 		utils.NATFlag,
@@ -130,6 +160,7 @@ func init() {
 		utils.PreloadJSFlag,
 		utils.WhisperEnabledFlag,
 		utils.DevModeFlag,
+		utils.DevPeriodFlag,
 		utils.TestNetFlag,
 		utils.VMForceJitFlag,
 		utils.VMJitCacheFlag,
@@ -0,0 +1,93 @@
+// Copyright 2017 The daxxcoreAuthors
+// This file is part of daxxCore.
+//
+// daxxcoreis free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// daxxcoreis distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with daxxCore. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/daxxcoin/daxxcore/cmd/utils"
+	"github.com/daxxcoin/daxxcore/common"
+	"github.com/daxxcoin/daxxcore/common/hexutil"
+	"github.com/daxxcoin/daxxcore/core"
+	"github.com/daxxcoin/daxxcore/core/state/snapshot"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	snapshotCommand = cli.Command{
+		Name:      "snapshot",
+		Usage:     "Manage the flat state snapshot",
+		ArgsUsage: "",
+		Category:  "BLOCKCHAIN COMMANDS",
+		Description: `
+With --snapshot-cache set, the state of the head block is kept as a flat list of
+accounts and storage slots next to the state trie, the snapshot, which serves
+state reads without walking the trie.
+`,
+		Subcommands: []cli.Command{
+			{
+				Action:    snapshotVerify,
+				Name:      "verify",
+				Usage:     "Check the flat state snapshot against its state root",
+				ArgsUsage: "[<root>]",
+				Description: `
+The verify command rebuilds the account and storage tries from the flat state of
+the snapshot and checks that they hash to the expected roots. The state root of
+the head block is verified unless another root is given.
+
+A snapshot which is missing or belongs to another state is generated first.
+`,
+			},
+		},
+	}
+)
+
+func snapshotVerify(ctx *cli.Context) error {
+	if len(ctx.Args()) > 1 {
+		utils.Fatalf("This command accepts at most one state root argument.")
+	}
+	stack := makeFullNode(ctx)
+	chainDb := utils.MakeChainDatabase(ctx, stack)
+	defer chainDb.Close()
+
+	var root common.Hash
+	if len(ctx.Args()) == 1 {
+		blob, err := hexutil.Decode(ctx.Args().First())
+		if err != nil || len(blob) != common.HashLength {
+			utils.Fatalf("Invalid state root %q", ctx.Args().First())
+		}
+		root = common.BytesToHash(blob)
+	} else {
+		hash := core.GetHeadBlockHash(chainDb)
+		header := core.GetHeader(chainDb, hash, core.GetBlockNumber(chainDb, hash))
+		if header == nil {
+			utils.Fatalf("Head block missing from the chain database")
+		}
+		root = header.Root
+	}
+	start := time.Now()
+
+	snaps := snapshot.New(chainDb, chainDb, ctx.GlobalInt(utils.SnapshotCacheFlag.Name), root, false)
+	defer snaps.Close()
+
+	if err := snaps.Verify(root); err != nil {
+		utils.Fatalf("Snapshot of state %x is corrupt: %v", root, err)
+	}
+	fmt.Printf("Verified snapshot of state %x in %v\n", root, time.Since(start))
+	return nil
+}
@@ -66,9 +66,14 @@ var AppHelpFlagGroups = []flagGroup{
 		Flags: []cli.Flag{
 			utils.DataDirFlag,
 			utils.KeyStoreDirFlag,
+			utils.AncientFlag,
+			utils.DatabaseCompressionFlag,
+			utils.DatabaseBodyCompressionFlag,
+			utils.DatabaseReceiptCompressionFlag,
 			utils.NetworkIdFlag,
 			utils.TestNetFlag,
 			utils.DevModeFlag,
+			utils.DevPeriodFlag,
 			utils.IdentityFlag,
 			utils.FastSyncFlag,
 			utils.LightModeFlag,
@@ -77,11 +82,30 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.LightKDFFlag,
 		},
 	},
+	{
+		Name: "TRANSACTION POOL",
+		Flags: []cli.Flag{
+			utils.TxPoolLocalsFlag,
+			utils.TxPoolJournalFlag,
+			utils.TxPoolRejournalFlag,
+			utils.TxPoolAccountSlotsFlag,
+			utils.TxPoolGlobalSlotsFlag,
+			utils.TxPoolAccountQueueFlag,
+			utils.TxPoolGlobalQueueFlag,
+			utils.TxPoolPriceBumpFlag,
+			utils.TxPoolLifetimeFlag,
+		},
+	},
 	{
 		Name: "PERFORMANCE TUNING",
 		Flags: []cli.Flag{
 			utils.CacheFlag,
 			utils.TrieCacheGenFlag,
+			utils.GCModeFlag,
+			utils.TrieCacheFlag,
+			utils.TrieFlushFlag,
+			utils.SnapshotCacheFlag,
+			utils.TxLookupLimitFlag,
 		},
 	},
 	{
@@ -136,6 +160,12 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.TargetGasLimitFlag,
 			utils.GasPriceFlag,
 			utils.ExtraDataFlag,
+			utils.StratumEnabledFlag,
+			utils.StratumListenAddrFlag,
+			utils.StratumPortFlag,
+			utils.StratumPasswordFlag,
+			utils.StratumDifficultyFlag,
+			utils.StratumMaxSessionsFlag,
 		},
 	},
 	{
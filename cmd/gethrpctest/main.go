@@ -24,6 +24,7 @@ import (
 	"os/signal"
 
 	"github.com/daxxcoin/daxxcore/accounts/keystore"
+	"github.com/daxxcoin/daxxcore/core"
 	"github.com/daxxcoin/daxxcore/crypto"
 	"github.com/daxxcoin/daxxcore/daxx"
 	"github.com/daxxcoin/daxxcore/daxxdb"
@@ -120,10 +121,13 @@ func MakeSystemNode(privkey string, test *tests.BlockTest) (*node.Node, error) {
 	if _, err := test.InsertPreState(db); err != nil {
 		return nil, err
 	}
+	if err := core.WriteChainConfig(db, test.Genesis.Hash(), &params.ChainConfig{HomesteadBlock: params.MainNetHomesteadBlock}); err != nil {
+		return nil, err
+	}
 	ethConf := &eth.Config{
 		TestGenesisState: db,
 		TestGenesisBlock: test.Genesis,
-		ChainConfig:      &params.ChainConfig{HomesteadBlock: params.MainNetHomesteadBlock},
+		TxPool:           core.DefaultTxPoolConfig,
 	}
 	if err := stack.Register(func(ctx *node.ServiceContext) (node.Service, error) { return eth.New(ctx, ethConf) }); err != nil {
 		return nil, err
@@ -28,10 +28,10 @@ import (
 	"strconv"
 	"strings"
 
-	"github.com/daxxnucleus/daxxhash"
 	"github.com/daxxcoin/daxxcore/accounts"
 	"github.com/daxxcoin/daxxcore/accounts/keystore"
 	"github.com/daxxcoin/daxxcore/common"
+	"github.com/daxxcoin/daxxcore/consensus/daxxhash"
 	"github.com/daxxcoin/daxxcore/core"
 	"github.com/daxxcoin/daxxcore/core/state"
 	"github.com/daxxcoin/daxxcore/core/vm"
@@ -44,13 +44,13 @@ import (
 	"github.com/daxxcoin/daxxcore/logger"
 	"github.com/daxxcoin/daxxcore/logger/glog"
 	"github.com/daxxcoin/daxxcore/metrics"
+	"github.com/daxxcoin/daxxcore/miner"
 	"github.com/daxxcoin/daxxcore/node"
 	"github.com/daxxcoin/daxxcore/p2p/discover"
 	"github.com/daxxcoin/daxxcore/p2p/discv5"
 	"github.com/daxxcoin/daxxcore/p2p/nat"
 	"github.com/daxxcoin/daxxcore/p2p/netutil"
 	"github.com/daxxcoin/daxxcore/params"
-	"github.com/daxxcoin/daxxcore/pow"
 	"github.com/daxxcoin/daxxcore/rpc"
 	whisper "github.com/daxxcoin/daxxcore/whisper/whisperv2"
 	"gopkg.in/urfave/cli.v1"
@@ -115,6 +115,22 @@ var (
 		Name:  "keystore",
 		Usage: "Directory for the keystore (default = inside the datadir)",
 	}
+	AncientFlag = DirectoryFlag{
+		Name:  "datadir.ancient",
+		Usage: "Directory for the ancient chain data store (default = inside the chaindata)",
+	}
+	DatabaseCompressionFlag = cli.BoolFlag{
+		Name:  "db.compression",
+		Usage: "Compress block bodies and receipts in the chain database (convert existing data with 'geth db recompress')",
+	}
+	DatabaseBodyCompressionFlag = cli.StringFlag{
+		Name:  "db.compression.bodies",
+		Usage: "Algorithm to store block bodies with, overriding --db.compression (none, snappy or rle)",
+	}
+	DatabaseReceiptCompressionFlag = cli.StringFlag{
+		Name:  "db.compression.receipts",
+		Usage: "Algorithm to store block receipts with, overriding --db.compression (none, snappy or rle)",
+	}
 	NetworkIdFlag = cli.IntFlag{
 		Name:  "networkid",
 		Usage: "Network identifier (integer, 1=Frontier, 2=Morden (disused), 3=Ropsten)",
@@ -126,7 +142,11 @@ var (
 	}
 	DevModeFlag = cli.BoolFlag{
 		Name:  "dev",
-		Usage: "Developer mode: pre-configured private network with several debugging flags",
+		Usage: "Developer mode: pre-configured private proof-of-authority network with several debugging flags",
+	}
+	DevPeriodFlag = cli.IntFlag{
+		Name:  "dev.period",
+		Usage: "Block period to use in developer mode (0 = mine only if transaction pending)",
 	}
 	IdentityFlag = cli.StringFlag{
 		Name:  "identity",
@@ -159,6 +179,51 @@ var (
 		Name:  "lightkdf",
 		Usage: "Reduce key-derivation RAM & CPU usage at some expense of KDF strength",
 	}
+	// Transaction pool settings
+	TxPoolLocalsFlag = cli.StringFlag{
+		Name:  "txpool.locals",
+		Usage: "Comma separated accounts to treat as locals (no flush, priority inclusion)",
+	}
+	TxPoolJournalFlag = cli.StringFlag{
+		Name:  "txpool.journal",
+		Usage: "Disk journal for local transaction to survive node restarts",
+		Value: core.DefaultTxPoolConfig.Journal,
+	}
+	TxPoolRejournalFlag = cli.DurationFlag{
+		Name:  "txpool.rejournal",
+		Usage: "Time interval to regenerate the local transaction journal",
+		Value: core.DefaultTxPoolConfig.Rejournal,
+	}
+	TxPoolAccountSlotsFlag = cli.Uint64Flag{
+		Name:  "txpool.accountslots",
+		Usage: "Minimum number of executable transaction slots guaranteed per account",
+		Value: core.DefaultTxPoolConfig.AccountSlots,
+	}
+	TxPoolGlobalSlotsFlag = cli.Uint64Flag{
+		Name:  "txpool.globalslots",
+		Usage: "Maximum number of executable transaction slots for all accounts",
+		Value: core.DefaultTxPoolConfig.GlobalSlots,
+	}
+	TxPoolAccountQueueFlag = cli.Uint64Flag{
+		Name:  "txpool.accountqueue",
+		Usage: "Maximum number of non-executable transaction slots permitted per account",
+		Value: core.DefaultTxPoolConfig.AccountQueue,
+	}
+	TxPoolGlobalQueueFlag = cli.Uint64Flag{
+		Name:  "txpool.globalqueue",
+		Usage: "Maximum number of non-executable transaction slots for all accounts",
+		Value: core.DefaultTxPoolConfig.GlobalQueue,
+	}
+	TxPoolPriceBumpFlag = cli.Uint64Flag{
+		Name:  "txpool.pricebump",
+		Usage: "Price bump percentage to replace an already existing transaction",
+		Value: core.DefaultTxPoolConfig.PriceBump,
+	}
+	TxPoolLifetimeFlag = cli.DurationFlag{
+		Name:  "txpool.lifetime",
+		Usage: "Maximum amount of time non-executable transaction are queued",
+		Value: core.DefaultTxPoolConfig.Lifetime,
+	}
 	// Performance tuning settings
 	CacheFlag = cli.IntFlag{
 		Name:  "cache",
@@ -170,6 +235,31 @@ var (
 		Usage: "Number of trie node generations to keep in memory",
 		Value: int(state.MaxTrieCacheGen),
 	}
+	GCModeFlag = cli.StringFlag{
+		Name:  "gcmode",
+		Usage: `Blockchain garbage collection mode ("full", "archive")`,
+		Value: "full",
+	}
+	TrieCacheFlag = cli.IntFlag{
+		Name:  "trie-cache",
+		Usage: "Megabytes of memory allowed for recent state tries before flushing them to disk (gcmode=full)",
+		Value: 256,
+	}
+	TrieFlushFlag = cli.IntFlag{
+		Name:  "trie-flush",
+		Usage: "Number of blocks after which recent state tries are flushed to disk (gcmode=full)",
+		Value: 1024,
+	}
+	SnapshotCacheFlag = cli.IntFlag{
+		Name:  "snapshot-cache",
+		Usage: "Megabytes of memory allocated to the flat state snapshot read cache (0 = snapshot disabled)",
+		Value: 0,
+	}
+	TxLookupLimitFlag = cli.IntFlag{
+		Name:  "txlookuplimit",
+		Usage: "Number of recent blocks to maintain transaction lookups for (0 = entire chain)",
+		Value: 0,
+	}
 	// Miner settings
 	MiningEnabledFlag = cli.BoolFlag{
 		Name:  "mine",
@@ -203,6 +293,34 @@ var (
 		Name:  "extradata",
 		Usage: "Block extra data set by the miner (default = client version)",
 	}
+	StratumEnabledFlag = cli.BoolFlag{
+		Name:  "stratum",
+		Usage: "Enable the stratum mining server for external miners",
+	}
+	StratumListenAddrFlag = cli.StringFlag{
+		Name:  "stratum.addr",
+		Usage: "Stratum server listening interface",
+		Value: "localhost",
+	}
+	StratumPortFlag = cli.IntFlag{
+		Name:  "stratum.port",
+		Usage: "Stratum server listening port",
+		Value: 8008,
+	}
+	StratumPasswordFlag = cli.StringFlag{
+		Name:  "stratum.password",
+		Usage: "Password required from stratum miners to log in (default = any miner accepted)",
+	}
+	StratumDifficultyFlag = cli.Uint64Flag{
+		Name:  "stratum.difficulty",
+		Usage: "Difficulty of the shares accepted from stratum miners",
+		Value: miner.DefaultStratumConfig.Difficulty.Uint64(),
+	}
+	StratumMaxSessionsFlag = cli.IntFlag{
+		Name:  "stratum.maxsessions",
+		Usage: "Maximum number of concurrent stratum miner connections",
+		Value: miner.DefaultStratumConfig.MaxSessions,
+	}
 	// Account settings
 	UnlockedAccountFlag = cli.StringFlag{
 		Name:  "unlock",
@@ -730,7 +848,7 @@ func RegisterEthService(ctx *cli.Context, stack *node.Node, extra []byte) {
 
 	ethConf := &eth.Config{
 		Daxxcoinbase:               MakeDaxxcoinbase(ks, ctx),
-		ChainConfig:             MakeChainConfig(ctx, stack),
+		Genesis:                 MakeGenesis(ctx),
 		FastSync:                ctx.GlobalBool(FastSyncFlag.Name),
 		LightMode:               ctx.GlobalBool(LightModeFlag.Name),
 		LightServ:               ctx.GlobalInt(LightServFlag.Name),
@@ -738,6 +856,8 @@ func RegisterEthService(ctx *cli.Context, stack *node.Node, extra []byte) {
 		MaxPeers:                ctx.GlobalInt(MaxPeersFlag.Name),
 		DatabaseCache:           ctx.GlobalInt(CacheFlag.Name),
 		DatabaseHandles:         MakeDatabaseHandles(),
+		DatabaseFreezer:         ctx.GlobalString(AncientFlag.Name),
+		DatabaseCompression:     MakeChainCompression(ctx),
 		NetworkId:               ctx.GlobalInt(NetworkIdFlag.Name),
 		MinerThreads:            ctx.GlobalInt(MinerThreadsFlag.Name),
 		ExtraData:               MakeMinerExtra(extra, ctx),
@@ -753,6 +873,17 @@ func RegisterEthService(ctx *cli.Context, stack *node.Node, extra []byte) {
 		AutoDAG:                 ctx.GlobalBool(AutoDAGFlag.Name) || ctx.GlobalBool(MiningEnabledFlag.Name),
 		EnablePreimageRecording: ctx.GlobalBool(VMEnableDebugFlag.Name),
 	}
+	cacheConfig := MakeCacheConfig(ctx)
+	ethConf.NoPruning = cacheConfig.Disabled
+	ethConf.TrieCache = ctx.GlobalInt(TrieCacheFlag.Name)
+	ethConf.TrieFlushInterval = cacheConfig.TrieFlushInterval
+	ethConf.SnapshotCache = cacheConfig.SnapshotLimit
+	ethConf.TxLookupLimit = cacheConfig.TxLookupLimit
+	ethConf.TxPool = MakeTxPoolConfig(ctx)
+
+	if ctx.GlobalBool(StratumEnabledFlag.Name) {
+		ethConf.Stratum = MakeStratumConfig(ctx)
+	}
 
 	// Override any default configs in dev mode or the test net
 	switch {
@@ -760,14 +891,41 @@ func RegisterEthService(ctx *cli.Context, stack *node.Node, extra []byte) {
 		if !ctx.GlobalIsSet(NetworkIdFlag.Name) {
 			ethConf.NetworkId = 3
 		}
-		ethConf.Genesis = core.DefaultTestnetGenesisBlock()
 
 	case ctx.GlobalBool(DevModeFlag.Name):
-		ethConf.Genesis = core.DevGenesisBlock()
+		// Create a new developer account or reuse the first existing one
+		var (
+			developer  accounts.Account
+			passphrase string
+			err        error
+		)
+		if list := MakePasswordList(ctx); len(list) > 0 {
+			passphrase = list[0]
+		}
+		if accs := ks.Accounts(); len(accs) > 0 {
+			developer = accs[0]
+		} else {
+			developer, err = ks.NewAccount(passphrase)
+			if err != nil {
+				Fatalf("Failed to create developer account: %v", err)
+			}
+		}
+		if err := ks.Unlock(developer, passphrase); err != nil {
+			glog.V(logger.Warn).Infof("Developer account %x locked, unlock it to seal blocks: %v", developer.Address, err)
+		}
+		glog.V(logger.Info).Infof("Using developer account %x", developer.Address)
+		ethConf.Daxxcoinbase = developer.Address
+
+		// Run a single-signer clique chain sealed by the developer account
+		config := *params.AllCliqueProtocolChanges
+		clique := *config.Clique
+		clique.Period = uint64(ctx.GlobalInt(DevPeriodFlag.Name))
+		config.Clique = &clique
+
+		ethConf.Genesis = core.DeveloperGenesisBlock(&config, developer.Address)
 		if !ctx.GlobalIsSet(GasPriceFlag.Name) {
 			ethConf.GasPrice = new(big.Int)
 		}
-		ethConf.PowTest = true
 	}
 	// Override any global options pertaining to the Daxxcoin protocol
 	if gen := ctx.GlobalInt(TrieCacheGenFlag.Name); gen > 0 {
@@ -823,63 +981,15 @@ func SetupNetwork(ctx *cli.Context) {
 	params.TargetGasLimit = common.String2Big(ctx.GlobalString(TargetGasLimitFlag.Name))
 }
 
-// MakeChainConfig reads the chain configuration from the database in ctx.Datadir.
-func MakeChainConfig(ctx *cli.Context, stack *node.Node) *params.ChainConfig {
-	db := MakeChainDatabase(ctx, stack)
-	defer db.Close()
-
-	return MakeChainConfigFromDb(ctx, db)
-}
-
-// MakeChainConfigFromDb reads the chain configuration from the given database.
-func MakeChainConfigFromDb(ctx *cli.Context, db ethdb.Database) *params.ChainConfig {
-	// If the chain is already initialized, use any existing chain configs
-	config := new(params.ChainConfig)
-
-	genesis := core.GetBlock(db, core.GetCanonicalHash(db, 0), 0)
-	if genesis != nil {
-		storedConfig, err := core.GetChainConfig(db, genesis.Hash())
-		switch err {
-		case nil:
-			config = storedConfig
-		case core.ChainConfigNotFoundErr:
-			// No configs found, use empty, will populate below
-		default:
-			Fatalf("Could not make chain configuration: %v", err)
-		}
-	}
-	// set chain id in case it's zero.
-	if config.ChainId == nil {
-		config.ChainId = new(big.Int)
-	}
-	// Check whether we are allowed to set default config params or not:
-	//  - If no genesis is set, we're running either mainnet or testnet (private nets use `geth init`)
-	//  - If a genesis is already set, ensure we have a configuration for it (mainnet or testnet)
-	defaults := genesis == nil ||
-		(genesis.Hash() == params.MainNetGenesisHash && !ctx.GlobalBool(TestNetFlag.Name)) ||
-		(genesis.Hash() == params.TestNetGenesisHash && ctx.GlobalBool(TestNetFlag.Name))
-
-	if defaults {
-		if ctx.GlobalBool(TestNetFlag.Name) {
-			config = params.TestnetChainConfig
-		} else {
-			// Homestead fork
-			config.HomesteadBlock = params.MainNetHomesteadBlock
-			// DAO fork
-			config.DAOForkBlock = params.MainNetDAOForkBlock
-			config.DAOForkSupport = true
-
-			// DoS reprice fork
-			config.EIP150Block = params.MainNetHomesteadGasRepriceBlock
-			config.EIP150Hash = params.MainNetHomesteadGasRepriceHash
-
-			// DoS state cleanup fork
-			config.EIP155Block = params.MainNetSpuriousDragon
-			config.EIP158Block = params.MainNetSpuriousDragon
-			config.ChainId = params.MainNetChainID
-		}
+// MakeGenesis returns the genesis block selected by the network flags, or nil
+// to use the main net genesis or the one already stored in the database. The
+// developer genesis depends on the unlocked signer and is assembled when the
+// Daxxcoin service is registered.
+func MakeGenesis(ctx *cli.Context) *core.Genesis {
+	if ctx.GlobalBool(TestNetFlag.Name) {
+		return core.DefaultTestnetGenesisBlock()
 	}
-	return config
+	return nil
 }
 
 func ChainDbName(ctx *cli.Context) string {
@@ -898,11 +1008,105 @@ func MakeChainDatabase(ctx *cli.Context, stack *node.Node) ethdb.Database {
 		name    = ChainDbName(ctx)
 	)
 
-	chainDb, err := stack.OpenDatabase(name, cache, handles)
+	var (
+		chainDb ethdb.Database
+		err     error
+	)
+	if name == "chaindata" {
+		chainDb, err = stack.OpenDatabaseWithFreezer(name, cache, handles, ctx.GlobalString(AncientFlag.Name))
+	} else {
+		chainDb, err = stack.OpenDatabase(name, cache, handles)
+	}
 	if err != nil {
 		Fatalf("Could not open database: %v", err)
 	}
-	return chainDb
+	return ethdb.NewCompressedDatabase(chainDb, core.ChainCompressionRules(MakeChainCompression(ctx)))
+}
+
+// MakeChainCompression creates the per table compression of the chain database
+// from the command line flags.
+func MakeChainCompression(ctx *cli.Context) core.ChainCompression {
+	var config core.ChainCompression
+	if ctx.GlobalBool(DatabaseCompressionFlag.Name) {
+		config = core.DefaultChainCompression
+	}
+	if ctx.GlobalIsSet(DatabaseBodyCompressionFlag.Name) {
+		algo, err := ethdb.ParseCompression(ctx.GlobalString(DatabaseBodyCompressionFlag.Name))
+		if err != nil {
+			Fatalf("Option %q: %v", DatabaseBodyCompressionFlag.Name, err)
+		}
+		config.Bodies = algo
+	}
+	if ctx.GlobalIsSet(DatabaseReceiptCompressionFlag.Name) {
+		algo, err := ethdb.ParseCompression(ctx.GlobalString(DatabaseReceiptCompressionFlag.Name))
+		if err != nil {
+			Fatalf("Option %q: %v", DatabaseReceiptCompressionFlag.Name, err)
+		}
+		config.Receipts = algo
+	}
+	return config
+}
+
+// MakeTxPoolConfig creates the transaction pool limits from the set command
+// line flags.
+func MakeTxPoolConfig(ctx *cli.Context) core.TxPoolConfig {
+	var locals []common.Address
+	if ctx.GlobalIsSet(TxPoolLocalsFlag.Name) {
+		for _, account := range strings.Split(ctx.GlobalString(TxPoolLocalsFlag.Name), ",") {
+			if trimmed := strings.TrimSpace(account); !common.IsHexAddress(trimmed) {
+				Fatalf("Invalid account in --%s: %s", TxPoolLocalsFlag.Name, trimmed)
+			} else {
+				locals = append(locals, common.HexToAddress(trimmed))
+			}
+		}
+	}
+	return core.TxPoolConfig{
+		AccountSlots: ctx.GlobalUint64(TxPoolAccountSlotsFlag.Name),
+		GlobalSlots:  ctx.GlobalUint64(TxPoolGlobalSlotsFlag.Name),
+		AccountQueue: ctx.GlobalUint64(TxPoolAccountQueueFlag.Name),
+		GlobalQueue:  ctx.GlobalUint64(TxPoolGlobalQueueFlag.Name),
+		PriceBump:    ctx.GlobalUint64(TxPoolPriceBumpFlag.Name),
+		Lifetime:     ctx.GlobalDuration(TxPoolLifetimeFlag.Name),
+		Locals:       locals,
+		Journal:      ctx.GlobalString(TxPoolJournalFlag.Name),
+		Rejournal:    ctx.GlobalDuration(TxPoolRejournalFlag.Name),
+	}
+}
+
+// MakeStratumConfig creates the stratum mining server configuration from the set
+// command line flags.
+func MakeStratumConfig(ctx *cli.Context) miner.StratumConfig {
+	return miner.StratumConfig{
+		Addr:        fmt.Sprintf("%s:%d", ctx.GlobalString(StratumListenAddrFlag.Name), ctx.GlobalInt(StratumPortFlag.Name)),
+		Password:    ctx.GlobalString(StratumPasswordFlag.Name),
+		Difficulty:  new(big.Int).SetUint64(ctx.GlobalUint64(StratumDifficultyFlag.Name)),
+		MaxSessions: ctx.GlobalInt(StratumMaxSessionsFlag.Name),
+	}
+}
+
+// MakeCacheConfig creates the trie caching and pruning configuration of the block
+// chain from the set command line flags.
+func MakeCacheConfig(ctx *cli.Context) *core.CacheConfig {
+	mode := ctx.GlobalString(GCModeFlag.Name)
+	if mode != "full" && mode != "archive" {
+		Fatalf("--%s must be either 'full' or 'archive'", GCModeFlag.Name)
+	}
+	if ctx.GlobalInt(TrieFlushFlag.Name) < 0 {
+		Fatalf("--%s must not be negative", TrieFlushFlag.Name)
+	}
+	if ctx.GlobalInt(SnapshotCacheFlag.Name) < 0 {
+		Fatalf("--%s must not be negative", SnapshotCacheFlag.Name)
+	}
+	if ctx.GlobalInt(TxLookupLimitFlag.Name) < 0 {
+		Fatalf("--%s must not be negative", TxLookupLimitFlag.Name)
+	}
+	return &core.CacheConfig{
+		Disabled:          mode == "archive",
+		TrieNodeLimit:     common.StorageSize(ctx.GlobalInt(TrieCacheFlag.Name)) * 1024 * 1024,
+		TrieFlushInterval: uint64(ctx.GlobalInt(TrieFlushFlag.Name)),
+		SnapshotLimit:     ctx.GlobalInt(SnapshotCacheFlag.Name),
+		TxLookupLimit:     uint64(ctx.GlobalInt(TxLookupLimitFlag.Name)),
+	}
 }
 
 // MakeChain creates a chain manager from set command line flags.
@@ -910,23 +1114,29 @@ func MakeChain(ctx *cli.Context, stack *node.Node) (chain *core.BlockChain, chai
 	var err error
 	chainDb = MakeChainDatabase(ctx, stack)
 
-	if ctx.GlobalBool(TestNetFlag.Name) {
-		_, err := core.WriteTestNetGenesisBlock(chainDb)
-		if err != nil {
-			glog.Fatalln(err)
-		}
+	chainConfig, genesisHash, genesisErr := core.SetupGenesisBlock(chainDb, MakeGenesis(ctx))
+	if _, ok := genesisErr.(*params.ConfigCompatError); genesisErr != nil && !ok {
+		Fatalf("%v", genesisErr)
 	}
 
-	chainConfig := MakeChainConfigFromDb(ctx, chainDb)
-
-	pow := pow.PoW(core.FakePow{})
+	engine := daxxhash.NewFaker()
 	if !ctx.GlobalBool(FakePoWFlag.Name) {
-		pow = ethash.New()
+		engine = daxxhash.New()
 	}
-	chain, err = core.NewBlockChain(chainDb, chainConfig, pow, new(event.TypeMux), vm.Config{EnablePreimageRecording: ctx.GlobalBool(VMEnableDebugFlag.Name)})
+	chain, err = core.NewBlockChain(chainDb, MakeCacheConfig(ctx), chainConfig, engine, new(event.TypeMux), vm.Config{EnablePreimageRecording: ctx.GlobalBool(VMEnableDebugFlag.Name)})
 	if err != nil {
 		Fatalf("Could not start chainmanager: %v", err)
 	}
+	// Rewind the chain in case of an incompatible config upgrade
+	if compat, ok := genesisErr.(*params.ConfigCompatError); ok {
+		glog.V(logger.Warn).Infof("Rewinding chain to #%d to upgrade configuration: %v", compat.RewindTo, compat)
+		if err := chain.SetHead(compat.RewindTo); err != nil {
+			Fatalf("Could not rewind chain: %v", err)
+		}
+		if err := core.WriteChainConfig(chainDb, genesisHash, chainConfig); err != nil {
+			Fatalf("Could not store chain configuration: %v", err)
+		}
+	}
 	return chain, chainDb
 }
 
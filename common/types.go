@@ -65,6 +65,18 @@ func (h Hash) MarshalJSON() ([]byte, error) {
 	return hexutil.Bytes(h[:]).MarshalJSON()
 }
 
+// MarshalText returns the hex representation of h, allowing hashes to be used
+// as JSON object keys.
+func (h Hash) MarshalText() ([]byte, error) {
+	return []byte(h.Hex()), nil
+}
+
+// UnmarshalText parses a hash in hex syntax.
+func (h *Hash) UnmarshalText(input []byte) error {
+	quoted := append(append([]byte{'"'}, input...), '"')
+	return hexutil.UnmarshalJSON("Hash", quoted, h[:])
+}
+
 // Sets the hash to the value of b. If b is larger than len(h) it will panic
 func (h *Hash) SetBytes(b []byte) {
 	if len(b) > len(h) {
@@ -154,6 +166,18 @@ func (a *Address) UnmarshalJSON(input []byte) error {
 	return hexutil.UnmarshalJSON("Address", input, a[:])
 }
 
+// MarshalText returns the hex representation of a, allowing addresses to be
+// used as JSON object keys.
+func (a Address) MarshalText() ([]byte, error) {
+	return []byte(a.Hex()), nil
+}
+
+// UnmarshalText parses an address in hex syntax.
+func (a *Address) UnmarshalText(input []byte) error {
+	quoted := append(append([]byte{'"'}, input...), '"')
+	return hexutil.UnmarshalJSON("Address", quoted, a[:])
+}
+
 // PP Pretty Prints a byte slice in the following format:
 // 	hex(value[:4])...(hex[len(value)-4:])
 func PP(value []byte) string {
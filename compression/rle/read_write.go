@@ -29,6 +29,8 @@ const (
 	emptyShaToken          = 0xfd
 	emptyListShaToken      = 0xfe
 	tokenToken             = 0xff
+
+	maxZeroRun = emptyShaToken - 3 // Longest run of zeros encodable in a single token
 )
 
 var empty = crypto.Keccak256([]byte(""))
@@ -67,8 +69,9 @@ func compressChunk(dat []byte) (ret []byte, n int) {
 	case dat[0] == token:
 		return []byte{token, tokenToken}, 1
 	case len(dat) > 1 && dat[0] == 0x0 && dat[1] == 0x0:
+		// Cap the run length so its token doesn't collide with the special ones
 		j := 0
-		for j <= 254 && j < len(dat) {
+		for j < maxZeroRun && j < len(dat) {
 			if dat[j] != 0 {
 				break
 			}
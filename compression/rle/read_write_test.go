@@ -60,6 +60,15 @@ func (s *CompressionRleSuite) TestDecompressSimple(c *checker.C) {
 	// }
 }
 
+func (s *CompressionRleSuite) TestCompressZeroRuns(c *checker.C) {
+	for n := 0; n <= 600; n++ {
+		in := append(append([]byte{0x01}, make([]byte, n)...), 0x01)
+		res, err := Decompress(Compress(in))
+		c.Assert(err, checker.IsNil)
+		c.Assert(res, checker.DeepEquals, in, checker.Commentf("run of %d zeros", n))
+	}
+}
+
 // func TestDecompressMulti(t *testing.T) {
 // 	res, err := Decompress([]byte{token, 0xfd, token, 0xfe, token, 12})
 // 	if err != nil {
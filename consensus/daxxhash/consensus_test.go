@@ -0,0 +1,142 @@
+// Copyright 2017 The daxxcoreAuthors
+// This file is part of the daxxcore library.
+//
+// The daxxcore library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The daxxcore library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the daxxcore library. If not, see <http://www.gnu.org/licenses/>.
+
+package daxxhash
+
+import (
+	"encoding/json"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/daxxcoin/daxxcore/common"
+	"github.com/daxxcoin/daxxcore/core/state"
+	"github.com/daxxcoin/daxxcore/core/types"
+	"github.com/daxxcoin/daxxcore/daxxdb"
+	"github.com/daxxcoin/daxxcore/params"
+)
+
+type diffTest struct {
+	ParentTimestamp    uint64
+	ParentDifficulty   *big.Int
+	CurrentTimestamp   uint64
+	CurrentBlocknumber *big.Int
+	CurrentDifficulty  *big.Int
+}
+
+func (d *diffTest) UnmarshalJSON(b []byte) (err error) {
+	var ext struct {
+		ParentTimestamp    string
+		ParentDifficulty   string
+		CurrentTimestamp   string
+		CurrentBlocknumber string
+		CurrentDifficulty  string
+	}
+	if err := json.Unmarshal(b, &ext); err != nil {
+		return err
+	}
+
+	d.ParentTimestamp = common.String2Big(ext.ParentTimestamp).Uint64()
+	d.ParentDifficulty = common.String2Big(ext.ParentDifficulty)
+	d.CurrentTimestamp = common.String2Big(ext.CurrentTimestamp).Uint64()
+	d.CurrentBlocknumber = common.String2Big(ext.CurrentBlocknumber)
+	d.CurrentDifficulty = common.String2Big(ext.CurrentDifficulty)
+
+	return nil
+}
+
+func TestCalcDifficulty(t *testing.T) {
+	file, err := os.Open("../../tests/files/BasicTests/difficulty.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	tests := make(map[string]diffTest)
+	err = json.NewDecoder(file).Decode(&tests)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := &params.ChainConfig{HomesteadBlock: big.NewInt(1150000)}
+	for name, test := range tests {
+		number := new(big.Int).Sub(test.CurrentBlocknumber, big.NewInt(1))
+		diff := CalcDifficulty(config, test.CurrentTimestamp, test.ParentTimestamp, number, test.ParentDifficulty)
+		if diff.Cmp(test.CurrentDifficulty) != 0 {
+			t.Error(name, "failed. Expected", test.CurrentDifficulty, "and calculated", diff)
+		}
+	}
+}
+
+// Tests that block and uncle rewards follow the schedule of the chain config.
+func TestAccumulateRewards(t *testing.T) {
+	var (
+		miner   = common.HexToAddress("0x0000000000000000000000000000000000000001")
+		uncler  = common.HexToAddress("0x0000000000000000000000000000000000000002")
+		halving = &params.ChainConfig{Rewards: []*params.RewardConfig{{BlockReward: big.NewInt(1000), EraLength: big.NewInt(100)}}}
+		fifths  = &params.ChainConfig{Rewards: []*params.RewardConfig{{BlockReward: big.NewInt(1000), UncleDivisor: big.NewInt(4), NephewDivisor: big.NewInt(10), EraLength: big.NewInt(100), EraNumerator: big.NewInt(4), EraDenominator: big.NewInt(5)}}}
+		forked  = &params.ChainConfig{Rewards: []*params.RewardConfig{
+			{Block: big.NewInt(1000), BlockReward: big.NewInt(1000), EraLength: big.NewInt(100)},
+			{Block: big.NewInt(2000), BlockReward: big.NewInt(300), UncleDivisor: big.NewInt(4)},
+		}}
+	)
+	tests := []struct {
+		config *params.ChainConfig
+		number int64
+		uncle  int64 // Number of the included uncle (0 = none)
+		miner  *big.Int
+		uncler *big.Int
+	}{
+		// Default schedule, flat reward with the stock uncle fractions
+		{&params.ChainConfig{}, 1, 0, params.BlockReward, new(big.Int)},
+		{&params.ChainConfig{}, 5000000, 4999999, new(big.Int).Add(params.BlockReward, new(big.Int).Div(params.BlockReward, big.NewInt(32))), new(big.Int).Div(new(big.Int).Mul(params.BlockReward, big.NewInt(7)), big.NewInt(8))},
+
+		// Halving schedule, reward halved every era
+		{halving, 99, 0, big.NewInt(1000), new(big.Int)},
+		{halving, 100, 0, big.NewInt(500), new(big.Int)},
+		{halving, 250, 249, big.NewInt(250 + 7), big.NewInt(218)},
+		{halving, 100000, 0, new(big.Int), new(big.Int)},
+		{halving, 1000000000000, 0, new(big.Int), new(big.Int)},
+
+		// Era based reduction with custom uncle fractions
+		{fifths, 150, 0, big.NewInt(800), new(big.Int)},
+		{fifths, 250, 248, big.NewInt(640 + 64), big.NewInt(320)},
+
+		// Schedules activated at fork blocks, eras counted from the activation
+		{forked, 999, 0, params.BlockReward, new(big.Int)},
+		{forked, 1000, 0, big.NewInt(1000), new(big.Int)},
+		{forked, 1199, 1198, big.NewInt(500 + 15), big.NewInt(437)},
+		{forked, 2000, 1999, big.NewInt(300 + 9), big.NewInt(225)},
+	}
+	for i, tt := range tests {
+		db, _ := ethdb.NewMemDatabase()
+		statedb, _ := state.New(common.Hash{}, db)
+
+		header := &types.Header{Number: big.NewInt(tt.number), Coinbase: miner}
+		var uncles []*types.Header
+		if tt.uncle != 0 {
+			uncles = append(uncles, &types.Header{Number: big.NewInt(tt.uncle), Coinbase: uncler})
+		}
+		AccumulateRewards(tt.config, statedb, header, uncles)
+
+		if balance := statedb.GetBalance(miner); balance.Cmp(tt.miner) != 0 {
+			t.Errorf("test %d: miner reward mismatch: have %v, want %v", i, balance, tt.miner)
+		}
+		if balance := statedb.GetBalance(uncler); balance.Cmp(tt.uncler) != 0 {
+			t.Errorf("test %d: uncle reward mismatch: have %v, want %v", i, balance, tt.uncler)
+		}
+	}
+}
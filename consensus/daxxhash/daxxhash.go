@@ -0,0 +1,104 @@
+// Copyright 2017 The daxxcoreAuthors
+// This file is part of the daxxcore library.
+//
+// The daxxcore library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The daxxcore library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the daxxcore library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package daxxhash implements the daxxhash proof-of-work consensus engine.
+package daxxhash
+
+import (
+	"time"
+
+	"github.com/daxxcoin/daxxcore/consensus"
+	"github.com/daxxcoin/daxxcore/pow"
+	"github.com/daxxcoin/daxxcore/rpc"
+	"github.com/daxxnucleus/daxxhash"
+)
+
+// Daxxhash is a consensus engine based on proof-of-work implementing the daxxhash
+// algorithm. The heavy lifting of hashimoto verification and nonce searching is
+// delegated to the underlying pow.PoW implementation.
+type Daxxhash struct {
+	pow pow.PoW // Hashimoto implementation doing the actual work (nil in fake mode)
+
+	// The fields below are hooks for testing
+	fakeMode  bool          // Flag whether to disable PoW checking
+	fakeFull  bool          // Flag whether to disable all consensus rules
+	fakeFail  uint64        // Block number which fails PoW check even in fake mode
+	fakeDelay time.Duration // Time delay to sleep for before returning from verify
+}
+
+// New creates a full sized daxxhash PoW scheme.
+func New() *Daxxhash {
+	return &Daxxhash{pow: ethash.New()}
+}
+
+// NewShared creates a full sized daxxhash PoW scheme, where a single verification
+// cache is shared across all instances created with NewShared.
+func NewShared() *Daxxhash {
+	return &Daxxhash{pow: ethash.NewShared()}
+}
+
+// NewTester creates a small sized daxxhash PoW scheme useful only for testing
+// purposes. Nonces found by a testing instance are not verifiable with a regular
+// size cache.
+func NewTester() (*Daxxhash, error) {
+	pow, err := ethash.NewForTesting()
+	if err != nil {
+		return nil, err
+	}
+	return &Daxxhash{pow: pow}, nil
+}
+
+// NewFaker creates a daxxhash consensus engine with a fake PoW scheme that accepts
+// all blocks' seal as valid, though they still have to conform to the Daxxcoin
+// consensus rules.
+func NewFaker() *Daxxhash {
+	return &Daxxhash{fakeMode: true}
+}
+
+// NewFakeFailer creates a daxxhash consensus engine with a fake PoW scheme that
+// accepts all blocks as valid apart from the single one specified, though they
+// still have to conform to the Daxxcoin consensus rules.
+func NewFakeFailer(fail uint64) *Daxxhash {
+	return &Daxxhash{fakeMode: true, fakeFail: fail}
+}
+
+// NewFakeDelayer creates a daxxhash consensus engine with a fake PoW scheme that
+// accepts all blocks as valid, but delays verifications by some time, though they
+// still have to conform to the Daxxcoin consensus rules.
+func NewFakeDelayer(delay time.Duration) *Daxxhash {
+	return &Daxxhash{fakeMode: true, fakeDelay: delay}
+}
+
+// NewFullFaker creates a daxxhash consensus engine with a full fake scheme that
+// accepts all blocks as valid, without checking any consensus rules whatsoever.
+func NewFullFaker() *Daxxhash {
+	return &Daxxhash{fakeMode: true, fakeFull: true}
+}
+
+// Hashrate implements PoW, returning the measured rate of the search invocations
+// per second over the last minute.
+func (daxxhash *Daxxhash) Hashrate() float64 {
+	if daxxhash.fakeMode {
+		return 0
+	}
+	return float64(daxxhash.pow.GetHashrate())
+}
+
+// APIs implements consensus.Engine, returning the user facing RPC APIs. Currently
+// that is empty.
+func (daxxhash *Daxxhash) APIs(chain consensus.ChainReader) []rpc.API {
+	return nil
+}
@@ -0,0 +1,45 @@
+// Copyright 2017 The daxxcoreAuthors
+// This file is part of the daxxcore library.
+//
+// The daxxcore library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The daxxcore library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the daxxcore library. If not, see <http://www.gnu.org/licenses/>.
+
+package daxxhash
+
+import (
+	"github.com/daxxcoin/daxxcore/common"
+	"github.com/daxxcoin/daxxcore/consensus"
+	"github.com/daxxcoin/daxxcore/core/types"
+)
+
+// Seal implements consensus.Engine, attempting to find a nonce that satisfies
+// the block's difficulty requirements. A nil block is returned if the search
+// was aborted through the stop channel.
+func (daxxhash *Daxxhash) Seal(chain consensus.ChainReader, block *types.Block, stop <-chan struct{}) (*types.Block, error) {
+	// If we're running a fake PoW, simply return a 0 nonce immediately
+	if daxxhash.fakeMode {
+		header := block.Header()
+		header.Nonce, header.MixDigest = types.BlockNonce{}, common.Hash{}
+		return block.WithSeal(header), nil
+	}
+	// Search for a valid nonce with the underlying hashimoto implementation
+	nonce, digest := daxxhash.pow.Search(block, stop, 0)
+	if digest == nil {
+		return nil, nil
+	}
+	header := block.Header()
+	header.Nonce = types.EncodeNonce(nonce)
+	header.MixDigest = common.BytesToHash(digest)
+
+	return block.WithSeal(header), nil
+}
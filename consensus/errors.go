@@ -1,4 +1,4 @@
-// Copyright 2014 The daxxcoreAuthors
+// Copyright 2017 The daxxcoreAuthors
 // This file is part of the daxxcore library.
 //
 // The daxxcore library is free software: you can redistribute it and/or modify
@@ -14,18 +14,20 @@
 // You should have received a copy of the GNU Lesser General Public License
 // along with the daxxcore library. If not, see <http://www.gnu.org/licenses/>.
 
-package core
+package consensus
 
-import (
-	"math/big"
-)
+import "errors"
 
-//var BlockReward *big.Int = big.NewInt(7e+18)
+var (
+	// ErrUnknownAncestor is returned when validating a block requires an ancestor
+	// that is unknown.
+	ErrUnknownAncestor = errors.New("unknown ancestor")
 
+	// ErrFutureBlock is returned when a block's timestamp is in the future according
+	// to the current node.
+	ErrFutureBlock = errors.New("block in the future")
 
-func setBlockReward() *big.Int {
-        var reward *big.Int = new(big.Int)
-        reward.SetString("30000000000000000000", 10)
-        return reward;
-}
-var BlockReward = setBlockReward()
+	// ErrInvalidNumber is returned if a block's number doesn't equal it's parent's
+	// plus one.
+	ErrInvalidNumber = errors.New("invalid block number")
+)
@@ -21,17 +21,16 @@ import (
 	"errors"
 	"fmt"
 	"io/ioutil"
-	"math/big"
 	"os"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/daxxcoin/daxxcore/common"
+	"github.com/daxxcoin/daxxcore/core"
 	"github.com/daxxcoin/daxxcore/daxx"
 	"github.com/daxxcoin/daxxcore/internal/jsre"
 	"github.com/daxxcoin/daxxcore/node"
-	"github.com/daxxcoin/daxxcore/params"
 )
 
 const (
@@ -97,9 +96,9 @@ func newTester(t *testing.T, confOverride func(*eth.Config)) *tester {
 		t.Fatalf("failed to create node: %v", err)
 	}
 	ethConf := &eth.Config{
-		ChainConfig: &params.ChainConfig{HomesteadBlock: new(big.Int), ChainId: new(big.Int)},
-		Daxxcoinbase:   common.HexToAddress(testAddress),
-		PowTest:     true,
+		Genesis:      core.DevGenesisBlock(),
+		Daxxcoinbase: common.HexToAddress(testAddress),
+		PowTest:      true,
 	}
 	if confOverride != nil {
 		confOverride(ethConf)
@@ -30,7 +30,9 @@ import (
 
 	"github.com/daxxcoin/daxxcore/common"
 	"github.com/daxxcoin/daxxcore/common/mclock"
+	"github.com/daxxcoin/daxxcore/consensus"
 	"github.com/daxxcoin/daxxcore/core/state"
+	"github.com/daxxcoin/daxxcore/core/state/snapshot"
 	"github.com/daxxcoin/daxxcore/core/types"
 	"github.com/daxxcoin/daxxcore/core/vm"
 	"github.com/daxxcoin/daxxcore/crypto"
@@ -40,10 +42,10 @@ import (
 	"github.com/daxxcoin/daxxcore/logger/glog"
 	"github.com/daxxcoin/daxxcore/metrics"
 	"github.com/daxxcoin/daxxcore/params"
-	"github.com/daxxcoin/daxxcore/pow"
 	"github.com/daxxcoin/daxxcore/rlp"
 	"github.com/daxxcoin/daxxcore/trie"
 	"github.com/hashicorp/golang-lru"
+	"gopkg.in/karalabe/cookiejar.v2/collections/prque"
 )
 
 var (
@@ -60,8 +62,22 @@ const (
 	// must be bumped when consensus algorithm is changed, this forces the upgradedb
 	// command to be run (forces the blocks to be imported again using the new algorithm)
 	BlockChainVersion = 3
+
+	// triesInMemory is the number of recent block states kept in memory when trie
+	// pruning is enabled. Older states are garbage collected unless flushed.
+	triesInMemory = 128
 )
 
+// CacheConfig contains the configuration values for the trie caching/pruning
+// that's resident in a blockchain.
+type CacheConfig struct {
+	Disabled          bool               // Whether to disable trie write caching and pruning (archive node)
+	TrieNodeLimit     common.StorageSize // Memory limit at which to flush the in-memory tries to disk
+	TrieFlushInterval uint64             // Number of blocks after which to flush the in-memory tries to disk
+	SnapshotLimit     int                // Memory allowance (MB) for the flat state snapshot read cache (0 disables snapshots)
+	TxLookupLimit     uint64             // Number of recent blocks to keep transaction lookups for (0 = entire chain)
+}
+
 // BlockChain represents the canonical chain given a database with a genesis
 // block. The Blockchain manages chain imports, reverts, chain reorganisations.
 //
@@ -92,6 +108,14 @@ type BlockChain struct {
 	currentBlock     *types.Block // Current head of the block chain
 	currentFastBlock *types.Block // Current head of the fast-sync chain (may be above the block chain!)
 
+	cacheConfig *CacheConfig       // Cache configuration for trie pruning
+	triedb      *trie.NodeDatabase // Memory cache of recent trie nodes (nil for archive nodes)
+	triegc      *prque.Prque       // Priority queue mapping block numbers to tries to gc
+	lastFlush   uint64             // Number of the last block whose state was flushed to disk
+	gcmu        sync.Mutex         // Trie garbage collection lock
+	freezemu    sync.Mutex         // Ancient store lock serializing freezing and rewinds
+	snaps       *snapshot.Tree     // Flat state snapshot for fast state reads (nil if disabled)
+
 	stateCache   *state.StateDB // State database to reuse between imports (contains state cache)
 	bodyCache    *lru.Cache     // Cache for the most recent block bodies
 	bodyRLPCache *lru.Cache     // Cache for the most recent block bodies in RLP encoded format
@@ -102,9 +126,10 @@ type BlockChain struct {
 	running int32         // running must be called atomically
 	// procInterrupt must be atomically called
 	procInterrupt int32          // interrupt signaler for block processing
+	txIndexing    int32          // whether old transactions are being indexed (atomic)
 	wg            sync.WaitGroup // chain processing wait group for shutting down
 
-	pow       pow.PoW
+	engine    consensus.Engine
 	processor Processor // block processor interface
 	validator Validator // block and state validator interface
 	vmConfig  vm.Config
@@ -112,8 +137,11 @@ type BlockChain struct {
 
 // NewBlockChain returns a fully initialised block chain using information
 // available in the database. It initialiser the default Daxxcoin Validator and
-// Processor.
-func NewBlockChain(chainDb ethdb.Database, config *params.ChainConfig, pow pow.PoW, mux *event.TypeMux, vmConfig vm.Config) (*BlockChain, error) {
+// Processor. If cacheConfig is nil, every state is written straight to disk.
+func NewBlockChain(chainDb ethdb.Database, cacheConfig *CacheConfig, config *params.ChainConfig, engine consensus.Engine, mux *event.TypeMux, vmConfig vm.Config) (*BlockChain, error) {
+	if cacheConfig == nil {
+		cacheConfig = &CacheConfig{Disabled: true}
+	}
 	bodyCache, _ := lru.New(bodyCacheLimit)
 	bodyRLPCache, _ := lru.New(bodyCacheLimit)
 	blockCache, _ := lru.New(blockCacheLimit)
@@ -121,6 +149,7 @@ func NewBlockChain(chainDb ethdb.Database, config *params.ChainConfig, pow pow.P
 
 	bc := &BlockChain{
 		config:       config,
+		cacheConfig:  cacheConfig,
 		chainDb:      chainDb,
 		eventMux:     mux,
 		quit:         make(chan struct{}),
@@ -128,15 +157,18 @@ func NewBlockChain(chainDb ethdb.Database, config *params.ChainConfig, pow pow.P
 		bodyRLPCache: bodyRLPCache,
 		blockCache:   blockCache,
 		futureBlocks: futureBlocks,
-		pow:          pow,
+		engine:       engine,
 		vmConfig:     vmConfig,
 	}
-	bc.SetValidator(NewBlockValidator(config, bc, pow))
-	bc.SetProcessor(NewStateProcessor(config, bc))
+	if !cacheConfig.Disabled {
+		bc.triedb = trie.NewNodeDatabase(chainDb)
+		bc.triegc = prque.New()
+	}
+	bc.SetValidator(NewBlockValidator(config, bc, engine))
+	bc.SetProcessor(NewStateProcessor(config, bc, engine))
 
-	gv := func() HeaderValidator { return bc.Validator() }
 	var err error
-	bc.hc, err = NewHeaderChain(chainDb, config, gv, bc.getProcInterrupt)
+	bc.hc, err = NewHeaderChain(chainDb, config, engine, bc.getProcInterrupt)
 	if err != nil {
 		return nil, err
 	}
@@ -149,6 +181,7 @@ func NewBlockChain(chainDb ethdb.Database, config *params.ChainConfig, pow pow.P
 	if err := bc.loadLastState(); err != nil {
 		return nil, err
 	}
+	bc.lastFlush = bc.currentBlock.NumberU64()
 	// Check the current state of the block hashes and make sure that we do not have any of the bad blocks in our chain
 	for hash := range BadHashes {
 		if header := bc.GetHeaderByHash(hash); header != nil {
@@ -157,13 +190,26 @@ func NewBlockChain(chainDb ethdb.Database, config *params.ChainConfig, pow pow.P
 			// make sure the headerByNumber (if present) is in our current canonical chain
 			if headerByNumber != nil && headerByNumber.Hash() == header.Hash() {
 				glog.V(logger.Error).Infof("Found bad hash, rewinding chain to block #%d [%x…]", header.Number, header.ParentHash[:4])
-				bc.SetHead(header.Number.Uint64() - 1)
+				if err := bc.SetHead(header.Number.Uint64() - 1); err != nil {
+					return nil, err
+				}
 				glog.V(logger.Error).Infoln("Chain rewind was successful, resuming normal operation")
 			}
 		}
 	}
 	// Take ownership of this particular state
 	go bc.update()
+
+	// Start moving immutable chain data into the ancient store, if there's one
+	if freezer := ethdb.AncientStore(chainDb); freezer != nil {
+		bc.wg.Add(1)
+		go bc.freeze(freezer)
+	}
+	// Keep the transaction lookups within the configured history limit
+	if cacheConfig.TxLookupLimit > 0 || GetTxIndexTail(chainDb) > 0 {
+		bc.wg.Add(1)
+		go bc.maintainTxIndex()
+	}
 	return bc, nil
 }
 
@@ -178,14 +224,18 @@ func (self *BlockChain) loadLastState() error {
 	head := GetHeadBlockHash(self.chainDb)
 	if head == (common.Hash{}) {
 		// Corrupt or empty database, init from scratch
-		self.Reset()
+		if err := self.Reset(); err != nil {
+			return err
+		}
 	} else {
 		if block := self.GetBlockByHash(head); block != nil {
 			// Block found, set as the current head
 			self.currentBlock = block
 		} else {
 			// Corrupt or empty database, init from scratch
-			self.Reset()
+			if err := self.Reset(); err != nil {
+				return err
+			}
 		}
 	}
 	// Restore the last known head header
@@ -203,8 +253,30 @@ func (self *BlockChain) loadLastState() error {
 			self.currentFastBlock = block
 		}
 	}
+	// Make sure the state associated with the head block is available. Pruning
+	// nodes lose the states not yet flushed to disk on an unclean shutdown.
+	if !self.hasState(self.currentBlock.Root()) {
+		glog.V(logger.Warn).Infof("Head state missing for block #%d [%x…], repairing chain", self.currentBlock.Number(), self.currentBlock.Hash().Bytes()[:4])
+		if err := self.repair(&self.currentBlock); err != nil {
+			return err
+		}
+	}
+	// Load the flat state snapshot of the head state, regenerating it if it does
+	// not match (e.g. after an unclean shutdown or a rewind)
+	if self.cacheConfig != nil && self.cacheConfig.SnapshotLimit > 0 {
+		root := self.currentBlock.Root()
+		if self.snaps == nil {
+			var triedb trie.Database = self.chainDb
+			if self.triedb != nil {
+				triedb = self.triedb
+			}
+			self.snaps = snapshot.New(self.chainDb, triedb, self.cacheConfig.SnapshotLimit, root, true)
+		} else if self.snaps.Snapshot(root) == nil {
+			self.snaps.Rebuild(root)
+		}
+	}
 	// Initialize a statedb cache to ensure singleton account bloom filter generation
-	statedb, err := state.New(self.currentBlock.Root(), self.chainDb)
+	statedb, err := state.NewWithSnapshots(self.currentBlock.Root(), self.chainDb, self.triedb, self.snaps)
 	if err != nil {
 		return err
 	}
@@ -223,14 +295,46 @@ func (self *BlockChain) loadLastState() error {
 	return nil
 }
 
+// repair tries to repair the current blockchain by rolling back the current block
+// until one with associated state is found. This is needed to fix incomplete db
+// writes caused either by crashes/power outages, or simply non-committed tries.
+//
+// This method only rolls back the current block. The current header and current
+// fast block are left intact.
+func (self *BlockChain) repair(head **types.Block) error {
+	for {
+		// Abort if we've rewound to a head block that does have associated state
+		if self.hasState((*head).Root()) {
+			glog.V(logger.Info).Infof("Rewound chain to past state #%d [%x…]", (*head).Number(), (*head).Hash().Bytes()[:4])
+			return nil
+		}
+		// Otherwise rewind one block and recheck state availability there
+		parent := self.GetBlock((*head).ParentHash(), (*head).NumberU64()-1)
+		if parent == nil {
+			return fmt.Errorf("missing state and parent of block #%d [%x…]", (*head).Number(), (*head).Hash().Bytes()[:4])
+		}
+		*head = parent
+	}
+}
+
 // SetHead rewinds the local chain to a new head. In the case of headers, everything
 // above the new head will be deleted and the new one set. In the case of blocks
 // though, the head may be further rewound if block bodies are missing (non-archive
 // nodes after a fast sync).
-func (bc *BlockChain) SetHead(head uint64) {
+func (bc *BlockChain) SetHead(head uint64) error {
+	bc.freezemu.Lock()
+	defer bc.freezemu.Unlock()
+
 	bc.mu.Lock()
 	defer bc.mu.Unlock()
 
+	// Drop any frozen blocks above the new head first, they are not canonical any
+	// more. If that fails, the chain is left untouched on its current head.
+	if freezer := ethdb.AncientStore(bc.chainDb); freezer != nil {
+		if err := freezer.TruncateAncients(head + 1); err != nil {
+			return fmt.Errorf("failed to truncate ancient store: %v", err)
+		}
+	}
 	delFn := func(hash common.Hash, num uint64) {
 		DeleteBody(bc.chainDb, hash, num)
 	}
@@ -264,7 +368,7 @@ func (bc *BlockChain) SetHead(head uint64) {
 	if err := WriteHeadFastBlockHash(bc.chainDb, bc.currentFastBlock.Hash()); err != nil {
 		glog.Fatalf("failed to reset head fast block hash: %v", err)
 	}
-	bc.loadLastState()
+	return bc.loadLastState()
 }
 
 // FastSyncCommitHead sets the current head block to the one defined by the hash
@@ -358,8 +462,8 @@ func (self *BlockChain) Processor() Processor {
 	return self.processor
 }
 
-// AuxValidator returns the auxiliary validator (Proof of work atm)
-func (self *BlockChain) AuxValidator() pow.PoW { return self.pow }
+// Engine retrieves the blockchain's consensus engine.
+func (self *BlockChain) Engine() consensus.Engine { return self.engine }
 
 // State returns a new mutable state based on the current HEAD block.
 func (self *BlockChain) State() (*state.StateDB, error) {
@@ -372,15 +476,17 @@ func (self *BlockChain) StateAt(root common.Hash) (*state.StateDB, error) {
 }
 
 // Reset purges the entire blockchain, restoring it to its genesis state.
-func (bc *BlockChain) Reset() {
-	bc.ResetWithGenesisBlock(bc.genesisBlock)
+func (bc *BlockChain) Reset() error {
+	return bc.ResetWithGenesisBlock(bc.genesisBlock)
 }
 
 // ResetWithGenesisBlock purges the entire blockchain, restoring it to the
 // specified genesis state.
-func (bc *BlockChain) ResetWithGenesisBlock(genesis *types.Block) {
+func (bc *BlockChain) ResetWithGenesisBlock(genesis *types.Block) error {
 	// Dump the entire block chain and purge the caches
-	bc.SetHead(0)
+	if err := bc.SetHead(0); err != nil {
+		return err
+	}
 
 	bc.mu.Lock()
 	defer bc.mu.Unlock()
@@ -398,6 +504,8 @@ func (bc *BlockChain) ResetWithGenesisBlock(genesis *types.Block) {
 	bc.hc.SetGenesis(bc.genesisBlock.Header())
 	bc.hc.SetCurrentHeader(bc.genesisBlock.Header())
 	bc.currentFastBlock = bc.genesisBlock
+
+	return nil
 }
 
 // Export writes the active chain to the given writer.
@@ -513,7 +621,13 @@ func (bc *BlockChain) HasBlockAndState(hash common.Hash) bool {
 		return false
 	}
 	// Ensure the associated state is also present
-	_, err := state.New(block.Root(), bc.chainDb)
+	return bc.hasState(block.Root())
+}
+
+// hasState checks whether the state trie with the given root is available,
+// either in the trie node cache or on disk.
+func (bc *BlockChain) hasState(root common.Hash) bool {
+	_, err := state.NewWithNodeCache(root, bc.chainDb, bc.triedb)
 	return err == nil
 }
 
@@ -586,6 +700,35 @@ func (bc *BlockChain) Stop() {
 
 	bc.wg.Wait()
 
+	// Ensure the state of a recent block is persisted to disk before shutting down,
+	// along with a few older ones to allow shallow reorgs after a restart.
+	if bc.triedb != nil {
+		bc.gcmu.Lock()
+		for _, offset := range []uint64{0, 1, triesInMemory - 1} {
+			if number := bc.CurrentBlock().NumberU64(); number > offset {
+				recent := bc.GetBlockByNumber(number - offset)
+
+				glog.V(logger.Info).Infof("Writing cached state of block #%d [%x…] to disk", recent.Number(), recent.Hash().Bytes()[:4])
+				if err := bc.triedb.Commit(recent.Root()); err != nil {
+					glog.V(logger.Error).Infof("Failed to commit recent state trie: %v", err)
+				}
+			}
+		}
+		for !bc.triegc.Empty() {
+			bc.triedb.Dereference(bc.triegc.PopItem().(common.Hash))
+		}
+		if nodes := bc.triedb.Nodes(); nodes != 0 {
+			glog.V(logger.Debug).Infof("Dropped %d unreferenced trie nodes (%v) from memory", nodes, bc.triedb.Size())
+		}
+		bc.gcmu.Unlock()
+	}
+	// Persist the snapshot of the head state, so it can be reused after a restart
+	if bc.snaps != nil {
+		if err := bc.snaps.Cap(bc.CurrentBlock().Root(), 0); err != nil {
+			glog.V(logger.Error).Infof("Failed to persist state snapshot: %v", err)
+		}
+		bc.snaps.Close()
+	}
 	glog.V(logger.Info).Infoln("Chain manager stopped")
 }
 
@@ -853,6 +996,76 @@ func (self *BlockChain) WriteBlock(block *types.Block) (status WriteStatus, err
 	return
 }
 
+// WriteBlockAndState commits the post-state of a fully processed block and
+// writes the block itself into the chain.
+func (self *BlockChain) WriteBlockAndState(block *types.Block, state *state.StateDB) (status WriteStatus, err error) {
+	if err := self.commitState(block, state); err != nil {
+		return NonStatTy, err
+	}
+	return self.WriteBlock(block)
+}
+
+// commitState commits the post-state of a processed block. Archive nodes write
+// it straight to disk, whereas pruning nodes keep it in memory until it either
+// gets garbage collected or flushed to disk with an older retained state.
+func (self *BlockChain) commitState(block *types.Block, state *state.StateDB) error {
+	root, err := state.Commit(self.config.IsEIP158(block.Number()))
+	if err != nil {
+		return err
+	}
+	// Flatten the snapshot layers beyond the retention window into the persistent
+	// one. The disk layer is kept at a state still referenced by the trie cache,
+	// as its generator might need to read it.
+	if self.snaps != nil && self.snaps.Snapshot(root) != nil {
+		if err := self.snaps.Cap(root, triesInMemory-1); err != nil {
+			glog.V(logger.Warn).Infof("Failed to flatten state snapshot of block #%d [%x…]: %v", block.Number(), block.Hash().Bytes()[:4], err)
+		}
+	}
+	if self.triedb == nil {
+		return nil
+	}
+	self.gcmu.Lock()
+	defer self.gcmu.Unlock()
+
+	// Keep the new state alive until it leaves the retention window
+	self.triedb.Reference(root, common.Hash{})
+	self.triegc.Push(root, -float32(block.NumberU64()))
+
+	current := block.NumberU64()
+	if current <= triesInMemory {
+		return nil
+	}
+	chosen := current - triesInMemory
+
+	// Flush the oldest retained canonical state if the flush interval elapsed or
+	// the in-memory tries outgrew their allowance
+	var (
+		size     = self.triedb.Size()
+		interval = self.cacheConfig.TrieFlushInterval
+	)
+	if size > self.cacheConfig.TrieNodeLimit || (interval > 0 && chosen >= self.lastFlush+interval) {
+		if header := self.GetHeaderByNumber(chosen); header != nil {
+			if chosen < self.lastFlush+triesInMemory {
+				glog.V(logger.Warn).Infof("State in memory for too long, committing (size %v, allowance %v)", size, self.cacheConfig.TrieNodeLimit)
+			}
+			if err := self.triedb.Commit(header.Root); err != nil {
+				return err
+			}
+			self.lastFlush = chosen
+		}
+	}
+	// Garbage collect anything below the retention window
+	for !self.triegc.Empty() {
+		root, number := self.triegc.Pop()
+		if uint64(-number) > chosen {
+			self.triegc.Push(root, number)
+			break
+		}
+		self.triedb.Dereference(root.(common.Hash))
+	}
+	return nil
+}
+
 // InsertChain will attempt to insert the given chain in to the canonical chain or, otherwise, create a fork. If an error is returned
 // it will return the index number of the failing block as well an error describing what went wrong (for possible errors see core/errors.go).
 func (self *BlockChain) InsertChain(chain types.Blocks) (int, error) {
@@ -881,12 +1094,17 @@ func (self *BlockChain) InsertChain(chain types.Blocks) (int, error) {
 		stats         = insertStats{startTime: mclock.Now()}
 		events        = make([]interface{}, 0, len(chain))
 		coalescedLogs []*types.Log
-		nonceChecked  = make([]bool, len(chain))
 	)
+	// Start the parallel header verifier
+	headers := make([]*types.Header, len(chain))
+	seals := make([]bool, len(chain))
 
-	// Start the parallel nonce verifier.
-	nonceAbort, nonceResults := verifyNoncesFromBlocks(self.pow, chain)
-	defer close(nonceAbort)
+	for i, block := range chain {
+		headers[i] = block.Header()
+		seals[i] = true
+	}
+	abort, results := self.engine.VerifyHeaders(self, headers, seals)
+	defer close(abort)
 
 	for i, block := range chain {
 		if atomic.LoadInt32(&self.procInterrupt) == 1 {
@@ -895,38 +1113,30 @@ func (self *BlockChain) InsertChain(chain types.Blocks) (int, error) {
 		}
 
 		bstart := time.Now()
-		// Wait for block i's nonce to be verified before processing
-		// its state transition.
-		for !nonceChecked[i] {
-			r := <-nonceResults
-			nonceChecked[r.index] = true
-			if !r.valid {
-				block := chain[r.index]
-				return r.index, &BlockNonceErr{Hash: block.Hash(), Number: block.Number(), Nonce: block.Nonce()}
-			}
-		}
-
+		// If the header is a banned one, straight out abort
 		if BadHashes[block.Hash()] {
 			err := BadHashError(block.Hash())
 			self.reportBlock(block, nil, err)
 			return i, err
 		}
-		// Stage 1 validation of the block using the chain's validator
-		// interface.
-		err := self.Validator().ValidateBlock(block)
+		// Wait for the block's verification to complete
+		err := <-results
+		if err == nil {
+			err = self.Validator().ValidateBody(block)
+		}
 		if err != nil {
 			if IsKnownBlockErr(err) {
 				stats.ignored++
 				continue
 			}
 
-			if err == BlockFutureErr {
+			if err == consensus.ErrFutureBlock {
 				// Allow up to MaxFuture second in the future blocks. If this limit
 				// is exceeded the chain is discarded and processed at a later time
 				// if given.
 				max := big.NewInt(time.Now().Unix() + maxTimeFutureBlocks)
 				if block.Time().Cmp(max) == 1 {
-					return i, fmt.Errorf("%v: BlockFutureErr, %v > %v", BlockFutureErr, block.Time(), max)
+					return i, fmt.Errorf("future block: %v > %v", block.Time(), max)
 				}
 
 				self.futureBlocks.Add(block.Hash(), block)
@@ -934,7 +1144,7 @@ func (self *BlockChain) InsertChain(chain types.Blocks) (int, error) {
 				continue
 			}
 
-			if IsParentErr(err) && self.futureBlocks.Contains(block.ParentHash()) {
+			if err == consensus.ErrUnknownAncestor && self.futureBlocks.Contains(block.ParentHash()) {
 				self.futureBlocks.Add(block.Hash(), block)
 				stats.queued++
 				continue
@@ -967,12 +1177,6 @@ func (self *BlockChain) InsertChain(chain types.Blocks) (int, error) {
 			self.reportBlock(block, receipts, err)
 			return i, err
 		}
-		// Write state changes to database
-		_, err = self.stateCache.Commit(self.config.IsEIP158(block.Number()))
-		if err != nil {
-			return i, err
-		}
-
 		// coalesce logs for later processing
 		coalescedLogs = append(coalescedLogs, logs...)
 
@@ -980,8 +1184,8 @@ func (self *BlockChain) InsertChain(chain types.Blocks) (int, error) {
 			return i, err
 		}
 
-		// write the block to the chain and get the status
-		status, err := self.WriteBlock(block)
+		// write the block and its state to the chain and get the status
+		status, err := self.WriteBlockAndState(block, self.stateCache)
 		if err != nil {
 			return i, err
 		}
@@ -1095,7 +1299,7 @@ func (self *BlockChain) reorg(oldBlock, newBlock *types.Block) error {
 		// These logs are later announced as deleted.
 		collectLogs = func(h common.Hash) {
 			// Coalesce logs and set 'Removed'.
-			receipts := GetBlockReceipts(self.chainDb, h, self.hc.GetBlockNumber(h))
+			receipts := GetBlockReceipts(self.chainDb, h, self.hc.GetBlockNumber(h), self.config)
 			for _, receipt := range receipts {
 				for _, log := range receipt.Logs {
 					del := *log
@@ -1172,7 +1376,7 @@ func (self *BlockChain) reorg(oldBlock, newBlock *types.Block) error {
 		if err := WriteTransactions(self.chainDb, block); err != nil {
 			return err
 		}
-		receipts := GetBlockReceipts(self.chainDb, block.Hash(), block.NumberU64())
+		receipts := GetBlockReceipts(self.chainDb, block.Hash(), block.NumberU64(), self.config)
 		// write receipts
 		if err := WriteReceipts(self.chainDb, receipts); err != nil {
 			return err
@@ -27,8 +27,8 @@ import (
 	"testing"
 	"time"
 
-	"github.com/daxxnucleus/daxxhash"
 	"github.com/daxxcoin/daxxcore/common"
+	"github.com/daxxcoin/daxxcore/consensus/daxxhash"
 	"github.com/daxxcoin/daxxcore/core/state"
 	"github.com/daxxcoin/daxxcore/core/types"
 	"github.com/daxxcoin/daxxcore/core/vm"
@@ -36,7 +36,6 @@ import (
 	"github.com/daxxcoin/daxxcore/daxxdb"
 	"github.com/daxxcoin/daxxcore/event"
 	"github.com/daxxcoin/daxxcore/params"
-	"github.com/daxxcoin/daxxcore/pow"
 	"github.com/daxxcoin/daxxcore/rlp"
 	"github.com/hashicorp/golang-lru"
 )
@@ -45,15 +44,11 @@ func init() {
 	runtime.GOMAXPROCS(runtime.NumCPU())
 }
 
-func thePow() pow.PoW {
-	pow, _ := ethash.NewForTesting()
-	return pow
-}
-
 func theBlockChain(db ethdb.Database, t *testing.T) *BlockChain {
 	var eventMux event.TypeMux
-	WriteTestNetGenesisBlock(db)
-	blockchain, err := NewBlockChain(db, testChainConfig(), thePow(), &eventMux, vm.Config{})
+	DefaultTestnetGenesisBlock().MustCommit(db)
+	engine, _ := daxxhash.NewTester()
+	blockchain, err := NewBlockChain(db, nil, testChainConfig(), engine, &eventMux, vm.Config{})
 	if err != nil {
 		t.Error("failed creating blockchain:", err)
 		t.FailNow()
@@ -130,7 +125,10 @@ func printChain(bc *BlockChain) {
 func testBlockChainImport(chain types.Blocks, blockchain *BlockChain) error {
 	for _, block := range chain {
 		// Try and process the block
-		err := blockchain.Validator().ValidateBlock(block)
+		err := blockchain.engine.VerifyHeader(blockchain, block.Header(), true)
+		if err == nil {
+			err = blockchain.validator.ValidateBody(block)
+		}
 		if err != nil {
 			if IsKnownBlockErr(err) {
 				continue
@@ -165,7 +163,7 @@ func testBlockChainImport(chain types.Blocks, blockchain *BlockChain) error {
 func testHeaderChainImport(chain []*types.Header, blockchain *BlockChain) error {
 	for _, header := range chain {
 		// Try and validate the header
-		if err := blockchain.Validator().ValidateHeader(header, blockchain.GetHeaderByHash(header.ParentHash), false); err != nil {
+		if err := blockchain.engine.VerifyHeader(blockchain, header, false); err != nil {
 			return err
 		}
 		// Manually insert the header into the database, but don't reorganise (allows subsequent testing)
@@ -430,8 +428,7 @@ func TestChainMultipleInsertions(t *testing.T) {
 
 type bproc struct{}
 
-func (bproc) ValidateBlock(*types.Block) error                        { return nil }
-func (bproc) ValidateHeader(*types.Header, *types.Header, bool) error { return nil }
+func (bproc) ValidateBody(*types.Block) error { return nil }
 func (bproc) ValidateState(block, parent *types.Block, state *state.StateDB, receipts types.Receipts, usedGas *big.Int) error {
 	return nil
 }
@@ -476,11 +473,10 @@ func chm(genesis *types.Block, db ethdb.Database) *BlockChain {
 		chainDb:      db,
 		genesisBlock: genesis,
 		eventMux:     &eventMux,
-		pow:          FakePow{},
+		engine:       daxxhash.NewFullFaker(),
 		config:       testChainConfig(),
 	}
-	valFn := func() HeaderValidator { return bc.Validator() }
-	bc.hc, _ = NewHeaderChain(db, testChainConfig(), valFn, bc.getProcInterrupt)
+	bc.hc, _ = NewHeaderChain(db, testChainConfig(), bc.engine, bc.getProcInterrupt)
 	bc.bodyCache, _ = lru.New(100)
 	bc.bodyRLPCache, _ = lru.New(100)
 	bc.blockCache, _ = lru.New(100)
@@ -513,7 +509,7 @@ func testReorgShort(t *testing.T, full bool) {
 func testReorg(t *testing.T, first, second []int, td int64, full bool) {
 	// Create a pristine block chain
 	db, _ := ethdb.NewMemDatabase()
-	genesis, _ := WriteTestNetGenesisBlock(db)
+	genesis := DefaultTestnetGenesisBlock().MustCommit(db)
 	bc := chm(genesis, db)
 
 	// Insert an easy and a difficult chain afterwards
@@ -560,7 +556,7 @@ func TestBadBlockHashes(t *testing.T)  { testBadHashes(t, true) }
 func testBadHashes(t *testing.T, full bool) {
 	// Create a pristine block chain
 	db, _ := ethdb.NewMemDatabase()
-	genesis, _ := WriteTestNetGenesisBlock(db)
+	genesis := DefaultTestnetGenesisBlock().MustCommit(db)
 	bc := chm(genesis, db)
 
 	// Create a chain, ban a hash and try to import
@@ -587,7 +583,7 @@ func TestReorgBadBlockHashes(t *testing.T)  { testReorgBadHashes(t, true) }
 func testReorgBadHashes(t *testing.T, full bool) {
 	// Create a pristine block chain
 	db, _ := ethdb.NewMemDatabase()
-	genesis, _ := WriteTestNetGenesisBlock(db)
+	genesis := DefaultTestnetGenesisBlock().MustCommit(db)
 	bc := chm(genesis, db)
 
 	// Create a chain, import and ban afterwards
@@ -614,7 +610,7 @@ func testReorgBadHashes(t *testing.T, full bool) {
 		defer func() { delete(BadHashes, headers[3].Hash()) }()
 	}
 	// Create a new chain manager and check it rolled back the state
-	ncm, err := NewBlockChain(db, testChainConfig(), FakePow{}, new(event.TypeMux), vm.Config{})
+	ncm, err := NewBlockChain(db, nil, testChainConfig(), daxxhash.NewFaker(), new(event.TypeMux), vm.Config{})
 	if err != nil {
 		t.Fatalf("failed to create new chain manager: %v", err)
 	}
@@ -645,19 +641,18 @@ func testInsertNonceError(t *testing.T, full bool) {
 		}
 		// Create and insert a chain with a failing nonce
 		var (
-			failAt   int
-			failRes  int
-			failNum  uint64
-			failHash common.Hash
+			failAt  int
+			failRes int
+			failNum uint64
 		)
 		if full {
 			blocks := makeBlockChain(blockchain.CurrentBlock(), i, db, 0)
 
 			failAt = rand.Int() % len(blocks)
 			failNum = blocks[failAt].NumberU64()
-			failHash = blocks[failAt].Hash()
 
-			blockchain.pow = failPow{failNum}
+			blockchain.engine = daxxhash.NewFakeFailer(failNum)
+			blockchain.hc.engine = blockchain.engine
 
 			failRes, err = blockchain.InsertChain(blocks)
 		} else {
@@ -665,26 +660,18 @@ func testInsertNonceError(t *testing.T, full bool) {
 
 			failAt = rand.Int() % len(headers)
 			failNum = headers[failAt].Number.Uint64()
-			failHash = headers[failAt].Hash()
 
-			blockchain.pow = failPow{failNum}
-			blockchain.validator = NewBlockValidator(testChainConfig(), blockchain, failPow{failNum})
+			blockchain.engine = daxxhash.NewFakeFailer(failNum)
+			blockchain.hc.engine = blockchain.engine
 
 			failRes, err = blockchain.InsertHeaderChain(headers, 1)
 		}
-		// Check that the returned error indicates the nonce failure.
+		// Check that the returned error indicates the failure.
 		if failRes != failAt {
 			t.Errorf("test %d: failure index mismatch: have %d, want %d", i, failRes, failAt)
 		}
-		if !IsBlockNonceErr(err) {
-			t.Fatalf("test %d: error mismatch: have %v, want nonce error %T", i, err, err)
-		}
-		nerr := err.(*BlockNonceErr)
-		if nerr.Number.Uint64() != failNum {
-			t.Errorf("test %d: number mismatch: have %v, want %v", i, nerr.Number, failNum)
-		}
-		if nerr.Hash != failHash {
-			t.Errorf("test %d: hash mismatch: have %x, want %x", i, nerr.Hash[:4], failHash[:4])
+		if err == nil {
+			t.Fatalf("test %d: expected nonce failure, got none", i)
 		}
 		// Check that all no blocks after the failing block have been inserted.
 		for j := 0; j < i-failAt; j++ {
@@ -733,17 +720,17 @@ func TestFastVsFullChains(t *testing.T) {
 	})
 	// Import the chain as an archive node for the comparison baseline
 	archiveDb, _ := ethdb.NewMemDatabase()
-	WriteGenesisBlockForTesting(archiveDb, GenesisAccount{address, funds})
+	GenesisBlockForTesting(archiveDb, address, funds)
 
-	archive, _ := NewBlockChain(archiveDb, testChainConfig(), FakePow{}, new(event.TypeMux), vm.Config{})
+	archive, _ := NewBlockChain(archiveDb, nil, testChainConfig(), daxxhash.NewFaker(), new(event.TypeMux), vm.Config{})
 
 	if n, err := archive.InsertChain(blocks); err != nil {
 		t.Fatalf("failed to process block %d: %v", n, err)
 	}
 	// Fast import the chain as a non-archive node to test
 	fastDb, _ := ethdb.NewMemDatabase()
-	WriteGenesisBlockForTesting(fastDb, GenesisAccount{address, funds})
-	fast, _ := NewBlockChain(fastDb, testChainConfig(), FakePow{}, new(event.TypeMux), vm.Config{})
+	GenesisBlockForTesting(fastDb, address, funds)
+	fast, _ := NewBlockChain(fastDb, nil, testChainConfig(), daxxhash.NewFaker(), new(event.TypeMux), vm.Config{})
 
 	headers := make([]*types.Header, len(blocks))
 	for i, block := range blocks {
@@ -772,7 +759,7 @@ func TestFastVsFullChains(t *testing.T) {
 		} else if types.CalcUncleHash(fblock.Uncles()) != types.CalcUncleHash(ablock.Uncles()) {
 			t.Errorf("block #%d [%x]: uncles mismatch: have %v, want %v", num, hash, fblock.Uncles(), ablock.Uncles())
 		}
-		if freceipts, areceipts := GetBlockReceipts(fastDb, hash, GetBlockNumber(fastDb, hash)), GetBlockReceipts(archiveDb, hash, GetBlockNumber(archiveDb, hash)); types.DeriveSha(freceipts) != types.DeriveSha(areceipts) {
+		if freceipts, areceipts := GetRawBlockReceipts(fastDb, hash, GetBlockNumber(fastDb, hash)), GetRawBlockReceipts(archiveDb, hash, GetBlockNumber(archiveDb, hash)); types.DeriveSha(freceipts) != types.DeriveSha(areceipts) {
 			t.Errorf("block #%d [%x]: receipts mismatch: have %v, want %v", num, hash, freceipts, areceipts)
 		}
 	}
@@ -817,9 +804,9 @@ func TestLightVsFastVsFullChainHeads(t *testing.T) {
 	}
 	// Import the chain as an archive node and ensure all pointers are updated
 	archiveDb, _ := ethdb.NewMemDatabase()
-	WriteGenesisBlockForTesting(archiveDb, GenesisAccount{address, funds})
+	GenesisBlockForTesting(archiveDb, address, funds)
 
-	archive, _ := NewBlockChain(archiveDb, testChainConfig(), FakePow{}, new(event.TypeMux), vm.Config{})
+	archive, _ := NewBlockChain(archiveDb, nil, testChainConfig(), daxxhash.NewFaker(), new(event.TypeMux), vm.Config{})
 
 	if n, err := archive.InsertChain(blocks); err != nil {
 		t.Fatalf("failed to process block %d: %v", n, err)
@@ -830,8 +817,8 @@ func TestLightVsFastVsFullChainHeads(t *testing.T) {
 
 	// Import the chain as a non-archive node and ensure all pointers are updated
 	fastDb, _ := ethdb.NewMemDatabase()
-	WriteGenesisBlockForTesting(fastDb, GenesisAccount{address, funds})
-	fast, _ := NewBlockChain(fastDb, testChainConfig(), FakePow{}, new(event.TypeMux), vm.Config{})
+	GenesisBlockForTesting(fastDb, address, funds)
+	fast, _ := NewBlockChain(fastDb, nil, testChainConfig(), daxxhash.NewFaker(), new(event.TypeMux), vm.Config{})
 
 	headers := make([]*types.Header, len(blocks))
 	for i, block := range blocks {
@@ -849,8 +836,8 @@ func TestLightVsFastVsFullChainHeads(t *testing.T) {
 
 	// Import the chain as a light node and ensure all pointers are updated
 	lightDb, _ := ethdb.NewMemDatabase()
-	WriteGenesisBlockForTesting(lightDb, GenesisAccount{address, funds})
-	light, _ := NewBlockChain(lightDb, testChainConfig(), FakePow{}, new(event.TypeMux), vm.Config{})
+	GenesisBlockForTesting(lightDb, address, funds)
+	light, _ := NewBlockChain(lightDb, nil, testChainConfig(), daxxhash.NewFaker(), new(event.TypeMux), vm.Config{})
 
 	if n, err := light.InsertHeaderChain(headers, 1); err != nil {
 		t.Fatalf("failed to insert header %d: %v", n, err)
@@ -875,11 +862,12 @@ func TestChainTxReorgs(t *testing.T) {
 		db, _   = ethdb.NewMemDatabase()
 		signer  = types.NewEIP155Signer(big.NewInt(1))
 	)
-	genesis := WriteGenesisBlockForTesting(db,
-		GenesisAccount{addr1, big.NewInt(1000000)},
-		GenesisAccount{addr2, big.NewInt(1000000)},
-		GenesisAccount{addr3, big.NewInt(1000000)},
-	)
+	gspec := &Genesis{Alloc: GenesisAlloc{
+		addr1: {Balance: big.NewInt(1000000)},
+		addr2: {Balance: big.NewInt(1000000)},
+		addr3: {Balance: big.NewInt(1000000)},
+	}}
+	genesis := gspec.MustCommit(db)
 	// Create two transactions shared between the chains:
 	//  - postponed: transaction included at a later block in the forked chain
 	//  - swapped: transaction included at the same block number in the forked chain
@@ -916,7 +904,7 @@ func TestChainTxReorgs(t *testing.T) {
 	})
 	// Import the chain. This runs all block validation rules.
 	evmux := &event.TypeMux{}
-	blockchain, _ := NewBlockChain(db, testChainConfig(), FakePow{}, evmux, vm.Config{})
+	blockchain, _ := NewBlockChain(db, nil, testChainConfig(), daxxhash.NewFaker(), evmux, vm.Config{})
 	if i, err := blockchain.InsertChain(chain); err != nil {
 		t.Fatalf("failed to insert original chain[%d]: %v", i, err)
 	}
@@ -985,12 +973,10 @@ func TestLogReorgs(t *testing.T) {
 		code   = common.Hex2Bytes("60606040525b7f24ec1d3ff24c2f6ff210738839dbc339cd45a5294d85c79361016243157aae7b60405180905060405180910390a15b600a8060416000396000f360606040526008565b00")
 		signer = types.NewEIP155Signer(big.NewInt(1))
 	)
-	genesis := WriteGenesisBlockForTesting(db,
-		GenesisAccount{addr1, big.NewInt(10000000000000)},
-	)
+	genesis := GenesisBlockForTesting(db, addr1, big.NewInt(10000000000000))
 
 	evmux := &event.TypeMux{}
-	blockchain, _ := NewBlockChain(db, testChainConfig(), FakePow{}, evmux, vm.Config{})
+	blockchain, _ := NewBlockChain(db, nil, testChainConfig(), daxxhash.NewFaker(), evmux, vm.Config{})
 
 	subs := evmux.Subscribe(RemovedLogsEvent{})
 	chain, _ := GenerateChain(params.TestChainConfig, genesis, db, 2, func(i int, gen *BlockGen) {
@@ -1022,12 +1008,12 @@ func TestReorgSideEvent(t *testing.T) {
 		db, _   = ethdb.NewMemDatabase()
 		key1, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
 		addr1   = crypto.PubkeyToAddress(key1.PublicKey)
-		genesis = WriteGenesisBlockForTesting(db, GenesisAccount{addr1, big.NewInt(10000000000000)})
+		genesis = GenesisBlockForTesting(db, addr1, big.NewInt(10000000000000))
 		signer  = types.NewEIP155Signer(big.NewInt(1))
 	)
 
 	evmux := &event.TypeMux{}
-	blockchain, _ := NewBlockChain(db, testChainConfig(), FakePow{}, evmux, vm.Config{})
+	blockchain, _ := NewBlockChain(db, nil, testChainConfig(), daxxhash.NewFaker(), evmux, vm.Config{})
 
 	chain, _ := GenerateChain(params.TestChainConfig, genesis, db, 3, func(i int, gen *BlockGen) {})
 	if _, err := blockchain.InsertChain(chain); err != nil {
@@ -1099,11 +1085,11 @@ done:
 func TestCanonicalBlockRetrieval(t *testing.T) {
 	var (
 		db, _   = ethdb.NewMemDatabase()
-		genesis = WriteGenesisBlockForTesting(db)
+		genesis = new(Genesis).MustCommit(db)
 	)
 
 	evmux := &event.TypeMux{}
-	blockchain, _ := NewBlockChain(db, testChainConfig(), FakePow{}, evmux, vm.Config{})
+	blockchain, _ := NewBlockChain(db, nil, testChainConfig(), daxxhash.NewFaker(), evmux, vm.Config{})
 
 	chain, _ := GenerateChain(params.TestChainConfig, genesis, db, 10, func(i int, gen *BlockGen) {})
 
@@ -1141,12 +1127,13 @@ func TestEIP155Transition(t *testing.T) {
 		address    = crypto.PubkeyToAddress(key.PublicKey)
 		funds      = big.NewInt(1000000000)
 		deleteAddr = common.Address{1}
-		genesis    = WriteGenesisBlockForTesting(db, GenesisAccount{address, funds}, GenesisAccount{deleteAddr, new(big.Int)})
+		gspec      = &Genesis{Alloc: GenesisAlloc{address: {Balance: funds}, deleteAddr: {Balance: new(big.Int)}}}
+		genesis    = gspec.MustCommit(db)
 		config     = &params.ChainConfig{ChainId: big.NewInt(1), EIP155Block: big.NewInt(2), HomesteadBlock: new(big.Int)}
 		mux        event.TypeMux
 	)
 
-	blockchain, _ := NewBlockChain(db, config, FakePow{}, &mux, vm.Config{})
+	blockchain, _ := NewBlockChain(db, nil, config, daxxhash.NewFaker(), &mux, vm.Config{})
 	blocks, _ := GenerateChain(config, genesis, db, 4, func(i int, block *BlockGen) {
 		var (
 			tx      *types.Transaction
@@ -1241,7 +1228,7 @@ func TestEIP161AccountRemoval(t *testing.T) {
 		address = crypto.PubkeyToAddress(key.PublicKey)
 		funds   = big.NewInt(1000000000)
 		theAddr = common.Address{1}
-		genesis = WriteGenesisBlockForTesting(db, GenesisAccount{address, funds})
+		genesis = GenesisBlockForTesting(db, address, funds)
 		config  = &params.ChainConfig{
 			ChainId:        big.NewInt(1),
 			HomesteadBlock: new(big.Int),
@@ -1250,7 +1237,7 @@ func TestEIP161AccountRemoval(t *testing.T) {
 		}
 		mux event.TypeMux
 
-		blockchain, _ = NewBlockChain(db, config, FakePow{}, &mux, vm.Config{})
+		blockchain, _ = NewBlockChain(db, nil, config, daxxhash.NewFaker(), &mux, vm.Config{})
 	)
 	blocks, _ := GenerateChain(config, genesis, db, 3, func(i int, block *BlockGen) {
 		var (
@@ -1295,3 +1282,122 @@ func TestEIP161AccountRemoval(t *testing.T) {
 		t.Error("account should not expect")
 	}
 }
+
+// Tests that the states of blocks leaving the in-memory retention window are
+// garbage collected when trie pruning is enabled, and that the recent states
+// are flushed to disk when the chain is stopped.
+func TestTrieGarbageCollection(t *testing.T) {
+	var (
+		gendb, _ = ethdb.NewMemDatabase()
+		gspec    = &Genesis{Config: params.TestChainConfig}
+		genesis  = gspec.MustCommit(gendb)
+	)
+	blocks, _ := GenerateChain(gspec.Config, genesis, gendb, 2*triesInMemory, func(i int, b *BlockGen) {
+		b.SetCoinbase(common.Address{byte(i), byte(i >> 8)})
+	})
+	diskdb, _ := ethdb.NewMemDatabase()
+	gspec.MustCommit(diskdb)
+
+	cacheConfig := &CacheConfig{TrieNodeLimit: 256 * 1024 * 1024}
+	chain, err := NewBlockChain(diskdb, cacheConfig, gspec.Config, daxxhash.NewFaker(), new(event.TypeMux), vm.Config{})
+	if err != nil {
+		t.Fatalf("failed to create pruning chain: %v", err)
+	}
+	if n, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("block %d: failed to insert into chain: %v", n, err)
+	}
+	for i, block := range blocks {
+		recent := i >= len(blocks)-triesInMemory
+		if have := chain.hasState(block.Root()); have != recent {
+			t.Errorf("block #%d: state availability mismatch: have %v, want %v", block.NumberU64(), have, recent)
+		}
+		if _, err := state.New(block.Root(), diskdb); err == nil {
+			t.Errorf("block #%d: state written to disk before flush", block.NumberU64())
+		}
+	}
+	// Stop the chain and ensure the head state is persisted
+	chain.Stop()
+
+	head := blocks[len(blocks)-1]
+	if _, err := state.New(head.Root(), diskdb); err != nil {
+		t.Errorf("head state not flushed on stop: %v", err)
+	}
+	if _, err := state.New(blocks[0].Root(), diskdb); err == nil {
+		t.Errorf("garbage collected state flushed on stop")
+	}
+}
+
+// Tests that recent states are flushed to disk, instead of only being kept in
+// memory, if they exceed the memory allowance or the flush interval elapses.
+func TestTrieFlushing(t *testing.T) {
+	var (
+		gendb, _ = ethdb.NewMemDatabase()
+		gspec    = &Genesis{Config: params.TestChainConfig}
+		genesis  = gspec.MustCommit(gendb)
+	)
+	blocks, _ := GenerateChain(gspec.Config, genesis, gendb, triesInMemory+20, func(i int, b *BlockGen) {
+		b.SetCoinbase(common.Address{byte(i), byte(i >> 8)})
+	})
+	tests := []struct {
+		config  *CacheConfig
+		flushed []int // Block indexes expected to have their state on disk
+	}{
+		// Memory allowance exceeded, flush every block leaving the window
+		{&CacheConfig{}, []int{0, 5, 19}},
+		// Flush interval elapsed, flush every tenth block leaving the window
+		{&CacheConfig{TrieNodeLimit: 256 * 1024 * 1024, TrieFlushInterval: 10}, []int{9, 19}},
+	}
+	for i, tt := range tests {
+		diskdb, _ := ethdb.NewMemDatabase()
+		gspec.MustCommit(diskdb)
+
+		chain, _ := NewBlockChain(diskdb, tt.config, gspec.Config, daxxhash.NewFaker(), new(event.TypeMux), vm.Config{})
+		if n, err := chain.InsertChain(blocks); err != nil {
+			t.Fatalf("test %d: block %d: failed to insert into chain: %v", i, n, err)
+		}
+		for _, index := range tt.flushed {
+			if _, err := state.New(blocks[index].Root(), diskdb); err != nil {
+				t.Errorf("test %d: block #%d: state not flushed: %v", i, blocks[index].NumberU64(), err)
+			}
+		}
+		if _, err := state.New(blocks[len(blocks)-1].Root(), diskdb); err == nil {
+			t.Errorf("test %d: head state flushed prematurely", i)
+		}
+	}
+}
+
+// Tests that a pruning chain which was not shut down cleanly rewinds its head to
+// the last block with a persisted state on restart.
+func TestTrieRepairOnRestart(t *testing.T) {
+	var (
+		gendb, _ = ethdb.NewMemDatabase()
+		gspec    = &Genesis{Config: params.TestChainConfig}
+		genesis  = gspec.MustCommit(gendb)
+	)
+	blocks, _ := GenerateChain(gspec.Config, genesis, gendb, 10, func(i int, b *BlockGen) {
+		b.SetCoinbase(common.Address{byte(i)})
+	})
+	diskdb, _ := ethdb.NewMemDatabase()
+	gspec.MustCommit(diskdb)
+
+	cacheConfig := &CacheConfig{TrieNodeLimit: 256 * 1024 * 1024}
+	chain, _ := NewBlockChain(diskdb, cacheConfig, gspec.Config, daxxhash.NewFaker(), new(event.TypeMux), vm.Config{})
+	if n, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("block %d: failed to insert into chain: %v", n, err)
+	}
+	// Reopen the chain without stopping it, losing all the in-memory states
+	chain, err := NewBlockChain(diskdb, cacheConfig, gspec.Config, daxxhash.NewFaker(), new(event.TypeMux), vm.Config{})
+	if err != nil {
+		t.Fatalf("failed to reopen chain: %v", err)
+	}
+	if head := chain.CurrentBlock(); head.Hash() != gspec.ToBlock().Hash() {
+		t.Errorf("head block mismatch: have #%d [%x], want genesis", head.NumberU64(), head.Hash())
+	}
+	// Reimport the lost blocks and ensure the chain can progress
+	if n, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("block %d: failed to reimport into chain: %v", n, err)
+	}
+	if head := chain.CurrentBlock(); head.Hash() != blocks[len(blocks)-1].Hash() {
+		t.Errorf("head block mismatch after reimport: have #%d, want #%d", head.NumberU64(), len(blocks))
+	}
+}
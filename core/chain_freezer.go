@@ -0,0 +1,192 @@
+// Copyright 2017 The daxxcoreAuthors
+// This file is part of the daxxcore library.
+//
+// The daxxcore library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The daxxcore library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the daxxcore library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/daxxcoin/daxxcore/common"
+	"github.com/daxxcoin/daxxcore/core/types"
+	"github.com/daxxcoin/daxxcore/daxxdb"
+	"github.com/daxxcoin/daxxcore/logger"
+	"github.com/daxxcoin/daxxcore/logger/glog"
+	"github.com/daxxcoin/daxxcore/rlp"
+)
+
+const (
+	// freezerThreshold is the number of recent blocks kept in the key-value store
+	// before being considered immutable and moved into the ancient store.
+	freezerThreshold = 90000
+
+	// freezerBatchLimit is the maximum number of blocks to freeze in one batch
+	// before doing an fsync and deleting them from the key-value store.
+	freezerBatchLimit = 30000
+
+	// freezerRecheckInterval is the frequency to check the key-value database for
+	// chain progression that might permit new blocks to be frozen.
+	freezerRecheckInterval = time.Minute
+)
+
+// freeze is a background thread that periodically checks the blockchain for any
+// import progress and moves ancient data from the key-value database into the
+// append-only ancient store.
+func (bc *BlockChain) freeze(freezer *ethdb.Freezer) {
+	defer bc.wg.Done()
+
+	for {
+		// Freeze everything below the threshold, one batch at a time
+		var frozen int
+		if head := bc.CurrentFastBlock().NumberU64(); head > freezerThreshold {
+			var err error
+
+			bc.freezemu.Lock()
+			frozen, err = freezeAncients(bc.chainDb, freezer, head-freezerThreshold)
+			bc.freezemu.Unlock()
+
+			if err != nil {
+				glog.V(logger.Error).Infof("Failed to freeze ancient blocks: %v", err)
+				frozen = 0
+			}
+		}
+		// If a full batch was frozen, continue right away, otherwise wait a bit
+		wait := freezerRecheckInterval
+		if frozen == freezerBatchLimit {
+			wait = 0
+		}
+		select {
+		case <-bc.quit:
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// freezeAncients moves the next batch of canonical blocks, up to and including
+// the given limit, from the key-value database into the ancient store. Frozen
+// data is only deleted from the database after it was synced to disk, together
+// with any side chain blocks at the frozen heights. The hash to number mappings
+// of the canonical blocks and the genesis block are retained in the database.
+//
+// The number of blocks frozen is returned.
+func freezeAncients(db ethdb.Database, freezer *ethdb.Freezer, limit uint64) (int, error) {
+	first := freezer.Ancients()
+	if first > limit {
+		return 0, nil
+	}
+	last := limit
+	if last-first+1 > freezerBatchLimit {
+		last = first + freezerBatchLimit - 1
+	}
+	start := time.Now()
+
+	hashes := make([]common.Hash, 0, last-first+1)
+	for number := first; number <= last; number++ {
+		hash, err := freezeAncient(db, freezer, number)
+		if err != nil {
+			if len(hashes) == 0 {
+				return 0, err
+			}
+			// Persist what's been frozen so far and report the failure afterwards
+			glog.V(logger.Warn).Infof("Stopped freezing at block #%d: %v", number, err)
+			break
+		}
+		hashes = append(hashes, hash)
+	}
+	if err := freezer.Sync(); err != nil {
+		return 0, err
+	}
+	// Ancient data is safely on disk, wipe it from the key-value store together
+	// with any side chain blocks at the same heights, which can't be reorged to
+	// anymore
+	batch := db.NewBatch()
+	for i, hash := range hashes {
+		number := first + uint64(i)
+		if number == 0 {
+			continue
+		}
+		enc := encodeBlockNumber(number)
+		for _, side := range blockHashesAt(db, number) {
+			if side != hash {
+				batch.Delete(append(blockHashPrefix, side.Bytes()...))
+			}
+			batch.Delete(append(append(headerPrefix, enc...), side.Bytes()...))
+			batch.Delete(append(append(append(headerPrefix, enc...), side.Bytes()...), tdSuffix...))
+			batch.Delete(append(append(bodyPrefix, enc...), side.Bytes()...))
+			batch.Delete(append(append(blockReceiptsPrefix, enc...), side.Bytes()...))
+		}
+		batch.Delete(append(append(headerPrefix, enc...), numSuffix...))
+	}
+	if err := batch.Write(); err != nil {
+		return 0, err
+	}
+	glog.V(logger.Info).Infof("Moved blocks #%d-#%d into the ancient store in %v", first, first+uint64(len(hashes))-1, time.Since(start))
+	return len(hashes), nil
+}
+
+// blockHashesAt returns the hashes of all the headers stored in the key-value
+// database at the given height, canonical or not.
+func blockHashesAt(db ethdb.Database, number uint64) []common.Hash {
+	prefix := append(append([]byte{}, headerPrefix...), encodeBlockNumber(number)...)
+
+	it := db.NewIterator(prefix, nil)
+	defer it.Release()
+
+	var hashes []common.Hash
+	for it.Next() {
+		if key := it.Key(); len(key) == len(prefix)+common.HashLength {
+			hashes = append(hashes, common.BytesToHash(key[len(prefix):]))
+		}
+	}
+	return hashes
+}
+
+// freezeAncient appends a single canonical block to the ancient store.
+func freezeAncient(db ethdb.Database, freezer *ethdb.Freezer, number uint64) (common.Hash, error) {
+	hash := GetCanonicalHash(db, number)
+	if hash == (common.Hash{}) {
+		return common.Hash{}, fmt.Errorf("canonical hash missing for #%d", number)
+	}
+	header := GetHeaderRLP(db, hash, number)
+	if len(header) == 0 {
+		return common.Hash{}, fmt.Errorf("block header missing for #%d [%x…]", number, hash[:4])
+	}
+	body := GetBodyRLP(db, hash, number)
+	if len(body) == 0 {
+		return common.Hash{}, fmt.Errorf("block body missing for #%d [%x…]", number, hash[:4])
+	}
+	td := getTdRLP(db, hash, number)
+	if len(td) == 0 {
+		return common.Hash{}, fmt.Errorf("total difficulty missing for #%d [%x…]", number, hash[:4])
+	}
+	receipts := getBlockReceiptsRLP(db, hash, number)
+	if len(receipts) == 0 {
+		// Blocks without transactions (e.g. genesis) may not have stored receipts
+		var content types.Body
+		if err := rlp.DecodeBytes(body, &content); err != nil {
+			return common.Hash{}, fmt.Errorf("invalid block body for #%d [%x…]: %v", number, hash[:4], err)
+		}
+		if len(content.Transactions) > 0 {
+			return common.Hash{}, fmt.Errorf("block receipts missing for #%d [%x…]", number, hash[:4])
+		}
+		receipts = rlp.EmptyList
+	}
+	if err := freezer.AppendAncient(number, hash.Bytes(), header, body, receipts, td); err != nil {
+		return common.Hash{}, err
+	}
+	return hash, nil
+}
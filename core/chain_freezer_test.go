@@ -0,0 +1,195 @@
+// Copyright 2017 The daxxcoreAuthors
+// This file is part of the daxxcore library.
+//
+// The daxxcore library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The daxxcore library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the daxxcore library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/daxxcoin/daxxcore/common"
+	"github.com/daxxcoin/daxxcore/consensus/daxxhash"
+	"github.com/daxxcoin/daxxcore/core/types"
+	"github.com/daxxcoin/daxxcore/core/vm"
+	"github.com/daxxcoin/daxxcore/crypto"
+	"github.com/daxxcoin/daxxcore/daxxdb"
+	"github.com/daxxcoin/daxxcore/event"
+	"github.com/daxxcoin/daxxcore/params"
+)
+
+// newFreezerTestChain creates a block chain on top of a disk database with an
+// attached ancient store and imports a few blocks with transactions into it.
+func newFreezerTestChain(t *testing.T, dir string, n int) (*BlockChain, *ethdb.LDBDatabase, []*types.Block) {
+	var (
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		address = crypto.PubkeyToAddress(key.PublicKey)
+		gspec   = &Genesis{Config: params.TestChainConfig, Alloc: GenesisAlloc{address: {Balance: big.NewInt(1000000000)}}}
+		signer  = types.NewEIP155Signer(big.NewInt(1))
+	)
+	gendb, _ := ethdb.NewMemDatabase()
+	genesis := gspec.MustCommit(gendb)
+	blocks, _ := GenerateChain(gspec.Config, genesis, gendb, n, func(i int, block *BlockGen) {
+		block.SetCoinbase(common.Address{0x00})
+		if i%2 == 0 {
+			tx, err := types.SignTx(types.NewTransaction(block.TxNonce(address), common.Address{0x00}, big.NewInt(1000), params.TxGas, nil, nil), signer, key)
+			if err != nil {
+				panic(err)
+			}
+			block.AddTx(tx)
+		}
+	})
+	db, err := ethdb.NewLDBDatabaseWithFreezer(filepath.Join(dir, "chaindata"), 0, 0, filepath.Join(dir, "ancient"))
+	if err != nil {
+		t.Fatalf("failed to create database: %v", err)
+	}
+	gspec.MustCommit(db)
+
+	chain, err := NewBlockChain(db, nil, gspec.Config, daxxhash.NewFaker(), new(event.TypeMux), vm.Config{})
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	if n, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("block %d: failed to insert into chain: %v", n, err)
+	}
+	return chain, db, append([]*types.Block{genesis}, blocks...)
+}
+
+// Tests that freezing moves old blocks out of the key-value store, while the
+// database accessors transparently keep serving them from the ancient store.
+func TestFreezeAncients(t *testing.T) {
+	dir, err := ioutil.TempDir("", "freezer-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	chain, db, blocks := newFreezerTestChain(t, dir, 16)
+	defer db.Close()
+	defer chain.Stop()
+
+	// Store a side chain block at a height about to be frozen
+	sideHeader := blocks[5].Header()
+	sideHeader.Extra = []byte("side chain")
+	side := types.NewBlockWithHeader(sideHeader)
+	if err := WriteBlock(db, side); err != nil {
+		t.Fatalf("failed to write side block: %v", err)
+	}
+	if err := WriteTd(db, side.Hash(), side.NumberU64(), big.NewInt(1)); err != nil {
+		t.Fatalf("failed to write side block td: %v", err)
+	}
+	if err := WriteBlockReceipts(db, side.Hash(), side.NumberU64(), nil); err != nil {
+		t.Fatalf("failed to write side block receipts: %v", err)
+	}
+	frozen, err := freezeAncients(db, db.Freezer(), 10)
+	if err != nil {
+		t.Fatalf("failed to freeze blocks: %v", err)
+	}
+	if frozen != 11 {
+		t.Fatalf("frozen block count mismatch: have %d, want %d", frozen, 11)
+	}
+	for _, block := range blocks {
+		hash, number := block.Hash(), block.NumberU64()
+
+		// Frozen blocks (except genesis) should be gone from the key-value store
+		_, err := db.Get(append(append(headerPrefix, encodeBlockNumber(number)...), hash.Bytes()...))
+		if inKV := err == nil; inKV != (number == 0 || number > 10) {
+			t.Errorf("block #%d: key-value presence mismatch: have %v", number, inKV)
+		}
+		// All the data should be retrievable nonetheless
+		if have := GetCanonicalHash(db, number); have != hash {
+			t.Errorf("block #%d: canonical hash mismatch: have %x, want %x", number, have, hash)
+		}
+		if have := GetBlock(db, hash, number); have == nil || have.Hash() != hash || len(have.Transactions()) != len(block.Transactions()) {
+			t.Errorf("block #%d: block mismatch: have %v", number, have)
+		}
+		if have := GetTd(db, hash, number); have == nil || have.Cmp(chain.GetTd(hash, number)) != 0 {
+			t.Errorf("block #%d: total difficulty mismatch: have %v", number, have)
+		}
+		if have := GetRawBlockReceipts(db, hash, number); len(have) != len(block.Transactions()) {
+			t.Errorf("block #%d: receipt count mismatch: have %d, want %d", number, len(have), len(block.Transactions()))
+		}
+		if have := GetBlockNumber(db, hash); have != number {
+			t.Errorf("block #%d: number mapping mismatch: have %d", number, have)
+		}
+	}
+	// Side chain blocks at the frozen heights should be gone entirely
+	if hashes := blockHashesAt(db, side.NumberU64()); len(hashes) != 0 {
+		t.Errorf("headers left at frozen height: %x", hashes)
+	}
+	if have := GetBlock(db, side.Hash(), side.NumberU64()); have != nil {
+		t.Errorf("side block retrieved: %v", have)
+	}
+	if have := GetTd(db, side.Hash(), side.NumberU64()); have != nil {
+		t.Errorf("side block total difficulty retrieved: %v", have)
+	}
+	if have := GetBlockNumber(db, side.Hash()); have != missingNumber {
+		t.Errorf("side block number mapping retained: %d", have)
+	}
+	// Non canonical lookups must not be served from the ancient store
+	if have := GetHeader(db, common.Hash{0x01}, 5); have != nil {
+		t.Errorf("non-canonical header retrieved: %v", have)
+	}
+	// Freezing again should continue where the previous round stopped
+	if frozen, err = freezeAncients(db, db.Freezer(), 12); err != nil || frozen != 2 {
+		t.Fatalf("refreeze mismatch: have %d/%v, want 2/nil", frozen, err)
+	}
+}
+
+// Tests that rewinding the chain below the frozen blocks truncates the ancient
+// store too, and that the ancient store survives a restart.
+func TestFreezerRewindAndReopen(t *testing.T) {
+	dir, err := ioutil.TempDir("", "freezer-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	chain, db, blocks := newFreezerTestChain(t, dir, 16)
+	if _, err := freezeAncients(db, db.Freezer(), 10); err != nil {
+		t.Fatalf("failed to freeze blocks: %v", err)
+	}
+	if err := chain.SetHead(5); err != nil {
+		t.Fatalf("failed to rewind chain: %v", err)
+	}
+	if frozen := db.Freezer().Ancients(); frozen != 6 {
+		t.Errorf("frozen block count mismatch after rewind: have %d, want %d", frozen, 6)
+	}
+	if hash := GetCanonicalHash(db, 6); hash != (common.Hash{}) {
+		t.Errorf("rewound canonical hash still present: %x", hash)
+	}
+	if head := chain.CurrentBlock(); head.Hash() != blocks[5].Hash() {
+		t.Errorf("head block mismatch: have #%d, want #5", head.NumberU64())
+	}
+	chain.Stop()
+	db.Close()
+
+	// Reopen the database and ensure the frozen blocks are still there
+	db, err = ethdb.NewLDBDatabaseWithFreezer(filepath.Join(dir, "chaindata"), 0, 0, filepath.Join(dir, "ancient"))
+	if err != nil {
+		t.Fatalf("failed to reopen database: %v", err)
+	}
+	defer db.Close()
+
+	if frozen := db.Freezer().Ancients(); frozen != 6 {
+		t.Errorf("frozen block count mismatch after reopen: have %d, want %d", frozen, 6)
+	}
+	if block := GetBlock(db, blocks[3].Hash(), 3); block == nil || block.Hash() != blocks[3].Hash() {
+		t.Errorf("frozen block not retrievable after reopen: %v", block)
+	}
+}
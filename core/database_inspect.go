@@ -0,0 +1,151 @@
+// Copyright 2017 The daxxcoreAuthors
+// This file is part of the daxxcore library.
+//
+// The daxxcore library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The daxxcore library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the daxxcore library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bytes"
+
+	"github.com/daxxcoin/daxxcore/common"
+	"github.com/daxxcoin/daxxcore/daxxdb"
+)
+
+// Key categories recognised by the database inspector, in display order.
+const (
+	headersCategory = iota
+	bodiesCategory
+	receiptsCategory
+	tdsCategory
+	canonicalCategory
+	txLookupsCategory
+	bloomsCategory
+	preimagesCategory
+	trieNodesCategory
+	unknownCategory
+)
+
+// databaseCategories are the display names of the inspected key categories.
+var databaseCategories = []string{
+	headersCategory:   "Headers",
+	bodiesCategory:    "Bodies",
+	receiptsCategory:  "Receipts",
+	tdsCategory:       "Difficulties",
+	canonicalCategory: "Canonical hashes",
+	txLookupsCategory: "Tx lookups",
+	bloomsCategory:    "Mipmap blooms",
+	preimagesCategory: "Preimages",
+	trieNodesCategory: "Trie nodes",
+	unknownCategory:   "Unknown",
+}
+
+// DatabaseStat is the number of entries and the total size of the keys and
+// values belonging to a single category of the chain database.
+type DatabaseStat struct {
+	Name  string
+	Count uint64
+	Size  common.StorageSize
+}
+
+// classifyKey maps a database key to the schema category it belongs to.
+func classifyKey(key []byte) int {
+	const numHashLen = 8 + common.HashLength // encoded block number + block hash
+
+	switch {
+	case bytes.HasPrefix(key, headerPrefix) && len(key) == len(headerPrefix)+numHashLen:
+		return headersCategory
+	case bytes.HasPrefix(key, headerPrefix) && len(key) == len(headerPrefix)+numHashLen+len(tdSuffix) && bytes.HasSuffix(key, tdSuffix):
+		return tdsCategory
+	case bytes.HasPrefix(key, headerPrefix) && len(key) == len(headerPrefix)+8+len(numSuffix) && bytes.HasSuffix(key, numSuffix):
+		return canonicalCategory
+	case bytes.HasPrefix(key, blockHashPrefix) && len(key) == len(blockHashPrefix)+common.HashLength:
+		return headersCategory
+	case bytes.HasPrefix(key, bodyPrefix) && len(key) == len(bodyPrefix)+numHashLen:
+		return bodiesCategory
+	case bytes.HasPrefix(key, blockReceiptsPrefix) && len(key) == len(blockReceiptsPrefix)+numHashLen:
+		return receiptsCategory
+	case bytes.HasPrefix(key, receiptsPrefix) && len(key) == len(receiptsPrefix)+common.HashLength:
+		return receiptsCategory
+	case bytes.HasPrefix(key, mipmapPre):
+		return bloomsCategory
+	case bytes.HasPrefix(key, []byte(preimagePrefix)) && len(key) == len(preimagePrefix)+common.HashLength:
+		return preimagesCategory
+	case len(key) == common.HashLength+len(txMetaSuffix) && bytes.HasSuffix(key, txMetaSuffix):
+		// May collide with blockHashPrefix for hashes starting with 'H', which is
+		// checked first. It's only statistics, the odd misattribution is fine.
+		return txLookupsCategory
+	case len(key) == common.HashLength:
+		return trieNodesCategory
+	}
+	return unknownCategory
+}
+
+// InspectDatabase iterates over every entry in the chain database, grouping the
+// keys by the schema prefixes they were stored under and accumulating the entry
+// counts and data sizes of each group. Sizes are measured as stored on disk, so
+// compressed values count with their compressed size. The tables of the ancient
+// store, if the database has one, are reported after the key-value categories.
+//
+// Transactions are stored under their bare hash, indistinguishable from trie
+// nodes by key alone. They are however always followed by their lookup entry in
+// iteration order, so the previous key is reclassified when its lookup is found.
+func InspectDatabase(db ethdb.Database) ([]*DatabaseStat, error) {
+	stats := make([]*DatabaseStat, len(databaseCategories))
+	for i, name := range databaseCategories {
+		stats[i] = &DatabaseStat{Name: name}
+	}
+	it := ethdb.RawDatabase(db).NewIterator(nil, nil)
+	defer it.Release()
+
+	var (
+		prevKey  []byte
+		prevSize common.StorageSize
+	)
+	for it.Next() {
+		key := it.Key()
+		size := common.StorageSize(len(key) + len(it.Value()))
+
+		category := classifyKey(key)
+		if category == txLookupsCategory && len(prevKey) == common.HashLength && bytes.Equal(prevKey, key[:common.HashLength]) {
+			stats[trieNodesCategory].Count--
+			stats[trieNodesCategory].Size -= prevSize
+
+			stats[txLookupsCategory].Count++
+			stats[txLookupsCategory].Size += prevSize
+		}
+		stats[category].Count++
+		stats[category].Size += size
+
+		prevKey, prevSize = append(prevKey[:0], key...), size
+	}
+	if err := it.Error(); err != nil {
+		return nil, err
+	}
+	// Append the sizes of the ancient store tables
+	if freezer := ethdb.AncientStore(db); freezer != nil {
+		for _, kind := range ethdb.FreezerTables {
+			size, err := freezer.AncientSize(kind)
+			if err != nil {
+				return nil, err
+			}
+			stats = append(stats, &DatabaseStat{
+				Name:  "Ancient " + kind,
+				Count: freezer.Ancients(),
+				Size:  common.StorageSize(size),
+			})
+		}
+	}
+	return stats, nil
+}
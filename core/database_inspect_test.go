@@ -0,0 +1,154 @@
+// Copyright 2017 The daxxcoreAuthors
+// This file is part of the daxxcore library.
+//
+// The daxxcore library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The daxxcore library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the daxxcore library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/daxxcoin/daxxcore/common"
+	"github.com/daxxcoin/daxxcore/core/types"
+	"github.com/daxxcoin/daxxcore/crypto"
+	"github.com/daxxcoin/daxxcore/daxxdb"
+	"github.com/daxxcoin/daxxcore/rlp"
+)
+
+// Tests that the database inspector attributes every entry written through the
+// database accessors to the correct category.
+func TestInspectDatabase(t *testing.T) {
+	dir, err := ioutil.TempDir("", "inspect-test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := ethdb.NewLDBDatabase(dir, 0, 0)
+	if err != nil {
+		t.Fatalf("failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	txs := []*types.Transaction{
+		types.NewTransaction(1, common.Address{0x11}, big.NewInt(111), big.NewInt(1111), big.NewInt(11111), nil),
+		types.NewTransaction(2, common.Address{0x22}, big.NewInt(222), big.NewInt(2222), big.NewInt(22222), nil),
+	}
+	receipts := types.Receipts{
+		&types.Receipt{TxHash: txs[0].Hash(), GasUsed: big.NewInt(1)},
+		&types.Receipt{TxHash: txs[1].Hash(), GasUsed: big.NewInt(2)},
+	}
+	block := types.NewBlock(&types.Header{Number: big.NewInt(1)}, txs, nil, receipts)
+
+	WriteBlock(db, block)                                       // header + hash->number, body
+	WriteTd(db, block.Hash(), block.NumberU64(), big.NewInt(1)) // td
+	WriteCanonicalHash(db, block.Hash(), block.NumberU64())     // canonical hash
+	WriteBlockReceipts(db, block.Hash(), block.NumberU64(), receipts)
+	WriteReceipts(db, receipts)                                   // per tx receipts
+	WriteTransactions(db, block)                                  // txs + lookups
+	WriteMipmapBloom(db, block.NumberU64(), receipts)             // one per level
+	WritePreimages(db, 1, map[common.Hash][]byte{{0x01}: {0x01}}) // preimage
+	db.Put(crypto.Keccak256([]byte{0xff}), []byte{0xff})          // trie node
+	WriteHeadBlockHash(db, block.Hash())                          // unknown
+
+	want := map[string]uint64{
+		"Headers":          2,
+		"Bodies":           1,
+		"Receipts":         3,
+		"Difficulties":     1,
+		"Canonical hashes": 1,
+		"Tx lookups":       4,
+		"Mipmap blooms":    uint64(len(MIPMapLevels)),
+		"Preimages":        1,
+		"Trie nodes":       1,
+		"Unknown":          1,
+	}
+	stats, err := InspectDatabase(db)
+	if err != nil {
+		t.Fatalf("failed to inspect database: %v", err)
+	}
+	if len(stats) != len(want) {
+		t.Fatalf("category count mismatch: have %d, want %d", len(stats), len(want))
+	}
+	for _, stat := range stats {
+		if stat.Count != want[stat.Name] {
+			t.Errorf("%s: entry count mismatch: have %d, want %d", stat.Name, stat.Count, want[stat.Name])
+		}
+		if (stat.Count == 0) != (stat.Size == 0) {
+			t.Errorf("%s: size %v inconsistent with %d entries", stat.Name, stat.Size, stat.Count)
+		}
+	}
+}
+
+// Tests that the database inspector measures compressed values by their size on
+// disk and reports the tables of the ancient store.
+func TestInspectDatabaseAncients(t *testing.T) {
+	dir, err := ioutil.TempDir("", "inspect-test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ldb, err := ethdb.NewLDBDatabaseWithFreezer(filepath.Join(dir, "chaindata"), 0, 0, filepath.Join(dir, "ancient"))
+	if err != nil {
+		t.Fatalf("failed to create database: %v", err)
+	}
+	defer ldb.Close()
+	db := ethdb.NewCompressedDatabase(ldb, ChainCompressionRules(DefaultChainCompression))
+
+	// Write a block with a well compressible body into the key-value store
+	tx := types.NewTransaction(1, common.Address{0x11}, big.NewInt(111), big.NewInt(1111), big.NewInt(11111), make([]byte, 4096))
+	block := types.NewBlock(&types.Header{Number: big.NewInt(1)}, []*types.Transaction{tx}, nil, nil)
+	WriteBody(db, block.Hash(), block.NumberU64(), block.Body())
+
+	body, err := rlp.EncodeToBytes(block.Body())
+	if err != nil {
+		t.Fatalf("failed to encode body: %v", err)
+	}
+	// Freeze a block into the ancient store
+	blobs := map[string][]byte{
+		ethdb.FreezerHashTable:       common.Hash{0x01}.Bytes(),
+		ethdb.FreezerHeaderTable:     []byte{0x02, 0x02},
+		ethdb.FreezerBodiesTable:     []byte{0x03, 0x03, 0x03},
+		ethdb.FreezerReceiptTable:    []byte{0x04, 0x04, 0x04, 0x04},
+		ethdb.FreezerDifficultyTable: []byte{0x05},
+	}
+	freezer := ldb.Freezer()
+	if err := freezer.AppendAncient(0, blobs[ethdb.FreezerHashTable], blobs[ethdb.FreezerHeaderTable], blobs[ethdb.FreezerBodiesTable], blobs[ethdb.FreezerReceiptTable], blobs[ethdb.FreezerDifficultyTable]); err != nil {
+		t.Fatalf("failed to freeze block: %v", err)
+	}
+	stats, err := InspectDatabase(db)
+	if err != nil {
+		t.Fatalf("failed to inspect database: %v", err)
+	}
+	if len(stats) != len(databaseCategories)+len(ethdb.FreezerTables) {
+		t.Fatalf("category count mismatch: have %d, want %d", len(stats), len(databaseCategories)+len(ethdb.FreezerTables))
+	}
+	if bodies := stats[bodiesCategory]; bodies.Count != 1 || bodies.Size >= common.StorageSize(len(body)) {
+		t.Errorf("bodies mismatch: have %d entries of %v, want 1 entry below %d bytes", bodies.Count, bodies.Size, len(body))
+	}
+	for i, kind := range ethdb.FreezerTables {
+		stat := stats[len(databaseCategories)+i]
+		if stat.Name != "Ancient "+kind {
+			t.Errorf("ancient table %d: name mismatch: have %q, want %q", i, stat.Name, "Ancient "+kind)
+		}
+		if stat.Count != 1 || stat.Size != common.StorageSize(len(blobs[kind])) {
+			t.Errorf("%s: mismatch: have %d entries of %v, want 1 entry of %d bytes", stat.Name, stat.Count, stat.Size, len(blobs[kind]))
+		}
+	}
+}
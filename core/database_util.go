@@ -40,6 +40,8 @@ var (
 	headBlockKey  = []byte("LastBlock")
 	headFastKey   = []byte("LastFast")
 
+	txIndexTailKey = []byte("TransactionIndexTail") // number of the oldest block with indexed transaction lookups
+
 	headerPrefix        = []byte("h")   // headerPrefix + num (uint64 big endian) + hash -> header
 	tdSuffix            = []byte("t")   // headerPrefix + num (uint64 big endian) + hash + tdSuffix -> td
 	numSuffix           = []byte("n")   // headerPrefix + num (uint64 big endian) + numSuffix -> hash
@@ -73,6 +75,36 @@ var (
 	preimageHitCounter = metrics.NewCounter("db/preimage/hits")
 )
 
+// ChainCompression selects the algorithms the tables of the chain database
+// holding bulky RLP data are stored with.
+type ChainCompression struct {
+	Bodies   ethdb.Compression // Algorithm to store block bodies with
+	Receipts ethdb.Compression // Algorithm to store block receipts with
+}
+
+// DefaultChainCompression is the compression of the chain database tables that
+// suits their contents best: block bodies are snappy compressed, while block
+// receipts, dominated by the mostly empty log blooms, are run-length encoded.
+var DefaultChainCompression = ChainCompression{
+	Bodies:   ethdb.SnappyCompression,
+	Receipts: ethdb.RLECompression,
+}
+
+// ChainCompressionRules returns the compression rules of the chain database
+// tables holding bulky RLP data. Tables with compression disabled are still
+// decoded, keeping any values compressed earlier readable.
+func ChainCompressionRules(config ChainCompression) []ethdb.CompressionRule {
+	return []ethdb.CompressionRule{
+		{Prefix: bodyPrefix, KeyLength: len(bodyPrefix) + 8 + common.HashLength, Algorithm: config.Bodies},
+
+		// Per transaction receipt lookups (receiptsPrefix + hash) share the first
+		// byte and the key length of the block receipts, shadow them to store them
+		// as is (decoding any values compressed by older versions still).
+		{Prefix: receiptsPrefix, KeyLength: len(receiptsPrefix) + common.HashLength, Algorithm: ethdb.NoCompression},
+		{Prefix: blockReceiptsPrefix, KeyLength: len(blockReceiptsPrefix) + 8 + common.HashLength, Algorithm: config.Receipts},
+	}
+}
+
 // encodeBlockNumber encodes a block number as big endian uint64
 func encodeBlockNumber(number uint64) []byte {
 	enc := make([]byte, 8)
@@ -86,12 +118,32 @@ func GetCanonicalHash(db ethdb.Database, number uint64) common.Hash {
 	if len(data) == 0 {
 		data, _ = db.Get(append(oldBlockNumPrefix, big.NewInt(int64(number)).Bytes()...))
 		if len(data) == 0 {
-			return common.Hash{}
+			if freezer := ethdb.AncientStore(db); freezer != nil {
+				data, _ = freezer.Ancient(ethdb.FreezerHashTable, number)
+			}
+			if len(data) == 0 {
+				return common.Hash{}
+			}
 		}
 	}
 	return common.BytesToHash(data)
 }
 
+// getAncient retrieves a frozen data blob of a canonical block from the ancient
+// store backing the database, or nil if the block is not (or not canonically)
+// frozen.
+func getAncient(db ethdb.Database, kind string, hash common.Hash, number uint64) []byte {
+	freezer := ethdb.AncientStore(db)
+	if freezer == nil || !freezer.HasAncient(kind, number) {
+		return nil
+	}
+	if frozen, _ := freezer.Ancient(ethdb.FreezerHashTable, number); common.BytesToHash(frozen) != hash {
+		return nil
+	}
+	data, _ := freezer.Ancient(kind, number)
+	return data
+}
+
 // missingNumber is returned by GetBlockNumber if no header with the
 // given block hash has been stored in the database
 const missingNumber = uint64(0xffffffffffffffff)
@@ -154,6 +206,9 @@ func GetHeaderRLP(db ethdb.Database, hash common.Hash, number uint64) rlp.RawVal
 	data, _ := db.Get(append(append(headerPrefix, encodeBlockNumber(number)...), hash.Bytes()...))
 	if len(data) == 0 {
 		data, _ = db.Get(append(append(oldBlockPrefix, hash.Bytes()...), oldHeaderSuffix...))
+		if len(data) == 0 {
+			data = getAncient(db, ethdb.FreezerHeaderTable, hash, number)
+		}
 	}
 	return data
 }
@@ -178,6 +233,9 @@ func GetBodyRLP(db ethdb.Database, hash common.Hash, number uint64) rlp.RawValue
 	data, _ := db.Get(append(append(bodyPrefix, encodeBlockNumber(number)...), hash.Bytes()...))
 	if len(data) == 0 {
 		data, _ = db.Get(append(append(oldBlockPrefix, hash.Bytes()...), oldBodySuffix...))
+		if len(data) == 0 {
+			data = getAncient(db, ethdb.FreezerBodiesTable, hash, number)
+		}
 	}
 	return data
 }
@@ -200,12 +258,9 @@ func GetBody(db ethdb.Database, hash common.Hash, number uint64) *types.Body {
 // GetTd retrieves a block's total difficulty corresponding to the hash, nil if
 // none found.
 func GetTd(db ethdb.Database, hash common.Hash, number uint64) *big.Int {
-	data, _ := db.Get(append(append(append(headerPrefix, encodeBlockNumber(number)...), hash[:]...), tdSuffix...))
+	data := getTdRLP(db, hash, number)
 	if len(data) == 0 {
-		data, _ = db.Get(append(append(oldBlockPrefix, hash.Bytes()...), oldTdSuffix...))
-		if len(data) == 0 {
-			return nil
-		}
+		return nil
 	}
 	td := new(big.Int)
 	if err := rlp.Decode(bytes.NewReader(data), td); err != nil {
@@ -215,6 +270,19 @@ func GetTd(db ethdb.Database, hash common.Hash, number uint64) *big.Int {
 	return td
 }
 
+// getTdRLP retrieves a block's total difficulty in its raw RLP database encoding,
+// or nil if it's not found.
+func getTdRLP(db ethdb.Database, hash common.Hash, number uint64) rlp.RawValue {
+	data, _ := db.Get(append(append(append(headerPrefix, encodeBlockNumber(number)...), hash[:]...), tdSuffix...))
+	if len(data) == 0 {
+		data, _ = db.Get(append(append(oldBlockPrefix, hash.Bytes()...), oldTdSuffix...))
+		if len(data) == 0 {
+			data = getAncient(db, ethdb.FreezerDifficultyTable, hash, number)
+		}
+	}
+	return data
+}
+
 // GetBlock retrieves an entire block corresponding to the hash, assembling it
 // back from the stored header and body. If either the header or body could not
 // be retrieved nil is returned.
@@ -235,15 +303,14 @@ func GetBlock(db ethdb.Database, hash common.Hash, number uint64) *types.Block {
 	return types.NewBlockWithHeader(header).WithBody(body.Transactions, body.Uncles)
 }
 
-// GetBlockReceipts retrieves the receipts generated by the transactions included
-// in a block given by its hash.
-func GetBlockReceipts(db ethdb.Database, hash common.Hash, number uint64) types.Receipts {
-	data, _ := db.Get(append(append(blockReceiptsPrefix, encodeBlockNumber(number)...), hash[:]...))
+// GetRawBlockReceipts retrieves the receipts generated by the transactions
+// included in a block given by its hash, without deriving the implementation
+// fields missing from the slim storage format. This is enough for consumers of
+// the consensus fields only, like the network protocols.
+func GetRawBlockReceipts(db ethdb.Database, hash common.Hash, number uint64) types.Receipts {
+	data := getBlockReceiptsRLP(db, hash, number)
 	if len(data) == 0 {
-		data, _ = db.Get(append(oldBlockReceiptsPrefix, hash.Bytes()...))
-		if len(data) == 0 {
-			return nil
-		}
+		return nil
 	}
 	storageReceipts := []*types.ReceiptForStorage{}
 	if err := rlp.DecodeBytes(data, &storageReceipts); err != nil {
@@ -257,6 +324,39 @@ func GetBlockReceipts(db ethdb.Database, hash common.Hash, number uint64) types.
 	return receipts
 }
 
+// GetBlockReceipts retrieves the receipts generated by the transactions included
+// in a block given by its hash, filling in the implementation fields from the
+// block body. Nil is returned if either the receipts or the block are missing.
+func GetBlockReceipts(db ethdb.Database, hash common.Hash, number uint64, config *params.ChainConfig) types.Receipts {
+	receipts := GetRawBlockReceipts(db, hash, number)
+	if receipts == nil {
+		return nil
+	}
+	block := GetBlock(db, hash, number)
+	if block == nil {
+		return nil
+	}
+	if len(block.Transactions()) != len(receipts) {
+		glog.V(logger.Error).Infof("receipt count mismatch for block #%d [%x…]: %d txs, %d receipts", number, hash[:4], len(block.Transactions()), len(receipts))
+		return nil
+	}
+	SetReceiptsData(config, block, receipts)
+	return receipts
+}
+
+// getBlockReceiptsRLP retrieves the receipts of a block in their raw RLP storage
+// encoding, or nil if they're not found.
+func getBlockReceiptsRLP(db ethdb.Database, hash common.Hash, number uint64) rlp.RawValue {
+	data, _ := db.Get(append(append(blockReceiptsPrefix, encodeBlockNumber(number)...), hash[:]...))
+	if len(data) == 0 {
+		data, _ = db.Get(append(oldBlockReceiptsPrefix, hash.Bytes()...))
+		if len(data) == 0 {
+			data = getAncient(db, ethdb.FreezerReceiptTable, hash, number)
+		}
+	}
+	return data
+}
+
 // GetTransaction retrieves a specific transaction from the database, along with
 // its added positional metadata.
 func GetTransaction(db ethdb.Database, hash common.Hash) (*types.Transaction, common.Hash, uint64, uint64) {
@@ -291,7 +391,7 @@ func GetReceipt(db ethdb.Database, txHash common.Hash) *types.Receipt {
 	if len(data) == 0 {
 		return nil
 	}
-	var receipt types.ReceiptForStorage
+	var receipt types.FullReceiptForStorage
 	err := rlp.DecodeBytes(data, &receipt)
 	if err != nil {
 		glog.V(logger.Core).Infoln("GetReceipt err:", err)
@@ -401,7 +501,8 @@ func WriteBlock(db ethdb.Database, block *types.Block) error {
 
 // WriteBlockReceipts stores all the transaction receipts belonging to a block
 // as a single receipt slice. This is used during chain reorganisations for
-// rescheduling dropped transactions.
+// rescheduling dropped transactions. Only the consensus fields are stored, the
+// rest is derived from the block on retrieval by GetBlockReceipts.
 func WriteBlockReceipts(db ethdb.Database, hash common.Hash, number uint64, receipts types.Receipts) error {
 	// Convert the receipts into their storage form and serialize them
 	storageReceipts := make([]*types.ReceiptForStorage, len(receipts))
@@ -465,7 +566,7 @@ func WriteTransactions(db ethdb.Database, block *types.Block) error {
 
 // WriteReceipt stores a single transaction receipt into the database.
 func WriteReceipt(db ethdb.Database, receipt *types.Receipt) error {
-	storageReceipt := (*types.ReceiptForStorage)(receipt)
+	storageReceipt := (*types.FullReceiptForStorage)(receipt)
 	data, err := rlp.EncodeToBytes(storageReceipt)
 	if err != nil {
 		return err
@@ -479,7 +580,7 @@ func WriteReceipts(db ethdb.Database, receipts types.Receipts) error {
 
 	// Iterate over all the receipts and queue them for database injection
 	for _, receipt := range receipts {
-		storageReceipt := (*types.ReceiptForStorage)(receipt)
+		storageReceipt := (*types.FullReceiptForStorage)(receipt)
 		data, err := rlp.EncodeToBytes(storageReceipt)
 		if err != nil {
 			return err
@@ -642,6 +743,39 @@ func WriteBlockChainVersion(db ethdb.Database, vsn int) {
 	db.Put([]byte("BlockchainVersion"), enc)
 }
 
+// GetTxIndexTail retrieves the number of the oldest canonical block whose
+// transaction lookups are indexed. Databases never limiting the lookup history
+// have all transactions indexed, so a missing entry yields zero.
+func GetTxIndexTail(db ethdb.Database) uint64 {
+	data, _ := db.Get(txIndexTailKey)
+	if len(data) != 8 {
+		return 0
+	}
+	return binary.BigEndian.Uint64(data)
+}
+
+// WriteTxIndexTail stores the number of the oldest canonical block whose
+// transaction lookups are indexed.
+func WriteTxIndexTail(db ethdb.Database, number uint64) error {
+	if err := db.Put(txIndexTailKey, encodeBlockNumber(number)); err != nil {
+		glog.Fatalf("failed to store transaction index tail into database: %v", err)
+	}
+	return nil
+}
+
+// DeleteTxLookups removes the transaction lookups of a block: the transactions
+// stored by hash, their positional metadata and their receipts.
+func DeleteTxLookups(db ethdb.Database, block *types.Block) error {
+	batch := db.NewBatch()
+	for _, tx := range block.Transactions() {
+		hash := tx.Hash()
+		batch.Delete(hash.Bytes())
+		batch.Delete(append(hash.Bytes(), txMetaSuffix...))
+		batch.Delete(append(receiptsPrefix, hash.Bytes()...))
+	}
+	return batch.Write()
+}
+
 // WriteChainConfig writes the chain config settings to the database.
 func WriteChainConfig(db ethdb.Database, hash common.Hash, cfg *params.ChainConfig) error {
 	// short circuit and ignore if nil config. GetChainConfig
@@ -18,7 +18,6 @@ package core
 
 import (
 	"bytes"
-	"encoding/json"
 	"io/ioutil"
 	"math/big"
 	"os"
@@ -33,58 +32,6 @@ import (
 	"github.com/daxxcoin/daxxcore/rlp"
 )
 
-type diffTest struct {
-	ParentTimestamp    uint64
-	ParentDifficulty   *big.Int
-	CurrentTimestamp   uint64
-	CurrentBlocknumber *big.Int
-	CurrentDifficulty  *big.Int
-}
-
-func (d *diffTest) UnmarshalJSON(b []byte) (err error) {
-	var ext struct {
-		ParentTimestamp    string
-		ParentDifficulty   string
-		CurrentTimestamp   string
-		CurrentBlocknumber string
-		CurrentDifficulty  string
-	}
-	if err := json.Unmarshal(b, &ext); err != nil {
-		return err
-	}
-
-	d.ParentTimestamp = common.String2Big(ext.ParentTimestamp).Uint64()
-	d.ParentDifficulty = common.String2Big(ext.ParentDifficulty)
-	d.CurrentTimestamp = common.String2Big(ext.CurrentTimestamp).Uint64()
-	d.CurrentBlocknumber = common.String2Big(ext.CurrentBlocknumber)
-	d.CurrentDifficulty = common.String2Big(ext.CurrentDifficulty)
-
-	return nil
-}
-
-func TestCalcDifficulty(t *testing.T) {
-	file, err := os.Open("../tests/files/BasicTests/difficulty.json")
-	if err != nil {
-		t.Fatal(err)
-	}
-	defer file.Close()
-
-	tests := make(map[string]diffTest)
-	err = json.NewDecoder(file).Decode(&tests)
-	if err != nil {
-		t.Fatal(err)
-	}
-
-	config := &params.ChainConfig{HomesteadBlock: big.NewInt(1150000)}
-	for name, test := range tests {
-		number := new(big.Int).Sub(test.CurrentBlocknumber, big.NewInt(1))
-		diff := CalcDifficulty(config, test.CurrentTimestamp, test.ParentTimestamp, number, test.ParentDifficulty)
-		if diff.Cmp(test.CurrentDifficulty) != 0 {
-			t.Error(name, "failed. Expected", test.CurrentDifficulty, "and calculated", diff)
-		}
-	}
-}
-
 // Tests block header storage and retrieval operations.
 func TestHeaderStorage(t *testing.T) {
 	db, _ := ethdb.NewMemDatabase()
@@ -390,7 +337,7 @@ func TestReceiptStorage(t *testing.T) {
 	db, _ := ethdb.NewMemDatabase()
 
 	receipt1 := &types.Receipt{
-		PostState:         []byte{0x01},
+		Status:            types.ReceiptStatusFailed,
 		CumulativeGasUsed: big.NewInt(1),
 		Logs: []*types.Log{
 			{Address: common.BytesToAddress([]byte{0x11})},
@@ -400,8 +347,10 @@ func TestReceiptStorage(t *testing.T) {
 		ContractAddress: common.BytesToAddress([]byte{0x01, 0x11, 0x11}),
 		GasUsed:         big.NewInt(111111),
 	}
+	receipt1.Bloom = types.CreateBloom(types.Receipts{receipt1})
+
 	receipt2 := &types.Receipt{
-		PostState:         []byte{0x02},
+		PostState:         common.Hash{2}.Bytes(),
 		CumulativeGasUsed: big.NewInt(2),
 		Logs: []*types.Log{
 			{Address: common.BytesToAddress([]byte{0x22})},
@@ -411,6 +360,8 @@ func TestReceiptStorage(t *testing.T) {
 		ContractAddress: common.BytesToAddress([]byte{0x02, 0x22, 0x22}),
 		GasUsed:         big.NewInt(222222),
 	}
+	receipt2.Bloom = types.CreateBloom(types.Receipts{receipt2})
+
 	receipts := []*types.Receipt{receipt1, receipt2}
 
 	// Check that no receipt entries are in a pristine database
@@ -449,7 +400,7 @@ func TestBlockReceiptStorage(t *testing.T) {
 	db, _ := ethdb.NewMemDatabase()
 
 	receipt1 := &types.Receipt{
-		PostState:         []byte{0x01},
+		Status:            types.ReceiptStatusFailed,
 		CumulativeGasUsed: big.NewInt(1),
 		Logs: []*types.Log{
 			{Address: common.BytesToAddress([]byte{0x11})},
@@ -459,8 +410,10 @@ func TestBlockReceiptStorage(t *testing.T) {
 		ContractAddress: common.BytesToAddress([]byte{0x01, 0x11, 0x11}),
 		GasUsed:         big.NewInt(111111),
 	}
+	receipt1.Bloom = types.CreateBloom(types.Receipts{receipt1})
+
 	receipt2 := &types.Receipt{
-		PostState:         []byte{0x02},
+		PostState:         common.Hash{2}.Bytes(),
 		CumulativeGasUsed: big.NewInt(2),
 		Logs: []*types.Log{
 			{Address: common.BytesToAddress([]byte{0x22})},
@@ -470,18 +423,20 @@ func TestBlockReceiptStorage(t *testing.T) {
 		ContractAddress: common.BytesToAddress([]byte{0x02, 0x22, 0x22}),
 		GasUsed:         big.NewInt(222222),
 	}
+	receipt2.Bloom = types.CreateBloom(types.Receipts{receipt2})
+
 	receipts := []*types.Receipt{receipt1, receipt2}
 
 	// Check that no receipt entries are in a pristine database
 	hash := common.BytesToHash([]byte{0x03, 0x14})
-	if rs := GetBlockReceipts(db, hash, 0); len(rs) != 0 {
+	if rs := GetRawBlockReceipts(db, hash, 0); len(rs) != 0 {
 		t.Fatalf("non existent receipts returned: %v", rs)
 	}
 	// Insert the receipt slice into the database and check presence
 	if err := WriteBlockReceipts(db, hash, 0, receipts); err != nil {
 		t.Fatalf("failed to write block receipts: %v", err)
 	}
-	if rs := GetBlockReceipts(db, hash, 0); len(rs) == 0 {
+	if rs := GetRawBlockReceipts(db, hash, 0); len(rs) == 0 {
 		t.Fatalf("no receipts returned")
 	} else {
 		for i := 0; i < len(receipts); i++ {
@@ -495,7 +450,7 @@ func TestBlockReceiptStorage(t *testing.T) {
 	}
 	// Delete the receipt slice and check purge
 	DeleteBlockReceipts(db, hash, 0)
-	if rs := GetBlockReceipts(db, hash, 0); len(rs) != 0 {
+	if rs := GetRawBlockReceipts(db, hash, 0); len(rs) != 0 {
 		t.Fatalf("deleted receipts returned: %v", rs)
 	}
 }
@@ -561,17 +516,17 @@ func TestMipmapChain(t *testing.T) {
 	)
 	defer db.Close()
 
-	genesis := WriteGenesisBlockForTesting(db, GenesisAccount{addr, big.NewInt(1000000)})
+	genesis := GenesisBlockForTesting(db, addr, big.NewInt(1000000))
 	chain, receipts := GenerateChain(params.TestChainConfig, genesis, db, 1010, func(i int, gen *BlockGen) {
 		var receipts types.Receipts
 		switch i {
 		case 1:
-			receipt := types.NewReceipt(nil, new(big.Int))
+			receipt := types.NewReceipt(nil, false, new(big.Int))
 			receipt.Logs = []*types.Log{{Address: addr, Topics: []common.Hash{hash1}}}
 			gen.AddUncheckedReceipt(receipt)
 			receipts = types.Receipts{receipt}
 		case 1000:
-			receipt := types.NewReceipt(nil, new(big.Int))
+			receipt := types.NewReceipt(nil, false, new(big.Int))
 			receipt.Logs = []*types.Log{{Address: addr2}}
 			gen.AddUncheckedReceipt(receipt)
 			receipts = types.Receipts{receipt}
@@ -603,3 +558,94 @@ func TestMipmapChain(t *testing.T) {
 		t.Error("address was included in bloom and should not have")
 	}
 }
+
+// Tests that the implementation fields dropped from the stored block receipts are
+// derived from the block body on retrieval.
+func TestBlockReceiptDerivation(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+
+	key, _ := crypto.GenerateKey()
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	signer := types.MakeSigner(params.TestChainConfig, big.NewInt(1))
+
+	tx1, _ := types.SignTx(types.NewTransaction(0, common.Address{0x11}, big.NewInt(1), big.NewInt(21000), big.NewInt(1), nil), signer, key)
+	tx2, _ := types.SignTx(types.NewContractCreation(1, big.NewInt(0), big.NewInt(100000), big.NewInt(1), []byte{0x00}), signer, key)
+	txs := types.Transactions{tx1, tx2}
+
+	receipts := types.Receipts{
+		types.NewReceipt(nil, false, big.NewInt(21000)),
+		types.NewReceipt(nil, false, big.NewInt(71000)),
+	}
+	receipts[0].Logs = []*types.Log{{Address: common.Address{0x11}}}
+	receipts[1].Logs = []*types.Log{{Address: common.Address{0x22}}, {Address: common.Address{0x33}}}
+
+	block := types.NewBlock(&types.Header{Number: big.NewInt(1)}, txs, nil, receipts)
+	WriteBlock(db, block)
+	if err := WriteBlockReceipts(db, block.Hash(), block.NumberU64(), receipts); err != nil {
+		t.Fatalf("failed to write block receipts: %v", err)
+	}
+	// The raw receipts only contain the consensus fields
+	if rs := GetRawBlockReceipts(db, block.Hash(), block.NumberU64()); len(rs) != 2 || rs[0].TxHash != (common.Hash{}) {
+		t.Fatalf("raw receipts mismatch: %v", rs)
+	}
+	rs := GetBlockReceipts(db, block.Hash(), block.NumberU64(), params.TestChainConfig)
+	if len(rs) != 2 {
+		t.Fatalf("receipt count mismatch: have %d, want 2", len(rs))
+	}
+	wantGas := []int64{21000, 50000}
+	wantContract := []common.Address{{}, crypto.CreateAddress(from, 1)}
+	logIndex := uint(0)
+	for i, r := range rs {
+		if r.TxHash != txs[i].Hash() {
+			t.Errorf("receipt %d: tx hash mismatch: have %x, want %x", i, r.TxHash, txs[i].Hash())
+		}
+		if r.GasUsed.Int64() != wantGas[i] {
+			t.Errorf("receipt %d: gas used mismatch: have %v, want %v", i, r.GasUsed, wantGas[i])
+		}
+		if r.ContractAddress != wantContract[i] {
+			t.Errorf("receipt %d: contract address mismatch: have %x, want %x", i, r.ContractAddress, wantContract[i])
+		}
+		for _, log := range r.Logs {
+			if log.BlockHash != block.Hash() || log.BlockNumber != 1 || log.TxHash != r.TxHash || log.TxIndex != uint(i) || log.Index != logIndex {
+				t.Errorf("receipt %d: log %d: derived fields mismatch: %+v", i, logIndex, log)
+			}
+			logIndex++
+		}
+	}
+	// Receipts of a missing block can't be derived
+	DeleteBody(db, block.Hash(), block.NumberU64())
+	if rs := GetBlockReceipts(db, block.Hash(), block.NumberU64(), params.TestChainConfig); rs != nil {
+		t.Errorf("receipts derived without a block body: %v", rs)
+	}
+}
+
+// Tests that the chain compression rules only compress the block receipts and
+// leave the per transaction receipt lookups sharing their key length alone.
+func TestChainCompressionRules(t *testing.T) {
+	raw, _ := ethdb.NewMemDatabase()
+	db := ethdb.NewCompressedDatabase(raw, ChainCompressionRules(DefaultChainCompression))
+
+	receipt := &types.Receipt{
+		PostState:         common.Hash{1}.Bytes(),
+		CumulativeGasUsed: big.NewInt(1),
+		TxHash:            common.BytesToHash([]byte{0x11, 0x11}),
+		GasUsed:           big.NewInt(1),
+	}
+	hash := common.BytesToHash([]byte{0x03, 0x14})
+	if err := WriteReceipts(db, types.Receipts{receipt}); err != nil {
+		t.Fatalf("failed to write receipts: %v", err)
+	}
+	if err := WriteBlockReceipts(db, hash, 1, types.Receipts{receipt}); err != nil {
+		t.Fatalf("failed to write block receipts: %v", err)
+	}
+	// Ensure the block receipts got compressed but the lookup did not
+	if blob, _ := raw.Get(append(receiptsPrefix, receipt.TxHash.Bytes()...)); len(blob) == 0 || blob[0] == 0x00 {
+		t.Errorf("transaction receipt stored compressed: %x", blob)
+	}
+	if blob, _ := raw.Get(append(append(blockReceiptsPrefix, encodeBlockNumber(1)...), hash.Bytes()...)); len(blob) < 2 || blob[0] != 0x00 || blob[1] != byte(ethdb.RLECompression) {
+		t.Errorf("block receipts not stored compressed: %x", blob)
+	}
+	if r := GetReceipt(db, receipt.TxHash); r == nil || r.TxHash != receipt.TxHash {
+		t.Errorf("transaction receipt mismatch: have %v", r)
+	}
+}
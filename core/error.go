@@ -17,20 +17,12 @@
 package core
 
 import (
-	"errors"
 	"fmt"
 	"math/big"
 
 	"github.com/daxxcoin/daxxcore/common"
 )
 
-var (
-	BlockNumberErr   = errors.New("block number invalid")
-	BlockFutureErr   = errors.New("block time is in the future")
-	BlockTSTooBigErr = errors.New("block time too big")
-	BlockEqualTSErr  = errors.New("block time stamp equal to previous")
-)
-
 // Parent error. In case a parent is unknown this error will be thrown
 // by the block manager
 type ParentErr struct {
@@ -103,23 +95,6 @@ func IsNonceErr(err error) bool {
 	return ok
 }
 
-// BlockNonceErr indicates that a block's nonce is invalid.
-type BlockNonceErr struct {
-	Number *big.Int
-	Hash   common.Hash
-	Nonce  uint64
-}
-
-func (err *BlockNonceErr) Error() string {
-	return fmt.Sprintf("nonce for #%d [%x…] is invalid (got %d)", err.Number, err.Hash, err.Nonce)
-}
-
-// IsBlockNonceErr returns true for invalid block nonce errors.
-func IsBlockNonceErr(err error) bool {
-	_, ok := err.(*BlockNonceErr)
-	return ok
-}
-
 type InvalidTxErr struct {
 	Message string
 }
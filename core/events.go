@@ -29,6 +29,46 @@ type TxPreEvent struct{ Tx *types.Transaction }
 // TxPostEvent is posted when a transaction has been processed.
 type TxPostEvent struct{ Tx *types.Transaction }
 
+// TxAddedEvent is posted when a transaction is accepted into the transaction pool.
+type TxAddedEvent struct {
+	Tx    *types.Transaction
+	Local bool
+}
+
+// TxPromotedEvent is posted when a transaction becomes executable and is moved
+// into the pending set of the transaction pool.
+type TxPromotedEvent struct{ Tx *types.Transaction }
+
+// TxReplacedEvent is posted when a pooled transaction is replaced by a better
+// paying one with the same nonce.
+type TxReplacedEvent struct {
+	Old *types.Transaction
+	New *types.Transaction
+}
+
+// TxDropReason describes why a transaction was dropped from the transaction pool.
+type TxDropReason string
+
+const (
+	TxDropUnderpriced TxDropReason = "underpriced"       // Below the minimum price, or outbid in a full pool
+	TxDropNonceTooLow TxDropReason = "nonceTooLow"       // Nonce used up by another transaction on chain
+	TxDropNoFunds     TxDropReason = "insufficientFunds" // Account can no longer pay for the transaction
+	TxDropRateLimit   TxDropReason = "rateLimit"         // Account or global pool limits exceeded
+	TxDropExpired     TxDropReason = "expired"           // Queued for longer than the pool lifetime
+	TxDropRemoved     TxDropReason = "removed"           // Explicitly removed (miner or API)
+)
+
+// TxDroppedEvent is posted when a transaction is dropped from the transaction
+// pool without being mined.
+type TxDroppedEvent struct {
+	Tx     *types.Transaction
+	Reason TxDropReason
+}
+
+// TxMinedEvent is posted when a pooled transaction is dropped from the pool due
+// to being included in the canonical chain.
+type TxMinedEvent struct{ Tx *types.Transaction }
+
 // PendingLogsEvent is posted pre mining and notifies of pending logs.
 type PendingLogsEvent struct {
 	Logs []*types.Log
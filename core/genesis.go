@@ -23,11 +23,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"math/big"
 	"strings"
 
 	"github.com/daxxcoin/daxxcore/common"
+	"github.com/daxxcoin/daxxcore/common/hexutil"
 	"github.com/daxxcoin/daxxcore/core/state"
 	"github.com/daxxcoin/daxxcore/core/types"
 	"github.com/daxxcoin/daxxcore/daxxdb"
@@ -36,181 +36,448 @@ import (
 	"github.com/daxxcoin/daxxcore/params"
 )
 
-// WriteGenesisBlock writes the genesis block to the database as block number 0
-func WriteGenesisBlock(chainDb ethdb.Database, reader io.Reader) (*types.Block, error) {
-	contents, err := ioutil.ReadAll(reader)
-	if err != nil {
-		return nil, err
-	}
+// Genesis specifies the header fields, state of a genesis block. It also defines
+// the chain configuration the network identified by the genesis block runs with.
+// A genesis without a configuration runs with every protocol change enabled.
+type Genesis struct {
+	Config     *params.ChainConfig
+	Nonce      uint64
+	Timestamp  uint64
+	ParentHash common.Hash
+	ExtraData  []byte
+	GasLimit   uint64
+	Difficulty *big.Int
+	Mixhash    common.Hash
+	Coinbase   common.Address
+	Alloc      GenesisAlloc
+}
 
-	var genesis struct {
-		ChainConfig *params.ChainConfig `json:"config"`
-		Nonce       string
-		Timestamp   string
-		ParentHash  string
-		ExtraData   string
-		GasLimit    string
-		Difficulty  string
-		Mixhash     string
-		Coinbase    string
-		Alloc       map[string]struct {
-			Code    string
-			Storage map[string]string
-			Balance string
-			Nonce   string
+// GenesisAlloc specifies the initial state that is part of the genesis block.
+type GenesisAlloc map[common.Address]GenesisAccount
+
+// GenesisAccount is an account in the state of the genesis block.
+type GenesisAccount struct {
+	Code    []byte
+	Storage map[common.Hash]common.Hash
+	Balance *big.Int
+	Nonce   uint64
+}
+
+// genesisJSON is the on-disk format of a genesis specification. Numeric fields
+// are accepted both as 0x prefixed hex and as plain decimal strings, addresses
+// and hashes with or without the 0x prefix.
+type genesisJSON struct {
+	Config     *params.ChainConfig           `json:"config,omitempty"`
+	Nonce      string                        `json:"nonce"`
+	Timestamp  string                        `json:"timestamp"`
+	ParentHash string                        `json:"parentHash"`
+	ExtraData  string                        `json:"extraData"`
+	GasLimit   string                        `json:"gasLimit"`
+	Difficulty string                        `json:"difficulty"`
+	Mixhash    string                        `json:"mixhash"`
+	Coinbase   string                        `json:"coinbase"`
+	Alloc      map[string]genesisAccountJSON `json:"alloc"`
+}
+
+type genesisAccountJSON struct {
+	Code    string            `json:"code,omitempty"`
+	Storage map[string]string `json:"storage,omitempty"`
+	Balance string            `json:"balance"`
+	Nonce   string            `json:"nonce,omitempty"`
+}
+
+// MarshalJSON encodes the genesis specification into its JSON format.
+func (g *Genesis) MarshalJSON() ([]byte, error) {
+	enc := genesisJSON{
+		Config:     g.Config,
+		Nonce:      hexutil.EncodeUint64(g.Nonce),
+		Timestamp:  hexutil.EncodeUint64(g.Timestamp),
+		ParentHash: g.ParentHash.Hex(),
+		ExtraData:  hexutil.Encode(g.ExtraData),
+		GasLimit:   hexutil.EncodeUint64(g.GasLimit),
+		Mixhash:    g.Mixhash.Hex(),
+		Coinbase:   g.Coinbase.Hex(),
+		Alloc:      make(map[string]genesisAccountJSON, len(g.Alloc)),
+	}
+	if g.Difficulty != nil {
+		enc.Difficulty = hexutil.EncodeBig(g.Difficulty)
+	}
+	for addr, account := range g.Alloc {
+		acc := genesisAccountJSON{Balance: "0x0"}
+		if account.Balance != nil {
+			acc.Balance = hexutil.EncodeBig(account.Balance)
 		}
+		if len(account.Code) > 0 {
+			acc.Code = hexutil.Encode(account.Code)
+		}
+		if account.Nonce != 0 {
+			acc.Nonce = hexutil.EncodeUint64(account.Nonce)
+		}
+		if len(account.Storage) > 0 {
+			acc.Storage = make(map[string]string, len(account.Storage))
+			for key, value := range account.Storage {
+				acc.Storage[key.Hex()] = value.Hex()
+			}
+		}
+		enc.Alloc[addr.Hex()] = acc
 	}
+	return json.Marshal(&enc)
+}
 
-	if err := json.Unmarshal(contents, &genesis); err != nil {
-		return nil, err
+// UnmarshalJSON decodes a genesis specification from its JSON format.
+func (g *Genesis) UnmarshalJSON(input []byte) error {
+	var dec genesisJSON
+	if err := json.Unmarshal(input, &dec); err != nil {
+		return err
+	}
+	// Legacy configurations may omit the chain id, treat it as zero
+	if dec.Config != nil && dec.Config.ChainId == nil {
+		dec.Config.ChainId = new(big.Int)
+	}
+	var err error
+	spec := Genesis{
+		Config:     dec.Config,
+		ParentHash: common.HexToHash(dec.ParentHash),
+		ExtraData:  common.FromHex(dec.ExtraData),
+		Mixhash:    common.HexToHash(dec.Mixhash),
+		Coinbase:   common.HexToAddress(dec.Coinbase),
+		Alloc:      make(GenesisAlloc, len(dec.Alloc)),
 	}
+	if spec.Nonce, err = parseGenesisUint64("nonce", dec.Nonce); err != nil {
+		return err
+	}
+	if spec.Timestamp, err = parseGenesisUint64("timestamp", dec.Timestamp); err != nil {
+		return err
+	}
+	if spec.GasLimit, err = parseGenesisUint64("gasLimit", dec.GasLimit); err != nil {
+		return err
+	}
+	if spec.Difficulty, err = parseGenesisBig("difficulty", dec.Difficulty); err != nil {
+		return err
+	}
+	for addr, acc := range dec.Alloc {
+		var account GenesisAccount
+		if acc.Code != "" {
+			account.Code = common.FromHex(acc.Code)
+		}
+		if account.Balance, err = parseGenesisBig("balance of "+addr, acc.Balance); err != nil {
+			return err
+		}
+		if account.Nonce, err = parseGenesisUint64("nonce of "+addr, acc.Nonce); err != nil {
+			return err
+		}
+		if len(acc.Storage) > 0 {
+			account.Storage = make(map[common.Hash]common.Hash, len(acc.Storage))
+			for key, value := range acc.Storage {
+				account.Storage[common.HexToHash(key)] = common.HexToHash(value)
+			}
+		}
+		spec.Alloc[common.HexToAddress(addr)] = account
+	}
+	*g = spec
+	return nil
+}
 
-	// creating with empty hash always works
-	statedb, _ := state.New(common.Hash{}, chainDb)
-	for addr, account := range genesis.Alloc {
-		address := common.HexToAddress(addr)
-		statedb.AddBalance(address, common.String2Big(account.Balance))
-		statedb.SetCode(address, common.FromHex(account.Code))
-		statedb.SetNonce(address, common.String2Big(account.Nonce).Uint64())
-		for key, value := range account.Storage {
-			statedb.SetState(address, common.HexToHash(key), common.HexToHash(value))
+// parseGenesisBig parses a genesis quantity given either in 0x prefixed hex or
+// in decimal notation. An empty field is returned as nil.
+func parseGenesisBig(field, s string) (*big.Int, error) {
+	if s == "" {
+		return nil, nil
+	}
+	var (
+		n  *big.Int
+		ok bool
+	)
+	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+		if s = s[2:]; s == "" {
+			return new(big.Int), nil
 		}
+		n, ok = new(big.Int).SetString(s, 16)
+	} else {
+		n, ok = new(big.Int).SetString(s, 10)
+	}
+	if !ok || n.Sign() < 0 {
+		return nil, fmt.Errorf("invalid genesis %s: %q", field, s)
+	}
+	return n, nil
+}
+
+// parseGenesisUint64 parses a genesis quantity that must fit into 64 bits.
+func parseGenesisUint64(field, s string) (uint64, error) {
+	n, err := parseGenesisBig(field, s)
+	if err != nil || n == nil {
+		return 0, err
 	}
-	root, stateBatch := statedb.CommitBatch(false)
+	if n.BitLen() > 64 {
+		return 0, fmt.Errorf("genesis %s overflows 64 bits: %q", field, s)
+	}
+	return n.Uint64(), nil
+}
 
-	difficulty := common.String2Big(genesis.Difficulty)
-	block := types.NewBlock(&types.Header{
-		Nonce:      types.EncodeNonce(common.String2Big(genesis.Nonce).Uint64()),
-		Time:       common.String2Big(genesis.Timestamp),
-		ParentHash: common.HexToHash(genesis.ParentHash),
-		Extra:      common.FromHex(genesis.ExtraData),
-		GasLimit:   common.String2Big(genesis.GasLimit),
-		Difficulty: difficulty,
-		MixDigest:  common.HexToHash(genesis.Mixhash),
-		Coinbase:   common.HexToAddress(genesis.Coinbase),
-		Root:       root,
-	}, nil, nil, nil)
+// GenesisMismatchError is returned when trying to set up a database with a
+// genesis block different from the one already stored in it.
+type GenesisMismatchError struct {
+	Stored, New common.Hash
+}
+
+func (e *GenesisMismatchError) Error() string {
+	return fmt.Sprintf("database already contains an incompatible genesis block (have %x, new %x)", e.Stored[:8], e.New[:8])
+}
 
-	if block := GetBlock(chainDb, block.Hash(), block.NumberU64()); block != nil {
-		glog.V(logger.Info).Infoln("Genesis block already in chain. Writing canonical number")
-		err := WriteCanonicalHash(chainDb, block.Hash(), block.NumberU64())
+// SetupGenesisBlock writes or updates the genesis block in db.
+// The block that will be used is:
+//
+//                          genesis == nil       genesis != nil
+//                       +------------------------------------------
+//     db has no genesis |  main-net default  |  genesis
+//     db has genesis    |  from DB           |  genesis (if compatible)
+//
+// The stored chain configuration will be updated with the one of the supplied
+// genesis, or the network default for the main and test networks. Custom chains
+// keep their stored configuration if no genesis is given.
+//
+// If the local chain is already past a fork block which the new configuration
+// reschedules, a *params.ConfigCompatError is returned and the stored config is
+// left untouched; the caller should rewind the chain to the block given in the
+// error and store the new config afterwards. Changes that can't be resolved by
+// rewinding the chain are returned as plain errors.
+//
+// The returned chain configuration is never nil.
+func SetupGenesisBlock(db ethdb.Database, genesis *Genesis) (*params.ChainConfig, common.Hash, error) {
+	if genesis != nil && genesis.Config != nil {
+		if err := genesis.Config.CheckConfig(); err != nil {
+			return genesis.Config, common.Hash{}, err
+		}
+	}
+	// Just commit the new block if there is no stored genesis block.
+	stored := GetCanonicalHash(db, 0)
+	if (stored == common.Hash{}) {
+		if genesis == nil {
+			glog.V(logger.Info).Infoln("Writing default main-net genesis block")
+			genesis = DefaultGenesisBlock()
+		} else {
+			glog.V(logger.Info).Infoln("Writing custom genesis block")
+		}
+		block, err := genesis.Commit(db)
 		if err != nil {
-			return nil, err
+			return genesis.configOrDefault(common.Hash{}), common.Hash{}, err
+		}
+		return genesis.configOrDefault(block.Hash()), block.Hash(), nil
+	}
+	// Check whether the genesis block is already written.
+	if genesis != nil {
+		hash := genesis.ToBlock().Hash()
+		if hash != stored {
+			return genesis.configOrDefault(hash), hash, &GenesisMismatchError{stored, hash}
+		}
+	}
+	// Get the existing chain configuration.
+	newcfg := genesis.configOrDefault(stored)
+	storedcfg, err := GetChainConfig(db, stored)
+	if err != nil {
+		if err == ChainConfigNotFoundErr {
+			// This case happens if a genesis write was interrupted.
+			glog.V(logger.Warn).Infof("Found genesis block %x without chain config", stored[:8])
+			return newcfg, stored, WriteChainConfig(db, stored, newcfg)
+		}
+		return newcfg, stored, err
+	}
+	if storedcfg.ChainId == nil {
+		storedcfg.ChainId = new(big.Int)
+	}
+	// Special case: don't change the existing config of a non-mainnet chain if no new
+	// config is supplied. These chains would get AllDaxxhashProtocolChanges otherwise.
+	if genesis == nil && stored != params.MainNetGenesisHash && stored != params.TestNetGenesisHash {
+		return storedcfg, stored, nil
+	}
+	// Check config compatibility and write the config. Compatibility errors
+	// are returned to the caller unless we're already at block zero.
+	var height uint64
+	if head := GetHeadHeaderHash(db); head != (common.Hash{}) {
+		if height = GetBlockNumber(db, head); height == missingNumber {
+			return newcfg, stored, fmt.Errorf("missing block number for head header hash %x", head)
 		}
-		return block, nil
 	}
+	if err := storedcfg.CheckCompatible(newcfg, height); err != nil {
+		return newcfg, stored, err
+	}
+	return newcfg, stored, WriteChainConfig(db, stored, newcfg)
+}
+
+// configOrDefault returns the chain configuration of the genesis specification,
+// falling back to the network defaults for well known genesis blocks.
+func (g *Genesis) configOrDefault(ghash common.Hash) *params.ChainConfig {
+	switch {
+	case g != nil && g.Config != nil:
+		return g.Config
+	case ghash == params.MainNetGenesisHash:
+		return params.MainnetChainConfig
+	case ghash == params.TestNetGenesisHash:
+		return params.TestnetChainConfig
+	default:
+		return params.AllDaxxhashProtocolChanges
+	}
+}
 
-	if err := stateBatch.Write(); err != nil {
+// ToBlock creates the genesis block without writing it, or its state, into any
+// database.
+func (g *Genesis) ToBlock() *types.Block {
+	db, _ := ethdb.NewMemDatabase()
+	block, _, err := g.toBlock(db)
+	if err != nil {
+		panic(fmt.Sprintf("cannot assemble genesis state: %v", err))
+	}
+	return block
+}
+
+// toBlock assembles the genesis block along with its state on top of db. The
+// state is not yet written, only the returned batch needs to be flushed.
+func (g *Genesis) toBlock(db ethdb.Database) (*types.Block, ethdb.Batch, error) {
+	statedb, err := state.New(common.Hash{}, db)
+	if err != nil {
+		return nil, nil, err
+	}
+	for addr, account := range g.Alloc {
+		balance := account.Balance
+		if balance == nil {
+			balance = new(big.Int)
+		}
+		statedb.AddBalance(addr, balance)
+		statedb.SetCode(addr, account.Code)
+		statedb.SetNonce(addr, account.Nonce)
+		for key, value := range account.Storage {
+			statedb.SetState(addr, key, value)
+		}
+	}
+	root, batch := statedb.CommitBatch(false)
+
+	head := &types.Header{
+		Nonce:      types.EncodeNonce(g.Nonce),
+		Time:       new(big.Int).SetUint64(g.Timestamp),
+		ParentHash: g.ParentHash,
+		Extra:      g.ExtraData,
+		GasLimit:   new(big.Int).SetUint64(g.GasLimit),
+		Difficulty: g.Difficulty,
+		MixDigest:  g.Mixhash,
+		Coinbase:   g.Coinbase,
+		Root:       root,
+	}
+	if g.GasLimit == 0 {
+		head.GasLimit = new(big.Int).Set(params.GenesisGasLimit)
+	}
+	if g.Difficulty == nil {
+		head.Difficulty = new(big.Int).Set(params.GenesisDifficulty)
+	}
+	return types.NewBlock(head, nil, nil, nil), batch, nil
+}
+
+// Commit writes the block and state of a genesis specification to the database.
+// The block is committed as the canonical head block.
+func (g *Genesis) Commit(db ethdb.Database) (*types.Block, error) {
+	block, batch, err := g.toBlock(db)
+	if err != nil {
+		return nil, err
+	}
+	if block.Number().Sign() != 0 {
+		return nil, fmt.Errorf("can't commit genesis block with number > 0")
+	}
+	if err := batch.Write(); err != nil {
 		return nil, fmt.Errorf("cannot write state: %v", err)
 	}
-	if err := WriteTd(chainDb, block.Hash(), block.NumberU64(), difficulty); err != nil {
+	if err := WriteTd(db, block.Hash(), block.NumberU64(), block.Difficulty()); err != nil {
 		return nil, err
 	}
-	if err := WriteBlock(chainDb, block); err != nil {
+	if err := WriteBlock(db, block); err != nil {
 		return nil, err
 	}
-	if err := WriteBlockReceipts(chainDb, block.Hash(), block.NumberU64(), nil); err != nil {
+	if err := WriteBlockReceipts(db, block.Hash(), block.NumberU64(), nil); err != nil {
 		return nil, err
 	}
-	if err := WriteCanonicalHash(chainDb, block.Hash(), block.NumberU64()); err != nil {
+	if err := WriteCanonicalHash(db, block.Hash(), block.NumberU64()); err != nil {
 		return nil, err
 	}
-	if err := WriteHeadBlockHash(chainDb, block.Hash()); err != nil {
+	if err := WriteHeadBlockHash(db, block.Hash()); err != nil {
 		return nil, err
 	}
-	if err := WriteChainConfig(chainDb, block.Hash(), genesis.ChainConfig); err != nil {
+	if err := WriteHeadHeaderHash(db, block.Hash()); err != nil {
 		return nil, err
 	}
-
-	return block, nil
+	return block, WriteChainConfig(db, block.Hash(), g.configOrDefault(block.Hash()))
 }
 
-// GenesisBlockForTesting creates a block in which addr has the given deibalance.
-// The state trie of the block is written to db. the passed db needs to contain a state root
-func GenesisBlockForTesting(db ethdb.Database, addr common.Address, balance *big.Int) *types.Block {
-	statedb, _ := state.New(common.Hash{}, db)
-	obj := statedb.GetOrNewStateObject(addr)
-	obj.SetBalance(balance)
-	root, err := statedb.Commit(false)
+// MustCommit writes the genesis block and state to db, panicking on error.
+// The block is committed as the canonical head block.
+func (g *Genesis) MustCommit(db ethdb.Database) *types.Block {
+	block, err := g.Commit(db)
 	if err != nil {
-		panic(fmt.Sprintf("cannot write state: %v", err))
+		panic(err)
 	}
-	block := types.NewBlock(&types.Header{
-		Difficulty: params.GenesisDifficulty,
-		GasLimit:   params.GenesisGasLimit,
-		Root:       root,
-	}, nil, nil, nil)
 	return block
 }
 
-type GenesisAccount struct {
-	Address common.Address
-	Balance *big.Int
+// GenesisBlockForTesting creates and writes a block in which addr has the given
+// wei balance.
+func GenesisBlockForTesting(db ethdb.Database, addr common.Address, balance *big.Int) *types.Block {
+	g := Genesis{Alloc: GenesisAlloc{addr: {Balance: balance}}}
+	return g.MustCommit(db)
 }
 
-func WriteGenesisBlockForTesting(db ethdb.Database, accounts ...GenesisAccount) *types.Block {
-	accountJson := "{"
-	for i, account := range accounts {
-		if i != 0 {
-			accountJson += ","
-		}
-		accountJson += fmt.Sprintf(`"0x%x":{"balance":"0x%x"}`, account.Address, account.Balance.Bytes())
+// DefaultGenesisBlock returns the Daxxcoin main net genesis block.
+func DefaultGenesisBlock() *Genesis {
+	reader, err := gzip.NewReader(base64.NewDecoder(base64.StdEncoding, strings.NewReader(defaultGenesisBlock)))
+	if err != nil {
+		panic(fmt.Sprintf("failed to access default genesis: %v", err))
 	}
-	accountJson += "}"
-
-	testGenesis := fmt.Sprintf(`{
-	"nonce":"0x%x",
-	"gasLimit":"0x%x",
-	"difficulty":"0x%x",
-	"alloc": %s
-}`, types.EncodeNonce(0), params.GenesisGasLimit.Bytes(), params.GenesisDifficulty.Bytes(), accountJson)
-	block, _ := WriteGenesisBlock(db, strings.NewReader(testGenesis))
-	return block
+	genesis := decodeGenesis(reader, "default")
+	genesis.Config = params.MainnetChainConfig
+	return genesis
 }
 
-// WriteDefaultGenesisBlock assembles the official Daxxcoin genesis block and
-// writes it - along with all associated state - into a chain database.
-func WriteDefaultGenesisBlock(chainDb ethdb.Database) (*types.Block, error) {
-	return WriteGenesisBlock(chainDb, strings.NewReader(DefaultGenesisBlock()))
+// DefaultTestnetGenesisBlock returns the Daxxcoin test network genesis block.
+func DefaultTestnetGenesisBlock() *Genesis {
+	reader := bzip2.NewReader(base64.NewDecoder(base64.StdEncoding, strings.NewReader(defaultTestnetGenesisBlock)))
+	genesis := decodeGenesis(reader, "testnet")
+	genesis.Config = params.TestnetChainConfig
+	return genesis
 }
 
-// WriteTestNetGenesisBlock assembles the test network genesis block and
-// writes it - along with all associated state - into a chain database.
-func WriteTestNetGenesisBlock(chainDb ethdb.Database) (*types.Block, error) {
-	return WriteGenesisBlock(chainDb, strings.NewReader(DefaultTestnetGenesisBlock()))
+// DevGenesisBlock returns a local dev genesis block.
+func DevGenesisBlock() *Genesis {
+	reader := bzip2.NewReader(base64.NewDecoder(base64.StdEncoding, strings.NewReader(defaultDevnetGenesisBlock)))
+	genesis := decodeGenesis(reader, "dev")
+	genesis.Config = params.AllDaxxhashProtocolChanges
+	return genesis
 }
 
-// DefaultGenesisBlock assembles a JSON string representing the default Daxxcoin
-// genesis block.
-func DefaultGenesisBlock() string {
-	reader, err := gzip.NewReader(base64.NewDecoder(base64.StdEncoding, strings.NewReader(defaultGenesisBlock)))
-	if err != nil {
-		panic(fmt.Sprintf("failed to access default genesis: %v", err))
+// decodeGenesis parses one of the embedded genesis specifications.
+func decodeGenesis(reader io.Reader, name string) *Genesis {
+	genesis := new(Genesis)
+	if err := json.NewDecoder(reader).Decode(genesis); err != nil {
+		panic(fmt.Sprintf("failed to load %s genesis: %v", name, err))
 	}
-	blob, err := ioutil.ReadAll(reader)
-	if err != nil {
-		panic(fmt.Sprintf("failed to load default genesis: %v", err))
-	}
-	return string(blob)
+	return genesis
 }
 
-// DefaultTestnetGenesisBlock assembles a JSON string representing the default Daxxcoin
-// test network genesis block.
-func DefaultTestnetGenesisBlock() string {
-	reader := bzip2.NewReader(base64.NewDecoder(base64.StdEncoding, strings.NewReader(defaultTestnetGenesisBlock)))
-	blob, err := ioutil.ReadAll(reader)
-	if err != nil {
-		panic(fmt.Sprintf("failed to load default genesis: %v", err))
-	}
-	return string(blob)
-}
+// DeveloperGenesisBlock returns a local dev genesis block running the clique
+// proof-of-authority engine, with the given address as the sole signer and
+// prefunded faucet.
+func DeveloperGenesisBlock(config *params.ChainConfig, faucet common.Address) *Genesis {
+	// Assemble the extra-data section: 32 bytes vanity, the signer and 65 bytes seal
+	extra := make([]byte, 32+common.AddressLength+65)
+	copy(extra[32:], faucet[:])
 
-// DevGenesisBlock assembles a JSON string representing a local dev genesis block.
-func DevGenesisBlock() string {
-	reader := bzip2.NewReader(base64.NewDecoder(base64.StdEncoding, strings.NewReader(defaultDevnetGenesisBlock)))
-	blob, err := ioutil.ReadAll(reader)
-	if err != nil {
-		panic(fmt.Sprintf("failed to load dev genesis: %v", err))
+	// Prefund the faucet and the precompiles (to avoid deleting them when touched)
+	alloc := GenesisAlloc{
+		faucet: {Balance: new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(9))},
+	}
+	for i := 1; i <= 8; i++ {
+		alloc[common.BigToAddress(big.NewInt(int64(i)))] = GenesisAccount{Balance: big.NewInt(1)}
+	}
+	return &Genesis{
+		Config:     config,
+		ExtraData:  extra,
+		GasLimit:   params.GenesisGasLimit.Uint64(),
+		Difficulty: big.NewInt(1),
+		Alloc:      alloc,
 	}
-	return string(blob)
 }
@@ -0,0 +1,276 @@
+// Copyright 2017 The daxxcoreAuthors
+// This file is part of the daxxcore library.
+//
+// The daxxcore library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The daxxcore library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the daxxcore library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"encoding/json"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/daxxcoin/daxxcore/common"
+	"github.com/daxxcoin/daxxcore/consensus/daxxhash"
+	"github.com/daxxcoin/daxxcore/core/state"
+	"github.com/daxxcoin/daxxcore/core/vm"
+	"github.com/daxxcoin/daxxcore/daxxdb"
+	"github.com/daxxcoin/daxxcore/event"
+	"github.com/daxxcoin/daxxcore/params"
+)
+
+// Tests that the embedded genesis specifications still produce the well known
+// genesis blocks of the main and test networks.
+func TestDefaultGenesisBlock(t *testing.T) {
+	if block := DefaultGenesisBlock().ToBlock(); block.Hash() != params.MainNetGenesisHash {
+		t.Errorf("main net genesis hash mismatch: have %x, want %x", block.Hash(), params.MainNetGenesisHash)
+	}
+	if block := DefaultTestnetGenesisBlock().ToBlock(); block.Hash() != params.TestNetGenesisHash {
+		t.Errorf("test net genesis hash mismatch: have %x, want %x", block.Hash(), params.TestNetGenesisHash)
+	}
+}
+
+// Tests that genesis specifications survive a JSON round trip, and that the
+// legacy notations (decimal quantities, addresses without prefix) are accepted.
+func TestGenesisJSON(t *testing.T) {
+	blob := `{
+		"config": {"chainId": 15, "homesteadBlock": 0, "eip155Block": 0, "eip158Block": 0},
+		"nonce": "0x0000000000000042",
+		"timestamp": "0x10",
+		"extraData": "0xdeadbeef",
+		"gasLimit": "4712388",
+		"difficulty": "0x400",
+		"alloc": {
+			"0000000000000000000000000000000000000001": {"balance": "1000"},
+			"0x0000000000000000000000000000000000000002": {
+				"balance": "0x10",
+				"nonce": "7",
+				"code": "0x6001600055",
+				"storage": {"0x01": "0x02"}
+			}
+		}
+	}`
+	genesis := new(Genesis)
+	if err := json.Unmarshal([]byte(blob), genesis); err != nil {
+		t.Fatalf("failed to decode genesis: %v", err)
+	}
+	want := &Genesis{
+		Config:     &params.ChainConfig{ChainId: big.NewInt(15), HomesteadBlock: new(big.Int), EIP155Block: new(big.Int), EIP158Block: new(big.Int)},
+		Nonce:      0x42,
+		Timestamp:  0x10,
+		ExtraData:  []byte{0xde, 0xad, 0xbe, 0xef},
+		GasLimit:   4712388,
+		Difficulty: big.NewInt(0x400),
+		Alloc: GenesisAlloc{
+			common.BytesToAddress([]byte{1}): {Balance: big.NewInt(1000)},
+			common.BytesToAddress([]byte{2}): {
+				Balance: big.NewInt(0x10),
+				Nonce:   7,
+				Code:    common.FromHex("0x6001600055"),
+				Storage: map[common.Hash]common.Hash{common.BytesToHash([]byte{1}): common.BytesToHash([]byte{2})},
+			},
+		},
+	}
+	if !reflect.DeepEqual(genesis, want) {
+		t.Fatalf("decoded genesis mismatch:\nhave %+v\nwant %+v", genesis, want)
+	}
+	enc, err := json.Marshal(genesis)
+	if err != nil {
+		t.Fatalf("failed to encode genesis: %v", err)
+	}
+	dec := new(Genesis)
+	if err := json.Unmarshal(enc, dec); err != nil {
+		t.Fatalf("failed to decode encoded genesis: %v", err)
+	}
+	if dec.ToBlock().Hash() != genesis.ToBlock().Hash() {
+		t.Errorf("genesis hash changed after round trip")
+	}
+	// Ensure invalid quantities are rejected instead of silently zeroed
+	if err := json.Unmarshal([]byte(`{"gasLimit": "0xzz"}`), new(Genesis)); err == nil {
+		t.Errorf("invalid gas limit accepted")
+	}
+	if err := json.Unmarshal([]byte(`{"nonce": "0x10000000000000000"}`), new(Genesis)); err == nil {
+		t.Errorf("overflowing nonce accepted")
+	}
+}
+
+// Tests that committing a genesis block writes its full allocation into the state.
+func TestGenesisCommit(t *testing.T) {
+	var (
+		addr    = common.HexToAddress("0x71562b71999873db5b286df957af199ec94617f7")
+		key     = common.HexToHash("0x01")
+		genesis = &Genesis{
+			Config: params.TestChainConfig,
+			Alloc: GenesisAlloc{
+				addr: {
+					Balance: big.NewInt(1000),
+					Nonce:   3,
+					Code:    []byte{0x60, 0x00},
+					Storage: map[common.Hash]common.Hash{key: common.HexToHash("0xff")},
+				},
+			},
+		}
+	)
+	db, _ := ethdb.NewMemDatabase()
+	block := genesis.MustCommit(db)
+
+	if block.Hash() != genesis.ToBlock().Hash() {
+		t.Errorf("committed block differs from assembled one")
+	}
+	if hash := GetCanonicalHash(db, 0); hash != block.Hash() {
+		t.Errorf("canonical hash mismatch: have %x, want %x", hash, block.Hash())
+	}
+	if config, err := GetChainConfig(db, block.Hash()); err != nil || !reflect.DeepEqual(config, params.TestChainConfig) {
+		t.Errorf("stored chain config mismatch: have %v (err %v), want %v", config, err, params.TestChainConfig)
+	}
+	statedb, err := state.New(block.Root(), db)
+	if err != nil {
+		t.Fatalf("failed to open genesis state: %v", err)
+	}
+	if balance := statedb.GetBalance(addr); balance.Cmp(big.NewInt(1000)) != 0 {
+		t.Errorf("balance mismatch: have %v, want %v", balance, 1000)
+	}
+	if nonce := statedb.GetNonce(addr); nonce != 3 {
+		t.Errorf("nonce mismatch: have %d, want %d", nonce, 3)
+	}
+	if code := statedb.GetCode(addr); !reflect.DeepEqual(code, []byte{0x60, 0x00}) {
+		t.Errorf("code mismatch: have %x, want %x", code, []byte{0x60, 0x00})
+	}
+	if value := statedb.GetState(addr, key); value != common.HexToHash("0xff") {
+		t.Errorf("storage mismatch: have %x, want %x", value, common.HexToHash("0xff"))
+	}
+}
+
+func TestSetupGenesis(t *testing.T) {
+	var (
+		customg = Genesis{
+			Config: &params.ChainConfig{ChainId: big.NewInt(15), HomesteadBlock: big.NewInt(3)},
+			Alloc: GenesisAlloc{
+				common.HexToAddress("0x0000000000000000000000000000000000000001"): {Balance: big.NewInt(1), Storage: map[common.Hash]common.Hash{{1}: {1}}},
+			},
+		}
+		oldcustomg = customg
+	)
+	oldcustomg.Config = &params.ChainConfig{ChainId: big.NewInt(15), HomesteadBlock: big.NewInt(2)}
+	customghash := customg.ToBlock().Hash()
+
+	tests := []struct {
+		name       string
+		fn         func(ethdb.Database) (*params.ChainConfig, common.Hash, error)
+		wantConfig *params.ChainConfig
+		wantHash   common.Hash
+		wantErr    error
+	}{
+		{
+			name: "genesis without ChainConfig",
+			fn: func(db ethdb.Database) (*params.ChainConfig, common.Hash, error) {
+				return SetupGenesisBlock(db, new(Genesis))
+			},
+			wantHash:   new(Genesis).ToBlock().Hash(),
+			wantConfig: params.AllDaxxhashProtocolChanges,
+		},
+		{
+			name: "no block in DB, genesis == nil",
+			fn: func(db ethdb.Database) (*params.ChainConfig, common.Hash, error) {
+				return SetupGenesisBlock(db, nil)
+			},
+			wantHash:   params.MainNetGenesisHash,
+			wantConfig: params.MainnetChainConfig,
+		},
+		{
+			name: "mainnet block in DB, genesis == nil",
+			fn: func(db ethdb.Database) (*params.ChainConfig, common.Hash, error) {
+				DefaultGenesisBlock().MustCommit(db)
+				return SetupGenesisBlock(db, nil)
+			},
+			wantHash:   params.MainNetGenesisHash,
+			wantConfig: params.MainnetChainConfig,
+		},
+		{
+			name: "custom block in DB, genesis == nil",
+			fn: func(db ethdb.Database) (*params.ChainConfig, common.Hash, error) {
+				customg.MustCommit(db)
+				return SetupGenesisBlock(db, nil)
+			},
+			wantHash:   customghash,
+			wantConfig: customg.Config,
+		},
+		{
+			name: "custom block in DB, genesis == testnet",
+			fn: func(db ethdb.Database) (*params.ChainConfig, common.Hash, error) {
+				customg.MustCommit(db)
+				return SetupGenesisBlock(db, DefaultTestnetGenesisBlock())
+			},
+			wantErr:    &GenesisMismatchError{Stored: customghash, New: params.TestNetGenesisHash},
+			wantHash:   params.TestNetGenesisHash,
+			wantConfig: params.TestnetChainConfig,
+		},
+		{
+			name: "updated config of custom block in DB",
+			fn: func(db ethdb.Database) (*params.ChainConfig, common.Hash, error) {
+				oldcustomg.MustCommit(db)
+				return SetupGenesisBlock(db, &customg)
+			},
+			wantHash:   customghash,
+			wantConfig: customg.Config,
+		},
+		{
+			name: "incompatible config in DB",
+			fn: func(db ethdb.Database) (*params.ChainConfig, common.Hash, error) {
+				// Commit the 'old' genesis block with Homestead transition at #2.
+				// Advance to block #4, past the homestead transition block of customg.
+				genesis := oldcustomg.MustCommit(db)
+				bc, _ := NewBlockChain(db, nil, oldcustomg.Config, daxxhash.NewFullFaker(), new(event.TypeMux), vm.Config{})
+				defer bc.Stop()
+				blocks, _ := GenerateChain(oldcustomg.Config, genesis, db, 4, nil)
+				bc.InsertChain(blocks)
+				// This should return a compatibility error.
+				return SetupGenesisBlock(db, &customg)
+			},
+			wantHash:   customghash,
+			wantConfig: customg.Config,
+			wantErr: &params.ConfigCompatError{
+				What:         "Homestead fork block",
+				StoredConfig: big.NewInt(2),
+				NewConfig:    big.NewInt(3),
+				RewindTo:     1,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		db, _ := ethdb.NewMemDatabase()
+		config, hash, err := test.fn(db)
+		// Check the return values.
+		if !reflect.DeepEqual(err, test.wantErr) {
+			t.Errorf("%s: returned error %#v, want %#v", test.name, err, test.wantErr)
+		}
+		if !reflect.DeepEqual(config, test.wantConfig) {
+			t.Errorf("%s:\nreturned %v\nwant     %v", test.name, config, test.wantConfig)
+		}
+		if hash != test.wantHash {
+			t.Errorf("%s: returned hash %s, want %s", test.name, hash.Hex(), test.wantHash.Hex())
+		} else if err == nil {
+			// Check database content.
+			stored := GetBlock(db, test.wantHash, 0)
+			if stored.Hash() != test.wantHash {
+				t.Errorf("%s: block in DB has hash %s, want %s", test.name, stored.Hash(), test.wantHash)
+			}
+			if storedcfg, _ := GetChainConfig(db, test.wantHash); !reflect.DeepEqual(storedcfg, test.wantConfig) {
+				t.Errorf("%s: stored config %v, want %v", test.name, storedcfg, test.wantConfig)
+			}
+		}
+	}
+}
@@ -19,9 +19,12 @@ package state
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 
 	"github.com/daxxcoin/daxxcore/common"
+	"github.com/daxxcoin/daxxcore/common/hexutil"
 	"github.com/daxxcoin/daxxcore/rlp"
+	"github.com/daxxcoin/daxxcore/trie"
 )
 
 type DumpAccount struct {
@@ -58,10 +61,10 @@ func (self *StateDB) RawDump() Dump {
 			Nonce:    data.Nonce,
 			Root:     common.Bytes2Hex(data.Root[:]),
 			CodeHash: common.Bytes2Hex(data.CodeHash),
-			Code:     common.Bytes2Hex(obj.Code(self.db)),
+			Code:     common.Bytes2Hex(obj.Code(self.trieDB())),
 			Storage:  make(map[string]string),
 		}
-		storageIt := obj.getTrie(self.db).Iterator()
+		storageIt := obj.getTrie(self.trieDB()).Iterator()
 		for storageIt.Next() {
 			account.Storage[common.Bytes2Hex(self.trie.GetKey(storageIt.Key))] = common.Bytes2Hex(storageIt.Value)
 		}
@@ -78,3 +81,211 @@ func (self *StateDB) Dump() []byte {
 
 	return json
 }
+
+// DumpConfig is a set of options controlling which accounts and which of their
+// fields are included in a streamed state dump.
+type DumpConfig struct {
+	SkipCode        bool   // Omit the contract code of the accounts
+	SkipStorage     bool   // Omit the storage slots of the accounts
+	OnlyWithStorage bool   // Omit the accounts with empty storage
+	Start           []byte // Hash of the address to start the dump at (nil = first account)
+	Max             uint64 // Maximum number of accounts to dump (0 = unlimited)
+}
+
+// DumpLine is a single account of a streamed state dump. Its field names and
+// encodings match the genesis alloc format, so lines can be collected into the
+// alloc section of a test genesis keyed by their address.
+type DumpLine struct {
+	Address  *common.Address             `json:"address,omitempty"` // omitted if the preimage is unknown
+	Key      common.Hash                 `json:"key"`               // hash of the address
+	Balance  *hexutil.Big                `json:"balance"`
+	Nonce    hexutil.Uint64              `json:"nonce"`
+	Root     common.Hash                 `json:"root"`
+	CodeHash common.Hash                 `json:"codeHash"`
+	Code     hexutil.Bytes               `json:"code,omitempty"`
+	Storage  map[common.Hash]common.Hash `json:"storage,omitempty"` // slots with unknown key preimages are keyed by the key hash
+}
+
+// IterativeDump streams the accounts of the state into w in the order of their
+// address hashes, one JSON object per line. The first line holds the state root.
+// If the dump stops at the configured limit, a last line holds the hash of the
+// next account, which is also returned to continue the dump from.
+func (self *StateDB) IterativeDump(conf DumpConfig, w io.Writer) (*common.Hash, error) {
+	enc := json.NewEncoder(w)
+	if err := enc.Encode(struct {
+		Root common.Hash `json:"root"`
+	}{self.trie.Hash()}); err != nil {
+		return nil, err
+	}
+	var dumped uint64
+
+	it := self.trie.IteratorFrom(conf.Start)
+	for it.Next() {
+		if conf.Max > 0 && dumped >= conf.Max {
+			next := common.BytesToHash(it.Key)
+			return &next, enc.Encode(struct {
+				Next common.Hash `json:"next"`
+			}{next})
+		}
+		var data Account
+		if err := rlp.DecodeBytes(it.Value, &data); err != nil {
+			return nil, err
+		}
+		if conf.OnlyWithStorage && data.Root == emptyRoot {
+			continue
+		}
+		line := DumpLine{
+			Key:      common.BytesToHash(it.Key),
+			Balance:  (*hexutil.Big)(data.Balance),
+			Nonce:    hexutil.Uint64(data.Nonce),
+			Root:     data.Root,
+			CodeHash: common.BytesToHash(data.CodeHash),
+		}
+		var addr common.Address
+		if preimage := self.trie.GetKey(it.Key); preimage != nil {
+			addr = common.BytesToAddress(preimage)
+			line.Address = &addr
+		}
+		obj := newObject(nil, addr, data, nil)
+		if !conf.SkipCode {
+			line.Code = obj.Code(self.trieDB())
+		}
+		if !conf.SkipStorage && data.Root != emptyRoot {
+			line.Storage = make(map[common.Hash]common.Hash)
+
+			storageIt := obj.getTrie(self.trieDB()).Iterator()
+			for storageIt.Next() {
+				_, content, _, err := rlp.Split(storageIt.Value)
+				if err != nil {
+					return nil, err
+				}
+				key := common.BytesToHash(storageIt.Key)
+				if preimage := self.trie.GetKey(storageIt.Key); preimage != nil {
+					key = common.BytesToHash(preimage)
+				}
+				line.Storage[key] = common.BytesToHash(content)
+			}
+			if err := storageIt.Error(); err != nil {
+				return nil, err
+			}
+		}
+		if obj.dbErr != nil {
+			return nil, obj.dbErr
+		}
+		if err := enc.Encode(line); err != nil {
+			return nil, err
+		}
+		dumped++
+	}
+	return nil, it.Error()
+}
+
+// AccountRange is a page of the accounts in the state trie, keyed and ordered by
+// the hash of their address.
+type AccountRange struct {
+	Accounts map[common.Hash]RangeAccount `json:"accounts"`
+	NextKey  *common.Hash                 `json:"nextKey"` // nil if the page includes the last account
+}
+
+// RangeAccount is a single account of an AccountRange.
+type RangeAccount struct {
+	Address  *common.Address `json:"address"` // nil if the address preimage is unknown
+	Balance  string          `json:"balance"`
+	Nonce    uint64          `json:"nonce"`
+	Root     common.Hash     `json:"root"`
+	CodeHash common.Hash     `json:"codeHash"`
+}
+
+// StorageRange is a page of the storage slots of an account, keyed and ordered
+// by the hash of their key.
+type StorageRange struct {
+	Storage map[common.Hash]RangeSlot `json:"storage"`
+	NextKey *common.Hash              `json:"nextKey"` // nil if the page includes the last slot
+}
+
+// RangeSlot is a single storage slot of a StorageRange.
+type RangeSlot struct {
+	Key   *common.Hash `json:"key"` // nil if the slot key preimage is unknown
+	Value common.Hash  `json:"value"`
+}
+
+// AccountRange returns at most maxResults accounts of the state trie whose
+// address hashes are ordered at or after start, along with the hash of the
+// account to continue from. Address preimages are included where known.
+func (self *StateDB) AccountRange(start []byte, maxResults int) (AccountRange, error) {
+	result := AccountRange{Accounts: make(map[common.Hash]RangeAccount)}
+
+	next, err := iterateRange(self.trie, start, maxResults, func(key, value []byte) error {
+		var data Account
+		if err := rlp.DecodeBytes(value, &data); err != nil {
+			return err
+		}
+		account := RangeAccount{
+			Balance:  data.Balance.String(),
+			Nonce:    data.Nonce,
+			Root:     data.Root,
+			CodeHash: common.BytesToHash(data.CodeHash),
+		}
+		if preimage := self.trie.GetKey(key); preimage != nil {
+			addr := common.BytesToAddress(preimage)
+			account.Address = &addr
+		}
+		result.Accounts[common.BytesToHash(key)] = account
+		return nil
+	})
+	if err != nil {
+		return AccountRange{}, err
+	}
+	result.NextKey = next
+	return result, nil
+}
+
+// StorageRange returns at most maxResults storage slots of the given account
+// whose key hashes are ordered at or after start, along with the hash of the
+// slot to continue from. Pending storage changes are included, as are the key
+// preimages where known.
+func (self *StateDB) StorageRange(a common.Address, start []byte, maxResults int) (StorageRange, error) {
+	result := StorageRange{Storage: make(map[common.Hash]RangeSlot)}
+
+	st := self.StorageTrie(a)
+	if st == nil {
+		return result, nil
+	}
+	next, err := iterateRange(st, start, maxResults, func(key, value []byte) error {
+		_, content, _, err := rlp.Split(value)
+		if err != nil {
+			return err
+		}
+		slot := RangeSlot{Value: common.BytesToHash(content)}
+		if preimage := st.GetKey(key); preimage != nil {
+			hash := common.BytesToHash(preimage)
+			slot.Key = &hash
+		}
+		result.Storage[common.BytesToHash(key)] = slot
+		return nil
+	})
+	if err != nil {
+		return StorageRange{}, err
+	}
+	result.NextKey = next
+	return result, nil
+}
+
+// iterateRange walks at most maxResults leaves of a trie starting at the given
+// hashed key, returning the hashed key of the next leaf if the trie has more.
+func iterateRange(tr *trie.SecureTrie, start []byte, maxResults int, onLeaf func(key, value []byte) error) (*common.Hash, error) {
+	it := tr.IteratorFrom(start)
+	for i := 0; i < maxResults; i++ {
+		if !it.Next() {
+			return nil, it.Error()
+		}
+		if err := onLeaf(it.Key, it.Value); err != nil {
+			return nil, err
+		}
+	}
+	if !it.Next() {
+		return nil, it.Error()
+	}
+	next := common.BytesToHash(it.Key)
+	return &next, nil
+}
@@ -0,0 +1,306 @@
+// Copyright 2017 The daxxcoreAuthors
+// This file is part of the daxxcore library.
+//
+// The daxxcore library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The daxxcore library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the daxxcore library. If not, see <http://www.gnu.org/licenses/>.
+
+package snapshot
+
+import (
+	"bytes"
+	"sync"
+
+	"github.com/daxxcoin/daxxcore/common"
+	"github.com/daxxcoin/daxxcore/daxxdb"
+	"github.com/daxxcoin/daxxcore/trie"
+	lru "github.com/hashicorp/golang-lru"
+)
+
+// cacheItemsPerMB is the approximate number of snapshot entries fitting into one
+// megabyte of read cache.
+const cacheItemsPerMB = 4096
+
+// diskLayer is a low level persistent snapshot built on top of a key-value store.
+type diskLayer struct {
+	diskdb ethdb.Database // Key-value store containing the base snapshot
+	triedb trie.Database  // Trie node database to generate the snapshot from
+	cache  *lru.Cache     // Cache to avoid hitting the disk for direct access
+	root   common.Hash    // Root hash of the base snapshot
+	stale  bool           // Signals that the layer became stale (state progressed)
+
+	genMarker []byte        // Last account covered by the generator, nil if fully generated
+	genAbort  chan struct{} // Notification channel to abort generating the snapshot in this layer
+	genDone   chan struct{} // Channel closed when the generator terminates
+
+	lock sync.RWMutex
+}
+
+// newDiskLayer creates a disk layer for the given root, without any generation
+// in progress.
+func newDiskLayer(diskdb ethdb.Database, triedb trie.Database, cache int, root common.Hash) *diskLayer {
+	items := cache * cacheItemsPerMB
+	if items <= 0 {
+		items = cacheItemsPerMB
+	}
+	lru, _ := lru.New(items)
+	return &diskLayer{
+		diskdb: diskdb,
+		triedb: triedb,
+		cache:  lru,
+		root:   root,
+	}
+}
+
+// Root returns root hash for which this snapshot was made.
+func (dl *diskLayer) Root() common.Hash {
+	return dl.root
+}
+
+// Parent always returns nil as there's no layer below the disk.
+func (dl *diskLayer) Parent() snapshot {
+	return nil
+}
+
+// Stale return whether this layer has become stale (was flattened across) or if
+// it's still live.
+func (dl *diskLayer) Stale() bool {
+	dl.lock.RLock()
+	defer dl.lock.RUnlock()
+
+	return dl.stale
+}
+
+// markStale flags the layer as stale, failing any further reads from it.
+func (dl *diskLayer) markStale() {
+	dl.lock.Lock()
+	defer dl.lock.Unlock()
+
+	dl.stale = true
+}
+
+// covered returns whether the generator already processed the given account.
+// The caller must hold the read lock.
+func (dl *diskLayer) covered(hash common.Hash) bool {
+	return dl.genMarker == nil || bytes.Compare(hash[:], dl.genMarker) <= 0
+}
+
+// Account directly retrieves the account associated with a particular hash in
+// the snapshot slim data format.
+func (dl *diskLayer) Account(hash common.Hash) (*Account, error) {
+	data, err := dl.AccountRLP(hash)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 { // can be both nil and []byte{}
+		return nil, nil
+	}
+	return FullAccount(data)
+}
+
+// AccountRLP directly retrieves the account RLP associated with a particular
+// hash in the snapshot slim data format.
+func (dl *diskLayer) AccountRLP(hash common.Hash) ([]byte, error) {
+	dl.lock.RLock()
+	defer dl.lock.RUnlock()
+
+	if dl.stale {
+		return nil, ErrSnapshotStale
+	}
+	if !dl.covered(hash) {
+		return nil, ErrNotCoveredYet
+	}
+	return dl.get(accountSnapshotKey(hash)), nil
+}
+
+// Storage directly retrieves the storage data associated with a particular hash,
+// within a particular account.
+func (dl *diskLayer) Storage(accountHash, storageHash common.Hash) ([]byte, error) {
+	dl.lock.RLock()
+	defer dl.lock.RUnlock()
+
+	if dl.stale {
+		return nil, ErrSnapshotStale
+	}
+	if !dl.covered(accountHash) {
+		return nil, ErrNotCoveredYet
+	}
+	return dl.get(storageSnapshotKey(accountHash, storageHash)), nil
+}
+
+// get retrieves a snapshot entry from the read cache, or from disk on a miss.
+func (dl *diskLayer) get(key []byte) []byte {
+	if blob, ok := dl.cache.Get(string(key)); ok {
+		return blob.([]byte)
+	}
+	blob, _ := dl.diskdb.Get(key)
+	if len(blob) == 0 {
+		blob = nil
+	}
+	dl.cache.Add(string(key), blob)
+	return blob
+}
+
+// Update creates a new layer on top of the existing snapshot diff tree with
+// the specified data items. Note, the maps are retained by the method to avoid
+// copying everything.
+func (dl *diskLayer) Update(blockRoot common.Hash, destructs map[common.Hash]struct{}, accounts map[common.Hash][]byte, storage map[common.Hash]map[common.Hash][]byte) *diffLayer {
+	return newDiffLayer(dl, blockRoot, destructs, accounts, storage)
+}
+
+// iterateAccounts creates an account iterator over the disk layer.
+func (dl *diskLayer) iterateAccounts(seek common.Hash) hashIterator {
+	return dl.iterator(snapshotAccountPrefix, seek)
+}
+
+// iterateStorage creates a storage iterator over the disk layer.
+func (dl *diskLayer) iterateStorage(account common.Hash, seek common.Hash) hashIterator {
+	return dl.iterator(storageSnapshotsKey(account), seek)
+}
+
+// iterator creates an iterator over the persisted entries with the given prefix.
+// Iteration is only supported on fully generated snapshots.
+func (dl *diskLayer) iterator(prefix []byte, seek common.Hash) hashIterator {
+	dl.lock.RLock()
+	defer dl.lock.RUnlock()
+
+	switch {
+	case dl.stale:
+		return &errorIterator{err: ErrSnapshotStale}
+	case dl.genMarker != nil:
+		return &errorIterator{err: ErrNotCoveredYet}
+	}
+	return &diskIterator{prefix: prefix, it: dl.diskdb.NewIterator(prefix, seek[:])}
+}
+
+// flatten merges the given diff layer (whose parent must be this disk layer)
+// into the persistent database, returning the new disk layer representing its
+// state. Only the entries already covered by the generator marker are written,
+// the rest will be generated from the new root later.
+//
+// The generator of the layer must be stopped before flattening.
+func (dl *diskLayer) flatten(diff *diffLayer, marker []byte) (*diskLayer, error) {
+	covered := func(hash common.Hash) bool {
+		return marker == nil || bytes.Compare(hash[:], marker) <= 0
+	}
+	// Drop the root marker first so a crash midway can't leave a snapshot on disk
+	// that claims to represent a root it only partially matches
+	if err := dl.diskdb.Delete(snapshotRootKey); err != nil {
+		return nil, err
+	}
+	batch := newSizedBatch(dl.diskdb)
+
+	diff.lock.RLock()
+	for hash := range diff.destructSet {
+		if !covered(hash) {
+			continue
+		}
+		dl.diskdb.Delete(accountSnapshotKey(hash))
+		dl.cache.Remove(string(accountSnapshotKey(hash)))
+
+		it := dl.diskdb.NewIterator(storageSnapshotsKey(hash), nil)
+		for it.Next() {
+			dl.diskdb.Delete(it.Key())
+			dl.cache.Remove(string(it.Key()))
+		}
+		it.Release()
+	}
+	for hash, data := range diff.accountData {
+		if !covered(hash) {
+			continue
+		}
+		key := accountSnapshotKey(hash)
+		if len(data) == 0 {
+			dl.diskdb.Delete(key)
+			dl.cache.Add(string(key), []byte(nil))
+			continue
+		}
+		if err := batch.put(key, data); err != nil {
+			diff.lock.RUnlock()
+			return nil, err
+		}
+		dl.cache.Add(string(key), data)
+	}
+	for accountHash, slots := range diff.storageData {
+		if !covered(accountHash) {
+			continue
+		}
+		for storageHash, data := range slots {
+			key := storageSnapshotKey(accountHash, storageHash)
+			if len(data) == 0 {
+				dl.diskdb.Delete(key)
+				dl.cache.Add(string(key), []byte(nil))
+				continue
+			}
+			if err := batch.put(key, data); err != nil {
+				diff.lock.RUnlock()
+				return nil, err
+			}
+			dl.cache.Add(string(key), data)
+		}
+	}
+	diff.lock.RUnlock()
+
+	// Update the snapshot markers and flush everything out
+	if marker != nil {
+		if err := batch.put(snapshotGeneratorKey, marker); err != nil {
+			return nil, err
+		}
+	}
+	if err := batch.put(snapshotRootKey, diff.root[:]); err != nil {
+		return nil, err
+	}
+	if err := batch.write(); err != nil {
+		return nil, err
+	}
+	dl.markStale()
+	diff.markStale()
+
+	return &diskLayer{
+		diskdb:    dl.diskdb,
+		triedb:    dl.triedb,
+		cache:     dl.cache,
+		root:      diff.root,
+		genMarker: marker,
+	}, nil
+}
+
+// sizedBatch is a database batch which writes itself out whenever the amount of
+// accumulated data exceeds ethdb.IdealBatchSize.
+type sizedBatch struct {
+	ethdb.Batch
+}
+
+// newSizedBatch creates a self flushing batch on top of the given database.
+func newSizedBatch(db ethdb.Database) *sizedBatch {
+	return &sizedBatch{db.NewBatch()}
+}
+
+// put inserts the given value into the batch, flushing it if it grew too large.
+func (b *sizedBatch) put(key, value []byte) error {
+	if err := b.Put(key, value); err != nil {
+		return err
+	}
+	if b.ValueSize() >= ethdb.IdealBatchSize {
+		return b.write()
+	}
+	return nil
+}
+
+// write flushes any accumulated data to disk and resets the batch for reuse.
+func (b *sizedBatch) write() error {
+	if err := b.Write(); err != nil {
+		return err
+	}
+	b.Reset()
+	return nil
+}
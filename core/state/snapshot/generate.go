@@ -0,0 +1,243 @@
+// Copyright 2017 The daxxcoreAuthors
+// This file is part of the daxxcore library.
+//
+// The daxxcore library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The daxxcore library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the daxxcore library. If not, see <http://www.gnu.org/licenses/>.
+
+package snapshot
+
+import (
+	"bytes"
+	"math/big"
+	"time"
+
+	"github.com/daxxcoin/daxxcore/common"
+	"github.com/daxxcoin/daxxcore/daxxdb"
+	"github.com/daxxcoin/daxxcore/logger"
+	"github.com/daxxcoin/daxxcore/logger/glog"
+	"github.com/daxxcoin/daxxcore/rlp"
+	"github.com/daxxcoin/daxxcore/trie"
+)
+
+// generatorLogInterval is the time between two generator progress reports.
+const generatorLogInterval = 8 * time.Second
+
+// generateSnapshot creates a new disk layer for the given root and starts
+// regenerating its flat state from the tries in the background. Any previously
+// persisted snapshot data is wiped by the generator before it starts.
+func generateSnapshot(diskdb ethdb.Database, triedb trie.Database, cache int, root common.Hash) *diskLayer {
+	batch := diskdb.NewBatch()
+	batch.Put(snapshotRootKey, root[:])
+	batch.Put(snapshotGeneratorKey, []byte{})
+	if err := batch.Write(); err != nil {
+		glog.V(logger.Error).Infof("Failed to write snapshot generator marker: %v", err)
+	}
+	dl := newDiskLayer(diskdb, triedb, cache, root)
+	dl.startGeneration([]byte{})
+	return dl
+}
+
+// startGeneration launches the background generator of the disk layer, resuming
+// after the given account marker. An empty marker starts from scratch.
+func (dl *diskLayer) startGeneration(marker []byte) {
+	if marker == nil {
+		marker = []byte{}
+	}
+	abort, done := make(chan struct{}), make(chan struct{})
+
+	dl.lock.Lock()
+	dl.genMarker = marker
+	dl.genAbort = abort
+	dl.genDone = done
+	dl.lock.Unlock()
+
+	go dl.generate(abort, done)
+}
+
+// stopGeneration aborts the background generator of the disk layer, if running,
+// and waits for it to persist its progress. It returns the last account marker
+// the generator reached, and whether the generation was still in progress.
+func (dl *diskLayer) stopGeneration() ([]byte, bool) {
+	dl.lock.Lock()
+	abort, done := dl.genAbort, dl.genDone
+	dl.genAbort = nil
+	dl.lock.Unlock()
+
+	if abort != nil {
+		close(abort)
+		<-done
+	}
+	dl.lock.RLock()
+	defer dl.lock.RUnlock()
+
+	return dl.genMarker, dl.genMarker != nil
+}
+
+// generate iterates over the account trie of the disk layer's root, writing all
+// accounts and storage slots after the generator marker into the flat snapshot.
+// Progress is persisted along with the data, allowing resumption after restarts.
+func (dl *diskLayer) generate(abort chan struct{}, done chan struct{}) {
+	defer close(done)
+
+	dl.lock.RLock()
+	marker := dl.genMarker
+	dl.lock.RUnlock()
+
+	// Starting from scratch, make sure no leftovers of earlier snapshots remain
+	if len(marker) == 0 {
+		if !wipeSnapshot(dl.diskdb, abort) {
+			return
+		}
+	}
+	accTrie, err := trie.NewSecure(dl.root, dl.triedb, 0)
+	if err != nil {
+		glog.V(logger.Error).Infof("Snapshot generation failed, missing account trie %x: %v", dl.root[:4], err)
+		return
+	}
+	var (
+		batch    = dl.diskdb.NewBatch()
+		accounts int
+		slots    int
+		start    = time.Now()
+		logged   = time.Now()
+	)
+	// flush writes out the accumulated data along with the new marker, only
+	// exposing the progress to readers once it hit the disk.
+	flush := func(next []byte) bool {
+		if err := batch.Put(snapshotGeneratorKey, next); err != nil {
+			glog.V(logger.Error).Infof("Failed to write snapshot generator marker: %v", err)
+			return false
+		}
+		if err := batch.Write(); err != nil {
+			glog.V(logger.Error).Infof("Failed to write snapshot data: %v", err)
+			return false
+		}
+		batch.Reset()
+		dl.lock.Lock()
+		dl.genMarker = next
+		dl.lock.Unlock()
+		return true
+	}
+	it := accTrie.IteratorFrom(marker)
+	for it.Next() {
+		hash := common.CopyBytes(it.Key)
+		if len(marker) > 0 && bytes.Compare(hash, marker) <= 0 {
+			continue
+		}
+		// Persist any progress and bail out if the generation was aborted
+		select {
+		case <-abort:
+			if len(marker) > 0 {
+				flush(marker)
+			}
+			glog.V(logger.Info).Infof("Aborted snapshot generation at %x", marker)
+			return
+		default:
+		}
+		var acc struct {
+			Nonce    uint64
+			Balance  *big.Int
+			Root     common.Hash
+			CodeHash []byte
+		}
+		if err := rlp.DecodeBytes(it.Value, &acc); err != nil {
+			glog.V(logger.Error).Infof("Snapshot generation failed, invalid account %x: %v", hash, err)
+			return
+		}
+		data := SlimAccountRLP(acc.Nonce, acc.Balance, acc.Root, acc.CodeHash)
+		accountHash := common.BytesToHash(hash)
+		if err := batch.Put(accountSnapshotKey(accountHash), data); err != nil {
+			glog.V(logger.Error).Infof("Failed to write snapshot account: %v", err)
+			return
+		}
+		accounts++
+
+		// Generate the storage slots of the account if it has any
+		if acc.Root != emptyRoot {
+			storeTrie, err := trie.NewSecure(acc.Root, dl.triedb, 0)
+			if err != nil {
+				glog.V(logger.Error).Infof("Snapshot generation failed, missing storage trie %x: %v", acc.Root[:4], err)
+				return
+			}
+			storeIt := storeTrie.Iterator()
+			for storeIt.Next() {
+				key := storageSnapshotKey(accountHash, common.BytesToHash(storeIt.Key))
+				if err := batch.Put(key, common.CopyBytes(storeIt.Value)); err != nil {
+					glog.V(logger.Error).Infof("Failed to write snapshot storage: %v", err)
+					return
+				}
+				slots++
+			}
+			if err := storeIt.Error(); err != nil {
+				glog.V(logger.Error).Infof("Snapshot generation failed, storage trie %x: %v", acc.Root[:4], err)
+				return
+			}
+		}
+		marker = hash
+
+		// Flush the data and the marker together once enough accumulated
+		if batch.ValueSize() >= ethdb.IdealBatchSize {
+			if !flush(marker) {
+				return
+			}
+		}
+		if time.Since(logged) > generatorLogInterval {
+			glog.V(logger.Info).Infof("Generating state snapshot: at %x, %d accounts, %d slots, elapsed %v", marker, accounts, slots, time.Since(start))
+			logged = time.Now()
+		}
+	}
+	if err := it.Error(); err != nil {
+		if len(marker) > 0 {
+			flush(marker)
+		}
+		glog.V(logger.Error).Infof("Snapshot generation failed, account trie %x: %v", dl.root[:4], err)
+		return
+	}
+	// Generation complete, drop the marker to signal full coverage
+	if err := batch.Write(); err != nil {
+		glog.V(logger.Error).Infof("Failed to write snapshot data: %v", err)
+		return
+	}
+	if err := dl.diskdb.Delete(snapshotGeneratorKey); err != nil {
+		glog.V(logger.Error).Infof("Failed to delete snapshot generator marker: %v", err)
+		return
+	}
+	dl.lock.Lock()
+	dl.genMarker = nil
+	dl.lock.Unlock()
+
+	glog.V(logger.Info).Infof("Generated state snapshot %x: %d accounts, %d slots, elapsed %v", dl.root[:4], accounts, slots, time.Since(start))
+}
+
+// wipeSnapshot deletes all the flat account and storage entries from the
+// database, returning false if it was aborted midway.
+func wipeSnapshot(db ethdb.Database, abort chan struct{}) bool {
+	for _, prefix := range [][]byte{snapshotAccountPrefix, snapshotStoragePrefix} {
+		it := db.NewIterator(prefix, nil)
+		for it.Next() {
+			select {
+			case <-abort:
+				it.Release()
+				return false
+			default:
+			}
+			if err := db.Delete(it.Key()); err != nil {
+				glog.V(logger.Error).Infof("Failed to wipe snapshot entry: %v", err)
+				it.Release()
+				return false
+			}
+		}
+		it.Release()
+	}
+	return true
+}
@@ -0,0 +1,283 @@
+// Copyright 2017 The daxxcoreAuthors
+// This file is part of the daxxcore library.
+//
+// The daxxcore library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The daxxcore library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the daxxcore library. If not, see <http://www.gnu.org/licenses/>.
+
+package snapshot
+
+import (
+	"bytes"
+
+	"github.com/daxxcoin/daxxcore/common"
+	"github.com/daxxcoin/daxxcore/daxxdb"
+)
+
+// Iterator is an iterator to step over all the accounts or the specific
+// storage in a snapshot which may or may not be composed of multiple layers.
+type Iterator interface {
+	// Next steps the iterator forward one element, returning false if exhausted,
+	// or an error if iteration failed for some reason (e.g. root being iterated
+	// becomes stale and garbage collected).
+	Next() bool
+
+	// Error returns any failure that occurred during iteration, which might have
+	// caused a premature iteration exit (e.g. snapshot stack becoming stale).
+	Error() error
+
+	// Hash returns the hash of the account or storage slot the iterator is
+	// currently at.
+	Hash() common.Hash
+
+	// Release releases associated resources. Release should always succeed and
+	// can be called multiple times without causing error.
+	Release()
+}
+
+// AccountIterator is an iterator to step over all the accounts in a snapshot,
+// which may or may not be composed of multiple layers.
+type AccountIterator interface {
+	Iterator
+
+	// Account returns the RLP encoded slim account the iterator is currently at.
+	Account() []byte
+}
+
+// StorageIterator is an iterator to step over the specific storage in a snapshot,
+// which may or may not be composed of multiple layers.
+type StorageIterator interface {
+	Iterator
+
+	// Slot returns the storage slot the iterator is currently at.
+	Slot() []byte
+}
+
+// hashIterator is the layer level iterator shared by accounts and storage slots.
+type hashIterator interface {
+	Iterator
+
+	// value returns the data blob the iterator is currently at. Iterators over a
+	// single diff layer return nil for deleted entries.
+	value() []byte
+}
+
+// accountIterator wraps a layer iterator to iterate over accounts.
+type accountIterator struct {
+	hashIterator
+}
+
+// Account returns the RLP encoded slim account the iterator is currently at.
+func (it accountIterator) Account() []byte {
+	return it.value()
+}
+
+// storageIterator wraps a layer iterator to iterate over storage slots.
+type storageIterator struct {
+	hashIterator
+}
+
+// Slot returns the storage slot the iterator is currently at.
+func (it storageIterator) Slot() []byte {
+	return it.value()
+}
+
+// errorIterator is an iterator that's failed before producing any element.
+type errorIterator struct {
+	err error
+}
+
+func (it *errorIterator) Next() bool        { return false }
+func (it *errorIterator) Error() error      { return it.err }
+func (it *errorIterator) Hash() common.Hash { return common.Hash{} }
+func (it *errorIterator) Release()          {}
+func (it *errorIterator) value() []byte     { return nil }
+
+// diffIterator is an iterator over the entries of a single diff layer, including
+// the entries the layer deleted.
+type diffIterator struct {
+	entries map[common.Hash][]byte
+	keys    []common.Hash
+	index   int
+}
+
+// Next steps the iterator forward one element, returning false if exhausted.
+func (it *diffIterator) Next() bool {
+	if it.index+1 >= len(it.keys) {
+		it.index = len(it.keys)
+		return false
+	}
+	it.index++
+	return true
+}
+
+// Error returns any failure that occurred during iteration, which is never the
+// case for in-memory iteration.
+func (it *diffIterator) Error() error {
+	return nil
+}
+
+// Hash returns the hash of the entry the iterator is currently at.
+func (it *diffIterator) Hash() common.Hash {
+	if it.index < 0 || it.index >= len(it.keys) {
+		return common.Hash{}
+	}
+	return it.keys[it.index]
+}
+
+// Release is a noop for diff iterators as there are no held resources.
+func (it *diffIterator) Release() {}
+
+// value returns the data blob the iterator is currently at, nil for deletions.
+func (it *diffIterator) value() []byte {
+	if it.index < 0 || it.index >= len(it.keys) {
+		return nil
+	}
+	return it.entries[it.keys[it.index]]
+}
+
+// diskIterator is an iterator over the entries persisted in the disk layer.
+type diskIterator struct {
+	prefix []byte
+	it     ethdb.Iterator
+}
+
+// Next steps the iterator forward one element, returning false if exhausted.
+func (it *diskIterator) Next() bool {
+	if it.it == nil {
+		return false
+	}
+	for it.it.Next() {
+		// Skip any entries not exactly one hash beyond the prefix (e.g. storage
+		// slots nested under an account hash prefix)
+		if len(it.it.Key()) == len(it.prefix)+common.HashLength {
+			return true
+		}
+	}
+	return false
+}
+
+// Error returns any failure that occurred during iteration.
+func (it *diskIterator) Error() error {
+	if it.it == nil {
+		return nil
+	}
+	return it.it.Error()
+}
+
+// Hash returns the hash of the entry the iterator is currently at.
+func (it *diskIterator) Hash() common.Hash {
+	return common.BytesToHash(it.it.Key()[len(it.prefix):])
+}
+
+// Release releases the database snapshot held by the iterator.
+func (it *diskIterator) Release() {
+	if it.it != nil {
+		it.it.Release()
+		it.it = nil
+	}
+}
+
+// value returns the data blob the iterator is currently at.
+func (it *diskIterator) value() []byte {
+	return common.CopyBytes(it.it.Value())
+}
+
+// binaryIterator is a simplistic iterator to step over the entries of a diff
+// layer merged with the iterator of all the layers below it. Entries of the diff
+// layer override the ones below, deleted entries are skipped.
+type binaryIterator struct {
+	a, b     hashIterator
+	aOk, bOk bool
+	hash     common.Hash
+	blob     []byte
+	fail     error
+}
+
+// newBinaryIterator creates a merging iterator over a diff layer iterator (a) and
+// the iterator of its parent layers (b).
+func newBinaryIterator(a, b hashIterator) *binaryIterator {
+	it := &binaryIterator{a: a, b: b}
+	it.aOk = a.Next()
+	it.bOk = b.Next()
+	return it
+}
+
+// Next steps the iterator forward one element, returning false if exhausted or
+// if the parent iterator failed.
+func (it *binaryIterator) Next() bool {
+	for {
+		if it.fail != nil {
+			return false
+		}
+		if !it.bOk {
+			if err := it.b.Error(); err != nil {
+				it.fail = err
+				return false
+			}
+		}
+		if !it.aOk && !it.bOk {
+			return false
+		}
+		var own bool
+		switch {
+		case !it.bOk:
+			own = true
+		case !it.aOk:
+			own = false
+		default:
+			ah, bh := it.a.Hash(), it.b.Hash()
+			switch bytes.Compare(ah[:], bh[:]) {
+			case -1:
+				own = true
+			case 1:
+				own = false
+			default:
+				// Same entry in both, the diff layer overrides the parent
+				own = true
+				it.bOk = it.b.Next()
+			}
+		}
+		if own {
+			it.hash, it.blob = it.a.Hash(), it.a.value()
+			it.aOk = it.a.Next()
+			if len(it.blob) == 0 {
+				continue // Deleted in the diff layer
+			}
+			return true
+		}
+		it.hash, it.blob = it.b.Hash(), it.b.value()
+		it.bOk = it.b.Next()
+		return true
+	}
+}
+
+// Error returns any failure that occurred during iteration.
+func (it *binaryIterator) Error() error {
+	return it.fail
+}
+
+// Hash returns the hash of the entry the iterator is currently at.
+func (it *binaryIterator) Hash() common.Hash {
+	return it.hash
+}
+
+// Release releases the resources held by the merged iterators.
+func (it *binaryIterator) Release() {
+	it.a.Release()
+	it.b.Release()
+}
+
+// value returns the data blob the iterator is currently at.
+func (it *binaryIterator) value() []byte {
+	return it.blob
+}
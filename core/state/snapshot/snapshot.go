@@ -0,0 +1,376 @@
+// Copyright 2017 The daxxcoreAuthors
+// This file is part of the daxxcore library.
+//
+// The daxxcore library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The daxxcore library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the daxxcore library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package snapshot implements a flat, hash keyed view of the account and storage
+// tries, allowing state reads with a single database lookup instead of walking
+// the tries.
+//
+// The snapshot of the most recent states is composed of a persistent disk layer,
+// holding the flat state of a single (older) block, and a tree of in-memory diff
+// layers on top, each containing the state changes of one block. Diff layers
+// beyond a retention depth are periodically flattened into the disk layer.
+package snapshot
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/daxxcoin/daxxcore/common"
+	"github.com/daxxcoin/daxxcore/daxxdb"
+	"github.com/daxxcoin/daxxcore/logger"
+	"github.com/daxxcoin/daxxcore/logger/glog"
+	"github.com/daxxcoin/daxxcore/trie"
+)
+
+var (
+	// snapshotRootKey tracks the hash of the state root the persisted disk layer
+	// of the snapshot represents.
+	snapshotRootKey = []byte("SnapshotRoot")
+
+	// snapshotGeneratorKey tracks the progress of the snapshot generation. It's
+	// missing if the snapshot was fully generated.
+	snapshotGeneratorKey = []byte("SnapshotGenerator")
+
+	snapshotAccountPrefix = []byte("snapshot-account-") // snapshotAccountPrefix + account hash -> slim account
+	snapshotStoragePrefix = []byte("snapshot-storage-") // snapshotStoragePrefix + account hash + storage hash -> slot value
+)
+
+var (
+	// ErrSnapshotStale is returned from data accessors if the underlying snapshot
+	// layer had been invalidated due to the chain progressing forward far enough
+	// to not maintain the layer's original state.
+	ErrSnapshotStale = errors.New("snapshot stale")
+
+	// ErrNotCoveredYet is returned from data accessors if the underlying snapshot
+	// is being generated currently and the requested data item is not yet in the
+	// range of accounts covered.
+	ErrNotCoveredYet = errors.New("not covered yet")
+
+	// errSnapshotCycle is returned if a snapshot is attempted to be inserted
+	// that forms a cycle in the snapshot tree.
+	errSnapshotCycle = errors.New("snapshot cycle")
+)
+
+// accountSnapshotKey = snapshotAccountPrefix + hash
+func accountSnapshotKey(hash common.Hash) []byte {
+	return append(append([]byte{}, snapshotAccountPrefix...), hash[:]...)
+}
+
+// storageSnapshotKey = snapshotStoragePrefix + account hash + storage hash
+func storageSnapshotKey(accountHash, storageHash common.Hash) []byte {
+	return append(append(append([]byte{}, snapshotStoragePrefix...), accountHash[:]...), storageHash[:]...)
+}
+
+// storageSnapshotsKey = snapshotStoragePrefix + account hash
+func storageSnapshotsKey(accountHash common.Hash) []byte {
+	return append(append([]byte{}, snapshotStoragePrefix...), accountHash[:]...)
+}
+
+// Snapshot represents the functionality supported by a snapshot storage layer.
+type Snapshot interface {
+	// Root returns the root hash for which this snapshot was made.
+	Root() common.Hash
+
+	// Account directly retrieves the account associated with a particular hash in
+	// the snapshot slim data format.
+	Account(hash common.Hash) (*Account, error)
+
+	// AccountRLP directly retrieves the account RLP associated with a particular
+	// hash in the snapshot slim data format.
+	AccountRLP(hash common.Hash) ([]byte, error)
+
+	// Storage directly retrieves the storage data associated with a particular hash,
+	// within a particular account.
+	Storage(accountHash, storageHash common.Hash) ([]byte, error)
+}
+
+// snapshot is the internal version of the snapshot data layer that supports some
+// additional methods compared to the public API.
+type snapshot interface {
+	Snapshot
+
+	// Parent returns the subsequent layer of a snapshot, or nil if the base was
+	// reached.
+	Parent() snapshot
+
+	// Update creates a new layer on top of the existing snapshot diff tree with
+	// the specified data items.
+	Update(blockRoot common.Hash, destructs map[common.Hash]struct{}, accounts map[common.Hash][]byte, storage map[common.Hash]map[common.Hash][]byte) *diffLayer
+
+	// Stale return whether this layer has become stale (was flattened across) or
+	// if it's still live.
+	Stale() bool
+
+	// iterateAccounts creates an account iterator over the layer and everything
+	// below it, starting at the given account hash.
+	iterateAccounts(seek common.Hash) hashIterator
+
+	// iterateStorage creates a storage iterator over the layer and everything
+	// below it for the given account, starting at the given storage hash.
+	iterateStorage(account common.Hash, seek common.Hash) hashIterator
+}
+
+// Tree is an Daxxcoin state snapshot tree. It consists of one persistent base
+// layer backed by a key-value store, on top of which arbitrarily many in-memory
+// diff layers are topped. The memory diffs can form a tree with branching, but
+// the disk layer is singleton and common to all. If a reorg goes deeper than the
+// disk layer, everything needs to be deleted.
+//
+// The goal of a state snapshot is twofold: to allow direct access to account and
+// storage data to avoid expensive multi-level trie lookups; and to allow sorted,
+// cheap iteration of the account/storage tries for sync aid.
+type Tree struct {
+	diskdb ethdb.Database           // Persistent database to store the snapshot
+	triedb trie.Database            // Trie database to generate the snapshot from
+	cache  int                      // Megabytes permitted to use for read caches
+	layers map[common.Hash]snapshot // Collection of all known layers
+	lock   sync.RWMutex
+}
+
+// New attempts to load an already existing snapshot from a persistent key-value
+// store, ensuring that the head of the snapshot matches the expected one.
+//
+// If the snapshot is missing, corrupted or belongs to a different state, it is
+// wiped and regenerated from the state trie in the background. If async is not
+// set, New blocks until the generation finishes.
+func New(diskdb ethdb.Database, triedb trie.Database, cache int, root common.Hash, async bool) *Tree {
+	snap := &Tree{
+		diskdb: diskdb,
+		triedb: triedb,
+		cache:  cache,
+		layers: make(map[common.Hash]snapshot),
+	}
+	head, err := loadSnapshot(diskdb, triedb, cache, root)
+	if err != nil {
+		glog.V(logger.Warn).Infof("Failed to load snapshot, regenerating: %v", err)
+		head = generateSnapshot(diskdb, triedb, cache, root)
+	}
+	snap.layers[head.root] = head
+
+	if !async {
+		snap.waitGeneration()
+	}
+	return snap
+}
+
+// loadSnapshot opens the persisted disk layer of the snapshot, resuming its
+// generation if it was interrupted.
+func loadSnapshot(diskdb ethdb.Database, triedb trie.Database, cache int, root common.Hash) (*diskLayer, error) {
+	blob, err := diskdb.Get(snapshotRootKey)
+	if err != nil || len(blob) != common.HashLength {
+		return nil, errors.New("missing or corrupted snapshot")
+	}
+	if base := common.BytesToHash(blob); base != root {
+		return nil, fmt.Errorf("head doesn't match snapshot: have %x, want %x", base, root)
+	}
+	dl := newDiskLayer(diskdb, triedb, cache, root)
+	if marker, err := diskdb.Get(snapshotGeneratorKey); err == nil {
+		glog.V(logger.Info).Infof("Resuming snapshot generation at %x", marker)
+		dl.startGeneration(marker)
+	}
+	return dl, nil
+}
+
+// waitGeneration blocks until the disk layer finishes or aborts generating.
+func (t *Tree) waitGeneration() {
+	t.lock.RLock()
+	dl := t.disklayer()
+	t.lock.RUnlock()
+
+	if dl != nil && dl.genDone != nil {
+		<-dl.genDone
+	}
+}
+
+// Snapshot retrieves a snapshot belonging to the given block root, or nil if no
+// snapshot is maintained for that block.
+func (t *Tree) Snapshot(blockRoot common.Hash) Snapshot {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	if layer := t.layers[blockRoot]; layer != nil {
+		return layer
+	}
+	return nil
+}
+
+// Update adds a new snapshot into the tree, if that can be linked to an existing
+// old parent. It is disallowed to insert a disk layer (the origin of all).
+//
+// The maps are taken over by the snapshot and must not be modified afterwards.
+func (t *Tree) Update(blockRoot common.Hash, parentRoot common.Hash, destructs map[common.Hash]struct{}, accounts map[common.Hash][]byte, storage map[common.Hash]map[common.Hash][]byte) error {
+	// Reject noop updates to avoid self-loops in the snapshot tree. This is a
+	// special case that can only happen for Clique networks where empty blocks
+	// don't modify the state (0 block subsidy).
+	if blockRoot == parentRoot {
+		return errSnapshotCycle
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	// Generate a new snapshot on top of the parent, unless already known
+	if _, ok := t.layers[blockRoot]; ok {
+		return nil
+	}
+	parent := t.layers[parentRoot]
+	if parent == nil {
+		return fmt.Errorf("parent [%x] snapshot missing", parentRoot[:4])
+	}
+	t.layers[blockRoot] = parent.Update(blockRoot, destructs, accounts, storage)
+	return nil
+}
+
+// Cap traverses downwards the snapshot tree from a head block hash until the
+// number of allowed layers are crossed. All layers beyond the permitted number
+// are flattened downwards into the disk layer. A layer count of zero flattens
+// everything up to and including the given root into the disk.
+//
+// Any layer not descending from the resulting disk layer is discarded.
+func (t *Tree) Cap(root common.Hash, layers int) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	snap := t.layers[root]
+	if snap == nil {
+		return fmt.Errorf("snapshot [%x] missing", root[:4])
+	}
+	// Collect the diff layers from the head down to the disk layer
+	var chain []*diffLayer
+	for layer := snap; layer != nil; layer = layer.Parent() {
+		diff, ok := layer.(*diffLayer)
+		if !ok {
+			break
+		}
+		chain = append(chain, diff)
+	}
+	if len(chain) <= layers {
+		return nil
+	}
+	// Flatten everything beyond the retained layers into the disk, oldest first
+	base := chain[len(chain)-1].Parent().(*diskLayer)
+	marker, generating := base.stopGeneration()
+
+	for i := len(chain) - 1; i >= layers; i-- {
+		next, err := base.flatten(chain[i], marker)
+		if err != nil {
+			return err
+		}
+		base = next
+	}
+	if generating {
+		base.startGeneration(marker)
+	}
+	if layers > 0 {
+		chain[layers-1].setParent(base)
+	}
+	// Drop all the layers not descending from the new disk layer
+	remains := map[common.Hash]snapshot{base.root: base}
+	for hash, layer := range t.layers {
+		if descendsFrom(layer, base) {
+			remains[hash] = layer
+		} else if diff, ok := layer.(*diffLayer); ok {
+			diff.markStale()
+		}
+	}
+	t.layers = remains
+	return nil
+}
+
+// descendsFrom returns whether the given layer has the base among its ancestors.
+func descendsFrom(layer snapshot, base *diskLayer) bool {
+	for ; layer != nil; layer = layer.Parent() {
+		if layer == snapshot(base) {
+			return true
+		}
+		if layer.Stale() {
+			return false
+		}
+	}
+	return false
+}
+
+// Rebuild wipes all available snapshot data from the persistent database and
+// discards all caches and diff layers. Afterwards, it starts a new snapshot
+// generator with the given root hash.
+func (t *Tree) Rebuild(root common.Hash) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	for _, layer := range t.layers {
+		switch layer := layer.(type) {
+		case *diskLayer:
+			layer.stopGeneration()
+			layer.markStale()
+		case *diffLayer:
+			layer.markStale()
+		}
+	}
+	glog.V(logger.Info).Infof("Rebuilding state snapshot at %x", root[:4])
+	t.layers = map[common.Hash]snapshot{
+		root: generateSnapshot(t.diskdb, t.triedb, t.cache, root),
+	}
+}
+
+// Close stops any running snapshot generation, persisting its progress so it can
+// be resumed after a restart.
+func (t *Tree) Close() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if dl := t.disklayer(); dl != nil {
+		dl.stopGeneration()
+	}
+}
+
+// AccountIterator creates a new account iterator for the specified root hash and
+// seeks to a starting account hash.
+func (t *Tree) AccountIterator(root common.Hash, seek common.Hash) (AccountIterator, error) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	snap := t.layers[root]
+	if snap == nil {
+		return nil, fmt.Errorf("unknown snapshot: %x", root)
+	}
+	return accountIterator{snap.iterateAccounts(seek)}, nil
+}
+
+// StorageIterator creates a new storage iterator for the specified root hash and
+// account. The iterator will be moved to the specific start position.
+func (t *Tree) StorageIterator(root common.Hash, account common.Hash, seek common.Hash) (StorageIterator, error) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	snap := t.layers[root]
+	if snap == nil {
+		return nil, fmt.Errorf("unknown snapshot: %x", root)
+	}
+	return storageIterator{snap.iterateStorage(account, seek)}, nil
+}
+
+// disklayer is an internal helper function to return the disk layer.
+// The lock of snapTree is assumed to be held already.
+func (t *Tree) disklayer() *diskLayer {
+	for _, layer := range t.layers {
+		for ; layer != nil; layer = layer.Parent() {
+			if dl, ok := layer.(*diskLayer); ok {
+				return dl
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,256 @@
+// Copyright 2017 The daxxcoreAuthors
+// This file is part of the daxxcore library.
+//
+// The daxxcore library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The daxxcore library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the daxxcore library. If not, see <http://www.gnu.org/licenses/>.
+
+package snapshot
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/daxxcoin/daxxcore/common"
+	"github.com/daxxcoin/daxxcore/crypto"
+	"github.com/daxxcoin/daxxcore/daxxdb"
+	"github.com/daxxcoin/daxxcore/rlp"
+	"github.com/daxxcoin/daxxcore/trie"
+)
+
+// makeTestState creates a state trie with the given number of accounts, every
+// second one of them having a few storage slots, returning its root.
+func makeTestState(t *testing.T, db ethdb.Database, accounts int) common.Hash {
+	accTrie, _ := trie.NewSecure(common.Hash{}, db, 0)
+	for i := 0; i < accounts; i++ {
+		root := emptyRoot
+		if i%2 == 0 {
+			storeTrie, _ := trie.NewSecure(common.Hash{}, db, 0)
+			for j := 1; j <= 3; j++ {
+				value, _ := rlp.EncodeToBytes([]byte{byte(i), byte(j)})
+				storeTrie.Update(common.Hash{byte(j)}.Bytes(), value)
+			}
+			var err error
+			if root, err = storeTrie.Commit(); err != nil {
+				t.Fatalf("failed to commit storage trie: %v", err)
+			}
+		}
+		blob, _ := rlp.EncodeToBytes([]interface{}{uint64(i), big.NewInt(int64(i)), root, emptyCode[:]})
+		accTrie.Update(common.BytesToAddress([]byte{byte(i)}).Bytes(), blob)
+	}
+	root, err := accTrie.Commit()
+	if err != nil {
+		t.Fatalf("failed to commit account trie: %v", err)
+	}
+	return root
+}
+
+// Tests that a snapshot generated from a state trie contains all the accounts
+// and storage slots of the trie.
+func TestGenerateSnapshot(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	root := makeTestState(t, db, 32)
+
+	snaps := New(db, db, 1, root, false)
+	snap := snaps.Snapshot(root)
+	if snap == nil {
+		t.Fatalf("snapshot missing for root %x", root)
+	}
+	for i := 0; i < 32; i++ {
+		hash := crypto.Keccak256Hash(common.BytesToAddress([]byte{byte(i)}).Bytes())
+
+		acc, err := snap.Account(hash)
+		if err != nil {
+			t.Fatalf("account %d: failed to retrieve: %v", i, err)
+		}
+		if acc == nil || acc.Nonce != uint64(i) || acc.Balance.Int64() != int64(i) {
+			t.Fatalf("account %d: mismatch: %+v", i, acc)
+		}
+		if i%2 != 0 {
+			continue
+		}
+		for j := 1; j <= 3; j++ {
+			want, _ := rlp.EncodeToBytes([]byte{byte(i), byte(j)})
+			have, err := snap.Storage(hash, crypto.Keccak256Hash(common.Hash{byte(j)}.Bytes()))
+			if err != nil || !bytes.Equal(have, want) {
+				t.Fatalf("account %d slot %d: mismatch: have %x, want %x (err %v)", i, j, have, want, err)
+			}
+		}
+	}
+	if _, err := db.Get(snapshotGeneratorKey); err == nil {
+		t.Errorf("generator marker not deleted after generation")
+	}
+	// Reopening the snapshot should load it without regeneration
+	snaps = New(db, db, 1, root, true)
+	if dl := snaps.disklayer(); dl.genMarker != nil {
+		t.Errorf("snapshot regenerated on reopen, marker %x", dl.genMarker)
+	}
+}
+
+// Tests that diff layers shadow their parents, and that destructed accounts hide
+// the storage of the layers below.
+func TestDiffLayerReads(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	root := makeTestState(t, db, 4)
+	snaps := New(db, db, 1, root, false)
+
+	var (
+		acc0  = crypto.Keccak256Hash(common.BytesToAddress([]byte{0}).Bytes())
+		acc1  = crypto.Keccak256Hash(common.BytesToAddress([]byte{1}).Bytes())
+		slot1 = crypto.Keccak256Hash(common.Hash{1}.Bytes())
+		root1 = common.Hash{0x01}
+		root2 = common.Hash{0x02}
+	)
+	accounts := map[common.Hash][]byte{acc1: SlimAccountRLP(100, big.NewInt(100), emptyRoot, emptyCode[:])}
+	if err := snaps.Update(root1, root, nil, accounts, nil); err != nil {
+		t.Fatalf("failed to update snapshot: %v", err)
+	}
+	destructs := map[common.Hash]struct{}{acc0: {}}
+	if err := snaps.Update(root2, root1, destructs, nil, nil); err != nil {
+		t.Fatalf("failed to update snapshot: %v", err)
+	}
+	if err := snaps.Update(common.Hash{0x03}, common.Hash{0xff}, nil, nil, nil); err == nil {
+		t.Errorf("update on missing parent succeeded")
+	}
+	head := snaps.Snapshot(root2)
+	if acc, _ := head.Account(acc1); acc == nil || acc.Nonce != 100 {
+		t.Errorf("diff account not shadowing parent: %+v", acc)
+	}
+	if acc, _ := head.Account(acc0); acc != nil {
+		t.Errorf("destructed account still present: %+v", acc)
+	}
+	if blob, _ := head.Storage(acc0, slot1); blob != nil {
+		t.Errorf("destructed storage still present: %x", blob)
+	}
+	if blob, _ := snaps.Snapshot(root1).Storage(acc0, slot1); blob == nil {
+		t.Errorf("storage missing below the destructing layer")
+	}
+}
+
+// Tests that capping the snapshot tree flattens the old layers into the disk
+// layer and marks the flattened and orphaned layers stale.
+func TestCapFlattens(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	root := makeTestState(t, db, 4)
+	snaps := New(db, db, 1, root, false)
+
+	acc1 := crypto.Keccak256Hash(common.BytesToAddress([]byte{1}).Bytes())
+	parent := root
+	for i := byte(1); i <= 3; i++ {
+		accounts := map[common.Hash][]byte{acc1: SlimAccountRLP(uint64(i), big.NewInt(0), emptyRoot, emptyCode[:])}
+		if err := snaps.Update(common.Hash{i}, parent, nil, accounts, nil); err != nil {
+			t.Fatalf("failed to update snapshot %d: %v", i, err)
+		}
+		parent = common.Hash{i}
+	}
+	// Create a side branch off the first layer, it should get dropped
+	snaps.Update(common.Hash{0xaa}, common.Hash{1}, nil, nil, nil)
+	side := snaps.Snapshot(common.Hash{0xaa})
+	first := snaps.Snapshot(common.Hash{1})
+
+	if err := snaps.Cap(common.Hash{3}, 1); err != nil {
+		t.Fatalf("failed to cap snapshot: %v", err)
+	}
+	if n := len(snaps.layers); n != 2 {
+		t.Errorf("layer count mismatch: have %d, want 2", n)
+	}
+	if dl := snaps.disklayer(); dl.root != (common.Hash{2}) {
+		t.Errorf("disk layer root mismatch: have %x, want %x", dl.root, common.Hash{2})
+	}
+	if _, err := first.Account(acc1); err != ErrSnapshotStale {
+		t.Errorf("flattened layer not stale: %v", err)
+	}
+	if _, err := side.Account(acc1); err != ErrSnapshotStale {
+		t.Errorf("orphaned layer not stale: %v", err)
+	}
+	if blob, _ := db.Get(snapshotRootKey); !bytes.Equal(blob, common.Hash{2}.Bytes()) {
+		t.Errorf("persisted root mismatch: have %x", blob)
+	}
+	if acc, _ := snaps.Snapshot(common.Hash{3}).Account(acc1); acc == nil || acc.Nonce != 3 {
+		t.Errorf("head account mismatch: %+v", acc)
+	}
+	if acc, _ := snaps.Snapshot(common.Hash{2}).Account(acc1); acc == nil || acc.Nonce != 2 {
+		t.Errorf("disk account mismatch: %+v", acc)
+	}
+}
+
+// Tests that account iteration merges the diff layers with the disk layer in
+// order, skipping deleted accounts.
+func TestAccountIteratorMerge(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	root := makeTestState(t, db, 8)
+	snaps := New(db, db, 1, root, false)
+
+	var (
+		deleted = crypto.Keccak256Hash(common.BytesToAddress([]byte{3}).Bytes())
+		added   = common.Hash{0xff}
+	)
+	destructs := map[common.Hash]struct{}{deleted: {}}
+	accounts := map[common.Hash][]byte{added: SlimAccountRLP(1, big.NewInt(1), emptyRoot, emptyCode[:])}
+	snaps.Update(common.Hash{1}, root, destructs, accounts, nil)
+
+	it, err := snaps.AccountIterator(common.Hash{1}, common.Hash{})
+	if err != nil {
+		t.Fatalf("failed to create iterator: %v", err)
+	}
+	defer it.Release()
+
+	var (
+		count int
+		last  common.Hash
+	)
+	for it.Next() {
+		hash := it.Hash()
+		if count > 0 && bytes.Compare(last[:], hash[:]) >= 0 {
+			t.Errorf("iteration out of order: %x after %x", hash, last)
+		}
+		if hash == deleted {
+			t.Errorf("deleted account iterated")
+		}
+		if len(it.Account()) == 0 {
+			t.Errorf("empty account %x iterated", hash)
+		}
+		last = hash
+		count++
+	}
+	if err := it.Error(); err != nil {
+		t.Fatalf("iteration failed: %v", err)
+	}
+	if count != 8 {
+		t.Errorf("account count mismatch: have %d, want 8", count)
+	}
+	if last != added {
+		t.Errorf("last account mismatch: have %x, want %x", last, added)
+	}
+}
+
+// Tests that verifying a snapshot against its state root succeeds for a freshly
+// generated snapshot and detects tampering with the flat state.
+func TestVerifySnapshot(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	root := makeTestState(t, db, 32)
+
+	snaps := New(db, db, 1, root, false)
+	if err := snaps.Verify(root); err != nil {
+		t.Fatalf("failed to verify generated snapshot: %v", err)
+	}
+	// Modify a storage slot and ensure verification fails
+	var (
+		account = crypto.Keccak256Hash(common.BytesToAddress([]byte{2}).Bytes())
+		slot    = crypto.Keccak256Hash(common.Hash{1}.Bytes())
+	)
+	db.Put(storageSnapshotKey(account, slot), []byte{0x82, 0xff, 0xff})
+	if err := New(db, db, 1, root, false).Verify(root); err == nil {
+		t.Fatalf("tampered snapshot verified")
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright 2017 The daxxcoreAuthors
+// This file is part of the daxxcore library.
+//
+// The daxxcore library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The daxxcore library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the daxxcore library. If not, see <http://www.gnu.org/licenses/>.
+
+package snapshot
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/daxxcoin/daxxcore/common"
+	"github.com/daxxcoin/daxxcore/logger"
+	"github.com/daxxcoin/daxxcore/logger/glog"
+	"github.com/daxxcoin/daxxcore/rlp"
+	"github.com/daxxcoin/daxxcore/trie"
+)
+
+// Verify rebuilds the account and storage tries of the snapshot with the given
+// root from its flat state and checks that they hash to the roots claimed by the
+// snapshot. As the flat entries are iterated in hash order, the tries are built
+// with stack tries, never holding more than a single path in memory.
+func (t *Tree) Verify(root common.Hash) error {
+	it, err := t.AccountIterator(root, common.Hash{})
+	if err != nil {
+		return err
+	}
+	defer it.Release()
+
+	var (
+		accTrie  = trie.NewStackTrie(nil)
+		accounts int
+		slots    int
+		start    = time.Now()
+	)
+	for it.Next() {
+		account, err := FullAccount(it.Account())
+		if err != nil {
+			return fmt.Errorf("invalid account %x: %v", it.Hash(), err)
+		}
+		// Rebuild the storage trie of the account and check its root
+		if !bytes.Equal(account.Root, emptyRoot[:]) {
+			n, storageRoot, err := t.storageRoot(root, it.Hash())
+			if err != nil {
+				return err
+			}
+			if !bytes.Equal(account.Root, storageRoot[:]) {
+				return fmt.Errorf("account %x: storage root mismatch: have %x, want %x", it.Hash(), storageRoot, account.Root)
+			}
+			slots += n
+		}
+		blob, err := rlp.EncodeToBytes(account)
+		if err != nil {
+			return err
+		}
+		hash := it.Hash()
+		if err := accTrie.TryUpdate(hash[:], blob); err != nil {
+			return err
+		}
+		accounts++
+	}
+	if err := it.Error(); err != nil {
+		return err
+	}
+	if have := accTrie.Hash(); have != root {
+		return fmt.Errorf("state root mismatch: have %x, want %x", have, root)
+	}
+	glog.V(logger.Info).Infof("Verified state snapshot %x: %d accounts, %d slots, elapsed %v", root[:4], accounts, slots, time.Since(start))
+	return nil
+}
+
+// storageRoot rebuilds the storage trie of an account from the flat slots of the
+// snapshot with the given root, returning the number of slots and the trie root.
+func (t *Tree) storageRoot(root common.Hash, account common.Hash) (int, common.Hash, error) {
+	it, err := t.StorageIterator(root, account, common.Hash{})
+	if err != nil {
+		return 0, common.Hash{}, err
+	}
+	defer it.Release()
+
+	var (
+		storeTrie = trie.NewStackTrie(nil)
+		slots     int
+	)
+	for it.Next() {
+		hash := it.Hash()
+		if err := storeTrie.TryUpdate(hash[:], it.Slot()); err != nil {
+			return 0, common.Hash{}, fmt.Errorf("account %x slot %x: %v", account, hash, err)
+		}
+		slots++
+	}
+	if err := it.Error(); err != nil {
+		return 0, common.Hash{}, err
+	}
+	return slots, storeTrie.Hash(), nil
+}
@@ -64,9 +64,10 @@ func (self Storage) Copy() Storage {
 // Account values can be accessed and modified through the object.
 // Finally, call CommitTrie to write the modified storage trie into a database.
 type StateObject struct {
-	address common.Address // Daxxcoin address of this account
-	data    Account
-	db      *StateDB
+	address  common.Address // Daxxcoin address of this account
+	addrHash common.Hash    // hash of daxxcoin address of the account
+	data     Account
+	db       *StateDB
 
 	// DB error.
 	// State objects are used by the consensus core and VM which are
@@ -79,8 +80,9 @@ type StateObject struct {
 	trie *trie.SecureTrie // storage trie, which becomes non-nil on first access
 	code Code             // contract bytecode, which gets set when code is loaded
 
-	cachedStorage Storage // Storage entry cache to avoid duplicate reads
-	dirtyStorage  Storage // Storage entries that need to be flushed to disk
+	cachedStorage  Storage // Storage entry cache to avoid duplicate reads
+	dirtyStorage   Storage // Storage entries that need to be flushed to disk
+	pendingStorage Storage // Storage entries flushed into the trie since the last commit, tracked for the snapshot
 
 	// Cache flags.
 	// When an object is marked suicided it will be delete from the trie
@@ -114,7 +116,16 @@ func newObject(db *StateDB, address common.Address, data Account, onDirty func(a
 	if data.CodeHash == nil {
 		data.CodeHash = emptyCodeHash
 	}
-	return &StateObject{db: db, address: address, data: data, cachedStorage: make(Storage), dirtyStorage: make(Storage), onDirty: onDirty}
+	return &StateObject{
+		db:             db,
+		address:        address,
+		addrHash:       crypto.Keccak256Hash(address[:]),
+		data:           data,
+		cachedStorage:  make(Storage),
+		dirtyStorage:   make(Storage),
+		pendingStorage: make(Storage),
+		onDirty:        onDirty,
+	}
 }
 
 // EncodeRLP implements rlp.Encoder.
@@ -170,8 +181,25 @@ func (self *StateObject) GetState(db trie.Database, key common.Hash) common.Hash
 	if exists {
 		return value
 	}
-	// Load from DB in case it is missing.
-	if enc := self.getTrie(db).Get(key[:]); len(enc) > 0 {
+	if value, exists := self.pendingStorage[key]; exists {
+		return value
+	}
+	// Load from the flat snapshot if available, or the DB in case it is missing.
+	var (
+		enc    []byte
+		cached bool
+	)
+	if snap := self.db.snap; snap != nil {
+		if _, destructed := self.db.snapDestructs[self.addrHash]; destructed {
+			return common.Hash{}
+		}
+		blob, err := snap.Storage(self.addrHash, crypto.Keccak256Hash(key[:]))
+		enc, cached = blob, err == nil
+	}
+	if !cached {
+		enc = self.getTrie(db).Get(key[:])
+	}
+	if len(enc) > 0 {
 		_, content, _, err := rlp.Split(enc)
 		if err != nil {
 			self.setError(err)
@@ -209,6 +237,9 @@ func (self *StateObject) updateTrie(db trie.Database) {
 	tr := self.getTrie(db)
 	for key, value := range self.dirtyStorage {
 		delete(self.dirtyStorage, key)
+		if self.db.snap != nil {
+			self.pendingStorage[key] = value
+		}
 		if (value == common.Hash{}) {
 			tr.Delete(key[:])
 			continue
@@ -296,6 +327,7 @@ func (self *StateObject) deepCopy(db *StateDB, onDirty func(addr common.Address)
 	stateObject.code = self.code
 	stateObject.dirtyStorage = self.dirtyStorage.Copy()
 	stateObject.cachedStorage = self.dirtyStorage.Copy()
+	stateObject.pendingStorage = self.pendingStorage.Copy()
 	stateObject.suicided = self.suicided
 	stateObject.dirtyCode = self.dirtyCode
 	stateObject.deleted = self.deleted
@@ -328,7 +360,7 @@ func (self *StateObject) Code(db trie.Database) []byte {
 }
 
 func (self *StateObject) SetCode(codeHash common.Hash, code []byte) {
-	prevcode := self.Code(self.db.db)
+	prevcode := self.Code(self.db.trieDB())
 	self.db.journal = append(self.db.journal, codeChange{
 		account:  &self.address,
 		prevhash: self.CodeHash(),
@@ -388,7 +420,7 @@ func (self *StateObject) ForEachStorage(cb func(key, value common.Hash) bool) {
 		cb(h, value)
 	}
 
-	it := self.getTrie(self.db.db).Iterator()
+	it := self.getTrie(self.db.trieDB()).Iterator()
 	for it.Next() {
 		// ignore cached values
 		key := common.BytesToHash(self.trie.GetKey(it.Key))
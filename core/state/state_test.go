@@ -18,7 +18,9 @@ package state
 
 import (
 	"bytes"
+	"encoding/json"
 	"math/big"
+	"strings"
 	"testing"
 
 	checker "gopkg.in/check.v1"
@@ -86,6 +88,82 @@ func (s *StateSuite) TestDump(c *checker.C) {
 	}
 }
 
+// Tests that a streamed dump can be continued from where a limited one stopped,
+// and that the filtering options are honoured.
+func TestIterativeDump(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	state, _ := New(common.Hash{}, db)
+
+	for i := byte(1); i <= 10; i++ {
+		addr := toAddr([]byte{i})
+		state.AddBalance(addr, big.NewInt(int64(i)))
+		if i%2 == 0 {
+			state.SetCode(addr, []byte{i})
+			state.SetState(addr, common.Hash{i}, common.Hash{i})
+		}
+	}
+	root, _ := state.Commit(false)
+	state, _ = New(root, db)
+
+	// dump parses the lines of a dump, returning the accounts and the next key
+	dump := func(conf DumpConfig) ([]DumpLine, *common.Hash) {
+		var buf bytes.Buffer
+		next, err := state.IterativeDump(conf, &buf)
+		if err != nil {
+			t.Fatalf("failed to dump state: %v", err)
+		}
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		if next != nil {
+			lines = lines[:len(lines)-1]
+		}
+		var accounts []DumpLine
+		for _, line := range lines[1:] {
+			var account DumpLine
+			if err := json.Unmarshal([]byte(line), &account); err != nil {
+				t.Fatalf("failed to decode dump line %q: %v", line, err)
+			}
+			accounts = append(accounts, account)
+		}
+		return accounts, next
+	}
+	// Dump the state in pages and ensure every account is visited once
+	seen := make(map[common.Address]bool)
+	for conf := (DumpConfig{Max: 3}); ; {
+		accounts, next := dump(conf)
+		if len(accounts) > 3 {
+			t.Fatalf("limit exceeded: have %d accounts, want at most 3", len(accounts))
+		}
+		for _, account := range accounts {
+			if account.Address == nil || seen[*account.Address] {
+				t.Fatalf("invalid or duplicate account: %v", account.Address)
+			}
+			seen[*account.Address] = true
+			if i := account.Address[common.AddressLength-1]; i%2 == 0 {
+				if len(account.Code) != 1 || account.Storage[common.Hash{i}] != (common.Hash{i}) {
+					t.Errorf("account %x: code or storage missing: %x, %v", *account.Address, account.Code, account.Storage)
+				}
+			}
+		}
+		if next == nil {
+			break
+		}
+		conf.Start = next[:]
+	}
+	if len(seen) != 10 {
+		t.Errorf("dumped account count mismatch: have %d, want %d", len(seen), 10)
+	}
+	// Ensure the filters drop the requested accounts and fields
+	accounts, _ := dump(DumpConfig{OnlyWithStorage: true, SkipCode: true, SkipStorage: true})
+	if len(accounts) != 5 {
+		t.Errorf("accounts with storage mismatch: have %d, want %d", len(accounts), 5)
+	}
+	for _, account := range accounts {
+		if len(account.Code) > 0 || len(account.Storage) > 0 {
+			t.Errorf("account %x: skipped fields present", *account.Address)
+		}
+	}
+}
+
 func (s *StateSuite) SetUpTest(c *checker.C) {
 	db, _ := ethdb.NewMemDatabase()
 	s.state, _ = New(common.Hash{}, db)
@@ -18,12 +18,14 @@
 package state
 
 import (
+	"bytes"
 	"fmt"
 	"math/big"
 	"sort"
 	"sync"
 
 	"github.com/daxxcoin/daxxcore/common"
+	"github.com/daxxcoin/daxxcore/core/state/snapshot"
 	"github.com/daxxcoin/daxxcore/core/types"
 	"github.com/daxxcoin/daxxcore/core/vm"
 	"github.com/daxxcoin/daxxcore/crypto"
@@ -38,6 +40,14 @@ import (
 // Trie cache generation limit after which to evic trie nodes from memory.
 var MaxTrieCacheGen = uint16(120)
 
+var (
+	// emptyRoot is the known root hash of an empty trie.
+	emptyRoot = common.HexToHash("56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421")
+
+	// emptyCode is the known hash of the empty EVM bytecode.
+	emptyCode = crypto.Keccak256Hash(nil)
+)
+
 const (
 	// Number of past tries to keep. This value is chosen such that
 	// reasonable chain reorg depths will hit an existing trie.
@@ -59,10 +69,18 @@ type revision struct {
 // * Accounts
 type StateDB struct {
 	db            ethdb.Database
+	triedb        *trie.NodeDatabase // Optional memory cache for committed trie nodes
 	trie          *trie.SecureTrie
 	pastTries     []*trie.SecureTrie
 	codeSizeCache *lru.Cache
 
+	// Flat state snapshot to read from, and the changes to feed back into it
+	snaps         *snapshot.Tree
+	snap          snapshot.Snapshot
+	snapDestructs map[common.Hash]struct{}
+	snapAccounts  map[common.Hash][]byte
+	snapStorage   map[common.Hash]map[common.Hash][]byte
+
 	// This map holds 'live' objects, which will get modified while processing a state transition.
 	stateObjects      map[common.Address]*StateObject
 	stateObjectsDirty map[common.Address]struct{}
@@ -88,21 +106,45 @@ type StateDB struct {
 
 // Create a new state from a given trie
 func New(root common.Hash, db ethdb.Database) (*StateDB, error) {
-	tr, err := trie.NewSecure(root, db, MaxTrieCacheGen)
+	return NewWithNodeCache(root, db, nil)
+}
+
+// NewWithNodeCache creates a new state from a given trie, pooling all committed
+// trie nodes and contract code in the reference counted memory cache triedb
+// instead of writing them to disk. It's up to the caller to reference, flush and
+// dereference the committed state roots. A nil cache is equivalent to New.
+func NewWithNodeCache(root common.Hash, db ethdb.Database, triedb *trie.NodeDatabase) (*StateDB, error) {
+	return NewWithSnapshots(root, db, triedb, nil)
+}
+
+// NewWithSnapshots creates a new state from a given trie, serving account and
+// storage reads from the flat state snapshot tree if it covers the root. All
+// committed changes are pushed into the snapshot tree as a new diff layer. A nil
+// tree is equivalent to NewWithNodeCache.
+func NewWithSnapshots(root common.Hash, db ethdb.Database, triedb *trie.NodeDatabase, snaps *snapshot.Tree) (*StateDB, error) {
+	var tdb trie.Database = db
+	if triedb != nil {
+		tdb = triedb
+	}
+	tr, err := trie.NewSecure(root, tdb, MaxTrieCacheGen)
 	if err != nil {
 		return nil, err
 	}
 	csc, _ := lru.New(codeSizeCacheSize)
-	return &StateDB{
+	state := &StateDB{
 		db:                db,
+		triedb:            triedb,
 		trie:              tr,
 		codeSizeCache:     csc,
+		snaps:             snaps,
 		stateObjects:      make(map[common.Address]*StateObject),
 		stateObjectsDirty: make(map[common.Address]struct{}),
 		refund:            new(big.Int),
 		logs:              make(map[common.Hash][]*types.Log),
 		preimages:         make(map[common.Hash][]byte),
-	}, nil
+	}
+	state.openSnapshot(root)
+	return state, nil
 }
 
 // New creates a new statedb by reusing any journalled tries to avoid costly
@@ -115,16 +157,20 @@ func (self *StateDB) New(root common.Hash) (*StateDB, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &StateDB{
+	state := &StateDB{
 		db:                self.db,
+		triedb:            self.triedb,
 		trie:              tr,
 		codeSizeCache:     self.codeSizeCache,
+		snaps:             self.snaps,
 		stateObjects:      make(map[common.Address]*StateObject),
 		stateObjectsDirty: make(map[common.Address]struct{}),
 		refund:            new(big.Int),
 		logs:              make(map[common.Hash][]*types.Log),
 		preimages:         make(map[common.Hash][]byte),
-	}, nil
+	}
+	state.openSnapshot(root)
+	return state, nil
 }
 
 // Reset clears out all emphemeral state objects from the state db, but keeps
@@ -146,11 +192,27 @@ func (self *StateDB) Reset(root common.Hash) error {
 	self.logs = make(map[common.Hash][]*types.Log)
 	self.logSize = 0
 	self.preimages = make(map[common.Hash][]byte)
+	self.openSnapshot(root)
 	self.clearJournalAndRefund()
 
 	return nil
 }
 
+// openSnapshot looks up the flat state snapshot belonging to the given root and
+// resets the changes tracked for it. Without a snapshot for the root, all reads
+// are served from the tries and no changes are tracked.
+func (self *StateDB) openSnapshot(root common.Hash) {
+	self.snap, self.snapDestructs, self.snapAccounts, self.snapStorage = nil, nil, nil, nil
+	if self.snaps == nil {
+		return
+	}
+	if self.snap = self.snaps.Snapshot(root); self.snap != nil {
+		self.snapDestructs = make(map[common.Hash]struct{})
+		self.snapAccounts = make(map[common.Hash][]byte)
+		self.snapStorage = make(map[common.Hash]map[common.Hash][]byte)
+	}
+}
+
 // openTrie creates a trie. It uses an existing trie if one is available
 // from the journal if available.
 func (self *StateDB) openTrie(root common.Hash) (*trie.SecureTrie, error) {
@@ -160,7 +222,16 @@ func (self *StateDB) openTrie(root common.Hash) (*trie.SecureTrie, error) {
 			return &tr, nil
 		}
 	}
-	return trie.NewSecure(root, self.db, MaxTrieCacheGen)
+	return trie.NewSecure(root, self.trieDB(), MaxTrieCacheGen)
+}
+
+// trieDB returns the database tries are loaded from and committed into, being
+// the node cache if the state has one, or the backing database otherwise.
+func (self *StateDB) trieDB() trie.Database {
+	if self.triedb != nil {
+		return self.triedb
+	}
+	return self.db
 }
 
 func (self *StateDB) pushTrie(t *trie.SecureTrie) {
@@ -262,7 +333,7 @@ func (self *StateDB) GetNonce(addr common.Address) uint64 {
 func (self *StateDB) GetCode(addr common.Address) []byte {
 	stateObject := self.GetStateObject(addr)
 	if stateObject != nil {
-		code := stateObject.Code(self.db)
+		code := stateObject.Code(self.trieDB())
 		key := common.BytesToHash(stateObject.CodeHash())
 		self.codeSizeCache.Add(key, len(code))
 		return code
@@ -279,7 +350,7 @@ func (self *StateDB) GetCodeSize(addr common.Address) int {
 	if cached, ok := self.codeSizeCache.Get(key); ok {
 		return cached.(int)
 	}
-	size := len(stateObject.Code(self.db))
+	size := len(stateObject.Code(self.trieDB()))
 	if stateObject.dbErr == nil {
 		self.codeSizeCache.Add(key, size)
 	}
@@ -297,11 +368,47 @@ func (self *StateDB) GetCodeHash(addr common.Address) common.Hash {
 func (self *StateDB) GetState(a common.Address, b common.Hash) common.Hash {
 	stateObject := self.GetStateObject(a)
 	if stateObject != nil {
-		return stateObject.GetState(self.db, b)
+		return stateObject.GetState(self.trieDB(), b)
 	}
 	return common.Hash{}
 }
 
+// GetProof returns the Merkle proof of the given account in the account trie.
+func (self *StateDB) GetProof(a common.Address) []rlp.RawValue {
+	return self.trie.Prove(a[:])
+}
+
+// GetStorageProof returns the Merkle proof of the given storage slot in the
+// storage trie of the account, or nil if the account does not exist.
+func (self *StateDB) GetStorageProof(a common.Address, key common.Hash) []rlp.RawValue {
+	stateObject := self.GetStateObject(a)
+	if stateObject == nil {
+		return nil
+	}
+	return stateObject.getTrie(self.trieDB()).Prove(key[:])
+}
+
+// StorageTrie returns the storage trie of an account, with all pending storage
+// changes flushed into it, or nil if the account does not exist.
+func (self *StateDB) StorageTrie(a common.Address) *trie.SecureTrie {
+	stateObject := self.GetStateObject(a)
+	if stateObject == nil {
+		return nil
+	}
+	stateObject.updateTrie(self.trieDB())
+	return stateObject.getTrie(self.trieDB())
+}
+
+// GetStorageRoot returns the root hash of the storage trie of the given account,
+// or the empty root if the account does not exist.
+func (self *StateDB) GetStorageRoot(a common.Address) common.Hash {
+	stateObject := self.GetStateObject(a)
+	if stateObject == nil {
+		return emptyRoot
+	}
+	return stateObject.data.Root
+}
+
 func (self *StateDB) HasSuicided(addr common.Address) bool {
 	stateObject := self.GetStateObject(addr)
 	if stateObject != nil {
@@ -354,7 +461,7 @@ func (self *StateDB) SetCode(addr common.Address, code []byte) {
 func (self *StateDB) SetState(addr common.Address, key common.Hash, value common.Hash) {
 	stateObject := self.GetOrNewStateObject(addr)
 	if stateObject != nil {
-		stateObject.SetState(self.db, key, value)
+		stateObject.SetState(self.trieDB(), key, value)
 	}
 }
 
@@ -397,6 +504,10 @@ func (self *StateDB) deleteStateObject(stateObject *StateObject) {
 	stateObject.deleted = true
 	addr := stateObject.Address()
 	self.trie.Delete(addr[:])
+
+	if self.snap != nil {
+		self.snapDestructs[stateObject.addrHash] = struct{}{}
+	}
 }
 
 // Retrieve a state object given my the address. Returns nil if not found.
@@ -409,18 +520,35 @@ func (self *StateDB) GetStateObject(addr common.Address) (stateObject *StateObje
 		return obj
 	}
 
-	// Load the object from the database.
-	enc := self.trie.Get(addr[:])
-	if len(enc) == 0 {
-		return nil
+	// Load the object from the flat snapshot if available, the trie otherwise
+	var data *Account
+	if self.snap != nil {
+		acc, err := self.snap.Account(crypto.Keccak256Hash(addr[:]))
+		if err == nil {
+			if acc == nil {
+				return nil
+			}
+			data = &Account{
+				Nonce:    acc.Nonce,
+				Balance:  acc.Balance,
+				Root:     common.BytesToHash(acc.Root),
+				CodeHash: acc.CodeHash,
+			}
+		}
 	}
-	var data Account
-	if err := rlp.DecodeBytes(enc, &data); err != nil {
-		glog.Errorf("can't decode object at %x: %v", addr[:], err)
-		return nil
+	if data == nil {
+		enc := self.trie.Get(addr[:])
+		if len(enc) == 0 {
+			return nil
+		}
+		data = new(Account)
+		if err := rlp.DecodeBytes(enc, data); err != nil {
+			glog.Errorf("can't decode object at %x: %v", addr[:], err)
+			return nil
+		}
 	}
 	// Insert into the live set.
-	obj := newObject(self, addr, data, self.MarkStateObjectDirty)
+	obj := newObject(self, addr, *data, self.MarkStateObjectDirty)
 	self.setStateObject(obj)
 	return obj
 }
@@ -456,7 +584,13 @@ func (self *StateDB) createObject(addr common.Address) (newobj, prev *StateObjec
 		}
 		self.journal = append(self.journal, createObjectChange{account: &addr})
 	} else {
-		self.journal = append(self.journal, resetObjectChange{prev: prev})
+		// The storage of the replaced account is gone, don't read it from the snapshot
+		var prevdestruct bool
+		if self.snap != nil {
+			_, prevdestruct = self.snapDestructs[prev.addrHash]
+			self.snapDestructs[prev.addrHash] = struct{}{}
+		}
+		self.journal = append(self.journal, resetObjectChange{prev: prev, prevdestruct: prevdestruct})
 	}
 	self.setStateObject(newobj)
 	return newobj, prev
@@ -489,9 +623,12 @@ func (self *StateDB) Copy() *StateDB {
 	// Copy all the basic fields, initialize the memory ones
 	state := &StateDB{
 		db:                self.db,
+		triedb:            self.triedb,
 		trie:              self.trie,
 		pastTries:         self.pastTries,
 		codeSizeCache:     self.codeSizeCache,
+		snaps:             self.snaps,
+		snap:              self.snap,
 		stateObjects:      make(map[common.Address]*StateObject, len(self.stateObjectsDirty)),
 		stateObjectsDirty: make(map[common.Address]struct{}, len(self.stateObjectsDirty)),
 		refund:            new(big.Int).Set(self.refund),
@@ -511,6 +648,14 @@ func (self *StateDB) Copy() *StateDB {
 	for hash, preimage := range self.preimages {
 		state.preimages[hash] = preimage
 	}
+	if self.snap != nil {
+		state.snapDestructs = make(map[common.Hash]struct{}, len(self.snapDestructs))
+		for hash := range self.snapDestructs {
+			state.snapDestructs[hash] = struct{}{}
+		}
+		state.snapAccounts = make(map[common.Hash][]byte)
+		state.snapStorage = make(map[common.Hash]map[common.Hash][]byte)
+	}
 	return state
 }
 
@@ -554,18 +699,25 @@ func (self *StateDB) GetRefund() *big.Int {
 // It is called in between transactions to get the root hash that
 // goes into transaction receipts.
 func (s *StateDB) IntermediateRoot(deleteEmptyObjects bool) common.Hash {
+	s.Finalise(deleteEmptyObjects)
+	return s.trie.Hash()
+}
+
+// Finalise finalises the state by removing the self destructed objects and
+// flushing the dirty objects into the trie, without computing the resulting
+// root hash. It also clears the journal and the refund counter.
+func (s *StateDB) Finalise(deleteEmptyObjects bool) {
 	for addr := range s.stateObjectsDirty {
 		stateObject := s.stateObjects[addr]
 		if stateObject.suicided || (deleteEmptyObjects && stateObject.empty()) {
 			s.deleteStateObject(stateObject)
 		} else {
-			stateObject.updateRoot(s.db)
+			stateObject.updateRoot(s.trieDB())
 			s.updateStateObject(stateObject)
 		}
 	}
 	// Invalidate journal because reverting across transactions is not allowed.
 	s.clearJournalAndRefund()
-	return s.trie.Hash()
 }
 
 // DeleteSuicides flags the suicided objects for deletion so that it
@@ -589,8 +741,12 @@ func (s *StateDB) DeleteSuicides() {
 	}
 }
 
-// Commit commits all state changes to the database.
+// Commit commits all state changes to the database, or to the trie node cache
+// if the state was created with one.
 func (s *StateDB) Commit(deleteEmptyObjects bool) (root common.Hash, err error) {
+	if s.triedb != nil {
+		return s.commit(s.triedb, deleteEmptyObjects)
+	}
 	root, batch := s.CommitBatch(deleteEmptyObjects)
 	return root, batch.Write()
 }
@@ -632,18 +788,65 @@ func (s *StateDB) commit(dbw trie.DatabaseWriter, deleteEmptyObjects bool) (root
 				stateObject.dirtyCode = false
 			}
 			// Write any storage changes in the state object to its storage trie.
-			if err := stateObject.CommitTrie(s.db, dbw); err != nil {
+			if err := stateObject.CommitTrie(s.trieDB(), dbw); err != nil {
 				return common.Hash{}, err
 			}
 			// Update the object in the main account trie.
 			s.updateStateObject(stateObject)
+
+			// Track the final state of the object for the snapshot diff layer
+			if s.snap != nil {
+				s.snapAccounts[stateObject.addrHash] = snapshot.SlimAccountRLP(stateObject.data.Nonce, stateObject.data.Balance, stateObject.data.Root, stateObject.data.CodeHash)
+				if len(stateObject.pendingStorage) > 0 {
+					storage := make(map[common.Hash][]byte, len(stateObject.pendingStorage))
+					for key, value := range stateObject.pendingStorage {
+						if (value == common.Hash{}) {
+							storage[crypto.Keccak256Hash(key[:])] = nil
+							continue
+						}
+						// Encoding []byte cannot fail, ok to ignore the error.
+						storage[crypto.Keccak256Hash(key[:])], _ = rlp.EncodeToBytes(bytes.TrimLeft(value[:], "\x00"))
+					}
+					s.snapStorage[stateObject.addrHash] = storage
+					stateObject.pendingStorage = make(Storage)
+				}
+			}
 		}
 		delete(s.stateObjectsDirty, addr)
 	}
-	// Write trie changes.
-	root, err = s.trie.CommitTo(dbw)
-	if err == nil {
-		s.pushTrie(s.trie)
+	// Write trie changes. If the nodes are pooled in the trie node cache, link the
+	// storage tries and contract code to the account leaves referencing them.
+	var onleaf trie.LeafCallback
+	if cache, ok := dbw.(*trie.NodeDatabase); ok {
+		onleaf = func(leaf []byte, parent common.Hash) error {
+			var account Account
+			if err := rlp.DecodeBytes(leaf, &account); err != nil {
+				return nil
+			}
+			if account.Root != emptyRoot {
+				cache.Reference(account.Root, parent)
+			}
+			if code := common.BytesToHash(account.CodeHash); code != emptyCode {
+				cache.Reference(code, parent)
+			}
+			return nil
+		}
+	}
+	root, err = s.trie.CommitToWithCallback(dbw, onleaf)
+	if err != nil {
+		return root, err
+	}
+	s.pushTrie(s.trie)
+
+	// Feed the changes into the snapshot tree as a new diff layer on top of the
+	// one the state was opened with
+	if s.snap != nil {
+		if parent := s.snap.Root(); parent != root {
+			if err := s.snaps.Update(root, parent, s.snapDestructs, s.snapAccounts, s.snapStorage); err != nil {
+				glog.V(logger.Warn).Infof("Failed to update snapshot tree from %x to %x: %v", parent[:4], root[:4], err)
+			}
+		}
+		s.openSnapshot(root)
 	}
-	return root, err
+	return root, nil
 }
@@ -19,6 +19,7 @@ package state
 import (
 	"bytes"
 	"encoding/binary"
+	"encoding/json"
 	"fmt"
 	"math"
 	"math/big"
@@ -30,7 +31,10 @@ import (
 
 	"github.com/daxxcoin/daxxcore/common"
 	"github.com/daxxcoin/daxxcore/core/types"
+	"github.com/daxxcoin/daxxcore/crypto"
 	"github.com/daxxcoin/daxxcore/daxxdb"
+	"github.com/daxxcoin/daxxcore/rlp"
+	"github.com/daxxcoin/daxxcore/trie"
 )
 
 // Tests that updating a state trie does not leak any database writes prior to
@@ -60,6 +64,177 @@ func TestUpdateLeaks(t *testing.T) {
 	}
 }
 
+// Tests that the account and storage proofs of a committed state verify against
+// its roots, both for existing and missing entries.
+func TestStateProofs(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	state, _ := New(common.Hash{}, db)
+
+	for i := byte(1); i < 64; i++ {
+		addr := common.BytesToAddress([]byte{i})
+		state.AddBalance(addr, big.NewInt(int64(i)))
+		state.SetState(addr, common.BytesToHash([]byte{i}), common.BytesToHash([]byte{i, i}))
+	}
+	root, _ := state.Commit(false)
+	state, _ = New(root, db)
+
+	addr := common.BytesToAddress([]byte{7})
+	enc, err := trie.VerifyProof(root, crypto.Keccak256(addr[:]), state.GetProof(addr))
+	if err != nil {
+		t.Fatalf("account proof failed to verify: %v", err)
+	}
+	var account Account
+	if err := rlp.DecodeBytes(enc, &account); err != nil {
+		t.Fatalf("failed to decode proven account: %v", err)
+	}
+	if account.Balance.Cmp(big.NewInt(7)) != 0 || account.Root != state.GetStorageRoot(addr) {
+		t.Errorf("proven account mismatch: have %+v", account)
+	}
+	key := common.BytesToHash([]byte{7})
+	enc, err = trie.VerifyProof(account.Root, crypto.Keccak256(key[:]), state.GetStorageProof(addr, key))
+	if err != nil {
+		t.Fatalf("storage proof failed to verify: %v", err)
+	}
+	if want, _ := rlp.EncodeToBytes([]byte{7, 7}); !bytes.Equal(enc, want) {
+		t.Errorf("proven storage mismatch: have %x, want %x", enc, want)
+	}
+	// Missing accounts and slots must be provably absent
+	missing := common.BytesToAddress([]byte{0xff})
+	if enc, err := trie.VerifyProof(root, crypto.Keccak256(missing[:]), state.GetProof(missing)); err != nil || enc != nil {
+		t.Errorf("missing account proof mismatch: value %x, err %v", enc, err)
+	}
+	if proof := state.GetStorageProof(missing, key); proof != nil {
+		t.Errorf("storage proof of missing account: have %v, want nil", proof)
+	}
+	key = common.BytesToHash([]byte{0xff})
+	if enc, err := trie.VerifyProof(account.Root, crypto.Keccak256(key[:]), state.GetStorageProof(addr, key)); err != nil || enc != nil {
+		t.Errorf("missing slot proof mismatch: value %x, err %v", enc, err)
+	}
+}
+
+// Tests that paging through the accounts and storage of a state visits every
+// entry exactly once, in hash order, with the preimages resolved.
+func TestStateRanges(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	state, _ := New(common.Hash{}, db)
+
+	contract := common.BytesToAddress([]byte{0xca})
+	for i := byte(0); i < 100; i++ {
+		state.AddBalance(common.BytesToAddress([]byte{i}), big.NewInt(int64(i)+1))
+		state.SetState(contract, common.BytesToHash([]byte{i}), common.BytesToHash([]byte{i + 1}))
+	}
+	root, _ := state.Commit(false)
+	state, _ = New(root, db)
+
+	// Page through all the accounts
+	var (
+		accounts = make(map[common.Address]bool)
+		start    []byte
+		last     common.Hash
+	)
+	for pages := 0; ; pages++ {
+		page, err := state.AccountRange(start, 7)
+		if err != nil {
+			t.Fatalf("page %d: failed to retrieve accounts: %v", pages, err)
+		}
+		if len(page.Accounts) > 7 {
+			t.Fatalf("page %d: too many accounts: have %d, want at most 7", pages, len(page.Accounts))
+		}
+		for hash, account := range page.Accounts {
+			if bytes.Compare(hash[:], last[:]) <= 0 {
+				t.Errorf("page %d: account %x out of order", pages, hash)
+			}
+			if account.Address == nil || crypto.Keccak256Hash(account.Address[:]) != hash {
+				t.Fatalf("page %d: account %x preimage mismatch: %v", pages, hash, account.Address)
+			}
+			accounts[*account.Address] = true
+		}
+		for hash := range page.Accounts {
+			if bytes.Compare(hash[:], last[:]) > 0 {
+				last = hash
+			}
+		}
+		if page.NextKey == nil {
+			break
+		}
+		start = page.NextKey[:]
+	}
+	if len(accounts) != 101 {
+		t.Errorf("account count mismatch: have %d, want %d", len(accounts), 101)
+	}
+	// Page through the contract storage
+	slots := make(map[common.Hash]common.Hash)
+	for start = nil; ; {
+		page, err := state.StorageRange(contract, start, 13)
+		if err != nil {
+			t.Fatalf("failed to retrieve storage: %v", err)
+		}
+		for hash, slot := range page.Storage {
+			if slot.Key == nil || crypto.Keccak256Hash(slot.Key[:]) != hash {
+				t.Fatalf("slot %x preimage mismatch: %v", hash, slot.Key)
+			}
+			slots[*slot.Key] = slot.Value
+		}
+		if page.NextKey == nil {
+			break
+		}
+		start = page.NextKey[:]
+	}
+	if len(slots) != 100 {
+		t.Errorf("slot count mismatch: have %d, want %d", len(slots), 100)
+	}
+	for key, value := range slots {
+		if want := state.GetState(contract, key); value != want {
+			t.Errorf("slot %x: value mismatch: have %x, want %x", key, value, want)
+		}
+	}
+}
+
+// Tests that the account and storage ranges served over RPC, keyed by hashes,
+// survive a JSON round trip.
+func TestStateRangesJSON(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	state, _ := New(common.Hash{}, db)
+
+	contract := common.BytesToAddress([]byte{0xca})
+	for i := byte(0); i < 10; i++ {
+		state.AddBalance(common.BytesToAddress([]byte{i}), big.NewInt(int64(i)+1))
+		state.SetState(contract, common.BytesToHash([]byte{i}), common.BytesToHash([]byte{i + 1}))
+	}
+	root, _ := state.Commit(false)
+	state, _ = New(root, db)
+
+	accounts, err := state.AccountRange(nil, 5)
+	if err != nil {
+		t.Fatalf("failed to retrieve accounts: %v", err)
+	}
+	blob, err := json.Marshal(accounts)
+	if err != nil {
+		t.Fatalf("failed to encode accounts: %v", err)
+	}
+	var decAccounts AccountRange
+	if err := json.Unmarshal(blob, &decAccounts); err != nil {
+		t.Fatalf("failed to decode accounts: %v", err)
+	}
+	if !reflect.DeepEqual(accounts, decAccounts) {
+		t.Errorf("account range mismatch:\nhave %+v\nwant %+v", decAccounts, accounts)
+	}
+	storage, err := state.StorageRange(contract, nil, 5)
+	if err != nil {
+		t.Fatalf("failed to retrieve storage: %v", err)
+	}
+	if blob, err = json.Marshal(storage); err != nil {
+		t.Fatalf("failed to encode storage: %v", err)
+	}
+	var decStorage StorageRange
+	if err := json.Unmarshal(blob, &decStorage); err != nil {
+		t.Fatalf("failed to decode storage: %v", err)
+	}
+	if !reflect.DeepEqual(storage, decStorage) {
+		t.Errorf("storage range mismatch:\nhave %+v\nwant %+v", decStorage, storage)
+	}
+}
+
 // Tests that no intermediate state of an object is stored into the database,
 // only the one right before the commit.
 func TestIntermediateLeaks(t *testing.T) {
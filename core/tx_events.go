@@ -0,0 +1,111 @@
+// Copyright 2017 The daxxcoreAuthors
+// This file is part of the daxxcore library.
+//
+// The daxxcore library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The daxxcore library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the daxxcore library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/daxxcoin/daxxcore/event"
+)
+
+// ErrTxEventsOverflow is returned on the error channel of a transaction event
+// subscription if the subscriber didn't keep up with the events of the pool.
+var ErrTxEventsOverflow = errors.New("transaction event buffer overflow")
+
+// txEventFeed delivers the lifecycle events of the pooled transactions to its
+// subscribers. Contrary to event.Feed, sending never blocks: events are buffered
+// in the channel of each subscriber, and a subscriber whose buffer is full gets
+// unsubscribed instead of stalling the pool and all other subscribers.
+type txEventFeed struct {
+	subs map[*txEventSub]struct{}
+	lock sync.Mutex
+}
+
+// txEventSub is a subscription to a transaction event feed.
+type txEventSub struct {
+	feed    *txEventFeed
+	channel chan<- interface{}
+	err     chan error
+	once    sync.Once
+}
+
+// subscribe adds a channel to the feed, delivering all future events into it.
+func (f *txEventFeed) subscribe(channel chan<- interface{}) event.Subscription {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.subs == nil {
+		f.subs = make(map[*txEventSub]struct{})
+	}
+	sub := &txEventSub{feed: f, channel: channel, err: make(chan error, 1)}
+	f.subs[sub] = struct{}{}
+
+	return sub
+}
+
+// send delivers a batch of events to all subscribers, dropping the ones that
+// cannot accept all of them.
+func (f *txEventFeed) send(events []interface{}) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	for sub := range f.subs {
+		if !sub.deliver(events) {
+			delete(f.subs, sub)
+			sub.close(ErrTxEventsOverflow)
+		}
+	}
+}
+
+// deliver pushes a batch of events into the subscriber's channel without
+// blocking, returning whether all of them fit.
+func (sub *txEventSub) deliver(events []interface{}) bool {
+	for _, ev := range events {
+		select {
+		case sub.channel <- ev:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// close terminates the subscription, reporting the given error (if any).
+func (sub *txEventSub) close(err error) {
+	sub.once.Do(func() {
+		if err != nil {
+			sub.err <- err
+		}
+		close(sub.err)
+	})
+}
+
+// Unsubscribe stops the delivery of events and closes the error channel.
+func (sub *txEventSub) Unsubscribe() {
+	sub.feed.lock.Lock()
+	delete(sub.feed.subs, sub)
+	sub.feed.lock.Unlock()
+
+	sub.close(nil)
+}
+
+// Err returns a channel which receives ErrTxEventsOverflow if the subscriber was
+// dropped for falling behind, and is closed when the subscription ends.
+func (sub *txEventSub) Err() <-chan error {
+	return sub.err
+}
@@ -0,0 +1,150 @@
+// Copyright 2017 The daxxcoreAuthors
+// This file is part of the daxxcore library.
+//
+// The daxxcore library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The daxxcore library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the daxxcore library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/daxxcoin/daxxcore/logger"
+	"github.com/daxxcoin/daxxcore/logger/glog"
+)
+
+const (
+	// txIndexBatchLimit is the maximum number of blocks to index or unindex in
+	// one batch before updating the index tail and rechecking the chain head.
+	txIndexBatchLimit = 10000
+
+	// txIndexRecheckInterval is the frequency to check the chain head for
+	// progression that requires old transaction lookups to be unindexed.
+	txIndexRecheckInterval = 10 * time.Second
+)
+
+// txIndexTarget returns the number of the oldest block whose transactions should
+// be indexed for the given chain head and lookup limit.
+func txIndexTarget(head uint64, limit uint64) uint64 {
+	if limit == 0 || head+1 <= limit {
+		return 0
+	}
+	return head + 1 - limit
+}
+
+// TxIndexProgress returns the number of the oldest block whose transactions are
+// guaranteed to be indexed, and whether older transactions are being indexed at
+// the moment. Lookups of transactions included in earlier blocks fail.
+func (bc *BlockChain) TxIndexProgress() (tail uint64, indexing bool) {
+	tail = GetTxIndexTail(bc.chainDb)
+	if target := txIndexTarget(bc.CurrentBlock().NumberU64(), bc.cacheConfig.TxLookupLimit); target > tail {
+		tail = target
+	}
+	return tail, atomic.LoadInt32(&bc.txIndexing) == 1
+}
+
+// maintainTxIndex is a background thread that keeps the transaction lookups of
+// the most recent blocks indexed according to the configured lookup limit. When
+// the chain progresses, the lookups of blocks dropping out of the limit are
+// deleted; when the limit is raised, the lookups of older blocks are backfilled.
+func (bc *BlockChain) maintainTxIndex() {
+	defer bc.wg.Done()
+
+	for {
+		done, err := bc.indexTransactions(bc.CurrentBlock().NumberU64())
+		if err != nil {
+			glog.V(logger.Error).Infof("Failed to maintain transaction index: %v", err)
+			done = true
+		}
+		// If a batch remains to be processed, continue right away, otherwise wait a bit
+		wait := txIndexRecheckInterval
+		if !done {
+			wait = 0
+		}
+		select {
+		case <-bc.quit:
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// indexTransactions moves the transaction index tail one batch towards the target
+// of the given chain head, returning whether the target was reached.
+func (bc *BlockChain) indexTransactions(head uint64) (bool, error) {
+	var (
+		tail   = GetTxIndexTail(bc.chainDb)
+		target = txIndexTarget(head, bc.cacheConfig.TxLookupLimit)
+	)
+	switch {
+	case tail < target:
+		// Chain progressed past the limit, unindex the oldest blocks
+		atomic.StoreInt32(&bc.txIndexing, 0)
+
+		last := target
+		if last-tail > txIndexBatchLimit {
+			last = tail + txIndexBatchLimit
+		}
+		start := time.Now()
+		for number := tail; number < last; number++ {
+			block := bc.GetBlockByNumber(number)
+			if block == nil {
+				return false, fmt.Errorf("canonical block #%d missing", number)
+			}
+			if err := DeleteTxLookups(bc.chainDb, block); err != nil {
+				return false, err
+			}
+		}
+		WriteTxIndexTail(bc.chainDb, last)
+		if last-tail > 1 {
+			glog.V(logger.Info).Infof("Unindexed transactions of blocks #%d-#%d in %v", tail, last-1, time.Since(start))
+		}
+		return last == target, nil
+
+	case tail > target:
+		// Lookup limit was raised or lifted, backfill the blocks below the tail
+		atomic.StoreInt32(&bc.txIndexing, 1)
+
+		first := target
+		if tail-first > txIndexBatchLimit {
+			first = tail - txIndexBatchLimit
+		}
+		start := time.Now()
+		for number := tail; number > first; number-- {
+			block := bc.GetBlockByNumber(number - 1)
+			if block == nil {
+				return false, fmt.Errorf("canonical block #%d missing", number-1)
+			}
+			if err := WriteTransactions(bc.chainDb, block); err != nil {
+				return false, err
+			}
+			if receipts := GetBlockReceipts(bc.chainDb, block.Hash(), block.NumberU64(), bc.config); receipts != nil {
+				if err := WriteReceipts(bc.chainDb, receipts); err != nil {
+					return false, err
+				}
+			}
+		}
+		WriteTxIndexTail(bc.chainDb, first)
+		glog.V(logger.Info).Infof("Indexed transactions of blocks #%d-#%d in %v", first, tail-1, time.Since(start))
+
+		if first == target {
+			atomic.StoreInt32(&bc.txIndexing, 0)
+			return true, nil
+		}
+		return false, nil
+	}
+	atomic.StoreInt32(&bc.txIndexing, 0)
+	return true, nil
+}
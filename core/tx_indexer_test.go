@@ -0,0 +1,98 @@
+// Copyright 2017 The daxxcoreAuthors
+// This file is part of the daxxcore library.
+//
+// The daxxcore library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The daxxcore library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the daxxcore library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/daxxcoin/daxxcore/common"
+	"github.com/daxxcoin/daxxcore/consensus/daxxhash"
+	"github.com/daxxcoin/daxxcore/core/types"
+	"github.com/daxxcoin/daxxcore/core/vm"
+	"github.com/daxxcoin/daxxcore/crypto"
+	"github.com/daxxcoin/daxxcore/daxxdb"
+	"github.com/daxxcoin/daxxcore/event"
+	"github.com/daxxcoin/daxxcore/params"
+)
+
+// Tests that the transaction indexer unindexes the lookups of the blocks beyond
+// the limit, and backfills them when the limit is lifted.
+func TestTxIndexLimit(t *testing.T) {
+	var (
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		address = crypto.PubkeyToAddress(key.PublicKey)
+		gspec   = &Genesis{Config: params.TestChainConfig, Alloc: GenesisAlloc{address: {Balance: big.NewInt(1000000000)}}}
+		signer  = types.NewEIP155Signer(big.NewInt(1))
+	)
+	db, _ := ethdb.NewMemDatabase()
+	genesis := gspec.MustCommit(db)
+	blocks, _ := GenerateChain(gspec.Config, genesis, db, 16, func(i int, block *BlockGen) {
+		tx, err := types.SignTx(types.NewTransaction(block.TxNonce(address), common.Address{0x00}, big.NewInt(1000), params.TxGas, nil, nil), signer, key)
+		if err != nil {
+			panic(err)
+		}
+		block.AddTx(tx)
+	})
+	chain, err := NewBlockChain(db, nil, gspec.Config, daxxhash.NewFaker(), new(event.TypeMux), vm.Config{})
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if n, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("block %d: failed to insert into chain: %v", n, err)
+	}
+	// indexed checks that exactly the transactions from the given block on can
+	// be looked up
+	indexed := func(tail uint64) {
+		for _, block := range blocks {
+			for _, tx := range block.Transactions() {
+				have, _, _, _ := GetTransaction(db, tx.Hash())
+				receipt := GetReceipt(db, tx.Hash())
+				if want := block.NumberU64() >= tail; (have != nil) != want || (receipt != nil) != want {
+					t.Errorf("block #%d: lookup presence mismatch: tx %v, receipt %v, want %v", block.NumberU64(), have != nil, receipt != nil, want)
+				}
+			}
+		}
+		if have, indexing := chain.TxIndexProgress(); have != tail || indexing {
+			t.Errorf("index progress mismatch: have %d/%v, want %d/false", have, indexing, tail)
+		}
+	}
+	indexed(0)
+
+	// Limit the lookups to the last 4 blocks and unindex the older ones
+	chain.cacheConfig.TxLookupLimit = 4
+	if done, err := chain.indexTransactions(chain.CurrentBlock().NumberU64()); err != nil || !done {
+		t.Fatalf("unindexing mismatch: done %v, err %v", done, err)
+	}
+	indexed(13)
+
+	// Lift the limit and ensure everything is backfilled
+	chain.cacheConfig.TxLookupLimit = 0
+	if done, err := chain.indexTransactions(chain.CurrentBlock().NumberU64()); err != nil || !done {
+		t.Fatalf("backfilling mismatch: done %v, err %v", done, err)
+	}
+	indexed(0)
+
+	if tx, _, _, _ := GetTransaction(db, blocks[0].Transactions()[0].Hash()); tx == nil {
+		t.Fatalf("backfilled transaction missing")
+	}
+	if receipt := GetReceipt(db, blocks[0].Transactions()[0].Hash()); receipt.TxHash != blocks[0].Transactions()[0].Hash() || receipt.GasUsed.Cmp(params.TxGas) != 0 {
+		t.Errorf("backfilled receipt mismatch: %v", receipt)
+	}
+}
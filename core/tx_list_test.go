@@ -38,7 +38,7 @@ func TestStrictTxListAdd(t *testing.T) {
 	// Insert the transactions in a random order
 	list := newTxList(true)
 	for _, v := range rand.Perm(len(txs)) {
-		list.Add(txs[v])
+		list.Add(txs[v], testTxPoolConfig.PriceBump)
 	}
 	// Verify internal state
 	if len(list.txs.items) != len(txs) {
@@ -50,3 +50,37 @@ func TestStrictTxListAdd(t *testing.T) {
 		}
 	}
 }
+
+// Tests that transactions can only replace ones with the same nonce in a list if
+// they bump the gas price by at least the required percentage.
+func TestTxListReplacement(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	list := newTxList(false)
+
+	original := pricedTransaction(0, big.NewInt(100000), big.NewInt(1000), key)
+	if inserted, old := list.Add(original, 10); !inserted || old != nil {
+		t.Fatalf("original insertion mismatch: inserted %v, old %v", inserted, old)
+	}
+	tests := []struct {
+		price    int64
+		inserted bool
+	}{
+		{999, false},  // Cheaper than the original
+		{1000, false}, // Same price as the original
+		{1099, false}, // Bumped, but below the threshold
+		{1100, true},  // Bumped exactly by the threshold
+	}
+	for i, tt := range tests {
+		tx := pricedTransaction(0, big.NewInt(100001), big.NewInt(tt.price), key)
+		inserted, old := list.Add(tx, 10)
+		if inserted != tt.inserted {
+			t.Errorf("test %d: insertion mismatch: have %v, want %v", i, inserted, tt.inserted)
+		}
+		if inserted && old != original {
+			t.Errorf("test %d: replaced transaction mismatch: have %v, want %v", i, old, original)
+		}
+	}
+	if list.Len() != 1 {
+		t.Errorf("list length mismatch: have %d, want 1", list.Len())
+	}
+}
@@ -27,6 +27,7 @@ import (
 	"github.com/daxxcoin/daxxcore/common"
 	"github.com/daxxcoin/daxxcore/core/state"
 	"github.com/daxxcoin/daxxcore/core/types"
+	"github.com/daxxcoin/daxxcore/daxxdb"
 	"github.com/daxxcoin/daxxcore/event"
 	"github.com/daxxcoin/daxxcore/logger"
 	"github.com/daxxcoin/daxxcore/logger/glog"
@@ -37,23 +38,21 @@ import (
 
 var (
 	// Transaction Pool Errors
-	ErrInvalidSender     = errors.New("Invalid sender")
-	ErrNonce             = errors.New("Nonce too low")
-	ErrCheap             = errors.New("Gas price too low for acceptance")
-	ErrBalance           = errors.New("Insufficient balance")
-	ErrInsufficientFunds = errors.New("Insufficient funds for gas * price + value")
-	ErrIntrinsicGas      = errors.New("Intrinsic gas too low")
-	ErrGasLimit          = errors.New("Exceeds block gas limit")
-	ErrNegativeValue     = errors.New("Negative value")
+	ErrInvalidSender      = errors.New("Invalid sender")
+	ErrNonce              = errors.New("Nonce too low")
+	ErrCheap              = errors.New("Gas price too low for acceptance")
+	ErrBalance            = errors.New("Insufficient balance")
+	ErrInsufficientFunds  = errors.New("Insufficient funds for gas * price + value")
+	ErrIntrinsicGas       = errors.New("Intrinsic gas too low")
+	ErrGasLimit           = errors.New("Exceeds block gas limit")
+	ErrNegativeValue      = errors.New("Negative value")
+	ErrUnderpriced        = errors.New("Transaction underpriced")
+	ErrReplaceUnderpriced = errors.New("Replacement transaction underpriced")
 )
 
 var (
-	minPendingPerAccount = uint64(16)    // Min number of guaranteed transaction slots per address
-	maxPendingTotal      = uint64(4096)  // Max limit of pending transactions from all accounts (soft)
-	maxQueuedPerAccount  = uint64(64)    // Max limit of queued transactions per address
-	maxQueuedInTotal     = uint64(1024)  // Max limit of queued transactions from all accounts
-	maxQueuedLifetime    = 3 * time.Hour // Max amount of time transactions from idle accounts are queued
-	evictionInterval     = time.Minute   // Time interval to check for evictable transactions
+	evictionInterval = time.Minute // Time interval to check for evictable transactions
+	minedTxsDepth    = 64          // Maximum number of new head blocks to search for mined transactions
 )
 
 var (
@@ -68,11 +67,78 @@ var (
 	queuedReplaceCounter = metrics.NewCounter("txpool/queued/replace")
 	queuedRLCounter      = metrics.NewCounter("txpool/queued/ratelimit") // Dropped due to rate limiting
 	queuedNofundsCounter = metrics.NewCounter("txpool/queued/nofunds")   // Dropped due to out-of-funds
+	queuedEvictCounter   = metrics.NewCounter("txpool/queued/eviction")  // Dropped due to lifetime
 
 	// General tx metrics
-	invalidTxCounter = metrics.NewCounter("txpool/invalid")
+	invalidTxCounter     = metrics.NewCounter("txpool/invalid")
+	underpricedTxCounter = metrics.NewCounter("txpool/underpriced") // Dropped due to a full pool or minimum price
 )
 
+// TxPoolConfig are the configuration parameters of the transaction pool.
+type TxPoolConfig struct {
+	AccountSlots uint64 // Minimum number of executable transaction slots guaranteed per account
+	GlobalSlots  uint64 // Maximum number of executable transaction slots for all accounts
+	AccountQueue uint64 // Maximum number of non-executable transaction slots permitted per account
+	GlobalQueue  uint64 // Maximum number of non-executable transaction slots for all accounts
+
+	PriceBump uint64        // Minimum price bump percentage to replace an already existing transaction (nonce)
+	Lifetime  time.Duration // Maximum amount of time non-executable transaction are queued
+
+	Locals    []common.Address // Addresses whose transactions are always treated as local
+	Journal   string           // Journal of local transactions to survive node restarts
+	Rejournal time.Duration    // Time interval to regenerate the local transaction journal
+}
+
+// DefaultTxPoolConfig contains the default configurations for the transaction
+// pool.
+var DefaultTxPoolConfig = TxPoolConfig{
+	AccountSlots: 16,
+	GlobalSlots:  4096,
+	AccountQueue: 64,
+	GlobalQueue:  1024,
+
+	PriceBump: 10,
+	Lifetime:  3 * time.Hour,
+
+	Journal:   "transactions.rlp",
+	Rejournal: time.Hour,
+}
+
+// sanitize checks the provided user configurations and changes anything that's
+// unreasonable or unworkable.
+func (config *TxPoolConfig) sanitize() TxPoolConfig {
+	conf := *config
+	if conf.AccountSlots < 1 {
+		glog.V(logger.Warn).Infof("Sanitizing invalid txpool account slots: provided %d, updated %d", conf.AccountSlots, DefaultTxPoolConfig.AccountSlots)
+		conf.AccountSlots = DefaultTxPoolConfig.AccountSlots
+	}
+	if conf.GlobalSlots < 1 {
+		glog.V(logger.Warn).Infof("Sanitizing invalid txpool global slots: provided %d, updated %d", conf.GlobalSlots, DefaultTxPoolConfig.GlobalSlots)
+		conf.GlobalSlots = DefaultTxPoolConfig.GlobalSlots
+	}
+	if conf.AccountQueue < 1 {
+		glog.V(logger.Warn).Infof("Sanitizing invalid txpool account queue: provided %d, updated %d", conf.AccountQueue, DefaultTxPoolConfig.AccountQueue)
+		conf.AccountQueue = DefaultTxPoolConfig.AccountQueue
+	}
+	if conf.GlobalQueue < 1 {
+		glog.V(logger.Warn).Infof("Sanitizing invalid txpool global queue: provided %d, updated %d", conf.GlobalQueue, DefaultTxPoolConfig.GlobalQueue)
+		conf.GlobalQueue = DefaultTxPoolConfig.GlobalQueue
+	}
+	if conf.PriceBump < 1 {
+		glog.V(logger.Warn).Infof("Sanitizing invalid txpool price bump: provided %d, updated %d", conf.PriceBump, DefaultTxPoolConfig.PriceBump)
+		conf.PriceBump = DefaultTxPoolConfig.PriceBump
+	}
+	if conf.Lifetime <= 0 {
+		glog.V(logger.Warn).Infof("Sanitizing invalid txpool lifetime: provided %v, updated %v", conf.Lifetime, DefaultTxPoolConfig.Lifetime)
+		conf.Lifetime = DefaultTxPoolConfig.Lifetime
+	}
+	if conf.Rejournal < time.Second {
+		glog.V(logger.Warn).Infof("Sanitizing invalid txpool journal time: provided %v, updated %v", conf.Rejournal, time.Second)
+		conf.Rejournal = time.Second
+	}
+	return conf
+}
+
 type stateFn func() (*state.StateDB, error)
 
 // TxPool contains all currently known transactions. Transactions
@@ -83,21 +149,30 @@ type stateFn func() (*state.StateDB, error)
 // current state) and future transactions. Transactions move between those
 // two states over time as they are received and processed.
 type TxPool struct {
-	config       *params.ChainConfig
-	currentState stateFn // The state function which will allow us to do some pre checks
+	config       TxPoolConfig
+	chainconfig  *params.ChainConfig
+	chainDb      ethdb.Database // Chain database to collect the transactions of new head blocks from
+	currentState stateFn        // The state function which will allow us to do some pre checks
 	pendingState *state.ManagedState
 	gasLimit     func() *big.Int // The current gas limit function callback
 	minGasPrice  *big.Int
 	eventMux     *event.TypeMux
 	events       *event.TypeMuxSubscription
 	localTx      *txSet
+	locals       *accountSet // Set of local accounts, whose transactions are exempt from the pool limits
+	journal      *txJournal  // Journal of local transactions to back up to disk
 	signer       types.Signer
 	mu           sync.RWMutex
 
+	txFeed   txEventFeed          // Feed of the transaction lifecycle events
+	txEvents []interface{}        // Transaction lifecycle events waiting for the current operation to finish
+	stale    []*types.Transaction // Transactions with used up nonces, to be reported as mined or dropped on the next head
+
 	pending map[common.Address]*txList         // All currently processable transactions
 	queue   map[common.Address]*txList         // Queued but non-processable transactions
 	all     map[common.Hash]*types.Transaction // All transactions to allow lookups
 	beats   map[common.Address]time.Time       // Last heartbeat from each known account
+	priced  *txPricedList                      // All transactions sorted by price
 
 	wg   sync.WaitGroup // for shutdown sync
 	quit chan struct{}
@@ -105,10 +180,18 @@ type TxPool struct {
 	homestead bool
 }
 
-func NewTxPool(config *params.ChainConfig, eventMux *event.TypeMux, currentStateFn stateFn, gasLimitFn func() *big.Int) *TxPool {
+// NewTxPool creates a new transaction pool to gather, sort and filter inbound
+// transactions from the network.
+func NewTxPool(config TxPoolConfig, chainconfig *params.ChainConfig, chainDb ethdb.Database, eventMux *event.TypeMux, currentStateFn stateFn, gasLimitFn func() *big.Int) *TxPool {
+	// Sanitize the input to ensure no unworkable limits are set
+	config = config.sanitize()
+
+	// Create the transaction pool with its initial settings
 	pool := &TxPool{
 		config:       config,
-		signer:       types.NewEIP155Signer(config.ChainId),
+		chainconfig:  chainconfig,
+		chainDb:      chainDb,
+		signer:       types.NewEIP155Signer(chainconfig.ChainId),
 		pending:      make(map[common.Address]*txList),
 		queue:        make(map[common.Address]*txList),
 		all:          make(map[common.Hash]*types.Transaction),
@@ -119,15 +202,28 @@ func NewTxPool(config *params.ChainConfig, eventMux *event.TypeMux, currentState
 		minGasPrice:  new(big.Int),
 		pendingState: nil,
 		localTx:      newTxSet(),
+		locals:       newAccountSet(types.NewEIP155Signer(chainconfig.ChainId), config.Locals...),
 		events:       eventMux.Subscribe(ChainHeadEvent{}, GasPriceChanged{}, RemovedTransactionEvent{}),
 		quit:         make(chan struct{}),
 	}
-
+	pool.priced = newTxPricedList(&pool.all)
 	pool.resetState()
 
-	pool.wg.Add(2)
+	// If local transactions and journaling is enabled, load from disk
+	if config.Journal != "" {
+		pool.journal = newTxJournal(config.Journal)
+
+		if err := pool.journal.load(pool.AddLocal); err != nil {
+			glog.V(logger.Warn).Infof("Failed to load transaction journal: %v", err)
+		}
+		if err := pool.journal.rotate(pool.local()); err != nil {
+			glog.V(logger.Warn).Infof("Failed to rotate transaction journal: %v", err)
+		}
+	}
+	pool.wg.Add(3)
 	go pool.eventLoop()
 	go pool.expirationLoop()
+	go pool.journalLoop()
 
 	return pool
 }
@@ -138,22 +234,27 @@ func (pool *TxPool) eventLoop() {
 	// Track chain events. When a chain events occurs (new chain canon block)
 	// we need to know the new state. The new state will help us determine
 	// the nonces in the managed state
+	var head uint64
 	for ev := range pool.events.Chan() {
 		switch ev := ev.Data.(type) {
 		case ChainHeadEvent:
+			// Collect the transactions of the new blocks before locking the pool
+			var mined map[common.Hash]struct{}
+			mined, head = pool.minedTxs(head)
+
 			pool.mu.Lock()
 			if ev.Block != nil {
-				if pool.config.IsHomestead(ev.Block.Number()) {
+				if pool.chainconfig.IsHomestead(ev.Block.Number()) {
 					pool.homestead = true
 				}
 			}
 
 			pool.resetState()
+			pool.reportStale(mined)
+			pool.postEvents()
 			pool.mu.Unlock()
 		case GasPriceChanged:
-			pool.mu.Lock()
-			pool.minGasPrice = ev.Price
-			pool.mu.Unlock()
+			pool.SetGasPrice(ev.Price)
 		case RemovedTransactionEvent:
 			pool.AddBatch(ev.Txs)
 		}
@@ -189,10 +290,32 @@ func (pool *TxPool) resetState() {
 	pool.promoteExecutables(currentState)
 }
 
+// SetGasPrice updates the minimum price required by the transaction pool for a
+// new transaction, and drops all remote transactions below this threshold.
+func (pool *TxPool) SetGasPrice(price *big.Int) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	defer pool.postEvents()
+
+	pool.minGasPrice = price
+	for _, tx := range pool.priced.Cap(price, pool.isLocal) {
+		if glog.V(logger.Core) {
+			glog.Infof("Removed underpriced transaction: %v", tx)
+		}
+		pool.removeTx(tx.Hash())
+		pool.notify(TxDroppedEvent{tx, TxDropUnderpriced})
+		underpricedTxCounter.Inc(1)
+	}
+}
+
 func (pool *TxPool) Stop() {
 	pool.events.Unsubscribe()
 	close(pool.quit)
 	pool.wg.Wait()
+
+	if pool.journal != nil {
+		pool.journal.close()
+	}
 	glog.V(logger.Info).Infoln("Transaction pool stopped")
 }
 
@@ -235,12 +358,29 @@ func (pool *TxPool) Content() (map[common.Address]types.Transactions, map[common
 	return pending, queued
 }
 
+// ContentFrom retrieves the data content of the transaction pool for a single
+// account, returning its pending as well as queued transactions sorted by nonce.
+func (pool *TxPool) ContentFrom(addr common.Address) (types.Transactions, types.Transactions) {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	var pending, queued types.Transactions
+	if list, ok := pool.pending[addr]; ok {
+		pending = list.Flatten()
+	}
+	if list, ok := pool.queue[addr]; ok {
+		queued = list.Flatten()
+	}
+	return pending, queued
+}
+
 // Pending retrieves all currently processable transactions, groupped by origin
 // account and sorted by nonce. The returned transaction set is a copy and can be
 // freely modified by calling code.
 func (pool *TxPool) Pending() (map[common.Address]types.Transactions, error) {
 	pool.mu.Lock()
 	defer pool.mu.Unlock()
+	defer pool.postEvents()
 
 	state, err := pool.currentState()
 	if err != nil {
@@ -268,10 +408,72 @@ func (pool *TxPool) SetLocal(tx *types.Transaction) {
 	pool.localTx.add(tx.Hash())
 }
 
+// AddLocal marks a transaction as local and queues it in the pool if it is valid.
+func (pool *TxPool) AddLocal(tx *types.Transaction) error {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	defer pool.postEvents()
+
+	pool.localTx.add(tx.Hash())
+	if err := pool.add(tx); err != nil {
+		return err
+	}
+	state, err := pool.currentState()
+	if err != nil {
+		return err
+	}
+	pool.promoteExecutables(state)
+
+	return nil
+}
+
+// isLocal returns whether a transaction was submitted locally or originates from
+// one of the configured local accounts.
+//
+// Note, this method assumes the pool lock is held!
+func (pool *TxPool) isLocal(tx *types.Transaction) bool {
+	return pool.localTx.contains(tx.Hash()) || pool.locals.containsTx(tx)
+}
+
+// local retrieves all currently known local transactions, groupped by origin
+// account and sorted by nonce.
+//
+// Note, this method assumes the pool lock is held!
+func (pool *TxPool) local() map[common.Address]types.Transactions {
+	txs := make(map[common.Address]types.Transactions)
+	for _, lists := range []map[common.Address]*txList{pool.pending, pool.queue} {
+		for addr, list := range lists {
+			for _, tx := range list.Flatten() {
+				if pool.isLocal(tx) {
+					txs[addr] = append(txs[addr], tx)
+				}
+			}
+		}
+	}
+	for addr, list := range txs {
+		sort.Sort(types.TxByNonce(list))
+		txs[addr] = list
+	}
+	return txs
+}
+
+// journalTx adds the specified transaction to the local disk journal if it is
+// deemed to have been sent from a local account.
+//
+// Note, this method assumes the pool lock is held!
+func (pool *TxPool) journalTx(tx *types.Transaction) {
+	if pool.journal == nil || !pool.isLocal(tx) {
+		return
+	}
+	if err := pool.journal.insert(tx); err != nil {
+		glog.V(logger.Warn).Infof("Failed to journal local transaction %x: %v", tx.Hash(), err)
+	}
+}
+
 // validateTx checks whether a transaction is valid according
 // to the consensus rules.
 func (pool *TxPool) validateTx(tx *types.Transaction) error {
-	local := pool.localTx.contains(tx.Hash())
+	local := pool.isLocal(tx)
 	// Drop transactions under our own minimal accepted gas price
 	if !local && pool.minGasPrice.Cmp(tx.GasPrice()) > 0 {
 		return ErrCheap
@@ -331,7 +533,74 @@ func (pool *TxPool) add(tx *types.Transaction) error {
 		invalidTxCounter.Inc(1)
 		return err
 	}
-	pool.enqueueTx(hash, tx)
+	// If the transaction replaces an already pooled one, ensure it pays enough to
+	// do so before making any room for it (it doesn't grow the pool either)
+	from, _ := types.Sender(pool.signer, tx) // already validated
+	replacing := false
+	if list := pool.pending[from]; list != nil && list.Overlaps(tx) {
+		if !list.Replaceable(tx, pool.config.PriceBump) {
+			pendingDiscardCounter.Inc(1)
+			return ErrReplaceUnderpriced
+		}
+		replacing = true
+	} else if list := pool.queue[from]; list != nil && list.Overlaps(tx) {
+		if !list.Replaceable(tx, pool.config.PriceBump) {
+			queuedDiscardCounter.Inc(1)
+			return ErrReplaceUnderpriced
+		}
+		replacing = true
+	}
+	// If the transaction pool is full, discard underpriced transactions
+	if !replacing && uint64(len(pool.all)) >= pool.config.GlobalSlots+pool.config.GlobalQueue {
+		// If the new transaction is underpriced, don't accept it
+		if pool.priced.Underpriced(tx, pool.isLocal) {
+			if glog.V(logger.Core) {
+				glog.Infof("Discarding underpriced transaction: %v", tx)
+			}
+			underpricedTxCounter.Inc(1)
+			return ErrUnderpriced
+		}
+		// New transaction is better than our worse ones, make room for it
+		drop := pool.priced.Discard(len(pool.all)-int(pool.config.GlobalSlots+pool.config.GlobalQueue-1), pool.isLocal)
+		for _, tx := range drop {
+			if glog.V(logger.Core) {
+				glog.Infof("Discarding freshly underpriced transaction: %v", tx)
+			}
+			pool.removeTx(tx.Hash())
+			pool.notify(TxDroppedEvent{tx, TxDropUnderpriced})
+			underpricedTxCounter.Inc(1)
+		}
+	}
+	// If the transaction is replacing an already pending one, do directly
+	if list := pool.pending[from]; list != nil && list.Overlaps(tx) {
+		// Nonce already pending, check if required price bump is met
+		inserted, old := list.Add(tx, pool.config.PriceBump)
+		if !inserted {
+			pendingDiscardCounter.Inc(1)
+			return ErrReplaceUnderpriced
+		}
+		// New transaction is better, replace old one
+		delete(pool.all, old.Hash())
+		pool.priced.Removed()
+		pendingReplaceCounter.Inc(1)
+
+		pool.all[hash] = tx
+		pool.priced.Put(tx)
+		pool.journalTx(tx)
+
+		pool.notify(TxReplacedEvent{old, tx})
+		pool.notify(TxAddedEvent{tx, pool.isLocal(tx)})
+		pool.notify(TxPromotedEvent{tx})
+
+		go pool.eventMux.Post(TxPreEvent{tx})
+		return nil
+	}
+	// New transaction isn't replacing a pending one, push into queue
+	if err := pool.enqueueTx(hash, tx); err != nil {
+		return err
+	}
+	pool.journalTx(tx)
+	pool.notify(TxAddedEvent{tx, pool.isLocal(tx)})
 
 	// Print a log message if low enough level is set
 	if glog.V(logger.Debug) {
@@ -345,26 +614,39 @@ func (pool *TxPool) add(tx *types.Transaction) error {
 	return nil
 }
 
-// enqueueTx inserts a new transaction into the non-executable transaction queue.
+// enqueueTx inserts a new transaction into the non-executable transaction queue,
+// returning ErrReplaceUnderpriced if it could not replace an already queued one.
 //
 // Note, this method assumes the pool lock is held!
-func (pool *TxPool) enqueueTx(hash common.Hash, tx *types.Transaction) {
+func (pool *TxPool) enqueueTx(hash common.Hash, tx *types.Transaction) error {
 	// Try to insert the transaction into the future queue
 	from, _ := types.Sender(pool.signer, tx) // already validated
 	if pool.queue[from] == nil {
 		pool.queue[from] = newTxList(false)
 	}
-	inserted, old := pool.queue[from].Add(tx)
+	inserted, old := pool.queue[from].Add(tx, pool.config.PriceBump)
 	if !inserted {
+		// An older transaction was better, discard this (and forget it if demoted)
+		if pool.all[hash] != nil {
+			delete(pool.all, hash)
+			pool.priced.Removed()
+			pool.notify(TxDroppedEvent{tx, TxDropUnderpriced})
+		}
 		queuedDiscardCounter.Inc(1)
-		return // An older transaction was better, discard this
+		return ErrReplaceUnderpriced
 	}
 	// Discard any previous transaction and mark this
 	if old != nil {
 		delete(pool.all, old.Hash())
+		pool.priced.Removed()
+		pool.notify(TxReplacedEvent{old, tx})
 		queuedReplaceCounter.Inc(1)
 	}
-	pool.all[hash] = tx
+	if pool.all[hash] == nil {
+		pool.all[hash] = tx
+		pool.priced.Put(tx)
+	}
+	return nil
 }
 
 // promoteTx adds a transaction to the pending (processable) list of transactions.
@@ -377,23 +659,35 @@ func (pool *TxPool) promoteTx(addr common.Address, hash common.Hash, tx *types.T
 	}
 	list := pool.pending[addr]
 
-	inserted, old := list.Add(tx)
+	inserted, old := list.Add(tx, pool.config.PriceBump)
 	if !inserted {
 		// An older transaction was better, discard this
-		delete(pool.all, hash)
+		if pool.all[hash] != nil {
+			delete(pool.all, hash)
+			pool.priced.Removed()
+			pool.notify(TxDroppedEvent{tx, TxDropUnderpriced})
+		}
 		pendingDiscardCounter.Inc(1)
 		return
 	}
 	// Otherwise discard any previous transaction and mark this
 	if old != nil {
 		delete(pool.all, old.Hash())
+		pool.priced.Removed()
+		pool.notify(TxReplacedEvent{old, tx})
 		pendingReplaceCounter.Inc(1)
 	}
-	pool.all[hash] = tx // Failsafe to work around direct pending inserts (tests)
+	// Failsafe to work around direct pending inserts (tests)
+	if pool.all[hash] == nil {
+		pool.all[hash] = tx
+		pool.priced.Put(tx)
+	}
 
 	// Set the potentially new pending nonce and notify any subsystems of the new tx
 	pool.beats[addr] = time.Now()
 	pool.pendingState.SetNonce(addr, tx.Nonce()+1)
+	pool.notify(TxPromotedEvent{tx})
+
 	go pool.eventMux.Post(TxPreEvent{tx})
 }
 
@@ -401,6 +695,7 @@ func (pool *TxPool) promoteTx(addr common.Address, hash common.Hash, tx *types.T
 func (pool *TxPool) Add(tx *types.Transaction) error {
 	pool.mu.Lock()
 	defer pool.mu.Unlock()
+	defer pool.postEvents()
 
 	if err := pool.add(tx); err != nil {
 		return err
@@ -420,6 +715,7 @@ func (pool *TxPool) Add(tx *types.Transaction) error {
 func (pool *TxPool) AddBatch(txs []*types.Transaction) error {
 	pool.mu.Lock()
 	defer pool.mu.Unlock()
+	defer pool.postEvents()
 
 	for _, tx := range txs {
 		if err := pool.add(tx); err != nil {
@@ -450,17 +746,25 @@ func (pool *TxPool) Get(hash common.Hash) *types.Transaction {
 func (pool *TxPool) Remove(hash common.Hash) {
 	pool.mu.Lock()
 	defer pool.mu.Unlock()
+	defer pool.postEvents()
 
-	pool.removeTx(hash)
+	if tx := pool.all[hash]; tx != nil {
+		pool.removeTx(hash)
+		pool.notify(TxDroppedEvent{tx, TxDropRemoved})
+	}
 }
 
 // RemoveBatch removes all given transactions from the pool.
 func (pool *TxPool) RemoveBatch(txs types.Transactions) {
 	pool.mu.Lock()
 	defer pool.mu.Unlock()
+	defer pool.postEvents()
 
 	for _, tx := range txs {
-		pool.removeTx(tx.Hash())
+		if pool.all[tx.Hash()] != nil {
+			pool.removeTx(tx.Hash())
+			pool.notify(TxDroppedEvent{tx, TxDropRemoved})
+		}
 	}
 }
 
@@ -476,6 +780,7 @@ func (pool *TxPool) removeTx(hash common.Hash) {
 
 	// Remove it from the list of known transactions
 	delete(pool.all, hash)
+	pool.priced.Removed()
 
 	// Remove the transaction from the pending lists and reset the account nonce
 	if pending := pool.pending[addr]; pending != nil {
@@ -518,6 +823,8 @@ func (pool *TxPool) promoteExecutables(state *state.StateDB) {
 				glog.Infof("Removed old queued transaction: %v", tx)
 			}
 			delete(pool.all, tx.Hash())
+			pool.priced.Removed()
+			pool.notifyStale(tx)
 		}
 		// Drop all transactions that are too costly (low balance)
 		drops, _ := list.Filter(state.GetBalance(addr))
@@ -526,6 +833,8 @@ func (pool *TxPool) promoteExecutables(state *state.StateDB) {
 				glog.Infof("Removed unpayable queued transaction: %v", tx)
 			}
 			delete(pool.all, tx.Hash())
+			pool.priced.Removed()
+			pool.notify(TxDroppedEvent{tx, TxDropNoFunds})
 			queuedNofundsCounter.Inc(1)
 		}
 		// Gather all executable transactions and promote them
@@ -536,11 +845,13 @@ func (pool *TxPool) promoteExecutables(state *state.StateDB) {
 			pool.promoteTx(addr, tx.Hash(), tx)
 		}
 		// Drop all transactions over the allowed limit
-		for _, tx := range list.Cap(int(maxQueuedPerAccount)) {
+		for _, tx := range list.Cap(int(pool.config.AccountQueue)) {
 			if glog.V(logger.Core) {
 				glog.Infof("Removed cap-exceeding queued transaction: %v", tx)
 			}
 			delete(pool.all, tx.Hash())
+			pool.priced.Removed()
+			pool.notify(TxDroppedEvent{tx, TxDropRateLimit})
 			queuedRLCounter.Inc(1)
 		}
 		queued += uint64(list.Len())
@@ -555,13 +866,13 @@ func (pool *TxPool) promoteExecutables(state *state.StateDB) {
 	for _, list := range pool.pending {
 		pending += uint64(list.Len())
 	}
-	if pending > maxPendingTotal {
+	if pending > pool.config.GlobalSlots {
 		pendingBeforeCap := pending
 		// Assemble a spam order to penalize large transactors first
 		spammers := prque.New()
 		for addr, list := range pool.pending {
 			// Only evict transactions from high rollers
-			if uint64(list.Len()) > minPendingPerAccount {
+			if uint64(list.Len()) > pool.config.AccountSlots && !pool.locals.contains(addr) {
 				// Skip local accounts as pools should maintain backlogs for themselves
 				for _, tx := range list.txs.items {
 					if !pool.localTx.contains(tx.Hash()) {
@@ -573,7 +884,7 @@ func (pool *TxPool) promoteExecutables(state *state.StateDB) {
 		}
 		// Gradually drop transactions from offenders
 		offenders := []common.Address{}
-		for pending > maxPendingTotal && !spammers.Empty() {
+		for pending > pool.config.GlobalSlots && !spammers.Empty() {
 			// Retrieve the next offender if not local address
 			offender, _ := spammers.Pop()
 			offenders = append(offenders, offender.(common.Address))
@@ -584,21 +895,29 @@ func (pool *TxPool) promoteExecutables(state *state.StateDB) {
 				threshold := pool.pending[offender.(common.Address)].Len()
 
 				// Iteratively reduce all offenders until below limit or threshold reached
-				for pending > maxPendingTotal && pool.pending[offenders[len(offenders)-2]].Len() > threshold {
+				for pending > pool.config.GlobalSlots && pool.pending[offenders[len(offenders)-2]].Len() > threshold {
 					for i := 0; i < len(offenders)-1; i++ {
 						list := pool.pending[offenders[i]]
-						list.Cap(list.Len() - 1)
+						for _, tx := range list.Cap(list.Len() - 1) {
+							delete(pool.all, tx.Hash())
+							pool.priced.Removed()
+							pool.notify(TxDroppedEvent{tx, TxDropRateLimit})
+						}
 						pending--
 					}
 				}
 			}
 		}
 		// If still above threshold, reduce to limit or min allowance
-		if pending > maxPendingTotal && len(offenders) > 0 {
-			for pending > maxPendingTotal && uint64(pool.pending[offenders[len(offenders)-1]].Len()) > minPendingPerAccount {
+		if pending > pool.config.GlobalSlots && len(offenders) > 0 {
+			for pending > pool.config.GlobalSlots && uint64(pool.pending[offenders[len(offenders)-1]].Len()) > pool.config.AccountSlots {
 				for _, addr := range offenders {
 					list := pool.pending[addr]
-					list.Cap(list.Len() - 1)
+					for _, tx := range list.Cap(list.Len() - 1) {
+						delete(pool.all, tx.Hash())
+						pool.priced.Removed()
+						pool.notify(TxDroppedEvent{tx, TxDropRateLimit})
+					}
 					pending--
 				}
 			}
@@ -606,16 +925,18 @@ func (pool *TxPool) promoteExecutables(state *state.StateDB) {
 		pendingRLCounter.Inc(int64(pendingBeforeCap - pending))
 	}
 	// If we've queued more transactions than the hard limit, drop oldest ones
-	if queued > maxQueuedInTotal {
-		// Sort all accounts with queued transactions by heartbeat
+	if queued > pool.config.GlobalQueue {
+		// Sort all non-local accounts with queued transactions by heartbeat
 		addresses := make(addresssByHeartbeat, 0, len(pool.queue))
 		for addr := range pool.queue {
-			addresses = append(addresses, addressByHeartbeat{addr, pool.beats[addr]})
+			if !pool.locals.contains(addr) {
+				addresses = append(addresses, addressByHeartbeat{addr, pool.beats[addr]})
+			}
 		}
 		sort.Sort(addresses)
 
-		// Drop transactions until the total is below the limit
-		for drop := queued - maxQueuedInTotal; drop > 0; {
+		// Drop transactions until the total is below the limit or only locals remain
+		for drop := queued - pool.config.GlobalQueue; drop > 0 && len(addresses) > 0; {
 			addr := addresses[len(addresses)-1]
 			list := pool.queue[addr.address]
 
@@ -625,6 +946,7 @@ func (pool *TxPool) promoteExecutables(state *state.StateDB) {
 			if size := uint64(list.Len()); size <= drop {
 				for _, tx := range list.Flatten() {
 					pool.removeTx(tx.Hash())
+					pool.notify(TxDroppedEvent{tx, TxDropRateLimit})
 				}
 				drop -= size
 				queuedRLCounter.Inc(int64(size))
@@ -634,6 +956,7 @@ func (pool *TxPool) promoteExecutables(state *state.StateDB) {
 			txs := list.Flatten()
 			for i := len(txs) - 1; i >= 0 && drop > 0; i-- {
 				pool.removeTx(txs[i].Hash())
+				pool.notify(TxDroppedEvent{txs[i], TxDropRateLimit})
 				drop--
 				queuedRLCounter.Inc(1)
 			}
@@ -655,6 +978,8 @@ func (pool *TxPool) demoteUnexecutables(state *state.StateDB) {
 				glog.Infof("Removed old pending transaction: %v", tx)
 			}
 			delete(pool.all, tx.Hash())
+			pool.priced.Removed()
+			pool.notifyStale(tx)
 		}
 		// Drop all transactions that are too costly (low balance), and queue any invalids back for later
 		drops, invalids := list.Filter(state.GetBalance(addr))
@@ -663,6 +988,8 @@ func (pool *TxPool) demoteUnexecutables(state *state.StateDB) {
 				glog.Infof("Removed unpayable pending transaction: %v", tx)
 			}
 			delete(pool.all, tx.Hash())
+			pool.priced.Removed()
+			pool.notify(TxDroppedEvent{tx, TxDropNoFunds})
 			pendingNofundsCounter.Inc(1)
 		}
 		for _, tx := range invalids {
@@ -693,12 +1020,45 @@ func (pool *TxPool) expirationLoop() {
 		case <-evict.C:
 			pool.mu.Lock()
 			for addr := range pool.queue {
-				if time.Since(pool.beats[addr]) > maxQueuedLifetime {
+				// Skip local accounts from the eviction mechanism
+				if pool.locals.contains(addr) {
+					continue
+				}
+				if time.Since(pool.beats[addr]) > pool.config.Lifetime {
 					for _, tx := range pool.queue[addr].Flatten() {
 						pool.removeTx(tx.Hash())
+						pool.notify(TxDroppedEvent{tx, TxDropExpired})
+						queuedEvictCounter.Inc(1)
 					}
 				}
 			}
+			pool.postEvents()
+			pool.mu.Unlock()
+
+		case <-pool.quit:
+			return
+		}
+	}
+}
+
+// journalLoop is a loop that periodically regenerates the local transaction
+// journal, dropping the transactions that are not in the pool any more.
+func (pool *TxPool) journalLoop() {
+	defer pool.wg.Done()
+
+	if pool.journal == nil {
+		return
+	}
+	journal := time.NewTicker(pool.config.Rejournal)
+	defer journal.Stop()
+
+	for {
+		select {
+		case <-journal.C:
+			pool.mu.Lock()
+			if err := pool.journal.rotate(pool.local()); err != nil {
+				glog.V(logger.Warn).Infof("Failed to rotate local transaction journal: %v", err)
+			}
 			pool.mu.Unlock()
 
 		case <-pool.quit:
@@ -707,6 +1067,92 @@ func (pool *TxPool) expirationLoop() {
 	}
 }
 
+// notify queues a transaction lifecycle event to be posted once the current pool
+// operation finishes.
+//
+// Note, this method assumes the pool lock is held!
+func (pool *TxPool) notify(ev interface{}) {
+	pool.txEvents = append(pool.txEvents, ev)
+}
+
+// notifyStale records a transaction dropped due to its nonce being used up on
+// chain. Whether it was mined or another transaction claimed its nonce is only
+// reported on the next head block, see reportStale.
+//
+// Note, this method assumes the pool lock is held!
+func (pool *TxPool) notifyStale(tx *types.Transaction) {
+	pool.stale = append(pool.stale, tx)
+}
+
+// reportStale queues the events of all transactions dropped due to their nonces
+// being used up: a mined event if the transaction was included in one of the new
+// head blocks, or a drop event if another transaction claimed its nonce.
+//
+// Note, this method assumes the pool lock is held!
+func (pool *TxPool) reportStale(mined map[common.Hash]struct{}) {
+	for _, tx := range pool.stale {
+		if _, ok := mined[tx.Hash()]; ok {
+			pool.notify(TxMinedEvent{tx})
+		} else {
+			pool.notify(TxDroppedEvent{tx, TxDropNonceTooLow})
+		}
+	}
+	pool.stale = nil
+}
+
+// minedTxs collects the hashes of the transactions included in the canonical
+// chain above the given block number, walking back from the current head block
+// at most minedTxsDepth blocks. The head block is always searched, even if it is
+// not above the given number (reorg). The current head number is returned to
+// continue from on the next call.
+func (pool *TxPool) minedTxs(since uint64) (map[common.Hash]struct{}, uint64) {
+	mined := make(map[common.Hash]struct{})
+	if pool.chainDb == nil {
+		return mined, since
+	}
+	hash := GetHeadBlockHash(pool.chainDb)
+	number := GetBlockNumber(pool.chainDb, hash)
+	if number == missingNumber {
+		return mined, since
+	}
+	head := number
+	for depth := 0; depth < minedTxsDepth; depth++ {
+		block := GetBlock(pool.chainDb, hash, number)
+		if block == nil {
+			break
+		}
+		for _, tx := range block.Transactions() {
+			mined[tx.Hash()] = struct{}{}
+		}
+		if number == 0 || number <= since+1 {
+			break
+		}
+		hash, number = block.ParentHash(), number-1
+	}
+	return mined, head
+}
+
+// postEvents delivers all queued transaction lifecycle events to the subscribers.
+// Delivery never blocks, so subscribers calling back into the pool cannot deadlock
+// on its lock.
+//
+// Note, this method assumes the pool lock is held!
+func (pool *TxPool) postEvents() {
+	if len(pool.txEvents) == 0 {
+		return
+	}
+	pool.txFeed.send(pool.txEvents)
+	pool.txEvents = nil
+}
+
+// SubscribeTxEvents registers a subscription for the lifecycle events of the
+// pooled transactions: TxAddedEvent, TxPromotedEvent, TxReplacedEvent,
+// TxDroppedEvent and TxMinedEvent. The channel should be buffered, a subscriber
+// not keeping up with the pool is unsubscribed with ErrTxEventsOverflow.
+func (pool *TxPool) SubscribeTxEvents(ch chan<- interface{}) event.Subscription {
+	return pool.txFeed.subscribe(ch)
+}
+
 // addressByHeartbeat is an account address tagged with its last activity timestamp.
 type addressByHeartbeat struct {
 	address   common.Address
@@ -764,3 +1210,41 @@ func (self *txSet) add(hash common.Hash) {
 		self.delPtr++
 	}
 }
+
+// accountSet is simply a set of addresses to check for existence, and a signer
+// capable of deriving addresses from transactions.
+type accountSet struct {
+	accounts map[common.Address]struct{}
+	signer   types.Signer
+}
+
+// newAccountSet creates a new address set with an associated signer for sender
+// derivations.
+func newAccountSet(signer types.Signer, addrs ...common.Address) *accountSet {
+	as := &accountSet{
+		accounts: make(map[common.Address]struct{}),
+		signer:   signer,
+	}
+	for _, addr := range addrs {
+		as.accounts[addr] = struct{}{}
+	}
+	return as
+}
+
+// contains checks if a given address is contained within the set.
+func (as *accountSet) contains(addr common.Address) bool {
+	_, exist := as.accounts[addr]
+	return exist
+}
+
+// containsTx checks if the sender of a given tx is within the set. If the sender
+// cannot be derived, this method returns false.
+func (as *accountSet) containsTx(tx *types.Transaction) bool {
+	if len(as.accounts) == 0 {
+		return false
+	}
+	if addr, err := types.Sender(as.signer, tx); err == nil {
+		return as.contains(addr)
+	}
+	return false
+}
@@ -18,8 +18,11 @@ package core
 
 import (
 	"crypto/ecdsa"
+	"io/ioutil"
 	"math/big"
 	"math/rand"
+	"os"
+	"reflect"
 	"testing"
 	"time"
 
@@ -31,8 +34,21 @@ import (
 	"github.com/daxxcoin/daxxcore/event"
 )
 
+// testTxPoolConfig is a transaction pool configuration without stateful disk
+// sideeffects used during testing.
+var testTxPoolConfig TxPoolConfig
+
+func init() {
+	testTxPoolConfig = DefaultTxPoolConfig
+	testTxPoolConfig.Journal = ""
+}
+
 func transaction(nonce uint64, gaslimit *big.Int, key *ecdsa.PrivateKey) *types.Transaction {
-	tx, _ := types.SignTx(types.NewTransaction(nonce, common.Address{}, big.NewInt(100), gaslimit, big.NewInt(1), nil), types.HomesteadSigner{}, key)
+	return pricedTransaction(nonce, gaslimit, big.NewInt(1), key)
+}
+
+func pricedTransaction(nonce uint64, gaslimit, gasprice *big.Int, key *ecdsa.PrivateKey) *types.Transaction {
+	tx, _ := types.SignTx(types.NewTransaction(nonce, common.Address{}, big.NewInt(100), gaslimit, gasprice, nil), types.HomesteadSigner{}, key)
 	return tx
 }
 
@@ -41,7 +57,7 @@ func setupTxPool() (*TxPool, *ecdsa.PrivateKey) {
 	statedb, _ := state.New(common.Hash{}, db)
 
 	key, _ := crypto.GenerateKey()
-	newPool := NewTxPool(testChainConfig(), new(event.TypeMux), func() (*state.StateDB, error) { return statedb, nil }, func() *big.Int { return big.NewInt(1000000) })
+	newPool := NewTxPool(testTxPoolConfig, testChainConfig(), db, new(event.TypeMux), func() (*state.StateDB, error) { return statedb, nil }, func() *big.Int { return big.NewInt(1000000) })
 	newPool.resetState()
 
 	return newPool, key
@@ -65,7 +81,7 @@ func TestStateChangeDuringPoolReset(t *testing.T) {
 	)
 
 	// setup pool with 2 transaction in it
-	statedb.SetBalance(address, new(big.Int).Mul(common.Big1, common.DAXXCOIN))
+	statedb.SetBalance(address, new(big.Int).Mul(common.Big1, common.Daxxcoin))
 
 	tx0 := transaction(0, big.NewInt(100000), key)
 	tx1 := transaction(1, big.NewInt(100000), key)
@@ -82,7 +98,7 @@ func TestStateChangeDuringPoolReset(t *testing.T) {
 			statedb, _ = state.New(common.Hash{}, db)
 			// simulate that the new head block included tx0 and tx1
 			statedb.SetNonce(address, 2)
-			statedb.SetBalance(address, new(big.Int).Mul(common.Big1, common.DAXXCOIN))
+			statedb.SetBalance(address, new(big.Int).Mul(common.Big1, common.Daxxcoin))
 			trigger = false
 		}
 		return stdb, nil
@@ -90,7 +106,7 @@ func TestStateChangeDuringPoolReset(t *testing.T) {
 
 	gasLimitFunc := func() *big.Int { return big.NewInt(1000000000) }
 
-	txpool := NewTxPool(testChainConfig(), mux, stateFunc, gasLimitFunc)
+	txpool := NewTxPool(testTxPoolConfig, testChainConfig(), db, mux, stateFunc, gasLimitFunc)
 	txpool.resetState()
 
 	nonce := txpool.State().GetNonce(address)
@@ -306,9 +322,9 @@ func TestTransactionDoubleNonce(t *testing.T) {
 	if tx := pool.pending[addr].txs.items[0]; tx.Hash() != tx2.Hash() {
 		t.Errorf("transaction mismatch: have %x, want %x", tx.Hash(), tx2.Hash())
 	}
-	// Add the thid transaction and ensure it's not saved (smaller price)
-	if err := pool.add(tx3); err != nil {
-		t.Error("didn't expect error", err)
+	// Add the third transaction and ensure it's not saved (smaller price)
+	if err := pool.add(tx3); err != ErrReplaceUnderpriced {
+		t.Errorf("replacement error mismatch: have %v, want %v", err, ErrReplaceUnderpriced)
 	}
 	pool.promoteExecutables(state)
 	if pool.pending[addr].Len() != 1 {
@@ -530,40 +546,39 @@ func TestTransactionQueueAccountLimiting(t *testing.T) {
 	pool.resetState()
 
 	// Keep queuing up transactions and make sure all above a limit are dropped
-	for i := uint64(1); i <= maxQueuedPerAccount+5; i++ {
+	for i := uint64(1); i <= testTxPoolConfig.AccountQueue+5; i++ {
 		if err := pool.Add(transaction(i, big.NewInt(100000), key)); err != nil {
 			t.Fatalf("tx %d: failed to add transaction: %v", i, err)
 		}
 		if len(pool.pending) != 0 {
 			t.Errorf("tx %d: pending pool size mismatch: have %d, want %d", i, len(pool.pending), 0)
 		}
-		if i <= maxQueuedPerAccount {
+		if i <= testTxPoolConfig.AccountQueue {
 			if pool.queue[account].Len() != int(i) {
 				t.Errorf("tx %d: queue size mismatch: have %d, want %d", i, pool.queue[account].Len(), i)
 			}
 		} else {
-			if pool.queue[account].Len() != int(maxQueuedPerAccount) {
-				t.Errorf("tx %d: queue limit mismatch: have %d, want %d", i, pool.queue[account].Len(), maxQueuedPerAccount)
+			if pool.queue[account].Len() != int(testTxPoolConfig.AccountQueue) {
+				t.Errorf("tx %d: queue limit mismatch: have %d, want %d", i, pool.queue[account].Len(), testTxPoolConfig.AccountQueue)
 			}
 		}
 	}
-	if len(pool.all) != int(maxQueuedPerAccount) {
-		t.Errorf("total transaction mismatch: have %d, want %d", len(pool.all), maxQueuedPerAccount)
+	if len(pool.all) != int(testTxPoolConfig.AccountQueue) {
+		t.Errorf("total transaction mismatch: have %d, want %d", len(pool.all), testTxPoolConfig.AccountQueue)
 	}
 }
 
 // Tests that if the transaction count belonging to multiple accounts go above
 // some threshold, the higher transactions are dropped to prevent DOS attacks.
 func TestTransactionQueueGlobalLimiting(t *testing.T) {
-	// Reduce the queue limits to shorten test time
-	defer func(old uint64) { maxQueuedInTotal = old }(maxQueuedInTotal)
-	maxQueuedInTotal = maxQueuedPerAccount * 3
-
 	// Create the pool to test the limit enforcement with
 	db, _ := ethdb.NewMemDatabase()
 	statedb, _ := state.New(common.Hash{}, db)
 
-	pool := NewTxPool(testChainConfig(), new(event.TypeMux), func() (*state.StateDB, error) { return statedb, nil }, func() *big.Int { return big.NewInt(1000000) })
+	config := testTxPoolConfig
+	config.GlobalQueue = config.AccountQueue * 3 // Reduce the queue limits to shorten test time
+
+	pool := NewTxPool(config, testChainConfig(), db, new(event.TypeMux), func() (*state.StateDB, error) { return statedb, nil }, func() *big.Int { return big.NewInt(1000000) })
 	pool.resetState()
 
 	// Create a number of test accounts and fund them
@@ -577,7 +592,7 @@ func TestTransactionQueueGlobalLimiting(t *testing.T) {
 	// Generate and queue a batch of transactions
 	nonces := make(map[common.Address]uint64)
 
-	txs := make(types.Transactions, 0, 3*maxQueuedInTotal)
+	txs := make(types.Transactions, 0, 3*config.GlobalQueue)
 	for len(txs) < cap(txs) {
 		key := keys[rand.Intn(len(keys))]
 		addr := crypto.PubkeyToAddress(key.PublicKey)
@@ -590,13 +605,13 @@ func TestTransactionQueueGlobalLimiting(t *testing.T) {
 
 	queued := 0
 	for addr, list := range pool.queue {
-		if list.Len() > int(maxQueuedPerAccount) {
-			t.Errorf("addr %x: queued accounts overflown allowance: %d > %d", addr, list.Len(), maxQueuedPerAccount)
+		if list.Len() > int(config.AccountQueue) {
+			t.Errorf("addr %x: queued accounts overflown allowance: %d > %d", addr, list.Len(), config.AccountQueue)
 		}
 		queued += list.Len()
 	}
-	if queued > int(maxQueuedInTotal) {
-		t.Fatalf("total transactions overflow allowance: %d > %d", queued, maxQueuedInTotal)
+	if queued > int(config.GlobalQueue) {
+		t.Fatalf("total transactions overflow allowance: %d > %d", queued, config.GlobalQueue)
 	}
 }
 
@@ -604,21 +619,29 @@ func TestTransactionQueueGlobalLimiting(t *testing.T) {
 // non-executable transactions queued up are dropped to prevent wasting resources
 // on shuffling them around.
 func TestTransactionQueueTimeLimiting(t *testing.T) {
-	// Reduce the queue limits to shorten test time
-	defer func(old time.Duration) { maxQueuedLifetime = old }(maxQueuedLifetime)
+	// Reduce the eviction interval to a testable amount
 	defer func(old time.Duration) { evictionInterval = old }(evictionInterval)
-	maxQueuedLifetime = time.Second
 	evictionInterval = time.Second
 
+	// Create the pool to test the non-expiration enforcement
+	db, _ := ethdb.NewMemDatabase()
+	statedb, _ := state.New(common.Hash{}, db)
+
+	config := testTxPoolConfig
+	config.Lifetime = time.Second
+
+	pool := NewTxPool(config, testChainConfig(), db, new(event.TypeMux), func() (*state.StateDB, error) { return statedb, nil }, func() *big.Int { return big.NewInt(1000000) })
+	pool.resetState()
+
 	// Create a test account and fund it
-	pool, key := setupTxPool()
+	key, _ := crypto.GenerateKey()
 	account, _ := deriveSender(transaction(0, big.NewInt(0), key))
 
 	state, _ := pool.currentState()
 	state.AddBalance(account, big.NewInt(1000000))
 
 	// Queue up a batch of transactions
-	for i := uint64(1); i <= maxQueuedPerAccount; i++ {
+	for i := uint64(1); i <= testTxPoolConfig.AccountQueue; i++ {
 		if err := pool.Add(transaction(i, big.NewInt(100000), key)); err != nil {
 			t.Fatalf("tx %d: failed to add transaction: %v", i, err)
 		}
@@ -643,7 +666,7 @@ func TestTransactionPendingLimiting(t *testing.T) {
 	pool.resetState()
 
 	// Keep queuing up transactions and make sure all above a limit are dropped
-	for i := uint64(0); i < maxQueuedPerAccount+5; i++ {
+	for i := uint64(0); i < testTxPoolConfig.AccountQueue+5; i++ {
 		if err := pool.Add(transaction(i, big.NewInt(100000), key)); err != nil {
 			t.Fatalf("tx %d: failed to add transaction: %v", i, err)
 		}
@@ -654,8 +677,8 @@ func TestTransactionPendingLimiting(t *testing.T) {
 			t.Errorf("tx %d: queue size mismatch: have %d, want %d", i, pool.queue[account].Len(), 0)
 		}
 	}
-	if len(pool.all) != int(maxQueuedPerAccount+5) {
-		t.Errorf("total transaction mismatch: have %d, want %d", len(pool.all), maxQueuedPerAccount+5)
+	if len(pool.all) != int(testTxPoolConfig.AccountQueue+5) {
+		t.Errorf("total transaction mismatch: have %d, want %d", len(pool.all), testTxPoolConfig.AccountQueue+5)
 	}
 }
 
@@ -671,7 +694,7 @@ func testTransactionLimitingEquivalency(t *testing.T, origin uint64) {
 	state1, _ := pool1.currentState()
 	state1.AddBalance(account1, big.NewInt(1000000))
 
-	for i := uint64(0); i < maxQueuedPerAccount+5; i++ {
+	for i := uint64(0); i < testTxPoolConfig.AccountQueue+5; i++ {
 		if err := pool1.Add(transaction(origin+i, big.NewInt(100000), key1)); err != nil {
 			t.Fatalf("tx %d: failed to add transaction: %v", i, err)
 		}
@@ -683,7 +706,7 @@ func testTransactionLimitingEquivalency(t *testing.T, origin uint64) {
 	state2.AddBalance(account2, big.NewInt(1000000))
 
 	txns := []*types.Transaction{}
-	for i := uint64(0); i < maxQueuedPerAccount+5; i++ {
+	for i := uint64(0); i < testTxPoolConfig.AccountQueue+5; i++ {
 		txns = append(txns, transaction(origin+i, big.NewInt(100000), key2))
 	}
 	pool2.AddBatch(txns)
@@ -704,15 +727,14 @@ func testTransactionLimitingEquivalency(t *testing.T, origin uint64) {
 // some hard threshold, the higher transactions are dropped to prevent DOS
 // attacks.
 func TestTransactionPendingGlobalLimiting(t *testing.T) {
-	// Reduce the queue limits to shorten test time
-	defer func(old uint64) { maxPendingTotal = old }(maxPendingTotal)
-	maxPendingTotal = minPendingPerAccount * 10
-
 	// Create the pool to test the limit enforcement with
 	db, _ := ethdb.NewMemDatabase()
 	statedb, _ := state.New(common.Hash{}, db)
 
-	pool := NewTxPool(testChainConfig(), new(event.TypeMux), func() (*state.StateDB, error) { return statedb, nil }, func() *big.Int { return big.NewInt(1000000) })
+	config := testTxPoolConfig
+	config.GlobalSlots = config.AccountSlots * 10 // Reduce the pending limits to shorten test time
+
+	pool := NewTxPool(config, testChainConfig(), db, new(event.TypeMux), func() (*state.StateDB, error) { return statedb, nil }, func() *big.Int { return big.NewInt(1000000) })
 	pool.resetState()
 
 	// Create a number of test accounts and fund them
@@ -729,7 +751,7 @@ func TestTransactionPendingGlobalLimiting(t *testing.T) {
 	txs := types.Transactions{}
 	for _, key := range keys {
 		addr := crypto.PubkeyToAddress(key.PublicKey)
-		for j := 0; j < int(maxPendingTotal)/len(keys)*2; j++ {
+		for j := 0; j < int(config.GlobalSlots)/len(keys)*2; j++ {
 			txs = append(txs, transaction(nonces[addr], big.NewInt(100000), key))
 			nonces[addr]++
 		}
@@ -741,8 +763,8 @@ func TestTransactionPendingGlobalLimiting(t *testing.T) {
 	for _, list := range pool.pending {
 		pending += list.Len()
 	}
-	if pending > int(maxPendingTotal) {
-		t.Fatalf("total pending transactions overflow allowance: %d > %d", pending, maxPendingTotal)
+	if pending > int(config.GlobalSlots) {
+		t.Fatalf("total pending transactions overflow allowance: %d > %d", pending, config.GlobalSlots)
 	}
 }
 
@@ -750,15 +772,14 @@ func TestTransactionPendingGlobalLimiting(t *testing.T) {
 // some hard threshold, if they are under the minimum guaranteed slot count then
 // the transactions are still kept.
 func TestTransactionPendingMinimumAllowance(t *testing.T) {
-	// Reduce the queue limits to shorten test time
-	defer func(old uint64) { maxPendingTotal = old }(maxPendingTotal)
-	maxPendingTotal = 0
-
 	// Create the pool to test the limit enforcement with
 	db, _ := ethdb.NewMemDatabase()
 	statedb, _ := state.New(common.Hash{}, db)
 
-	pool := NewTxPool(testChainConfig(), new(event.TypeMux), func() (*state.StateDB, error) { return statedb, nil }, func() *big.Int { return big.NewInt(1000000) })
+	config := testTxPoolConfig
+	config.GlobalSlots = 1
+
+	pool := NewTxPool(config, testChainConfig(), db, new(event.TypeMux), func() (*state.StateDB, error) { return statedb, nil }, func() *big.Int { return big.NewInt(1000000) })
 	pool.resetState()
 
 	// Create a number of test accounts and fund them
@@ -775,7 +796,7 @@ func TestTransactionPendingMinimumAllowance(t *testing.T) {
 	txs := types.Transactions{}
 	for _, key := range keys {
 		addr := crypto.PubkeyToAddress(key.PublicKey)
-		for j := 0; j < int(minPendingPerAccount)*2; j++ {
+		for j := 0; j < int(config.AccountSlots)*2; j++ {
 			txs = append(txs, transaction(nonces[addr], big.NewInt(100000), key))
 			nonces[addr]++
 		}
@@ -784,10 +805,426 @@ func TestTransactionPendingMinimumAllowance(t *testing.T) {
 	pool.AddBatch(txs)
 
 	for addr, list := range pool.pending {
-		if list.Len() != int(minPendingPerAccount) {
-			t.Errorf("addr %x: total pending transactions mismatch: have %d, want %d", addr, list.Len(), minPendingPerAccount)
+		if list.Len() != int(config.AccountSlots) {
+			t.Errorf("addr %x: total pending transactions mismatch: have %d, want %d", addr, list.Len(), config.AccountSlots)
+		}
+	}
+}
+
+// Tests that unworkable pool limits are replaced by the defaults instead of
+// crippling the pool.
+func TestTransactionPoolConfigSanitize(t *testing.T) {
+	config := testTxPoolConfig
+	config.AccountSlots, config.GlobalSlots = 0, 0
+	config.AccountQueue, config.GlobalQueue = 0, 0
+
+	conf := config.sanitize()
+	if conf.AccountSlots != DefaultTxPoolConfig.AccountSlots || conf.GlobalSlots != DefaultTxPoolConfig.GlobalSlots {
+		t.Errorf("slots not sanitized: have %d/%d, want %d/%d", conf.AccountSlots, conf.GlobalSlots, DefaultTxPoolConfig.AccountSlots, DefaultTxPoolConfig.GlobalSlots)
+	}
+	if conf.AccountQueue != DefaultTxPoolConfig.AccountQueue || conf.GlobalQueue != DefaultTxPoolConfig.GlobalQueue {
+		t.Errorf("queue not sanitized: have %d/%d, want %d/%d", conf.AccountQueue, conf.GlobalQueue, DefaultTxPoolConfig.AccountQueue, DefaultTxPoolConfig.GlobalQueue)
+	}
+}
+
+// Tests that the pool rejects replacement transactions that don't meet the
+// minimum price bump required, both in the pending and the queued pools.
+func TestTransactionReplacement(t *testing.T) {
+	// Create a test account and fund it
+	pool, key := setupTxPool()
+	account, _ := deriveSender(transaction(0, big.NewInt(0), key))
+
+	state, _ := pool.currentState()
+	state.AddBalance(account, big.NewInt(1000000000))
+	pool.resetState()
+
+	// Add pending and queued transactions to replace, and the lowest and highest
+	// prices that should fail or succeed the replacement for each
+	price := int64(100)
+	threshold := (price * (100 + int64(testTxPoolConfig.PriceBump))) / 100
+
+	for _, nonce := range []uint64{0, 2} {
+		if err := pool.Add(pricedTransaction(nonce, big.NewInt(100000), big.NewInt(price), key)); err != nil {
+			t.Fatalf("nonce %d: failed to add original transaction: %v", nonce, err)
+		}
+		if err := pool.Add(pricedTransaction(nonce, big.NewInt(100001), big.NewInt(price), key)); err != ErrReplaceUnderpriced {
+			t.Fatalf("nonce %d: same price replacement error mismatch: have %v, want %v", nonce, err, ErrReplaceUnderpriced)
+		}
+		if err := pool.Add(pricedTransaction(nonce, big.NewInt(100000), big.NewInt(threshold-1), key)); err != ErrReplaceUnderpriced {
+			t.Fatalf("nonce %d: under threshold replacement error mismatch: have %v, want %v", nonce, err, ErrReplaceUnderpriced)
+		}
+		replacement := pricedTransaction(nonce, big.NewInt(100000), big.NewInt(threshold), key)
+		if err := pool.Add(replacement); err != nil {
+			t.Fatalf("nonce %d: failed to replace transaction at threshold: %v", nonce, err)
+		}
+		if pool.Get(replacement.Hash()) == nil {
+			t.Fatalf("nonce %d: replacement missing from pool", nonce)
+		}
+	}
+	if pending, queued := pool.Stats(); pending != 1 || queued != 1 {
+		t.Fatalf("pool size mismatch: have %d/%d, want %d/%d", pending, queued, 1, 1)
+	}
+	if len(pool.all) != 2 {
+		t.Fatalf("total transaction count mismatch: have %d, want %d", len(pool.all), 2)
+	}
+}
+
+// Tests that setting the transaction pool gas price to a higher value correctly
+// discards everything cheaper than that, except for local transactions.
+func TestTransactionPoolRepricing(t *testing.T) {
+	// Create the pool to test the pricing enforcement with
+	pool, _ := setupTxPool()
+
+	// Create a number of test accounts and fund them
+	state, _ := pool.currentState()
+
+	keys := make([]*ecdsa.PrivateKey, 3)
+	for i := 0; i < len(keys); i++ {
+		keys[i], _ = crypto.GenerateKey()
+		state.AddBalance(crypto.PubkeyToAddress(keys[i].PublicKey), big.NewInt(1000000))
+	}
+	// Generate and queue a batch of transactions, both pending and queued
+	txs := types.Transactions{
+		pricedTransaction(0, big.NewInt(100000), big.NewInt(2), keys[0]),
+		pricedTransaction(1, big.NewInt(100000), big.NewInt(1), keys[0]),
+		pricedTransaction(1, big.NewInt(100000), big.NewInt(1), keys[1]),
+		pricedTransaction(2, big.NewInt(100000), big.NewInt(2), keys[1]),
+		pricedTransaction(0, big.NewInt(100000), big.NewInt(1), keys[2]),
+	}
+	pool.SetLocal(txs[4])
+	pool.AddBatch(txs)
+
+	if pending, queued := pool.Stats(); pending != 3 || queued != 2 {
+		t.Fatalf("pool size mismatch: have %d/%d, want %d/%d", pending, queued, 3, 2)
+	}
+	// Reprice the pool and check that underpriced remote transactions get dropped
+	pool.SetGasPrice(big.NewInt(2))
+
+	if pending, queued := pool.Stats(); pending != 2 || queued != 1 {
+		t.Fatalf("pool size mismatch after repricing: have %d/%d, want %d/%d", pending, queued, 2, 1)
+	}
+	for i, tx := range txs {
+		if want := i != 1 && i != 2; (pool.Get(tx.Hash()) != nil) != want {
+			t.Errorf("tx %d: presence mismatch: have %v, want %v", i, !want, want)
+		}
+	}
+	// Check that new remote transactions below the price are rejected
+	if err := pool.Add(pricedTransaction(1, big.NewInt(100000), big.NewInt(1), keys[2])); err != ErrCheap {
+		t.Fatalf("adding underpriced transaction error mismatch: have %v, want %v", err, ErrCheap)
+	}
+}
+
+// Tests that when the pool reaches its global transaction limit, underpriced
+// transactions are gradually shifted out for more expensive ones and any gapped
+// pending transactions are moved into the queue.
+//
+// Note, local transactions are never allowed to be dropped.
+func TestTransactionPoolUnderpricing(t *testing.T) {
+	// Create the pool to test the pricing enforcement with
+	db, _ := ethdb.NewMemDatabase()
+	statedb, _ := state.New(common.Hash{}, db)
+
+	config := testTxPoolConfig
+	config.GlobalSlots = 2
+	config.GlobalQueue = 2
+
+	pool := NewTxPool(config, testChainConfig(), db, new(event.TypeMux), func() (*state.StateDB, error) { return statedb, nil }, func() *big.Int { return big.NewInt(1000000) })
+	pool.resetState()
+
+	// Create a number of test accounts and fund them
+	state, _ := pool.currentState()
+
+	keys := make([]*ecdsa.PrivateKey, 3)
+	for i := 0; i < len(keys); i++ {
+		keys[i], _ = crypto.GenerateKey()
+		state.AddBalance(crypto.PubkeyToAddress(keys[i].PublicKey), big.NewInt(1000000))
+	}
+	// Fill up the pool with pending and queued transactions
+	txs := types.Transactions{
+		pricedTransaction(0, big.NewInt(100000), big.NewInt(3), keys[0]),
+		pricedTransaction(1, big.NewInt(100000), big.NewInt(2), keys[0]),
+		pricedTransaction(0, big.NewInt(100000), big.NewInt(4), keys[1]),
+		pricedTransaction(2, big.NewInt(100000), big.NewInt(1), keys[1]),
+	}
+	for i, tx := range txs {
+		if err := pool.Add(tx); err != nil {
+			t.Fatalf("tx %d: failed to add transaction: %v", i, err)
+		}
+	}
+	if pending, queued := pool.Stats(); pending != 3 || queued != 1 {
+		t.Fatalf("pool size mismatch: have %d/%d, want %d/%d", pending, queued, 3, 1)
+	}
+	// Ensure that adding an underpriced transaction on a full pool fails
+	if err := pool.Add(pricedTransaction(0, big.NewInt(100000), big.NewInt(1), keys[2])); err != ErrUnderpriced {
+		t.Fatalf("adding underpriced transaction error mismatch: have %v, want %v", err, ErrUnderpriced)
+	}
+	// Ensure that a better transaction replaces the cheapest one
+	better := pricedTransaction(0, big.NewInt(100000), big.NewInt(2), keys[2])
+	if err := pool.Add(better); err != nil {
+		t.Fatalf("failed to add well priced transaction: %v", err)
+	}
+	if pool.Get(txs[3].Hash()) != nil {
+		t.Errorf("cheapest transaction not discarded")
+	}
+	if pending, queued := pool.Stats(); pending != 4 || queued != 0 {
+		t.Fatalf("pool size mismatch: have %d/%d, want %d/%d", pending, queued, 4, 0)
+	}
+	// Ensure that replacements on a full pool don't evict anything, whether they
+	// pay the required price bump or not
+	if err := pool.Add(pricedTransaction(0, big.NewInt(100001), big.NewInt(3), keys[0])); err != ErrReplaceUnderpriced {
+		t.Fatalf("adding underpriced replacement error mismatch: have %v, want %v", err, ErrReplaceUnderpriced)
+	}
+	replacement := pricedTransaction(0, big.NewInt(100000), big.NewInt(5), keys[0])
+	if err := pool.Add(replacement); err != nil {
+		t.Fatalf("failed to add replacement transaction: %v", err)
+	}
+	for i, tx := range []*types.Transaction{replacement, txs[1], txs[2], better} {
+		if pool.Get(tx.Hash()) == nil {
+			t.Errorf("transaction %d evicted by replacement", i)
+		}
+	}
+	if pending, queued := pool.Stats(); pending != 4 || queued != 0 {
+		t.Fatalf("pool size mismatch: have %d/%d, want %d/%d", pending, queued, 4, 0)
+	}
+	// Ensure that a cheap local transaction still makes it in, pushing out the
+	// cheapest remote one (the highest nonce on a price tie)
+	local := pricedTransaction(1, big.NewInt(100000), big.NewInt(1), keys[2])
+	pool.SetLocal(local)
+	if err := pool.Add(local); err != nil {
+		t.Fatalf("failed to add local transaction: %v", err)
+	}
+	if pool.Get(txs[1].Hash()) != nil || pool.Get(better.Hash()) == nil {
+		t.Errorf("wrong transaction discarded for local one")
+	}
+	if pending, queued := pool.Stats(); pending != 4 || queued != 0 {
+		t.Fatalf("pool size mismatch: have %d/%d, want %d/%d", pending, queued, 4, 0)
+	}
+}
+
+// Tests that local transactions are journaled to disk, but remote transactions
+// get discarded between restarts.
+func TestTransactionJournaling(t *testing.T) {
+	// Create a temporary file for the journal
+	file, err := ioutil.TempFile("", "")
+	if err != nil {
+		t.Fatalf("failed to create temporary journal: %v", err)
+	}
+	journal := file.Name()
+	defer os.Remove(journal)
+
+	// Clean up the temporary file, we only need the path for now
+	file.Close()
+	os.Remove(journal)
+
+	// Create the original pool to inject transaction into the journal
+	db, _ := ethdb.NewMemDatabase()
+	statedb, _ := state.New(common.Hash{}, db)
+
+	var (
+		local, _  = crypto.GenerateKey()
+		config, _ = crypto.GenerateKey()
+		remote, _ = crypto.GenerateKey()
+	)
+	for _, key := range []*ecdsa.PrivateKey{local, config, remote} {
+		statedb.AddBalance(crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000000))
+	}
+	poolConfig := testTxPoolConfig
+	poolConfig.Journal = journal
+	poolConfig.Rejournal = time.Second
+	poolConfig.Locals = []common.Address{crypto.PubkeyToAddress(config.PublicKey)}
+
+	newPool := func() *TxPool {
+		pool := NewTxPool(poolConfig, testChainConfig(), db, new(event.TypeMux), func() (*state.StateDB, error) { return statedb, nil }, func() *big.Int { return big.NewInt(1000000) })
+		pool.resetState()
+		return pool
+	}
+	pool := newPool()
+
+	// Add three local (submitted and configured) and a remote transaction
+	if err := pool.AddLocal(pricedTransaction(0, big.NewInt(100000), big.NewInt(1), local)); err != nil {
+		t.Fatalf("failed to add local transaction: %v", err)
+	}
+	if err := pool.AddLocal(pricedTransaction(2, big.NewInt(100000), big.NewInt(1), local)); err != nil {
+		t.Fatalf("failed to add local transaction: %v", err)
+	}
+	if err := pool.Add(pricedTransaction(0, big.NewInt(100000), big.NewInt(1), config)); err != nil {
+		t.Fatalf("failed to add configured local transaction: %v", err)
+	}
+	if err := pool.Add(pricedTransaction(0, big.NewInt(100000), big.NewInt(1), remote)); err != nil {
+		t.Fatalf("failed to add remote transaction: %v", err)
+	}
+	if pending, queued := pool.Stats(); pending != 3 || queued != 1 {
+		t.Fatalf("pool size mismatch: have %d/%d, want %d/%d", pending, queued, 3, 1)
+	}
+	// Terminate the old pool, restart a new one and ensure only the locals were reloaded
+	pool.Stop()
+	pool = newPool()
+
+	if pending, queued := pool.Stats(); pending != 2 || queued != 1 {
+		t.Fatalf("pool size mismatch after restart: have %d/%d, want %d/%d", pending, queued, 2, 1)
+	}
+	// Mine the first local transaction and ensure the journal is rotated without it
+	statedb.SetNonce(crypto.PubkeyToAddress(local.PublicKey), 1)
+	pool.resetState()
+	time.Sleep(2 * poolConfig.Rejournal)
+	pool.Stop()
+
+	statedb.SetNonce(crypto.PubkeyToAddress(local.PublicKey), 0)
+	pool = newPool()
+	defer pool.Stop()
+
+	if pending, queued := pool.Stats(); pending != 1 || queued != 1 {
+		t.Fatalf("pool size mismatch after rotation: have %d/%d, want %d/%d", pending, queued, 1, 1)
+	}
+}
+
+// Tests that transactions from configured local accounts are exempt from the
+// pricing limits of the pool.
+func TestTransactionPoolLocalAccounts(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	statedb, _ := state.New(common.Hash{}, db)
+
+	local, _ := crypto.GenerateKey()
+	remote, _ := crypto.GenerateKey()
+	for _, key := range []*ecdsa.PrivateKey{local, remote} {
+		statedb.AddBalance(crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000000))
+	}
+	config := testTxPoolConfig
+	config.Locals = []common.Address{crypto.PubkeyToAddress(local.PublicKey)}
+
+	pool := NewTxPool(config, testChainConfig(), db, new(event.TypeMux), func() (*state.StateDB, error) { return statedb, nil }, func() *big.Int { return big.NewInt(1000000) })
+	defer pool.Stop()
+	pool.resetState()
+
+	// Add a cheap transaction from both accounts and reprice the pool
+	if err := pool.Add(pricedTransaction(0, big.NewInt(100000), big.NewInt(1), local)); err != nil {
+		t.Fatalf("failed to add local transaction: %v", err)
+	}
+	if err := pool.Add(pricedTransaction(0, big.NewInt(100000), big.NewInt(1), remote)); err != nil {
+		t.Fatalf("failed to add remote transaction: %v", err)
+	}
+	pool.SetGasPrice(big.NewInt(2))
+
+	if pending, _ := pool.Stats(); pending != 1 {
+		t.Fatalf("pending count mismatch after repricing: have %d, want %d", pending, 1)
+	}
+	// Ensure only the local account can still add cheap transactions
+	if err := pool.Add(pricedTransaction(1, big.NewInt(100000), big.NewInt(1), local)); err != nil {
+		t.Fatalf("failed to add cheap local transaction: %v", err)
+	}
+	if err := pool.Add(pricedTransaction(0, big.NewInt(100000), big.NewInt(1), remote)); err != ErrCheap {
+		t.Fatalf("cheap remote transaction error mismatch: have %v, want %v", err, ErrCheap)
+	}
+}
+
+// Tests that the transaction pool posts the lifecycle events of its transactions,
+// in order, as they are added, promoted, replaced, dropped and mined.
+func TestTransactionPoolEvents(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	statedb, _ := state.New(common.Hash{}, db)
+
+	key, _ := crypto.GenerateKey()
+	account := crypto.PubkeyToAddress(key.PublicKey)
+	statedb.AddBalance(account, big.NewInt(1000000000))
+
+	mux := new(event.TypeMux)
+	pool := NewTxPool(testTxPoolConfig, testChainConfig(), db, mux, func() (*state.StateDB, error) { return statedb, nil }, func() *big.Int { return big.NewInt(1000000) })
+	defer pool.Stop()
+	pool.resetState()
+
+	events := make(chan interface{}, 16)
+	sub := pool.SubscribeTxEvents(events)
+	defer sub.Unsubscribe()
+
+	expect := func(want ...interface{}) {
+		for i, w := range want {
+			select {
+			case ev := <-events:
+				if !reflect.DeepEqual(ev, w) {
+					t.Fatalf("event %d: mismatch: have %+v, want %+v", i, ev, w)
+				}
+			case <-time.After(time.Second):
+				t.Fatalf("event %d: timeout waiting for %T", i, w)
+			}
 		}
 	}
+	// Add an executable and a future transaction, then replace the executable one
+	tx0 := pricedTransaction(0, big.NewInt(100000), big.NewInt(1), key)
+	if err := pool.Add(tx0); err != nil {
+		t.Fatalf("failed to add transaction: %v", err)
+	}
+	expect(TxAddedEvent{tx0, false}, TxPromotedEvent{tx0})
+
+	tx2 := transaction(2, big.NewInt(100000), key)
+	if err := pool.Add(tx2); err != nil {
+		t.Fatalf("failed to add future transaction: %v", err)
+	}
+	expect(TxAddedEvent{tx2, false})
+
+	tx0b := pricedTransaction(0, big.NewInt(100000), big.NewInt(2), key)
+	if err := pool.Add(tx0b); err != nil {
+		t.Fatalf("failed to replace transaction: %v", err)
+	}
+	expect(TxReplacedEvent{tx0, tx0b}, TxAddedEvent{tx0b, false}, TxPromotedEvent{tx0b})
+
+	// Explicitly remove the future transaction and fill the gap
+	pool.Remove(tx2.Hash())
+	expect(TxDroppedEvent{tx2, TxDropRemoved})
+
+	tx1 := transaction(1, big.NewInt(100000), key)
+	if err := pool.Add(tx1); err != nil {
+		t.Fatalf("failed to add transaction: %v", err)
+	}
+	expect(TxAddedEvent{tx1, false}, TxPromotedEvent{tx1})
+
+	// Mine the replacement, and use up the next nonce with an unknown transaction
+	block := types.NewBlock(&types.Header{Number: big.NewInt(1)}, []*types.Transaction{tx0b}, nil, nil)
+	if err := WriteBlock(db, block); err != nil {
+		t.Fatalf("failed to write mined block: %v", err)
+	}
+	if err := WriteHeadBlockHash(db, block.Hash()); err != nil {
+		t.Fatalf("failed to write head block hash: %v", err)
+	}
+	statedb.SetNonce(account, 2)
+	mux.Post(ChainHeadEvent{block})
+	expect(TxMinedEvent{tx0b}, TxDroppedEvent{tx1, TxDropNonceTooLow})
+
+	if pending, queued := pool.ContentFrom(account); len(pending) != 0 || len(queued) != 0 {
+		t.Fatalf("account content mismatch: have %d/%d, want %d/%d", len(pending), len(queued), 0, 0)
+	}
+}
+
+// Tests that transaction event subscribers not keeping up with the pool are
+// dropped instead of stalling it and the other subscribers.
+func TestTransactionPoolEventsOverflow(t *testing.T) {
+	pool, key := setupTxPool()
+	defer pool.Stop()
+
+	account, _ := deriveSender(transaction(0, big.NewInt(0), key))
+	state, _ := pool.currentState()
+	state.AddBalance(account, big.NewInt(1000000000))
+	pool.resetState()
+
+	slow, fast := make(chan interface{}), make(chan interface{}, 64)
+	slowSub, fastSub := pool.SubscribeTxEvents(slow), pool.SubscribeTxEvents(fast)
+	defer fastSub.Unsubscribe()
+
+	for i := uint64(0); i < 4; i++ {
+		if err := pool.Add(transaction(i, big.NewInt(100000), key)); err != nil {
+			t.Fatalf("tx %d: failed to add transaction: %v", i, err)
+		}
+	}
+	select {
+	case err := <-slowSub.Err():
+		if err != ErrTxEventsOverflow {
+			t.Fatalf("slow subscriber error mismatch: have %v, want %v", err, ErrTxEventsOverflow)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("slow subscriber not dropped")
+	}
+	if len(fast) != 8 {
+		t.Fatalf("fast subscriber event count mismatch: have %d, want %d", len(fast), 8)
+	}
 }
 
 // Benchmarks the speed of validating the contents of the pending queue of the
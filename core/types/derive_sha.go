@@ -29,13 +29,28 @@ type DerivableList interface {
 	GetRlp(i int) []byte
 }
 
+// DeriveSha computes the root hash of the trie mapping the RLP encoded indices
+// of the list items to their RLP encodings. The items are inserted into a stack
+// trie in the byte order of their keys: rlp(0) = 0x80 sorts after the single
+// byte encodings of 1-127, and before the multi byte encodings from 128 on.
 func DeriveSha(list DerivableList) common.Hash {
-	keybuf := new(bytes.Buffer)
-	trie := new(trie.Trie)
-	for i := 0; i < list.Len(); i++ {
+	var (
+		keybuf = new(bytes.Buffer)
+		st     = trie.NewStackTrie(nil)
+	)
+	update := func(i int) {
 		keybuf.Reset()
 		rlp.Encode(keybuf, uint(i))
-		trie.Update(keybuf.Bytes(), list.GetRlp(i))
+		st.Update(keybuf.Bytes(), list.GetRlp(i))
 	}
-	return trie.Hash()
+	for i := 1; i < list.Len() && i <= 0x7f; i++ {
+		update(i)
+	}
+	if list.Len() > 0 {
+		update(0)
+	}
+	for i := 0x80; i < list.Len(); i++ {
+		update(i)
+	}
+	return st.Hash()
 }
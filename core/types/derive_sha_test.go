@@ -0,0 +1,46 @@
+// Copyright 2017 The daxxcoreAuthors
+// This file is part of the daxxcore library.
+//
+// The daxxcore library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The daxxcore library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the daxxcore library. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/daxxcoin/daxxcore/common"
+	"github.com/daxxcoin/daxxcore/rlp"
+	"github.com/daxxcoin/daxxcore/trie"
+)
+
+// Tests that deriving the root hash of a list through the stack trie yields the
+// same result as inserting the items into a regular trie, especially around the
+// item counts where the RLP encoding of the indices changes.
+func TestDeriveSha(t *testing.T) {
+	for _, n := range []int{0, 1, 2, 127, 128, 129, 256, 300} {
+		txs := make(Transactions, n)
+		for i := range txs {
+			txs[i] = NewTransaction(uint64(i), common.Address{byte(i)}, big.NewInt(int64(i)), big.NewInt(21000), big.NewInt(1), nil)
+		}
+		tr := new(trie.Trie)
+		for i := range txs {
+			key, _ := rlp.EncodeToBytes(uint(i))
+			tr.Update(key, txs.GetRlp(i))
+		}
+		if have, want := DeriveSha(txs), tr.Hash(); have != want {
+			t.Errorf("n=%d: root mismatch: have %x, want %x", n, have, want)
+		}
+	}
+}
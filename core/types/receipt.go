@@ -17,6 +17,7 @@
 package types
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -29,14 +30,28 @@ import (
 )
 
 var (
-	errMissingReceiptPostState = errors.New("missing post state root in JSON receipt")
+	errMissingReceiptPostState = errors.New("missing post state root or status in JSON receipt")
 	errMissingReceiptFields    = errors.New("missing required JSON receipt fields")
 )
 
+var (
+	receiptStatusFailedRLP     = []byte{}
+	receiptStatusSuccessfulRLP = []byte{0x01}
+)
+
+const (
+	// ReceiptStatusFailed is the status code of a transaction if execution failed.
+	ReceiptStatusFailed = uint(0)
+
+	// ReceiptStatusSuccessful is the status code of a transaction if execution succeeded.
+	ReceiptStatusSuccessful = uint(1)
+)
+
 // Receipt represents the results of a transaction.
 type Receipt struct {
 	// Consensus fields
-	PostState         []byte
+	PostState         []byte // Intermediate state root, only set before the Byzantium fork
+	Status            uint   // Execution status code, only meaningful if PostState is empty
 	CumulativeGasUsed *big.Int
 	Bloom             Bloom
 	Logs              []*Log
@@ -48,7 +63,8 @@ type Receipt struct {
 }
 
 type jsonReceipt struct {
-	PostState         *common.Hash    `json:"root"`
+	PostState         *common.Hash    `json:"root,omitempty"`
+	Status            *hexutil.Uint   `json:"status,omitempty"`
 	CumulativeGasUsed *hexutil.Big    `json:"cumulativeGasUsed"`
 	Bloom             *Bloom          `json:"logsBloom"`
 	Logs              []*Log          `json:"logs"`
@@ -58,21 +74,28 @@ type jsonReceipt struct {
 }
 
 // NewReceipt creates a barebone transaction receipt, copying the init fields.
-func NewReceipt(root []byte, cumulativeGasUsed *big.Int) *Receipt {
-	return &Receipt{PostState: common.CopyBytes(root), CumulativeGasUsed: new(big.Int).Set(cumulativeGasUsed)}
+// A nil root yields a post-Byzantium receipt carrying the execution status.
+func NewReceipt(root []byte, failed bool, cumulativeGasUsed *big.Int) *Receipt {
+	r := &Receipt{PostState: common.CopyBytes(root), CumulativeGasUsed: new(big.Int).Set(cumulativeGasUsed)}
+	if failed {
+		r.Status = ReceiptStatusFailed
+	} else {
+		r.Status = ReceiptStatusSuccessful
+	}
+	return r
 }
 
 // EncodeRLP implements rlp.Encoder, and flattens the consensus fields of a receipt
 // into an RLP stream.
 func (r *Receipt) EncodeRLP(w io.Writer) error {
-	return rlp.Encode(w, []interface{}{r.PostState, r.CumulativeGasUsed, r.Bloom, r.Logs})
+	return rlp.Encode(w, []interface{}{r.statusEncoding(), r.CumulativeGasUsed, r.Bloom, r.Logs})
 }
 
 // DecodeRLP implements rlp.Decoder, and loads the consensus fields of a receipt
 // from an RLP stream.
 func (r *Receipt) DecodeRLP(s *rlp.Stream) error {
 	var receipt struct {
-		PostState         []byte
+		PostStateOrStatus []byte
 		CumulativeGasUsed *big.Int
 		Bloom             Bloom
 		Logs              []*Log
@@ -80,23 +103,59 @@ func (r *Receipt) DecodeRLP(s *rlp.Stream) error {
 	if err := s.Decode(&receipt); err != nil {
 		return err
 	}
-	r.PostState, r.CumulativeGasUsed, r.Bloom, r.Logs = receipt.PostState, receipt.CumulativeGasUsed, receipt.Bloom, receipt.Logs
+	if err := r.setStatus(receipt.PostStateOrStatus); err != nil {
+		return err
+	}
+	r.CumulativeGasUsed, r.Bloom, r.Logs = receipt.CumulativeGasUsed, receipt.Bloom, receipt.Logs
 	return nil
 }
 
+// setStatus interprets the first consensus field of a receipt, which is either
+// the intermediate state root (pre-Byzantium) or the execution status code.
+func (r *Receipt) setStatus(postStateOrStatus []byte) error {
+	switch {
+	case bytes.Equal(postStateOrStatus, receiptStatusSuccessfulRLP):
+		r.PostState, r.Status = nil, ReceiptStatusSuccessful
+	case bytes.Equal(postStateOrStatus, receiptStatusFailedRLP):
+		r.PostState, r.Status = nil, ReceiptStatusFailed
+	case len(postStateOrStatus) == len(common.Hash{}):
+		r.PostState = postStateOrStatus
+	default:
+		return fmt.Errorf("invalid receipt status %x", postStateOrStatus)
+	}
+	return nil
+}
+
+// statusEncoding returns the first consensus field of a receipt: the intermediate
+// state root if one is present, otherwise the RLP form of the status code.
+func (r *Receipt) statusEncoding() []byte {
+	if len(r.PostState) == 0 {
+		if r.Status == ReceiptStatusFailed {
+			return receiptStatusFailedRLP
+		}
+		return receiptStatusSuccessfulRLP
+	}
+	return r.PostState
+}
+
 // MarshalJSON encodes receipts into the web3 RPC response block format.
 func (r *Receipt) MarshalJSON() ([]byte, error) {
-	root := common.BytesToHash(r.PostState)
-
-	return json.Marshal(&jsonReceipt{
-		PostState:         &root,
+	enc := &jsonReceipt{
 		CumulativeGasUsed: (*hexutil.Big)(r.CumulativeGasUsed),
 		Bloom:             &r.Bloom,
 		Logs:              r.Logs,
 		TxHash:            &r.TxHash,
 		ContractAddress:   &r.ContractAddress,
 		GasUsed:           (*hexutil.Big)(r.GasUsed),
-	})
+	}
+	if len(r.PostState) > 0 {
+		root := common.BytesToHash(r.PostState)
+		enc.PostState = &root
+	} else {
+		status := hexutil.Uint(r.Status)
+		enc.Status = &status
+	}
+	return json.Marshal(enc)
 }
 
 // UnmarshalJSON decodes the web3 RPC receipt format.
@@ -107,8 +166,9 @@ func (r *Receipt) UnmarshalJSON(input []byte) error {
 	}
 	// Ensure that all fields are set. PostState is checked separately because it is a
 	// recent addition to the RPC spec (as of August 2016) and older implementations might
-	// not provide it. Note that ContractAddress is not checked because it can be null.
-	if dec.PostState == nil {
+	// not provide it, whereas post-Byzantium receipts carry a status code instead. Note
+	// that ContractAddress is not checked because it can be null.
+	if dec.PostState == nil && dec.Status == nil {
 		return errMissingReceiptPostState
 	}
 	if dec.CumulativeGasUsed == nil || dec.Bloom == nil ||
@@ -116,13 +176,18 @@ func (r *Receipt) UnmarshalJSON(input []byte) error {
 		return errMissingReceiptFields
 	}
 	*r = Receipt{
-		PostState:         (*dec.PostState)[:],
 		CumulativeGasUsed: (*big.Int)(dec.CumulativeGasUsed),
 		Bloom:             *dec.Bloom,
 		Logs:              dec.Logs,
 		TxHash:            *dec.TxHash,
 		GasUsed:           (*big.Int)(dec.GasUsed),
 	}
+	if dec.PostState != nil {
+		r.PostState = (*dec.PostState)[:]
+	}
+	if dec.Status != nil {
+		r.Status = uint(*dec.Status)
+	}
 	if dec.ContractAddress != nil {
 		r.ContractAddress = *dec.ContractAddress
 	}
@@ -131,28 +196,97 @@ func (r *Receipt) UnmarshalJSON(input []byte) error {
 
 // String implements the Stringer interface.
 func (r *Receipt) String() string {
+	if len(r.PostState) == 0 {
+		return fmt.Sprintf("receipt{status=%d cgas=%v bloom=%x logs=%v}", r.Status, r.CumulativeGasUsed, r.Bloom, r.Logs)
+	}
 	return fmt.Sprintf("receipt{med=%x cgas=%v bloom=%x logs=%v}", r.PostState, r.CumulativeGasUsed, r.Bloom, r.Logs)
 }
 
-// ReceiptForStorage is a wrapper around a Receipt that flattens and parses the
-// entire content of a receipt, as opposed to only the consensus fields originally.
+// ReceiptForStorage is a wrapper around a Receipt that flattens and parses a
+// receipt in the slim format the block receipts are stored in. Only the consensus
+// fields are stored, without the log bloom; the bloom is recomputed on decoding
+// and the implementation fields can be re-derived from the block the receipt
+// belongs to.
+//
+// Decoding also accepts the legacy storage format of FullReceiptForStorage, so
+// databases written before the slim format remain readable.
 type ReceiptForStorage Receipt
 
+// storedReceiptRLP is the slim storage encoding of a receipt.
+type storedReceiptRLP struct {
+	PostStateOrStatus []byte
+	CumulativeGasUsed *big.Int
+	Logs              []*Log
+}
+
+// EncodeRLP implements rlp.Encoder, and flattens the consensus fields of a receipt
+// into an RLP stream, omitting the log bloom.
+func (r *ReceiptForStorage) EncodeRLP(w io.Writer) error {
+	return rlp.Encode(w, &storedReceiptRLP{
+		PostStateOrStatus: (*Receipt)(r).statusEncoding(),
+		CumulativeGasUsed: r.CumulativeGasUsed,
+		Logs:              r.Logs,
+	})
+}
+
+// DecodeRLP implements rlp.Decoder, and loads the consensus fields of a receipt
+// from an RLP stream in either the slim or the legacy storage format.
+func (r *ReceiptForStorage) DecodeRLP(s *rlp.Stream) error {
+	blob, err := s.Raw()
+	if err != nil {
+		return err
+	}
+	content, _, err := rlp.SplitList(blob)
+	if err != nil {
+		return err
+	}
+	fields, err := rlp.CountValues(content)
+	if err != nil {
+		return err
+	}
+	switch fields {
+	case 3:
+		var stored storedReceiptRLP
+		if err := rlp.DecodeBytes(blob, &stored); err != nil {
+			return err
+		}
+		if err := (*Receipt)(r).setStatus(stored.PostStateOrStatus); err != nil {
+			return err
+		}
+		r.CumulativeGasUsed, r.Logs = stored.CumulativeGasUsed, stored.Logs
+		r.Bloom = CreateBloom(Receipts{(*Receipt)(r)})
+		return nil
+
+	case 7:
+		return rlp.DecodeBytes(blob, (*FullReceiptForStorage)(r))
+
+	default:
+		return fmt.Errorf("invalid stored receipt: %d fields", fields)
+	}
+}
+
+// FullReceiptForStorage is a wrapper around a Receipt that flattens and parses
+// the entire content of a receipt, as opposed to only the consensus fields. It
+// is used for the receipts stored by transaction hash, which are looked up
+// without the block they belong to, and was the format of the block receipts
+// before the slim one.
+type FullReceiptForStorage Receipt
+
 // EncodeRLP implements rlp.Encoder, and flattens all content fields of a receipt
 // into an RLP stream.
-func (r *ReceiptForStorage) EncodeRLP(w io.Writer) error {
+func (r *FullReceiptForStorage) EncodeRLP(w io.Writer) error {
 	logs := make([]*LogForStorage, len(r.Logs))
 	for i, log := range r.Logs {
 		logs[i] = (*LogForStorage)(log)
 	}
-	return rlp.Encode(w, []interface{}{r.PostState, r.CumulativeGasUsed, r.Bloom, r.TxHash, r.ContractAddress, logs, r.GasUsed})
+	return rlp.Encode(w, []interface{}{(*Receipt)(r).statusEncoding(), r.CumulativeGasUsed, r.Bloom, r.TxHash, r.ContractAddress, logs, r.GasUsed})
 }
 
 // DecodeRLP implements rlp.Decoder, and loads both consensus and implementation
 // fields of a receipt from an RLP stream.
-func (r *ReceiptForStorage) DecodeRLP(s *rlp.Stream) error {
+func (r *FullReceiptForStorage) DecodeRLP(s *rlp.Stream) error {
 	var receipt struct {
-		PostState         []byte
+		PostStateOrStatus []byte
 		CumulativeGasUsed *big.Int
 		Bloom             Bloom
 		TxHash            common.Hash
@@ -164,7 +298,10 @@ func (r *ReceiptForStorage) DecodeRLP(s *rlp.Stream) error {
 		return err
 	}
 	// Assign the consensus fields
-	r.PostState, r.CumulativeGasUsed, r.Bloom = receipt.PostState, receipt.CumulativeGasUsed, receipt.Bloom
+	if err := (*Receipt)(r).setStatus(receipt.PostStateOrStatus); err != nil {
+		return err
+	}
+	r.CumulativeGasUsed, r.Bloom = receipt.CumulativeGasUsed, receipt.Bloom
 	r.Logs = make([]*Log, len(receipt.Logs))
 	for i, log := range receipt.Logs {
 		r.Logs[i] = (*Log)(log)
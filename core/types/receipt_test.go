@@ -0,0 +1,143 @@
+// Copyright 2017 The daxxcoreAuthors
+// This file is part of the daxxcore library.
+//
+// The daxxcore library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The daxxcore library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the daxxcore library. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/daxxcoin/daxxcore/common"
+	"github.com/daxxcoin/daxxcore/rlp"
+)
+
+// Tests that receipts carrying either an intermediate state root or a status
+// code survive the consensus, storage and JSON encodings.
+func TestReceiptEncoding(t *testing.T) {
+	root := common.HexToHash("0x0102030405060708091011121314151617181920212223242526272829303132")
+
+	tests := []*Receipt{
+		{PostState: root.Bytes(), CumulativeGasUsed: big.NewInt(1)},
+		NewReceipt(nil, false, big.NewInt(2)),
+		NewReceipt(nil, true, big.NewInt(3)),
+	}
+	for i, want := range tests {
+		want.TxHash = common.BytesToHash([]byte{byte(i)})
+		want.GasUsed = big.NewInt(int64(i))
+		want.Logs = []*Log{}
+
+		// Check the consensus encoding
+		blob, err := rlp.EncodeToBytes(want)
+		if err != nil {
+			t.Fatalf("test %d: failed to encode receipt: %v", i, err)
+		}
+		have := new(Receipt)
+		if err := rlp.DecodeBytes(blob, have); err != nil {
+			t.Fatalf("test %d: failed to decode receipt: %v", i, err)
+		}
+		if !bytes.Equal(have.PostState, want.PostState) || have.Status != want.Status {
+			t.Errorf("test %d: status mismatch: have %x/%d, want %x/%d", i, have.PostState, have.Status, want.PostState, want.Status)
+		}
+		// Check the slim storage encoding, which drops the implementation fields
+		if blob, err = rlp.EncodeToBytes((*ReceiptForStorage)(want)); err != nil {
+			t.Fatalf("test %d: failed to encode stored receipt: %v", i, err)
+		}
+		stored := new(ReceiptForStorage)
+		if err := rlp.DecodeBytes(blob, stored); err != nil {
+			t.Fatalf("test %d: failed to decode stored receipt: %v", i, err)
+		}
+		if !bytes.Equal(stored.PostState, want.PostState) || stored.Status != want.Status {
+			t.Errorf("test %d: stored status mismatch: have %x/%d, want %x/%d", i, stored.PostState, stored.Status, want.PostState, want.Status)
+		}
+		if stored.TxHash != (common.Hash{}) || stored.GasUsed != nil {
+			t.Errorf("test %d: stored implementation fields not dropped: %v", i, stored)
+		}
+		// Check the full storage encoding
+		if blob, err = rlp.EncodeToBytes((*FullReceiptForStorage)(want)); err != nil {
+			t.Fatalf("test %d: failed to encode full stored receipt: %v", i, err)
+		}
+		full := new(FullReceiptForStorage)
+		if err := rlp.DecodeBytes(blob, full); err != nil {
+			t.Fatalf("test %d: failed to decode full stored receipt: %v", i, err)
+		}
+		if !bytes.Equal(full.PostState, want.PostState) || full.Status != want.Status {
+			t.Errorf("test %d: full stored status mismatch: have %x/%d, want %x/%d", i, full.PostState, full.Status, want.PostState, want.Status)
+		}
+		if full.TxHash != want.TxHash || full.GasUsed.Cmp(want.GasUsed) != 0 {
+			t.Errorf("test %d: full stored fields mismatch: have %v, want %v", i, full, want)
+		}
+		// Check the JSON encoding
+		if blob, err = json.Marshal(want); err != nil {
+			t.Fatalf("test %d: failed to marshal receipt: %v", i, err)
+		}
+		have = new(Receipt)
+		if err := json.Unmarshal(blob, have); err != nil {
+			t.Fatalf("test %d: failed to unmarshal receipt: %v", i, err)
+		}
+		if !bytes.Equal(have.PostState, want.PostState) || have.Status != want.Status {
+			t.Errorf("test %d: JSON status mismatch: have %x/%d, want %x/%d", i, have.PostState, have.Status, want.PostState, want.Status)
+		}
+	}
+}
+
+// Tests that the slim storage format recomputes the log bloom, and that receipts
+// stored in the legacy full format can still be decoded.
+func TestStoredReceiptFormats(t *testing.T) {
+	want := NewReceipt(nil, false, big.NewInt(21000))
+	want.Logs = []*Log{{Address: common.Address{0x11}, Topics: []common.Hash{{0x22}}, Data: []byte{0x33}}}
+	want.Bloom = CreateBloom(Receipts{want})
+	want.TxHash = common.Hash{0x44}
+	want.GasUsed = big.NewInt(21000)
+
+	slim, err := rlp.EncodeToBytes([]*ReceiptForStorage{(*ReceiptForStorage)(want)})
+	if err != nil {
+		t.Fatalf("failed to encode slim receipts: %v", err)
+	}
+	legacy, err := rlp.EncodeToBytes([]*FullReceiptForStorage{(*FullReceiptForStorage)(want)})
+	if err != nil {
+		t.Fatalf("failed to encode legacy receipts: %v", err)
+	}
+	if len(slim) >= len(legacy) {
+		t.Errorf("slim encoding not smaller: %d bytes, legacy %d", len(slim), len(legacy))
+	}
+	for name, blob := range map[string][]byte{"slim": slim, "legacy": legacy} {
+		var receipts []*ReceiptForStorage
+		if err := rlp.DecodeBytes(blob, &receipts); err != nil {
+			t.Fatalf("%s: failed to decode receipts: %v", name, err)
+		}
+		if len(receipts) != 1 {
+			t.Fatalf("%s: receipt count mismatch: have %d, want 1", name, len(receipts))
+		}
+		have := receipts[0]
+		if have.Status != want.Status || have.CumulativeGasUsed.Cmp(want.CumulativeGasUsed) != 0 {
+			t.Errorf("%s: consensus fields mismatch: have %v, want %v", name, have, want)
+		}
+		if have.Bloom != want.Bloom {
+			t.Errorf("%s: bloom mismatch: have %x, want %x", name, have.Bloom, want.Bloom)
+		}
+		if len(have.Logs) != 1 || have.Logs[0].Address != want.Logs[0].Address || !bytes.Equal(have.Logs[0].Data, want.Logs[0].Data) {
+			t.Errorf("%s: logs mismatch: have %v, want %v", name, have.Logs, want.Logs)
+		}
+	}
+	// Re-encoding a legacy receipt must yield the slim format
+	var receipts []*ReceiptForStorage
+	rlp.DecodeBytes(legacy, &receipts)
+	if blob, _ := rlp.EncodeToBytes(receipts); !bytes.Equal(blob, slim) {
+		t.Errorf("re-encoded legacy receipts mismatch: have %x, want %x", blob, slim)
+	}
+}
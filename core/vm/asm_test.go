@@ -0,0 +1,41 @@
+// Copyright 2017 The daxxcoreAuthors
+// This file is part of the daxxcore library.
+//
+// The daxxcore library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The daxxcore library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the daxxcore library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"reflect"
+	"testing"
+)
+
+// Tests that the disassembler and the opcode name tables know about the
+// Constantinople instructions.
+func TestDisassembleConstantinople(t *testing.T) {
+	code := []byte{
+		byte(PUSH1), 0x01, byte(PUSH1), 0x02, byte(SHL),
+		byte(PUSH1), 0x01, byte(SHR), byte(PUSH1), 0x01, byte(SAR),
+		byte(EXTCODEHASH), byte(CREATE2),
+	}
+	want := []string{"PUSH1", "0x01", "PUSH1", "0x02", "SHL", "PUSH1", "0x01", "SHR", "PUSH1", "0x01", "SAR", "EXTCODEHASH", "CREATE2"}
+	if have := Disassemble(code); !reflect.DeepEqual(have, want) {
+		t.Errorf("disassembly mismatch:\nhave %v\nwant %v", have, want)
+	}
+	for _, op := range []OpCode{SHL, SHR, SAR, EXTCODEHASH, CREATE2} {
+		if have := StringToOp(op.String()); have != op {
+			t.Errorf("%v: name round trip mismatch: have %#x, want %#x", op, byte(have), byte(op))
+		}
+	}
+}
@@ -43,6 +43,8 @@ var (
 	One  = common.Big1 // Shortcut to common.Big1
 
 	max = big.NewInt(math.MaxInt64) // Maximum 64 bit integer
+
+	big256 = big.NewInt(256) // Shift amounts at or above this clear the word
 )
 
 // calculates the memory size required for a step
@@ -106,6 +108,20 @@ func getData(data []byte, start, size *big.Int) []byte {
 	return common.RightPadBytes(data[s.Uint64():e.Uint64()], int(size.Uint64()))
 }
 
+// getDataUint64 is a uint64 variant of getData, returning a zero padded slice
+// of data based on the start and size.
+func getDataUint64(data []byte, start, size uint64) []byte {
+	length := uint64(len(data))
+	if start > length {
+		start = length
+	}
+	end := start + size
+	if end > length || end < start {
+		end = length
+	}
+	return common.RightPadBytes(data[start:end], int(size))
+}
+
 // useGas attempts to subtract the amount of gas and returns whether it was
 // successful
 func useGas(gas, amount *big.Int) bool {
@@ -0,0 +1,258 @@
+// Copyright 2017 The daxxcoreAuthors
+// This file is part of the daxxcore library.
+//
+// The daxxcore library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The daxxcore library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the daxxcore library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/daxxcoin/daxxcore/common"
+	"github.com/daxxcoin/daxxcore/params"
+)
+
+// precompiledTest defines the input/output pairs for precompiled contract tests.
+type precompiledTest struct {
+	name     string
+	input    string
+	expected string
+	gas      uint64
+}
+
+var bigModExpTests = []precompiledTest{
+	{
+		name: "eip_example1",
+		input: "00000000000000000000000000000000000000000000000000000000000000010000000000000000000000000000000000000000000000000000000000000020" +
+			"000000000000000000000000000000000000000000000000000000000000002003fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc" +
+			"2efffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2f",
+		expected: "0000000000000000000000000000000000000000000000000000000000000001",
+		gas:      13056,
+	},
+	{
+		name: "eip_example2",
+		input: "00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000020" +
+			"0000000000000000000000000000000000000000000000000000000000000020fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2e" +
+			"fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2f",
+		expected: "0000000000000000000000000000000000000000000000000000000000000000",
+		gas:      13056,
+	},
+	{
+		name: "small",
+		input: "00000000000000000000000000000000000000000000000000000000000000010000000000000000000000000000000000000000000000000000000000000001" +
+			"0000000000000000000000000000000000000000000000000000000000000001020507",
+		expected: "04",
+		gas:      0,
+	},
+	{
+		name: "zero_modulus",
+		input: "00000000000000000000000000000000000000000000000000000000000000010000000000000000000000000000000000000000000000000000000000000001" +
+			"0000000000000000000000000000000000000000000000000000000000000001020500",
+		expected: "00",
+		gas:      0,
+	},
+}
+
+var bn256AddTests = []precompiledTest{
+	{
+		name: "double",
+		input: "00000000000000000000000000000000000000000000000000000000000000010000000000000000000000000000000000000000000000000000000000000002" +
+			"00000000000000000000000000000000000000000000000000000000000000010000000000000000000000000000000000000000000000000000000000000002",
+		expected: "030644e72e131a029b85045b68181585d97816a916871ca8d3c208c16d87cfd315ed738c0e0a7c92e7845f96b2ae9c0a68a6a449e3538fc7ff3ebf7a5a18a2c4",
+		gas:      500,
+	},
+	{
+		name: "add",
+		input: "118c7a14188755cb285f38c9a3416340925c49b322fecd8ac879256bfd25d4f81c4f00185ffac2a999df2683fa5a886a964d908c95488b3f76f574f7fb3b77ed" +
+			"29b5b4c0d71e762ffce3daaa4c07f16731f1f52eb8f7875662b38cbf62519fb30ad6352700213b61e46d18e0a3a03080ca42aa9c2b9ab8f77e1d0eb507a3010e",
+		expected: "1545f55128b2c5ff0f47b87ffffb5f22b3faf4245dc5b07b8836ed14867824f109af2a9740e08fd9f8711f2c0ba0826e7ebd2391d38812a4b9b565318bd27cef",
+		gas:      500,
+	},
+	{
+		name: "add_infinity",
+		input: "118c7a14188755cb285f38c9a3416340925c49b322fecd8ac879256bfd25d4f81c4f00185ffac2a999df2683fa5a886a964d908c95488b3f76f574f7fb3b77ed" +
+			"00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000",
+		expected: "118c7a14188755cb285f38c9a3416340925c49b322fecd8ac879256bfd25d4f81c4f00185ffac2a999df2683fa5a886a964d908c95488b3f76f574f7fb3b77ed",
+		gas:      500,
+	},
+	{
+		name: "add_negation",
+		input: "118c7a14188755cb285f38c9a3416340925c49b322fecd8ac879256bfd25d4f81c4f00185ffac2a999df2683fa5a886a964d908c95488b3f76f574f7fb3b77ed" +
+			"118c7a14188755cb285f38c9a3416340925c49b322fecd8ac879256bfd25d4f814154e5a8136dd801e711f328726cff30133da04d3293f4dc52b171edd41855a",
+		expected: "00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000",
+		gas:      500,
+	},
+	{
+		name:     "empty",
+		input:    "",
+		expected: "00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000",
+		gas:      500,
+	},
+}
+
+var bn256ScalarMulTests = []precompiledTest{
+	{
+		name: "mul",
+		input: "118c7a14188755cb285f38c9a3416340925c49b322fecd8ac879256bfd25d4f81c4f00185ffac2a999df2683fa5a886a964d908c95488b3f76f574f7fb3b77ed" +
+			"000000000000000000000000000000000000000000000000fedcba0987654321",
+		expected: "2f06193d63a5d284a26f6d6a14f27ab8f8b93d431c14e0d00cc3a72ae17f79311778e0278ad159a19115f0e7c0ad5a93290a18823245978f87e2e470db73ed1f",
+		gas:      40000,
+	},
+	{
+		name: "mul_zero",
+		input: "118c7a14188755cb285f38c9a3416340925c49b322fecd8ac879256bfd25d4f81c4f00185ffac2a999df2683fa5a886a964d908c95488b3f76f574f7fb3b77ed" +
+			"0000000000000000000000000000000000000000000000000000000000000000",
+		expected: "00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000",
+		gas:      40000,
+	},
+}
+
+var bn256PairingTests = []precompiledTest{
+	{
+		name:     "empty",
+		input:    "",
+		expected: "0000000000000000000000000000000000000000000000000000000000000001",
+		gas:      100000,
+	},
+	{
+		name: "two_point_match",
+		input: "118c7a14188755cb285f38c9a3416340925c49b322fecd8ac879256bfd25d4f81c4f00185ffac2a999df2683fa5a886a964d908c95488b3f76f574f7fb3b77ed" +
+			"2ffcab8638d3fa85bfd64350cf8ccfc47b3e79ff4cf3955080b57ffa14d13ae42dba22f273ae488cca6451a36601183a50d36bff86eaa488743ab2fdfbd427bc" +
+			"17ba3db9cf1944ebc55c322ffc563331e30447da26ffe3a8c0029e501278c462076ee51d506056fab36cbffb9ab7cb36d5be2c57aa743d36a607a9a829985808" +
+			"2f06193d63a5d284a26f6d6a14f27ab8f8b93d431c14e0d00cc3a72ae17f793118eb6e4b56604688273a54cec0d3fdca6e77520f362c32fdb43da7a5fd091028" +
+			"198e9393920d483a7260bfb731fb5d25f1aa493335a9e71297e485b7aef312c21800deef121f1e76426a00665e5c4479674322d4f75edadd46debd5cd992f6ed" +
+			"090689d0585ff075ec9e99ad690c3395bc4b313370b38ef355acdadcd122975b12c85ea5db8c6deb4aab71808dcb408fe3d1e7690c43d37b4ce6cc0166fa7daa",
+		expected: "0000000000000000000000000000000000000000000000000000000000000001",
+		gas:      260000,
+	},
+	{
+		name: "two_point_mismatch",
+		input: "118c7a14188755cb285f38c9a3416340925c49b322fecd8ac879256bfd25d4f81c4f00185ffac2a999df2683fa5a886a964d908c95488b3f76f574f7fb3b77ed" +
+			"2ffcab8638d3fa85bfd64350cf8ccfc47b3e79ff4cf3955080b57ffa14d13ae42dba22f273ae488cca6451a36601183a50d36bff86eaa488743ab2fdfbd427bc" +
+			"17ba3db9cf1944ebc55c322ffc563331e30447da26ffe3a8c0029e501278c462076ee51d506056fab36cbffb9ab7cb36d5be2c57aa743d36a607a9a829985808" +
+			"2f06193d63a5d284a26f6d6a14f27ab8f8b93d431c14e0d00cc3a72ae17f79311778e0278ad159a19115f0e7c0ad5a93290a18823245978f87e2e470db73ed1f" +
+			"198e9393920d483a7260bfb731fb5d25f1aa493335a9e71297e485b7aef312c21800deef121f1e76426a00665e5c4479674322d4f75edadd46debd5cd992f6ed" +
+			"090689d0585ff075ec9e99ad690c3395bc4b313370b38ef355acdadcd122975b12c85ea5db8c6deb4aab71808dcb408fe3d1e7690c43d37b4ce6cc0166fa7daa",
+		expected: "0000000000000000000000000000000000000000000000000000000000000000",
+		gas:      260000,
+	},
+	{
+		name: "one_point",
+		input: "00000000000000000000000000000000000000000000000000000000000000010000000000000000000000000000000000000000000000000000000000000002" +
+			"198e9393920d483a7260bfb731fb5d25f1aa493335a9e71297e485b7aef312c21800deef121f1e76426a00665e5c4479674322d4f75edadd46debd5cd992f6ed" +
+			"090689d0585ff075ec9e99ad690c3395bc4b313370b38ef355acdadcd122975b12c85ea5db8c6deb4aab71808dcb408fe3d1e7690c43d37b4ce6cc0166fa7daa",
+		expected: "0000000000000000000000000000000000000000000000000000000000000000",
+		gas:      180000,
+	},
+}
+
+// bn256InvalidTests contains inputs the bn256 contracts must reject.
+var bn256InvalidTests = []struct {
+	addr  string
+	name  string
+	input string
+}{
+	{
+		addr:  "06",
+		name:  "point_off_curve",
+		input: "0000000000000000000000000000000000000000000000000000000000000001" + "0000000000000000000000000000000000000000000000000000000000000001",
+	},
+	{
+		addr:  "07",
+		name:  "coordinate_overflow",
+		input: "30644e72e131a029b85045b68181585d97816a916871ca8d3c208c16d87cfd47" + "0000000000000000000000000000000000000000000000000000000000000002",
+	},
+	{
+		addr:  "08",
+		name:  "bad_size",
+		input: "0000000000000000000000000000000000000000000000000000000000000001" + "0000000000000000000000000000000000000000000000000000000000000002",
+	},
+}
+
+func testPrecompiled(addr string, test precompiledTest, t *testing.T) {
+	p := PrecompiledContractsByzantium[common.HexToAddress(addr)]
+	in := common.Hex2Bytes(test.input)
+	contract := NewContract(&dummyContractRef{}, &dummyContractRef{}, new(big.Int), new(big.Int).Set(p.RequiredGas(in)))
+	t.Run(fmt.Sprintf("%s-Gas=%d", test.name, contract.Gas.Uint64()), func(t *testing.T) {
+		if gas := p.RequiredGas(in); gas.Uint64() != test.gas {
+			t.Errorf("gas mismatch: have %v, want %d", gas, test.gas)
+		}
+		if res, err := RunPrecompiledContract(p, in, contract); err != nil {
+			t.Error(err)
+		} else if !bytes.Equal(res, common.Hex2Bytes(test.expected)) {
+			t.Errorf("result mismatch: have %x, want %s", res, test.expected)
+		}
+	})
+}
+
+func TestPrecompiledModExp(t *testing.T) {
+	for _, test := range bigModExpTests {
+		testPrecompiled("05", test, t)
+	}
+}
+
+func TestPrecompiledBn256Add(t *testing.T) {
+	for _, test := range bn256AddTests {
+		testPrecompiled("06", test, t)
+	}
+}
+
+func TestPrecompiledBn256ScalarMul(t *testing.T) {
+	for _, test := range bn256ScalarMulTests {
+		testPrecompiled("07", test, t)
+	}
+}
+
+func TestPrecompiledBn256Pairing(t *testing.T) {
+	for _, test := range bn256PairingTests {
+		testPrecompiled("08", test, t)
+	}
+}
+
+func TestPrecompiledBn256Invalid(t *testing.T) {
+	for _, test := range bn256InvalidTests {
+		p := PrecompiledContractsByzantium[common.HexToAddress(test.addr)]
+		in := common.Hex2Bytes(test.input)
+		contract := NewContract(&dummyContractRef{}, &dummyContractRef{}, new(big.Int), new(big.Int).Set(p.RequiredGas(in)))
+		if _, err := RunPrecompiledContract(p, in, contract); err == nil {
+			t.Errorf("%s: expected failure", test.name)
+		}
+	}
+}
+
+func TestPrecompiledForkActivation(t *testing.T) {
+	config := &params.ChainConfig{ChainId: big.NewInt(1), ByzantiumBlock: big.NewInt(10)}
+	for _, tt := range []struct {
+		number *big.Int
+		count  int
+	}{
+		{big.NewInt(9), 4},
+		{big.NewInt(10), 8},
+		{big.NewInt(11), 8},
+	} {
+		env := NewEVM(Context{BlockNumber: tt.number}, nil, config, Config{})
+		precompiles := env.Precompiles()
+		if len(precompiles) != tt.count {
+			t.Errorf("block %v: precompile count mismatch: have %d, want %d", tt.number, len(precompiles), tt.count)
+		}
+		for i := byte(1); i <= byte(tt.count); i++ {
+			if precompiles[common.BytesToAddress([]byte{i})] == nil {
+				t.Errorf("block %v: precompile %d missing", tt.number, i)
+			}
+		}
+	}
+}
@@ -26,6 +26,10 @@ import (
 	"github.com/daxxcoin/daxxcore/params"
 )
 
+// emptyCodeHash is used by create to ensure deployment is disallowed to already
+// deployed contract addresses (relevant after the account abstraction).
+var emptyCodeHash = crypto.Keccak256Hash(nil)
+
 type (
 	CanTransferFunc func(StateDB, common.Address, *big.Int) bool
 	TransferFunc    func(StateDB, common.Address, common.Address, *big.Int)
@@ -93,6 +97,15 @@ func NewEVM(ctx Context, statedb StateDB, chainConfig *params.ChainConfig, vmCon
 	return evm
 }
 
+// Precompiles returns the set of precompiled contracts active at the block
+// number the EVM is executing in.
+func (evm *EVM) Precompiles() map[common.Address]PrecompiledContract {
+	if evm.ChainConfig().IsByzantium(evm.BlockNumber) {
+		return PrecompiledContractsByzantium
+	}
+	return PrecompiledContractsHomestead
+}
+
 // Cancel cancels any running EVM operation. This may be called concurrently and it's safe to be
 // called multiple times.
 func (evm *EVM) Cancel() {
@@ -127,7 +140,7 @@ func (evm *EVM) Call(caller ContractRef, addr common.Address, input []byte, gas,
 		snapshot = evm.StateDB.Snapshot()
 	)
 	if !evm.StateDB.Exist(addr) {
-		if PrecompiledContracts[addr] == nil && evm.ChainConfig().IsEIP158(evm.BlockNumber) && value.BitLen() == 0 {
+		if evm.Precompiles()[addr] == nil && evm.ChainConfig().IsEIP158(evm.BlockNumber) && value.BitLen() == 0 {
 			caller.ReturnGas(gas)
 			return nil, nil
 		}
@@ -150,8 +163,9 @@ func (evm *EVM) Call(caller ContractRef, addr common.Address, input []byte, gas,
 	// above we revert to the snapshot and consume any gas remaining. Additionally
 	// when we're in homestead this also counts for code storage gas errors.
 	if err != nil {
-		contract.UseGas(contract.Gas)
-
+		if err != ErrExecutionReverted {
+			contract.UseGas(contract.Gas)
+		}
 		evm.StateDB.RevertToSnapshot(snapshot)
 	}
 	return ret, err
@@ -195,8 +209,9 @@ func (evm *EVM) CallCode(caller ContractRef, addr common.Address, input []byte,
 
 	ret, err = evm.interpreter.Run(contract, input)
 	if err != nil {
-		contract.UseGas(contract.Gas)
-
+		if err != ErrExecutionReverted {
+			contract.UseGas(contract.Gas)
+		}
 		evm.StateDB.RevertToSnapshot(snapshot)
 	}
 
@@ -234,14 +249,73 @@ func (evm *EVM) DelegateCall(caller ContractRef, addr common.Address, input []by
 
 	ret, err = evm.interpreter.Run(contract, input)
 	if err != nil {
-		contract.UseGas(contract.Gas)
-
+		if err != ErrExecutionReverted {
+			contract.UseGas(contract.Gas)
+		}
 		evm.StateDB.RevertToSnapshot(snapshot)
 	}
 
 	return ret, err
 }
 
+// StaticCall executes the contract associated with the addr with the given input
+// as parameters while disallowing any modifications to the state during the call.
+// Opcodes that attempt to perform such modifications will result in exceptions
+// instead of performing the modifications.
+func (evm *EVM) StaticCall(caller ContractRef, addr common.Address, input []byte, gas *big.Int) (ret []byte, err error) {
+	if evm.vmConfig.NoRecursion && evm.depth > 0 {
+		caller.ReturnGas(gas)
+
+		return nil, nil
+	}
+
+	// Depth check execution. Fail if we're trying to execute above the
+	// limit.
+	if evm.depth > int(params.CallCreateDepth.Int64()) {
+		caller.ReturnGas(gas)
+		return nil, ErrDepth
+	}
+	// Make sure the readonly is only set if we aren't in readonly yet
+	// this makes also sure that the readonly flag isn't removed for
+	// child calls.
+	if !evm.interpreter.readOnly {
+		evm.interpreter.readOnly = true
+		defer func() { evm.interpreter.readOnly = false }()
+	}
+
+	var (
+		to       Account
+		snapshot = evm.StateDB.Snapshot()
+	)
+	if !evm.StateDB.Exist(addr) {
+		if evm.Precompiles()[addr] == nil && evm.ChainConfig().IsEIP158(evm.BlockNumber) {
+			caller.ReturnGas(gas)
+			return nil, nil
+		}
+		to = evm.StateDB.CreateAccount(addr)
+	} else {
+		to = evm.StateDB.GetAccount(addr)
+	}
+	// Initialise a new contract and set the code that is to be used by the
+	// EVM. The contract is a scoped environment for this execution context
+	// only.
+	contract := NewContract(caller, to, new(big.Int), gas)
+	contract.SetCallCode(&addr, evm.StateDB.GetCodeHash(addr), evm.StateDB.GetCode(addr))
+	defer contract.Finalise()
+
+	// When an error was returned by the EVM or when setting the creation code
+	// above we revert to the snapshot and consume any gas remaining. Additionally
+	// when we're in Homestead this also counts for code storage gas errors.
+	ret, err = evm.interpreter.Run(contract, input)
+	if err != nil {
+		if err != ErrExecutionReverted {
+			contract.UseGas(contract.Gas)
+		}
+		evm.StateDB.RevertToSnapshot(snapshot)
+	}
+	return ret, err
+}
+
 // Create creates a new contract using code as deployment code.
 func (evm *EVM) Create(caller ContractRef, code []byte, gas, value *big.Int) (ret []byte, contractAddr common.Address, err error) {
 	if evm.vmConfig.NoRecursion && evm.depth > 0 {
@@ -267,8 +341,54 @@ func (evm *EVM) Create(caller ContractRef, code []byte, gas, value *big.Int) (re
 	nonce := evm.StateDB.GetNonce(caller.Address())
 	evm.StateDB.SetNonce(caller.Address(), nonce+1)
 
+	return evm.create(caller, code, gas, value, crypto.CreateAddress(caller.Address(), nonce))
+}
+
+// Create2 creates a new contract using code as deployment code.
+//
+// The difference between Create2 and Create is that Create2 uses sha3(0xff ++ msg.sender ++ salt ++ sha3(init_code))[12:]
+// instead of the usual sender-and-nonce-hash as the address where the contract is initialized at.
+func (evm *EVM) Create2(caller ContractRef, code []byte, gas, value, salt *big.Int) (ret []byte, contractAddr common.Address, err error) {
+	if evm.vmConfig.NoRecursion && evm.depth > 0 {
+		caller.ReturnGas(gas)
+
+		return nil, common.Address{}, nil
+	}
+
+	// Depth check execution. Fail if we're trying to execute above the
+	// limit.
+	if evm.depth > int(params.CallCreateDepth.Int64()) {
+		caller.ReturnGas(gas)
+
+		return nil, common.Address{}, ErrDepth
+	}
+	if !evm.CanTransfer(evm.StateDB, caller.Address(), value) {
+		caller.ReturnGas(gas)
+
+		return nil, common.Address{}, ErrInsufficientBalance
+	}
+
+	// Bump the sender's nonce as with any other contract creation
+	nonce := evm.StateDB.GetNonce(caller.Address())
+	evm.StateDB.SetNonce(caller.Address(), nonce+1)
+
+	contractAddr = crypto.CreateAddress2(caller.Address(), common.BigToHash(salt), crypto.Keccak256(code))
+	return evm.create(caller, code, gas, value, contractAddr)
+}
+
+// create creates a new contract at the given address using code as deployment
+// code.
+func (evm *EVM) create(caller ContractRef, code []byte, gas, value *big.Int, contractAddr common.Address) ([]byte, common.Address, error) {
+	// Ensure there's no existing contract already at the designated address. As
+	// CREATE2 makes addresses predictable this is enforced from Constantinople.
+	if evm.ChainConfig().IsConstantinople(evm.BlockNumber) {
+		contractHash := evm.StateDB.GetCodeHash(contractAddr)
+		if evm.StateDB.GetNonce(contractAddr) != 0 || (contractHash != (common.Hash{}) && contractHash != emptyCodeHash) {
+			caller.ReturnGas(gas)
+			return nil, common.Address{}, ErrContractAddressCollision
+		}
+	}
 	snapshot := evm.StateDB.Snapshot()
-	contractAddr = crypto.CreateAddress(caller.Address(), nonce)
 	to := evm.StateDB.CreateAccount(contractAddr)
 	if evm.ChainConfig().IsEIP158(evm.BlockNumber) {
 		evm.StateDB.SetNonce(contractAddr, 1)
@@ -282,7 +402,7 @@ func (evm *EVM) Create(caller ContractRef, code []byte, gas, value *big.Int) (re
 	contract.SetCallCode(&contractAddr, crypto.Keccak256Hash(code), code)
 	defer contract.Finalise()
 
-	ret, err = evm.interpreter.Run(contract, nil)
+	ret, err := evm.interpreter.Run(contract, nil)
 
 	// check whether the max code size has been exceeded
 	maxCodeSizeExceeded := len(ret) > params.MaxCodeSize
@@ -305,10 +425,14 @@ func (evm *EVM) Create(caller ContractRef, code []byte, gas, value *big.Int) (re
 	// when we're in homestead this also counts for code storage gas errors.
 	if maxCodeSizeExceeded ||
 		(err != nil && (evm.ChainConfig().IsHomestead(evm.BlockNumber) || err != ErrCodeStoreOutOfGas)) {
-		contract.UseGas(contract.Gas)
 		evm.StateDB.RevertToSnapshot(snapshot)
 
-		// Nothing should be returned when an error is thrown.
+		// A reverted creation keeps its remaining gas and hands back the
+		// revert reason, nothing else should be returned when an error is thrown.
+		if err == ErrExecutionReverted {
+			return ret, contractAddr, err
+		}
+		contract.UseGas(contract.Gas)
 		return nil, contractAddr, err
 	}
 	// If the vm returned with an error the return value should be set to nil.
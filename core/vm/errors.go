@@ -24,4 +24,10 @@ var (
 	ErrDepth               = errors.New("max call depth exceeded")
 	ErrTraceLimitReached   = errors.New("the number of logs reached the specified limit")
 	ErrInsufficientBalance = errors.New("insufficient balance for transfer")
+
+	ErrContractAddressCollision = errors.New("contract address collision")
+
+	ErrExecutionReverted     = errors.New("evm: execution reverted")
+	ErrWriteProtection       = errors.New("evm: write protection")
+	ErrReturnDataOutOfBounds = errors.New("evm: return data out of bounds")
 )
@@ -47,6 +47,14 @@ func gasCalldataCopy(gt params.GasTable, env *EVM, contract *Contract, stack *St
 	return gas.Add(gas, words.Mul(words, params.CopyGas))
 }
 
+func gasReturnDataCopy(gt params.GasTable, env *EVM, contract *Contract, stack *Stack, mem *Memory, memorySize *big.Int) *big.Int {
+	gas := memoryGasCost(mem, memorySize)
+	gas.Add(gas, GasFastestStep)
+	words := toWordSize(stack.Back(2))
+
+	return gas.Add(gas, words.Mul(words, params.CopyGas))
+}
+
 func gasSStore(gt params.GasTable, env *EVM, contract *Contract, stack *Stack, mem *Memory, memorySize *big.Int) *big.Int {
 	var (
 		y, x = stack.Back(1), stack.Back(0)
@@ -119,6 +127,13 @@ func gasCreate(gt params.GasTable, env *EVM, contract *Contract, stack *Stack, m
 	return new(big.Int).Add(params.CreateGas, memoryGasCost(mem, memorySize))
 }
 
+func gasCreate2(gt params.GasTable, env *EVM, contract *Contract, stack *Stack, mem *Memory, memorySize *big.Int) *big.Int {
+	gas := memoryGasCost(mem, memorySize)
+	gas.Add(gas, params.Create2Gas)
+	words := toWordSize(stack.Back(2))
+	return gas.Add(gas, words.Mul(words, params.Sha3WordGas))
+}
+
 func gasBalance(gt params.GasTable, env *EVM, contract *Contract, stack *Stack, mem *Memory, memorySize *big.Int) *big.Int {
 	return gt.Balance
 }
@@ -127,6 +142,10 @@ func gasExtCodeSize(gt params.GasTable, env *EVM, contract *Contract, stack *Sta
 	return gt.ExtcodeSize
 }
 
+func gasExtCodeHash(gt params.GasTable, env *EVM, contract *Contract, stack *Stack, mem *Memory, memorySize *big.Int) *big.Int {
+	return gt.ExtcodeHash
+}
+
 func gasSLoad(gt params.GasTable, env *EVM, contract *Contract, stack *Stack, mem *Memory, memorySize *big.Int) *big.Int {
 	return gt.SLoad
 }
@@ -193,6 +212,10 @@ func gasReturn(gt params.GasTable, env *EVM, contract *Contract, stack *Stack, m
 	return memoryGasCost(mem, memorySize)
 }
 
+func gasRevert(gt params.GasTable, env *EVM, contract *Contract, stack *Stack, mem *Memory, memorySize *big.Int) *big.Int {
+	return memoryGasCost(mem, memorySize)
+}
+
 func gasSuicide(gt params.GasTable, env *EVM, contract *Contract, stack *Stack, mem *Memory, memorySize *big.Int) *big.Int {
 	gas := new(big.Int)
 	// EIP150 homestead gas reprice fork:
@@ -233,6 +256,20 @@ func gasDelegateCall(gt params.GasTable, env *EVM, contract *Contract, stack *St
 	return gas.Add(gas, cg)
 }
 
+func gasStaticCall(gt params.GasTable, env *EVM, contract *Contract, stack *Stack, mem *Memory, memorySize *big.Int) *big.Int {
+	gas := new(big.Int).Add(gt.Calls, memoryGasCost(mem, memorySize))
+
+	cg := callGas(gt, contract.Gas, gas, stack.data[stack.len()-1])
+	// Replace the stack item with the new gas calculation. This means that
+	// either the original item is left on the stack or the item is replaced by:
+	// (availableGas - gas) * 63 / 64
+	// We replace the stack item so that it's available when the opStaticCall
+	// instruction is called.
+	stack.data[stack.len()-1] = cg
+
+	return gas.Add(gas, cg)
+}
+
 func gasPush(gt params.GasTable, env *EVM, contract *Contract, stack *Stack, mem *Memory, memorySize *big.Int) *big.Int {
 	return GasFastestStep
 }
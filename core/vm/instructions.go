@@ -222,6 +222,50 @@ func opByte(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack *Sta
 	}
 	return nil, nil
 }
+
+// opSHL implements Shift Left
+// The SHL instruction (shift left) pops 2 values from the stack, first arg1 and then arg2,
+// and pushes on the stack arg2 shifted to the left by arg1 number of bits.
+func opSHL(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
+	shift, value := stack.pop(), stack.pop()
+	if shift.Cmp(big256) >= 0 {
+		stack.push(new(big.Int))
+		return nil, nil
+	}
+	stack.push(U256(value.Lsh(value, uint(shift.Uint64()))))
+	return nil, nil
+}
+
+// opSHR implements Logical Shift Right
+// The SHR instruction (logical shift right) pops 2 values from the stack, first arg1 and then arg2,
+// and pushes on the stack arg2 shifted to the right by arg1 number of bits with zero fill.
+func opSHR(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
+	shift, value := stack.pop(), stack.pop()
+	if shift.Cmp(big256) >= 0 {
+		stack.push(new(big.Int))
+		return nil, nil
+	}
+	stack.push(value.Rsh(value, uint(shift.Uint64())))
+	return nil, nil
+}
+
+// opSAR implements Arithmetic Shift Right
+// The SAR instruction (arithmetic shift right) pops 2 values from the stack, first arg1 and then arg2,
+// and pushes on the stack arg2 shifted to the right by arg1 number of bits with sign extension.
+func opSAR(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
+	shift, value := stack.pop(), S256(stack.pop())
+	if shift.Cmp(big256) >= 0 {
+		if value.Sign() >= 0 {
+			stack.push(new(big.Int))
+		} else {
+			stack.push(U256(big.NewInt(-1)))
+		}
+		return nil, nil
+	}
+	stack.push(U256(value.Rsh(value, uint(shift.Uint64()))))
+	return nil, nil
+}
+
 func opAddmod(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
 	x, y, z := stack.pop(), stack.pop(), stack.pop()
 	if z.Cmp(Zero) > 0 {
@@ -306,6 +350,25 @@ func opCalldataCopy(pc *uint64, env *EVM, contract *Contract, memory *Memory, st
 	return nil, nil
 }
 
+func opReturnDataSize(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
+	stack.push(big.NewInt(int64(len(env.interpreter.returnData))))
+	return nil, nil
+}
+
+func opReturnDataCopy(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
+	var (
+		mOff = stack.pop()
+		dOff = stack.pop()
+		l    = stack.pop()
+	)
+	end := new(big.Int).Add(dOff, l)
+	if end.BitLen() > 64 || uint64(len(env.interpreter.returnData)) < end.Uint64() {
+		return nil, ErrReturnDataOutOfBounds
+	}
+	memory.Set(mOff.Uint64(), l.Uint64(), env.interpreter.returnData[dOff.Uint64():end.Uint64()])
+	return nil, nil
+}
+
 func opExtCodeSize(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
 	addr := common.BigToAddress(stack.pop())
 	l := big.NewInt(int64(env.StateDB.GetCodeSize(addr)))
@@ -313,6 +376,19 @@ func opExtCodeSize(pc *uint64, env *EVM, contract *Contract, memory *Memory, sta
 	return nil, nil
 }
 
+// opExtCodeHash pushes the code hash of the given account, or zero if the
+// account does not exist or is empty. An existing account without code yields
+// the hash of the empty code.
+func opExtCodeHash(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
+	addr := common.BigToAddress(stack.pop())
+	if env.StateDB.Empty(addr) {
+		stack.push(new(big.Int))
+	} else {
+		stack.push(env.StateDB.GetCodeHash(addr).Big())
+	}
+	return nil, nil
+}
+
 func opCodeSize(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
 	l := big.NewInt(int64(len(contract.Code)))
 	stack.push(l)
@@ -479,7 +555,7 @@ func opCreate(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack *S
 	}
 
 	contract.UseGas(gas)
-	_, addr, suberr := env.Create(contract, input, gas, value)
+	res, addr, suberr := env.Create(contract, input, gas, value)
 	// Push item on the stack based on the returned error. If the ruleset is
 	// homestead we must check for CodeStoreOutOfGasError (homestead only
 	// rule) and treat as an error, if the ruleset is frontier we must
@@ -491,6 +567,37 @@ func opCreate(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack *S
 	} else {
 		stack.push(addr.Big())
 	}
+	// Only a reverted creation exposes its output as return data
+	if suberr == ErrExecutionReverted {
+		return res, nil
+	}
+	return nil, nil
+}
+
+func opCreate2(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
+	var (
+		endowment    = stack.pop()
+		offset, size = stack.pop(), stack.pop()
+		salt         = stack.pop()
+		input        = memory.Get(offset.Int64(), size.Int64())
+		gas          = new(big.Int).Set(contract.Gas)
+	)
+	// Apply EIP150
+	gas.Div(gas, n64)
+	gas = gas.Sub(contract.Gas, gas)
+
+	contract.UseGas(gas)
+	res, addr, suberr := env.Create2(contract, input, gas, endowment, salt)
+	// Push item on the stack based on the returned error.
+	if suberr != nil {
+		stack.push(new(big.Int))
+	} else {
+		stack.push(addr.Big())
+	}
+	// Only a reverted creation exposes its output as return data
+	if suberr == ErrExecutionReverted {
+		return res, nil
+	}
 	return nil, nil
 }
 
@@ -517,13 +624,13 @@ func opCall(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack *Sta
 
 	if err != nil {
 		stack.push(new(big.Int))
-
 	} else {
 		stack.push(big.NewInt(1))
-
+	}
+	if err == nil || err == ErrExecutionReverted {
 		memory.Set(retOffset.Uint64(), retSize.Uint64(), ret)
 	}
-	return nil, nil
+	return ret, nil
 }
 
 func opCallCode(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
@@ -549,13 +656,13 @@ func opCallCode(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack
 
 	if err != nil {
 		stack.push(new(big.Int))
-
 	} else {
 		stack.push(big.NewInt(1))
-
+	}
+	if err == nil || err == ErrExecutionReverted {
 		memory.Set(retOffset.Uint64(), retSize.Uint64(), ret)
 	}
-	return nil, nil
+	return ret, nil
 }
 
 func opDelegateCall(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
@@ -574,9 +681,28 @@ func opDelegateCall(pc *uint64, env *EVM, contract *Contract, memory *Memory, st
 		stack.push(new(big.Int))
 	} else {
 		stack.push(big.NewInt(1))
+	}
+	if err == nil || err == ErrExecutionReverted {
 		memory.Set(outOffset.Uint64(), outSize.Uint64(), ret)
 	}
-	return nil, nil
+	return ret, nil
+}
+
+func opStaticCall(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
+	gas, to, inOffset, inSize, outOffset, outSize := stack.pop(), stack.pop(), stack.pop(), stack.pop(), stack.pop(), stack.pop()
+
+	toAddr := common.BigToAddress(to)
+	args := memory.Get(inOffset.Int64(), inSize.Int64())
+	ret, err := env.StaticCall(contract, toAddr, args, gas)
+	if err != nil {
+		stack.push(new(big.Int))
+	} else {
+		stack.push(big.NewInt(1))
+	}
+	if err == nil || err == ErrExecutionReverted {
+		memory.Set(outOffset.Uint64(), outSize.Uint64(), ret)
+	}
+	return ret, nil
 }
 
 func opReturn(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
@@ -586,6 +712,13 @@ func opReturn(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack *S
 	return ret, nil
 }
 
+func opRevert(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
+	offset, size := stack.pop(), stack.pop()
+	ret := memory.GetPtr(offset.Int64(), size.Int64())
+
+	return ret, nil
+}
+
 func opStop(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
 	return nil, nil
 }
@@ -44,11 +44,100 @@ type operation struct {
 	// jumps indicates whether operation made a jump. This prevents the program
 	// counter from further incrementing.
 	jumps bool
+	// writes determines whether this a state modifying operation
+	writes bool
+	// returns determines whether the operation sets the return data content
+	returns bool
+	// reverts determines whether the operation reverts state (implicitly halts)
+	reverts bool
 	// valid is used to check whether the retrieved operation is valid and known
 	valid bool
 }
 
-var defaultJumpTable = NewJumpTable()
+var (
+	defaultJumpTable        = NewJumpTable()
+	byzantiumJumpTable      = NewByzantiumJumpTable()
+	constantinopleJumpTable = NewConstantinopleJumpTable()
+)
+
+// NewConstantinopleJumpTable returns the instruction table of the Constantinople
+// fork, which extends the Byzantium table with the bitwise shifts, EXTCODEHASH
+// and CREATE2.
+func NewConstantinopleJumpTable() [256]operation {
+	instructionSet := NewByzantiumJumpTable()
+	instructionSet[SHL] = operation{
+		execute:       opSHL,
+		gasCost:       constGasFunc(GasFastestStep),
+		validateStack: makeStackFunc(2, -1),
+		valid:         true,
+	}
+	instructionSet[SHR] = operation{
+		execute:       opSHR,
+		gasCost:       constGasFunc(GasFastestStep),
+		validateStack: makeStackFunc(2, -1),
+		valid:         true,
+	}
+	instructionSet[SAR] = operation{
+		execute:       opSAR,
+		gasCost:       constGasFunc(GasFastestStep),
+		validateStack: makeStackFunc(2, -1),
+		valid:         true,
+	}
+	instructionSet[EXTCODEHASH] = operation{
+		execute:       opExtCodeHash,
+		gasCost:       gasExtCodeHash,
+		validateStack: makeStackFunc(1, 0),
+		valid:         true,
+	}
+	instructionSet[CREATE2] = operation{
+		execute:       opCreate2,
+		gasCost:       gasCreate2,
+		validateStack: makeStackFunc(4, -3),
+		memorySize:    memoryCreate2,
+		valid:         true,
+		writes:        true,
+		returns:       true,
+	}
+	return instructionSet
+}
+
+// NewByzantiumJumpTable returns the instruction table of the Byzantium fork,
+// which extends the default table with REVERT, the return data accessors and
+// STATICCALL.
+func NewByzantiumJumpTable() [256]operation {
+	instructionSet := NewJumpTable()
+	instructionSet[STATICCALL] = operation{
+		execute:       opStaticCall,
+		gasCost:       gasStaticCall,
+		validateStack: makeStackFunc(6, -5),
+		memorySize:    memoryStaticCall,
+		valid:         true,
+		returns:       true,
+	}
+	instructionSet[RETURNDATASIZE] = operation{
+		execute:       opReturnDataSize,
+		gasCost:       constGasFunc(GasQuickStep),
+		validateStack: makeStackFunc(0, 1),
+		valid:         true,
+	}
+	instructionSet[RETURNDATACOPY] = operation{
+		execute:       opReturnDataCopy,
+		gasCost:       gasReturnDataCopy,
+		validateStack: makeStackFunc(3, -3),
+		memorySize:    memoryReturnDataCopy,
+		valid:         true,
+	}
+	instructionSet[REVERT] = operation{
+		execute:       opRevert,
+		gasCost:       gasRevert,
+		validateStack: makeStackFunc(2, -2),
+		memorySize:    memoryRevert,
+		valid:         true,
+		reverts:       true,
+		returns:       true,
+	}
+	return instructionSet
+}
 
 func NewJumpTable() [256]operation {
 	return [256]operation{
@@ -354,6 +443,7 @@ func NewJumpTable() [256]operation {
 			gasCost:       gasSStore,
 			validateStack: makeStackFunc(2, -2),
 			valid:         true,
+			writes:        true,
 		},
 		JUMP: {
 			execute:       opJump,
@@ -783,6 +873,7 @@ func NewJumpTable() [256]operation {
 			validateStack: makeStackFunc(2, -2),
 			memorySize:    memoryLog,
 			valid:         true,
+			writes:        true,
 		},
 		LOG1: {
 			execute:       makeLog(1),
@@ -790,6 +881,7 @@ func NewJumpTable() [256]operation {
 			validateStack: makeStackFunc(3, -3),
 			memorySize:    memoryLog,
 			valid:         true,
+			writes:        true,
 		},
 		LOG2: {
 			execute:       makeLog(2),
@@ -797,6 +889,7 @@ func NewJumpTable() [256]operation {
 			validateStack: makeStackFunc(4, -4),
 			memorySize:    memoryLog,
 			valid:         true,
+			writes:        true,
 		},
 		LOG3: {
 			execute:       makeLog(3),
@@ -804,6 +897,7 @@ func NewJumpTable() [256]operation {
 			validateStack: makeStackFunc(5, -5),
 			memorySize:    memoryLog,
 			valid:         true,
+			writes:        true,
 		},
 		LOG4: {
 			execute:       makeLog(4),
@@ -811,6 +905,7 @@ func NewJumpTable() [256]operation {
 			validateStack: makeStackFunc(6, -6),
 			memorySize:    memoryLog,
 			valid:         true,
+			writes:        true,
 		},
 		CREATE: {
 			execute:       opCreate,
@@ -818,6 +913,8 @@ func NewJumpTable() [256]operation {
 			validateStack: makeStackFunc(3, -2),
 			memorySize:    memoryCreate,
 			valid:         true,
+			writes:        true,
+			returns:       true,
 		},
 		CALL: {
 			execute:       opCall,
@@ -825,6 +922,7 @@ func NewJumpTable() [256]operation {
 			validateStack: makeStackFunc(7, -6),
 			memorySize:    memoryCall,
 			valid:         true,
+			returns:       true,
 		},
 		CALLCODE: {
 			execute:       opCallCode,
@@ -832,6 +930,7 @@ func NewJumpTable() [256]operation {
 			validateStack: makeStackFunc(7, -6),
 			memorySize:    memoryCall,
 			valid:         true,
+			returns:       true,
 		},
 		RETURN: {
 			execute:       opReturn,
@@ -847,6 +946,7 @@ func NewJumpTable() [256]operation {
 			validateStack: makeStackFunc(6, -5),
 			memorySize:    memoryDelegateCall,
 			valid:         true,
+			returns:       true,
 		},
 		SELFDESTRUCT: {
 			execute:       opSuicide,
@@ -854,6 +954,7 @@ func NewJumpTable() [256]operation {
 			validateStack: makeStackFunc(1, -1),
 			halts:         true,
 			valid:         true,
+			writes:        true,
 		},
 	}
 }
@@ -25,7 +25,9 @@ import (
 	"github.com/daxxcoin/daxxcore/common"
 	"github.com/daxxcoin/daxxcore/core/state"
 	"github.com/daxxcoin/daxxcore/core/vm"
+	"github.com/daxxcoin/daxxcore/crypto"
 	"github.com/daxxcoin/daxxcore/daxxdb"
+	"github.com/daxxcoin/daxxcore/params"
 )
 
 func TestDefaults(t *testing.T) {
@@ -117,6 +119,234 @@ func TestCall(t *testing.T) {
 	}
 }
 
+func TestRevert(t *testing.T) {
+	ret, _, err := Execute([]byte{
+		byte(vm.PUSH1), 10,
+		byte(vm.PUSH1), 0,
+		byte(vm.MSTORE),
+		byte(vm.PUSH1), 32,
+		byte(vm.PUSH1), 0,
+		byte(vm.REVERT),
+	}, nil, nil)
+	if err != vm.ErrExecutionReverted {
+		t.Fatalf("error mismatch: have %v, want %v", err, vm.ErrExecutionReverted)
+	}
+
+	num := common.BytesToBig(ret)
+	if num.Cmp(big.NewInt(10)) != 0 {
+		t.Error("Expected 10, got", num)
+	}
+}
+
+func TestStaticCallReturnData(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	state, _ := state.New(common.Hash{}, db)
+	callee := common.HexToAddress("0x0b")
+	state.SetCode(callee, []byte{
+		byte(vm.PUSH1), 10,
+		byte(vm.PUSH1), 0,
+		byte(vm.MSTORE),
+		byte(vm.PUSH1), 32,
+		byte(vm.PUSH1), 0,
+		byte(vm.RETURN),
+	})
+	address := common.HexToAddress("0x0a")
+	state.SetCode(address, []byte{
+		byte(vm.PUSH1), 0, // out size
+		byte(vm.PUSH1), 0, // out offset
+		byte(vm.PUSH1), 0, // in size
+		byte(vm.PUSH1), 0, // in offset
+		byte(vm.PUSH1), 0x0b,
+		byte(vm.GAS),
+		byte(vm.STATICCALL),
+		byte(vm.POP),
+		byte(vm.RETURNDATASIZE),
+		byte(vm.PUSH1), 0,
+		byte(vm.PUSH1), 0,
+		byte(vm.RETURNDATACOPY),
+		byte(vm.RETURNDATASIZE),
+		byte(vm.PUSH1), 0,
+		byte(vm.RETURN),
+	})
+
+	ret, err := Call(address, nil, &Config{State: state})
+	if err != nil {
+		t.Fatal("didn't expect error", err)
+	}
+
+	num := common.BytesToBig(ret)
+	if len(ret) != 32 || num.Cmp(big.NewInt(10)) != 0 {
+		t.Errorf("Expected 10 as return data, got %x", ret)
+	}
+}
+
+func TestStaticCallWriteProtection(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	state, _ := state.New(common.Hash{}, db)
+	callee := common.HexToAddress("0x0b")
+	state.SetCode(callee, []byte{
+		byte(vm.PUSH1), 1,
+		byte(vm.PUSH1), 0,
+		byte(vm.SSTORE),
+		byte(vm.STOP),
+	})
+	address := common.HexToAddress("0x0a")
+	state.SetCode(address, []byte{
+		byte(vm.PUSH1), 0, // out size
+		byte(vm.PUSH1), 0, // out offset
+		byte(vm.PUSH1), 0, // in size
+		byte(vm.PUSH1), 0, // in offset
+		byte(vm.PUSH1), 0x0b,
+		byte(vm.GAS),
+		byte(vm.STATICCALL),
+		byte(vm.PUSH1), 0,
+		byte(vm.MSTORE),
+		byte(vm.PUSH1), 32,
+		byte(vm.PUSH1), 0,
+		byte(vm.RETURN),
+	})
+
+	ret, err := Call(address, nil, &Config{State: state})
+	if err != nil {
+		t.Fatal("didn't expect error", err)
+	}
+	if num := common.BytesToBig(ret); num.BitLen() != 0 {
+		t.Errorf("Expected static call to fail, got %v", num)
+	}
+	if val := state.GetState(callee, common.Hash{}); val != (common.Hash{}) {
+		t.Errorf("Storage modified within static call: %x", val)
+	}
+}
+
+// returnTop wraps code so that the value it leaves on top of the stack is
+// returned as a 32 byte word.
+func returnTop(code ...byte) []byte {
+	return append(code,
+		byte(vm.PUSH1), 0,
+		byte(vm.MSTORE),
+		byte(vm.PUSH1), 32,
+		byte(vm.PUSH1), 0,
+		byte(vm.RETURN),
+	)
+}
+
+func TestShifts(t *testing.T) {
+	tests := []struct {
+		value, shift  string
+		shl, shr, sar string
+	}{
+		{"0000000000000000000000000000000000000000000000000000000000000001", "00", "0000000000000000000000000000000000000000000000000000000000000001", "0000000000000000000000000000000000000000000000000000000000000001", "0000000000000000000000000000000000000000000000000000000000000001"},
+		{"0000000000000000000000000000000000000000000000000000000000000001", "01", "0000000000000000000000000000000000000000000000000000000000000002", "0000000000000000000000000000000000000000000000000000000000000000", "0000000000000000000000000000000000000000000000000000000000000000"},
+		{"8000000000000000000000000000000000000000000000000000000000000000", "01", "0000000000000000000000000000000000000000000000000000000000000000", "4000000000000000000000000000000000000000000000000000000000000000", "c000000000000000000000000000000000000000000000000000000000000000"},
+		{"8000000000000000000000000000000000000000000000000000000000000000", "ff", "0000000000000000000000000000000000000000000000000000000000000000", "0000000000000000000000000000000000000000000000000000000000000001", "ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff"},
+		{"8000000000000000000000000000000000000000000000000000000000000000", "0100", "0000000000000000000000000000000000000000000000000000000000000000", "0000000000000000000000000000000000000000000000000000000000000000", "ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff"},
+		{"ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff", "01", "fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffe", "7fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff", "ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff"},
+		{"ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff", "ff", "8000000000000000000000000000000000000000000000000000000000000000", "0000000000000000000000000000000000000000000000000000000000000001", "ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff"},
+		{"ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff", "0101", "0000000000000000000000000000000000000000000000000000000000000000", "0000000000000000000000000000000000000000000000000000000000000000", "ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff"},
+		{"7fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff", "fe", "c000000000000000000000000000000000000000000000000000000000000000", "0000000000000000000000000000000000000000000000000000000000000001", "0000000000000000000000000000000000000000000000000000000000000001"},
+	}
+	for i, tt := range tests {
+		for _, op := range []struct {
+			code vm.OpCode
+			want string
+		}{{vm.SHL, tt.shl}, {vm.SHR, tt.shr}, {vm.SAR, tt.sar}} {
+			code := append([]byte{byte(vm.PUSH32)}, common.Hex2Bytes(tt.value)...)
+			code = append(code, byte(vm.PUSH32))
+			code = append(code, common.LeftPadBytes(common.Hex2Bytes(tt.shift), 32)...)
+
+			ret, _, err := Execute(returnTop(append(code, byte(op.code))...), nil, nil)
+			if err != nil {
+				t.Fatalf("test %d: %v failed: %v", i, op.code, err)
+			}
+			if have := common.Bytes2Hex(ret); have != op.want {
+				t.Errorf("test %d: %v result mismatch: have %s, want %s", i, op.code, have, op.want)
+			}
+		}
+	}
+}
+
+func TestExtCodeHash(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	state, _ := state.New(common.Hash{}, db)
+
+	code := []byte{byte(vm.PUSH1), 1, byte(vm.STOP)}
+	state.SetCode(common.HexToAddress("0x0b"), code)
+	state.AddBalance(common.HexToAddress("0x0c"), big.NewInt(1))
+
+	tests := []struct {
+		addr byte
+		want common.Hash
+	}{
+		{0x0b, crypto.Keccak256Hash(code)}, // contract account
+		{0x0c, crypto.Keccak256Hash(nil)},  // account without code
+		{0x0d, common.Hash{}},              // non-existent account
+	}
+	for _, tt := range tests {
+		ret, _, err := Execute(returnTop(byte(vm.PUSH1), tt.addr, byte(vm.EXTCODEHASH)), nil, &Config{State: state})
+		if err != nil {
+			t.Fatalf("account %x: execution failed: %v", tt.addr, err)
+		}
+		if have := common.BytesToHash(ret); have != tt.want {
+			t.Errorf("account %x: hash mismatch: have %x, want %x", tt.addr, have, tt.want)
+		}
+	}
+}
+
+func TestCreate2(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	state, _ := state.New(common.Hash{}, db)
+
+	// Deploy a single STOP byte as init code twice using the same salt
+	address := common.HexToAddress("0x0a")
+	create := []byte{
+		byte(vm.PUSH1), 0x2a, // salt
+		byte(vm.PUSH1), 1, // size
+		byte(vm.PUSH1), 0, // offset
+		byte(vm.PUSH1), 0, // endowment
+		byte(vm.CREATE2),
+	}
+	state.SetCode(address, returnTop(create...))
+
+	ret, err := Call(address, nil, &Config{State: state})
+	if err != nil {
+		t.Fatalf("create2 failed: %v", err)
+	}
+	want := crypto.CreateAddress2(address, common.BigToHash(big.NewInt(0x2a)), crypto.Keccak256([]byte{0}))
+	if have := common.BytesToAddress(ret); have != want {
+		t.Errorf("address mismatch: have %x, want %x", have, want)
+	}
+	if nonce := state.GetNonce(want); nonce != 1 {
+		t.Errorf("created account nonce mismatch: have %d, want 1", nonce)
+	}
+	// Creating at the same address again must fail
+	ret, err = Call(address, nil, &Config{State: state})
+	if err != nil {
+		t.Fatalf("second create2 failed: %v", err)
+	}
+	if have := common.BytesToAddress(ret); have != (common.Address{}) {
+		t.Errorf("expected collision, got address %x", have)
+	}
+}
+
+func TestConstantinopleInactive(t *testing.T) {
+	chainConfig := &params.ChainConfig{
+		ChainId:        big.NewInt(1),
+		HomesteadBlock: new(big.Int),
+		EIP150Block:    new(big.Int),
+		EIP155Block:    new(big.Int),
+		EIP158Block:    new(big.Int),
+		ByzantiumBlock: new(big.Int),
+	}
+	code := returnTop(byte(vm.PUSH1), 1, byte(vm.PUSH1), 1, byte(vm.SHL))
+	if _, _, err := Execute(code, nil, &Config{ChainConfig: chainConfig}); err == nil {
+		t.Fatal("expected SHL to be invalid before constantinople")
+	}
+	chainConfig.ConstantinopleBlock = new(big.Int)
+	if _, _, err := Execute(code, nil, &Config{ChainConfig: chainConfig}); err != nil {
+		t.Fatalf("expected SHL to be valid after constantinople: %v", err)
+	}
+}
+
 func BenchmarkCall(b *testing.B) {
 	var definition = `[{"constant":true,"inputs":[],"name":"seller","outputs":[{"name":"","type":"address"}],"type":"function"},{"constant":false,"inputs":[],"name":"abort","outputs":[],"type":"function"},{"constant":true,"inputs":[],"name":"value","outputs":[{"name":"","type":"uint256"}],"type":"function"},{"constant":false,"inputs":[],"name":"refund","outputs":[],"type":"function"},{"constant":true,"inputs":[],"name":"buyer","outputs":[{"name":"","type":"address"}],"type":"function"},{"constant":false,"inputs":[],"name":"confirmReceived","outputs":[],"type":"function"},{"constant":true,"inputs":[],"name":"state","outputs":[{"name":"","type":"uint8"}],"type":"function"},{"constant":false,"inputs":[],"name":"confirmPurchase","outputs":[],"type":"function"},{"inputs":[],"type":"constructor"},{"anonymous":false,"inputs":[],"name":"Aborted","type":"event"},{"anonymous":false,"inputs":[],"name":"PurchaseConfirmed","type":"event"},{"anonymous":false,"inputs":[],"name":"ItemReceived","type":"event"},{"anonymous":false,"inputs":[],"name":"Refunded","type":"event"}]`
 
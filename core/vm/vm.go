@@ -60,6 +60,9 @@ type Interpreter struct {
 	env      *EVM
 	cfg      Config
 	gasTable params.GasTable
+
+	readOnly   bool   // Whether to throw on stateful modifications
+	returnData []byte // Last CALL's return data for subsequent reuse
 }
 
 // NewInterpreter returns a new instance of the Interpreter.
@@ -68,7 +71,14 @@ func NewInterpreter(env *EVM, cfg Config) *Interpreter {
 	// the jump table was initialised. If it was not
 	// we'll set the default jump table.
 	if !cfg.JumpTable[STOP].valid {
-		cfg.JumpTable = defaultJumpTable
+		switch {
+		case env.ChainConfig().IsConstantinople(env.BlockNumber):
+			cfg.JumpTable = constantinopleJumpTable
+		case env.ChainConfig().IsByzantium(env.BlockNumber):
+			cfg.JumpTable = byzantiumJumpTable
+		default:
+			cfg.JumpTable = defaultJumpTable
+		}
 	}
 
 	return &Interpreter{
@@ -84,10 +94,13 @@ func (evm *Interpreter) Run(contract *Contract, input []byte) (ret []byte, err e
 	defer func() { evm.env.depth-- }()
 
 	if contract.CodeAddr != nil {
-		if p := PrecompiledContracts[*contract.CodeAddr]; p != nil {
+		if p := evm.env.Precompiles()[*contract.CodeAddr]; p != nil {
 			return RunPrecompiledContract(p, input, contract)
 		}
 	}
+	// Reset the previous call's return data. It's unimportant to preserve the old
+	// buffer as every returning call will return new data anyway.
+	evm.returnData = nil
 
 	// Don't bother with the execution if there's no code.
 	if len(contract.Code) == 0 {
@@ -146,6 +159,10 @@ func (evm *Interpreter) Run(contract *Contract, input []byte) (ret []byte, err e
 		if err := operation.validateStack(stack); err != nil {
 			return nil, err
 		}
+		// If the operation is valid, enforce any write restrictions
+		if err := evm.enforceRestrictions(op, operation, stack); err != nil {
+			return nil, err
+		}
 
 		var memorySize *big.Int
 		// calculate the new memory size and expand the memory to fit
@@ -175,9 +192,17 @@ func (evm *Interpreter) Run(contract *Contract, input []byte) (ret []byte, err e
 
 		// execute the operation
 		res, err := operation.execute(&pc, evm.env, contract, mem, stack)
+		// if the operation clears the return data (e.g. it has returning data)
+		// set the last return to the result of the operation.
+		if operation.returns {
+			evm.returnData = res
+		}
+
 		switch {
 		case err != nil:
 			return nil, err
+		case operation.reverts:
+			return res, ErrExecutionReverted
 		case operation.halts:
 			return res, nil
 		case !operation.jumps:
@@ -186,3 +211,19 @@ func (evm *Interpreter) Run(contract *Contract, input []byte) (ret []byte, err e
 	}
 	return nil, nil
 }
+
+// enforceRestrictions returns an error if the operation would modify the state
+// while the interpreter is executing a static call.
+func (evm *Interpreter) enforceRestrictions(op OpCode, operation operation, stack *Stack) error {
+	if evm.readOnly {
+		// If the interpreter is operating in readonly mode, make sure no
+		// state-modifying operation is performed. The 3rd stack item
+		// for a call operation is the value. Transferring value from one
+		// account to the others means the state is modified and should also
+		// return with an error.
+		if operation.writes || (op == CALL && stack.Back(2).BitLen() > 0) {
+			return ErrWriteProtection
+		}
+	}
+	return nil
+}
@@ -0,0 +1,290 @@
+// Copyright 2012 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package bn256
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"math/big"
+	"testing"
+)
+
+func TestGFp2Invert(t *testing.T) {
+	a := newGFp2()
+	a.x.SetString("23423492374", 10)
+	a.y.SetString("12934872398472394827398470", 10)
+
+	inv := newGFp2()
+	inv.Invert(a)
+
+	b := newGFp2().Mul(inv, a)
+	if b.x.Int64() != 0 || b.y.Int64() != 1 {
+		t.Fatalf("bad result for a^-1*a: %s %s", b.x, b.y)
+	}
+}
+
+func isZero(n *big.Int) bool {
+	return new(big.Int).Mod(n, P).Int64() == 0
+}
+
+func isOne(n *big.Int) bool {
+	return new(big.Int).Mod(n, P).Int64() == 1
+}
+
+func TestGFp6Invert(t *testing.T) {
+	a := newGFp6()
+	a.x.x.SetString("239487238491", 10)
+	a.x.y.SetString("2356249827341", 10)
+	a.y.x.SetString("082659782", 10)
+	a.y.y.SetString("182703523765", 10)
+	a.z.x.SetString("978236549263", 10)
+	a.z.y.SetString("64893242", 10)
+
+	inv := newGFp6()
+	inv.Invert(a)
+
+	b := newGFp6().Mul(inv, a)
+	if !isZero(b.x.x) || !isZero(b.x.y) || !isZero(b.y.x) || !isZero(b.y.y) || !isZero(b.z.x) || !isOne(b.z.y) {
+		t.Fatalf("bad result for a^-1*a: %s", b)
+	}
+}
+
+func TestGFp12Invert(t *testing.T) {
+	a := newGFp12()
+	a.x.x.x.SetString("239846234862342323958623", 10)
+	a.x.x.y.SetString("2359862352529835623", 10)
+	a.x.y.x.SetString("928836523", 10)
+	a.x.y.y.SetString("9856234", 10)
+	a.x.z.x.SetString("235635286", 10)
+	a.x.z.y.SetString("5628392833", 10)
+	a.y.x.x.SetString("252936598265329856238956532167968", 10)
+	a.y.x.y.SetString("23596239865236954178968", 10)
+	a.y.y.x.SetString("95421692834", 10)
+	a.y.y.y.SetString("236548", 10)
+	a.y.z.x.SetString("924523", 10)
+	a.y.z.y.SetString("12954623", 10)
+
+	inv := newGFp12()
+	inv.Invert(a)
+
+	b := newGFp12().Mul(inv, a)
+	if !b.IsOne() {
+		t.Fatalf("bad result for a^-1*a: %s", b)
+	}
+}
+
+func TestFrobenius(t *testing.T) {
+	a := newGFp12()
+	a.x.x.x.SetString("239846234862342323958623", 10)
+	a.x.y.y.SetString("9856234", 10)
+	a.y.x.y.SetString("23596239865236954178968", 10)
+	a.y.z.x.SetString("924523", 10)
+
+	// The Frobenius map must equal exponentiation by p
+	want := newGFp12().Exp(a, P)
+	have := newGFp12().Frobenius(a)
+	want.Minimal()
+	have.Minimal()
+	if want.String() != have.String() {
+		t.Fatalf("frobenius mismatch: have %s, want %s", have, want)
+	}
+	want.Exp(want, P)
+	have.FrobeniusP2(a)
+	want.Minimal()
+	have.Minimal()
+	if want.String() != have.String() {
+		t.Fatalf("frobenius p² mismatch: have %s, want %s", have, want)
+	}
+}
+
+func TestCurveImpl(t *testing.T) {
+	if !curveGen.IsOnCurve() {
+		t.Fatal("G1 generator not on curve")
+	}
+	if !twistGen.IsOnCurve() {
+		t.Fatal("G2 generator not on twist")
+	}
+	if !newCurvePoint().Mul(curveGen, Order).IsInfinity() {
+		t.Error("Order*G1 is not infinity")
+	}
+	if !newTwistPoint().Mul(twistGen, Order).IsInfinity() {
+		t.Error("Order*G2 is not infinity")
+	}
+	// Doubling must match adding a point to itself
+	a := newCurvePoint().Mul(curveGen, big.NewInt(7))
+	d := newCurvePoint()
+	d.Double(a)
+	s := newCurvePoint()
+	s.Add(a, a)
+	d.MakeAffine()
+	s.MakeAffine()
+	if d.String() != s.String() {
+		t.Errorf("G1 double mismatch: %s != %s", d, s)
+	}
+}
+
+func TestOrderG1(t *testing.T) {
+	g := new(G1).ScalarBaseMult(Order)
+	if !g.p.IsInfinity() {
+		t.Error("G1 has incorrect order")
+	}
+
+	one := new(G1).ScalarBaseMult(new(big.Int).SetInt64(1))
+	g.Add(g, one)
+	g.p.MakeAffine()
+	if g.p.x.Cmp(one.p.x) != 0 || g.p.y.Cmp(one.p.y) != 0 {
+		t.Errorf("1+0 != 1 in G1")
+	}
+}
+
+func TestOrderGT(t *testing.T) {
+	gt := Pair(&G1{curveGen}, &G2{twistGen})
+	g := new(GT).ScalarMult(gt, Order)
+	if !g.IsOne() {
+		t.Error("GT has incorrect order")
+	}
+}
+
+func TestBilinearity(t *testing.T) {
+	for i := 0; i < 2; i++ {
+		a, p1, _ := RandomG1(rand.Reader)
+		b, p2, _ := RandomG2(rand.Reader)
+		e1 := Pair(p1, p2)
+
+		e2 := Pair(&G1{curveGen}, &G2{twistGen})
+		e2.ScalarMult(e2, a)
+		e2.ScalarMult(e2, b)
+
+		minusE2 := new(GT).Neg(e2)
+		e1.Add(e1, minusE2)
+
+		if !e1.IsOne() {
+			t.Fatalf("bad pairing result: %s", e1)
+		}
+	}
+}
+
+func TestG1Marshal(t *testing.T) {
+	g := new(G1).ScalarBaseMult(new(big.Int).SetInt64(1))
+	form := g.Marshal()
+	_, err := new(G1).Unmarshal(form)
+	if err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+
+	g.ScalarBaseMult(Order)
+	form = g.Marshal()
+
+	g2 := new(G1)
+	if _, err = g2.Unmarshal(form); err != nil {
+		t.Fatalf("failed to unmarshal ∞: %v", err)
+	}
+	if !g2.p.IsInfinity() {
+		t.Fatalf("∞ unmarshaled incorrectly")
+	}
+	// Points off the curve must be rejected
+	form = make([]byte, 64)
+	form[31], form[63] = 1, 1
+	if _, err := new(G1).Unmarshal(form); err != errNotOnCurve {
+		t.Fatalf("off-curve point: have %v, want %v", err, errNotOnCurve)
+	}
+}
+
+func TestG2Marshal(t *testing.T) {
+	g := new(G2).ScalarBaseMult(new(big.Int).SetInt64(1))
+	form := g.Marshal()
+	_, err := new(G2).Unmarshal(form)
+	if err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+
+	g.ScalarBaseMult(Order)
+	form = g.Marshal()
+	g2 := new(G2)
+	if _, err = g2.Unmarshal(form); err != nil {
+		t.Fatalf("failed to unmarshal ∞: %v", err)
+	}
+	if !g2.p.IsInfinity() {
+		t.Fatalf("∞ unmarshaled incorrectly")
+	}
+}
+
+func TestG1Identity(t *testing.T) {
+	g := new(G1).ScalarBaseMult(new(big.Int).SetInt64(0))
+	if !g.p.IsInfinity() {
+		t.Error("failure")
+	}
+}
+
+func TestG2Identity(t *testing.T) {
+	g := new(G2).ScalarBaseMult(new(big.Int).SetInt64(0))
+	if !g.p.IsInfinity() {
+		t.Error("failure")
+	}
+}
+
+func TestTripartiteDiffieHellman(t *testing.T) {
+	a, _ := rand.Int(rand.Reader, Order)
+	b, _ := rand.Int(rand.Reader, Order)
+	c, _ := rand.Int(rand.Reader, Order)
+
+	pa, pb, pc := new(G1), new(G1), new(G1)
+	qa, qb, qc := new(G2), new(G2), new(G2)
+
+	pa.Unmarshal(new(G1).ScalarBaseMult(a).Marshal())
+	qa.Unmarshal(new(G2).ScalarBaseMult(a).Marshal())
+	pb.Unmarshal(new(G1).ScalarBaseMult(b).Marshal())
+	qb.Unmarshal(new(G2).ScalarBaseMult(b).Marshal())
+	pc.Unmarshal(new(G1).ScalarBaseMult(c).Marshal())
+	qc.Unmarshal(new(G2).ScalarBaseMult(c).Marshal())
+
+	k1 := Pair(pb, qc)
+	k1.ScalarMult(k1, a)
+	k1.p.Minimal()
+
+	k2 := Pair(pc, qa)
+	k2.ScalarMult(k2, b)
+	k2.p.Minimal()
+
+	k3 := Pair(pa, qb)
+	k3.ScalarMult(k3, c)
+	k3.p.Minimal()
+
+	if k1.String() != k2.String() || k2.String() != k3.String() {
+		t.Errorf("keys didn't agree")
+	}
+}
+
+func TestPairingCheck(t *testing.T) {
+	a, _ := rand.Int(rand.Reader, Order)
+	p := new(G1).ScalarBaseMult(a)
+	q := new(G2).ScalarBaseMult(big.NewInt(1))
+	minusP := new(G1).Neg(p)
+
+	if !PairingCheck([]*G1{p, minusP}, []*G2{q, q}) {
+		t.Error("e(P,Q)·e(-P,Q) != 1")
+	}
+	if PairingCheck([]*G1{p, p}, []*G2{q, q}) {
+		t.Error("e(P,Q)·e(P,Q) == 1")
+	}
+	// e(aG1, G2) == e(G1, aG2)
+	aq := new(G2).ScalarBaseMult(a)
+	g := new(G1).ScalarBaseMult(big.NewInt(1))
+	if !PairingCheck([]*G1{p, new(G1).Neg(g)}, []*G2{q, aq}) {
+		t.Error("e(aP,Q) != e(P,aQ)")
+	}
+}
+
+func TestMarshalVectors(t *testing.T) {
+	// The G2 generator from EIP-197, imaginary parts first
+	want, _ := hex.DecodeString("198e9393920d483a7260bfb731fb5d25f1aa493335a9e71297e485b7aef312c2" +
+		"1800deef121f1e76426a00665e5c4479674322d4f75edadd46debd5cd992f6ed" +
+		"090689d0585ff075ec9e99ad690c3395bc4b313370b38ef355acdadcd122975b" +
+		"12c85ea5db8c6deb4aab71808dcb408fe3d1e7690c43d37b4ce6cc0166fa7daa")
+	if have := new(G2).ScalarBaseMult(big.NewInt(1)).Marshal(); !bytes.Equal(have, want) {
+		t.Errorf("G2 generator encoding mismatch: have %x, want %x", have, want)
+	}
+}
@@ -0,0 +1,229 @@
+// Copyright 2012 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package bn256
+
+import (
+	"math/big"
+)
+
+// twistPoint implements the elliptic curve y²=x³+3/ξ over GF(p²). Points are
+// kept in Jacobian form and t=z² when valid. The group G₂ is the set of
+// n-torsion points of this curve over GF(p²) (where n = Order)
+type twistPoint struct {
+	x, y, z, t *gfP2
+}
+
+var twistB = &gfP2{
+	bigFromBase10("266929791119991161246907387137283842545076965332900288569378510910307636690"),
+	bigFromBase10("19485874751759354771024239261021720505790618469301721065564631296452457478373"),
+}
+
+// twistGen is the generator of group G₂.
+var twistGen = &twistPoint{
+	&gfP2{
+		bigFromBase10("11559732032986387107991004021392285783925812861821192530917403151452391805634"),
+		bigFromBase10("10857046999023057135944570762232829481370756359578518086990519993285655852781"),
+	},
+	&gfP2{
+		bigFromBase10("4082367875863433681332203403145435568316851327593401208105741076214120093531"),
+		bigFromBase10("8495653923123431417604973247489272438418190587263600148770280649306958101930"),
+	},
+	&gfP2{
+		bigFromBase10("0"),
+		bigFromBase10("1"),
+	},
+	&gfP2{
+		bigFromBase10("0"),
+		bigFromBase10("1"),
+	},
+}
+
+func newTwistPoint() *twistPoint {
+	return &twistPoint{
+		newGFp2(),
+		newGFp2(),
+		newGFp2(),
+		newGFp2(),
+	}
+}
+
+func (c *twistPoint) String() string {
+	return "(" + c.x.String() + ", " + c.y.String() + ", " + c.z.String() + ")"
+}
+
+func (c *twistPoint) Set(a *twistPoint) {
+	c.x.Set(a.x)
+	c.y.Set(a.y)
+	c.z.Set(a.z)
+	c.t.Set(a.t)
+}
+
+// IsOnCurve returns true iff c is on the curve where c must be in affine form.
+func (c *twistPoint) IsOnCurve() bool {
+	yy := newGFp2().Square(c.y)
+	xxx := newGFp2().Square(c.x)
+	xxx.Mul(xxx, c.x)
+	yy.Sub(yy, xxx)
+	yy.Sub(yy, twistB)
+	yy.Minimal()
+	return yy.x.Sign() == 0 && yy.y.Sign() == 0
+}
+
+func (c *twistPoint) SetInfinity() {
+	c.z.SetZero()
+}
+
+func (c *twistPoint) IsInfinity() bool {
+	return c.z.IsZero()
+}
+
+func (c *twistPoint) Add(a, b *twistPoint) {
+	// For additional comments, see the same function in curve.go.
+
+	if a.IsInfinity() {
+		c.Set(b)
+		return
+	}
+	if b.IsInfinity() {
+		c.Set(a)
+		return
+	}
+
+	// See http://hyperelliptic.org/EFD/g1p/auto-code/shortw/jacobian-0/addition/add-2007-bl.op3
+	z1z1 := newGFp2().Square(a.z)
+	z2z2 := newGFp2().Square(b.z)
+	u1 := newGFp2().Mul(a.x, z2z2)
+	u2 := newGFp2().Mul(b.x, z1z1)
+
+	t := newGFp2().Mul(b.z, z2z2)
+	s1 := newGFp2().Mul(a.y, t)
+
+	t.Mul(a.z, z1z1)
+	s2 := newGFp2().Mul(b.y, t)
+
+	h := newGFp2().Sub(u2, u1)
+	h.Minimal()
+	xEqual := h.IsZero()
+
+	t.Add(h, h)
+	i := newGFp2().Square(t)
+	j := newGFp2().Mul(h, i)
+
+	t.Sub(s2, s1)
+	t.Minimal()
+	yEqual := t.IsZero()
+	if xEqual && yEqual {
+		c.Double(a)
+		return
+	}
+	r := newGFp2().Add(t, t)
+
+	v := newGFp2().Mul(u1, i)
+
+	t4 := newGFp2().Square(r)
+	t.Add(v, v)
+	t6 := newGFp2().Sub(t4, j)
+
+	// z = ((z1+z2)² - z1z1 - z2z2)·h is computed before c is written, as c
+	// may alias a or b
+	z := newGFp2().Add(a.z, b.z)
+	z.Square(z)
+	z.Sub(z, z1z1)
+	z.Sub(z, z2z2)
+	z.Mul(z, h)
+
+	c.x.Sub(t6, t)
+
+	t.Sub(v, c.x)  // t7
+	t4.Mul(s1, j)  // t8
+	t6.Add(t4, t4) // t9
+	t4.Mul(r, t)   // t10
+	c.y.Sub(t4, t6)
+
+	c.z.Set(z)
+}
+
+func (c *twistPoint) Double(a *twistPoint) {
+	// See http://hyperelliptic.org/EFD/g1p/auto-code/shortw/jacobian-0/doubling/dbl-2009-l.op3
+	A := newGFp2().Square(a.x)
+	B := newGFp2().Square(a.y)
+	C := newGFp2().Square(B)
+
+	// z = 2·y·z is computed upfront, as c may alias a
+	z := newGFp2().Mul(a.y, a.z)
+	z.Add(z, z)
+
+	t := newGFp2().Add(a.x, B)
+	t2 := newGFp2().Square(t)
+	t.Sub(t2, A)
+	t2.Sub(t, C)
+	d := newGFp2().Add(t2, t2)
+	t.Add(A, A)
+	e := newGFp2().Add(t, A)
+	f := newGFp2().Square(e)
+
+	t.Add(d, d)
+	c.x.Sub(f, t)
+
+	t.Add(C, C)
+	t2.Add(t, t)
+	t.Add(t2, t2)
+	c.y.Sub(d, c.x)
+	t2.Mul(e, c.y)
+	c.y.Sub(t2, t)
+
+	c.z.Set(z)
+}
+
+func (c *twistPoint) Mul(a *twistPoint, scalar *big.Int) *twistPoint {
+	sum := newTwistPoint()
+	sum.SetInfinity()
+	t := newTwistPoint()
+
+	for i := scalar.BitLen(); i >= 0; i-- {
+		t.Double(sum)
+		if scalar.Bit(i) != 0 {
+			sum.Add(t, a)
+		} else {
+			sum.Set(t)
+		}
+	}
+
+	c.Set(sum)
+	return c
+}
+
+// MakeAffine converts c to affine form and returns c. If c is ∞, then it sets
+// c to 0 : 1 : 0.
+func (c *twistPoint) MakeAffine() *twistPoint {
+	if c.z.IsOne() {
+		return c
+	}
+	if c.IsInfinity() {
+		c.x.SetZero()
+		c.y.SetOne()
+		c.z.SetZero()
+		c.t.SetZero()
+		return c
+	}
+	zInv := newGFp2().Invert(c.z)
+	t := newGFp2().Mul(c.y, zInv)
+	zInv2 := newGFp2().Square(zInv)
+	c.y.Mul(t, zInv2)
+	t.Mul(c.x, zInv2)
+	c.x.Set(t)
+	c.z.SetOne()
+	c.t.SetOne()
+
+	return c
+}
+
+func (c *twistPoint) Negative(a *twistPoint) {
+	c.x.Set(a.x)
+	c.y.SetZero()
+	c.y.Sub(c.y, a.y)
+	c.z.Set(a.z)
+	c.t.SetZero()
+}
@@ -19,7 +19,6 @@ package eth
 import (
 	"bytes"
 	"compress/gzip"
-	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -83,10 +82,7 @@ type PublicMinerAPI struct {
 
 // NewPublicMinerAPI create a new PublicMinerAPI instance.
 func NewPublicMinerAPI(e *Daxxcoin) *PublicMinerAPI {
-	agent := miner.NewRemoteAgent(e.Pow())
-	e.Miner().Register(agent)
-
-	return &PublicMinerAPI{e, agent}
+	return &PublicMinerAPI{e, e.remoteAgent}
 }
 
 // Mining returns an indication if this node is currently mining.
@@ -196,6 +192,15 @@ func (s *PrivateMinerAPI) MakeDAG(blockNr rpc.BlockNumber) (bool, error) {
 	return true, nil
 }
 
+// StratumStats returns the statistics of the stratum mining server, including
+// the shares submitted and the hashrate reported by each worker.
+func (s *PrivateMinerAPI) StratumStats() (*miner.StratumStats, error) {
+	if s.e.stratum == nil {
+		return nil, fmt.Errorf("stratum server not enabled")
+	}
+	return s.e.stratum.Stats(), nil
+}
+
 // PrivateAdminAPI is the collection of Daxxcoin full node-related APIs
 // exposed over the private admin endpoint.
 type PrivateAdminAPI struct {
@@ -314,6 +319,44 @@ func (api *PublicDebugAPI) DumpBlock(number uint64) (state.Dump, error) {
 	return stateDb.RawDump(), nil
 }
 
+// maxRangeResults is the maximum number of accounts or storage slots returned by
+// a single paginated state range query.
+const maxRangeResults = 256
+
+// capRangeResults limits the requested number of range query results.
+func capRangeResults(maxResults int) int {
+	if maxResults <= 0 || maxResults > maxRangeResults {
+		return maxRangeResults
+	}
+	return maxResults
+}
+
+// AccountRange retrieves a page of the accounts in the state at a given block,
+// ordered by the hash of their address and starting at start. Use the returned
+// next key as the start of the following page.
+func (api *PublicDebugAPI) AccountRange(ctx context.Context, blockNr rpc.BlockNumber, start hexutil.Bytes, maxResults int) (state.AccountRange, error) {
+	var (
+		stateDb *state.StateDB
+		err     error
+	)
+	switch blockNr {
+	case rpc.PendingBlockNumber:
+		_, stateDb = api.eth.miner.Pending()
+	case rpc.LatestBlockNumber:
+		stateDb, err = api.eth.BlockChain().State()
+	default:
+		block := api.eth.BlockChain().GetBlockByNumber(uint64(blockNr))
+		if block == nil {
+			return state.AccountRange{}, fmt.Errorf("block #%d not found", blockNr)
+		}
+		stateDb, err = api.eth.BlockChain().StateAt(block.Root())
+	}
+	if err != nil {
+		return state.AccountRange{}, err
+	}
+	return stateDb.AccountRange(start, capRangeResults(maxResults))
+}
+
 // PrivateDebugAPI is the collection of Daxxcoin full node APIs exposed over
 // the private debugging endpoint.
 type PrivateDebugAPI struct {
@@ -417,7 +460,7 @@ func (api *PrivateDebugAPI) traceBlock(block *types.Block, logConfig *vm.LogConf
 		Tracer: structLogger,
 	}
 
-	if err := core.ValidateHeader(api.config, blockchain.AuxValidator(), block.Header(), blockchain.GetHeader(block.ParentHash(), block.NumberU64()-1), true, false); err != nil {
+	if err := blockchain.Engine().VerifyHeader(blockchain, block.Header(), true); err != nil {
 		return false, structLogger.StructLogs(), err
 	}
 	statedb, err := blockchain.StateAt(blockchain.GetBlock(block.ParentHash(), block.NumberU64()-1).Root())
@@ -501,64 +544,83 @@ func (api *PrivateDebugAPI) TraceTransaction(ctx context.Context, txHash common.
 		tracer = vm.NewStructLogger(config.LogConfig)
 	}
 
-	// Retrieve the tx from the chain and the containing block
+	// Retrieve the tx from the chain and the environment it was executed in
 	tx, blockHash, _, txIndex := core.GetTransaction(api.eth.ChainDb(), txHash)
 	if tx == nil {
 		return nil, fmt.Errorf("transaction %x not found", txHash)
 	}
+	msg, vmctx, stateDb, err := api.computeTxEnv(blockHash, int(txIndex))
+	if err != nil {
+		return nil, err
+	}
+	// Trace the selected transaction
+	vmenv := vm.NewEVM(vmctx, stateDb, api.config, vm.Config{Debug: true, Tracer: tracer})
+	ret, gas, failed, err := core.ApplyMessage(vmenv, msg, new(core.GasPool).AddGas(tx.Gas()))
+	if err != nil {
+		return nil, fmt.Errorf("tracing failed: %v", err)
+	}
+	switch tracer := tracer.(type) {
+	case *vm.StructLogger:
+		return &ethapi.ExecutionResult{
+			Gas:         gas,
+			Failed:      failed,
+			ReturnValue: fmt.Sprintf("%x", ret),
+			StructLogs:  ethapi.FormatLogs(tracer.StructLogs()),
+		}, nil
+	case *ethapi.JavascriptTracer:
+		return tracer.GetResult()
+	default:
+		panic(fmt.Sprintf("bad tracer type %T", tracer))
+	}
+}
+
+// computeTxEnv returns the execution environment of a certain transaction: its
+// message, the EVM context and the state right before its execution.
+func (api *PrivateDebugAPI) computeTxEnv(blockHash common.Hash, txIndex int) (core.Message, vm.Context, *state.StateDB, error) {
+	// Create the parent state database
 	block := api.eth.BlockChain().GetBlockByHash(blockHash)
 	if block == nil {
-		return nil, fmt.Errorf("block %x not found", blockHash)
+		return nil, vm.Context{}, nil, fmt.Errorf("block %x not found", blockHash)
 	}
-	// Create the state database to mutate and eventually trace
 	parent := api.eth.BlockChain().GetBlock(block.ParentHash(), block.NumberU64()-1)
 	if parent == nil {
-		return nil, fmt.Errorf("block parent %x not found", block.ParentHash())
+		return nil, vm.Context{}, nil, fmt.Errorf("block parent %x not found", block.ParentHash())
 	}
 	stateDb, err := api.eth.BlockChain().StateAt(parent.Root())
 	if err != nil {
-		return nil, err
+		return nil, vm.Context{}, nil, err
 	}
-
+	// Recompute transactions up to the target index
 	signer := types.MakeSigner(api.config, block.Number())
-	// Mutate the state and trace the selected transaction
 	for idx, tx := range block.Transactions() {
 		// Assemble the transaction call message
 		msg, err := tx.AsMessage(signer)
 		if err != nil {
-			return nil, fmt.Errorf("sender retrieval failed: %v", err)
+			return nil, vm.Context{}, nil, fmt.Errorf("sender retrieval failed: %v", err)
 		}
 		context := core.NewEVMContext(msg, block.Header(), api.eth.BlockChain())
-
-		// Mutate the state if we haven't reached the tracing transaction yet
-		if uint64(idx) < txIndex {
-			vmenv := vm.NewEVM(context, stateDb, api.config, vm.Config{})
-			_, _, err := core.ApplyMessage(vmenv, msg, new(core.GasPool).AddGas(tx.Gas()))
-			if err != nil {
-				return nil, fmt.Errorf("mutation failed: %v", err)
-			}
-			stateDb.DeleteSuicides()
-			continue
+		if idx == txIndex {
+			return msg, context, stateDb, nil
 		}
-
-		vmenv := vm.NewEVM(context, stateDb, api.config, vm.Config{Debug: true, Tracer: tracer})
-		ret, gas, err := core.ApplyMessage(vmenv, msg, new(core.GasPool).AddGas(tx.Gas()))
-		if err != nil {
-			return nil, fmt.Errorf("tracing failed: %v", err)
+		// Not yet the searched for transaction, execute on top of the current state
+		vmenv := vm.NewEVM(context, stateDb, api.config, vm.Config{})
+		if _, _, _, err := core.ApplyMessage(vmenv, msg, new(core.GasPool).AddGas(tx.Gas())); err != nil {
+			return nil, vm.Context{}, nil, fmt.Errorf("mutation failed: %v", err)
 		}
+		stateDb.DeleteSuicides()
+	}
+	return nil, vm.Context{}, nil, fmt.Errorf("tx index %d out of range for block %x", txIndex, blockHash)
+}
 
-		switch tracer := tracer.(type) {
-		case *vm.StructLogger:
-			return &ethapi.ExecutionResult{
-				Gas:         gas,
-				ReturnValue: fmt.Sprintf("%x", ret),
-				StructLogs:  ethapi.FormatLogs(tracer.StructLogs()),
-			}, nil
-		case *ethapi.JavascriptTracer:
-			return tracer.GetResult()
-		}
+// StorageRangeAt returns a page of the storage of the given contract, as it was
+// right before executing the transaction at txIndex in the given block. Slots
+// are ordered by the hash of their keys, starting at keyStart.
+func (api *PrivateDebugAPI) StorageRangeAt(ctx context.Context, blockHash common.Hash, txIndex int, contractAddress common.Address, keyStart hexutil.Bytes, maxResult int) (state.StorageRange, error) {
+	_, _, stateDb, err := api.computeTxEnv(blockHash, txIndex)
+	if err != nil {
+		return state.StorageRange{}, err
 	}
-	return nil, errors.New("database inconsistency")
+	return stateDb.StorageRange(contractAddress, keyStart, capRangeResults(maxResult))
 }
 
 // Preimage is a debug API function that returns the preimage for a sha3 hash, if known.
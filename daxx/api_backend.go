@@ -31,6 +31,7 @@ import (
 	"github.com/daxxcoin/daxxcore/event"
 	"github.com/daxxcoin/daxxcore/internal/ethapi"
 	"github.com/daxxcoin/daxxcore/params"
+	"github.com/daxxcoin/daxxcore/rlp"
 	"github.com/daxxcoin/daxxcore/rpc"
 	"golang.org/x/net/context"
 )
@@ -49,8 +50,8 @@ func (b *EthApiBackend) CurrentBlock() *types.Block {
 	return b.eth.blockchain.CurrentBlock()
 }
 
-func (b *EthApiBackend) SetHead(number uint64) {
-	b.eth.blockchain.SetHead(number)
+func (b *EthApiBackend) SetHead(number uint64) error {
+	return b.eth.blockchain.SetHead(number)
 }
 
 func (b *EthApiBackend) HeaderByNumber(ctx context.Context, blockNr rpc.BlockNumber) (*types.Header, error) {
@@ -99,7 +100,11 @@ func (b *EthApiBackend) GetBlock(ctx context.Context, blockHash common.Hash) (*t
 }
 
 func (b *EthApiBackend) GetReceipts(ctx context.Context, blockHash common.Hash) (types.Receipts, error) {
-	return core.GetBlockReceipts(b.eth.chainDb, blockHash, core.GetBlockNumber(b.eth.chainDb, blockHash)), nil
+	return core.GetBlockReceipts(b.eth.chainDb, blockHash, core.GetBlockNumber(b.eth.chainDb, blockHash), b.eth.chainConfig), nil
+}
+
+func (b *EthApiBackend) TxIndexProgress() (tail uint64, indexing bool) {
+	return b.eth.blockchain.TxIndexProgress()
 }
 
 func (b *EthApiBackend) GetTd(blockHash common.Hash) *big.Int {
@@ -175,6 +180,17 @@ func (b *EthApiBackend) TxPoolContent() (map[common.Address]types.Transactions,
 	return b.eth.TxPool().Content()
 }
 
+func (b *EthApiBackend) TxPoolContentFrom(addr common.Address) (types.Transactions, types.Transactions) {
+	b.eth.txMu.Lock()
+	defer b.eth.txMu.Unlock()
+
+	return b.eth.TxPool().ContentFrom(addr)
+}
+
+func (b *EthApiBackend) SubscribeTxEvents(ch chan<- interface{}) event.Subscription {
+	return b.eth.TxPool().SubscribeTxEvents(ch)
+}
+
 func (b *EthApiBackend) Downloader() *downloader.Downloader {
 	return b.eth.Downloader()
 }
@@ -218,3 +234,19 @@ func (s EthApiState) GetState(ctx context.Context, a common.Address, b common.Ha
 func (s EthApiState) GetNonce(ctx context.Context, addr common.Address) (uint64, error) {
 	return s.state.GetNonce(addr), nil
 }
+
+func (s EthApiState) GetCodeHash(ctx context.Context, addr common.Address) (common.Hash, error) {
+	return s.state.GetCodeHash(addr), nil
+}
+
+func (s EthApiState) GetStorageRoot(ctx context.Context, addr common.Address) (common.Hash, error) {
+	return s.state.GetStorageRoot(addr), nil
+}
+
+func (s EthApiState) GetProof(ctx context.Context, addr common.Address) ([]rlp.RawValue, error) {
+	return s.state.GetProof(addr), nil
+}
+
+func (s EthApiState) GetStorageProof(ctx context.Context, addr common.Address, key common.Hash) ([]rlp.RawValue, error) {
+	return s.state.GetStorageProof(addr, key), nil
+}
@@ -18,19 +18,19 @@
 package eth
 
 import (
-	"errors"
 	"fmt"
 	"math/big"
 	"os"
 	"path/filepath"
 	"regexp"
-	"strings"
 	"sync"
 	"time"
 
-	"github.com/daxxnucleus/daxxhash"
 	"github.com/daxxcoin/daxxcore/accounts"
 	"github.com/daxxcoin/daxxcore/common"
+	"github.com/daxxcoin/daxxcore/consensus"
+	"github.com/daxxcoin/daxxcore/consensus/clique"
+	"github.com/daxxcoin/daxxcore/consensus/daxxhash"
 	"github.com/daxxcoin/daxxcore/core"
 	"github.com/daxxcoin/daxxcore/core/types"
 	"github.com/daxxcoin/daxxcore/core/vm"
@@ -46,8 +46,8 @@ import (
 	"github.com/daxxcoin/daxxcore/node"
 	"github.com/daxxcoin/daxxcore/p2p"
 	"github.com/daxxcoin/daxxcore/params"
-	"github.com/daxxcoin/daxxcore/pow"
 	"github.com/daxxcoin/daxxcore/rpc"
+	"github.com/daxxnucleus/daxxhash"
 )
 
 const (
@@ -56,6 +56,9 @@ const (
 
 	autoDAGcheckInterval = 10 * time.Hour
 	autoDAGepochHeight   = epochLength / 2
+
+	defaultTrieCache         = 256  // Megabytes of memory allowed for recent state tries if unset
+	defaultTrieFlushInterval = 1024 // Number of blocks after which recent state tries are flushed if unset
 )
 
 var (
@@ -64,19 +67,30 @@ var (
 )
 
 type Config struct {
-	ChainConfig *params.ChainConfig // chain configuration
-
-	NetworkId  int    // Network ID to use for selecting peers to connect to
-	Genesis    string // Genesis JSON to seed the chain database with
-	FastSync   bool   // Enables the state download based fast synchronisation algorithm
-	LightMode  bool   // Running in light client mode
-	LightServ  int    // Maximum percentage of time allowed for serving LES requests
-	LightPeers int    // Maximum number of LES client peers
-	MaxPeers   int    // Maximum number of global peers
-
-	SkipBcVersionCheck bool // e.g. blockchain export
-	DatabaseCache      int
-	DatabaseHandles    int
+	// The genesis block, which is inserted if the database is empty.
+	// If nil, the Daxxcoin main net block is used.
+	Genesis *core.Genesis
+
+	NetworkId  int  // Network ID to use for selecting peers to connect to
+	FastSync   bool // Enables the state download based fast synchronisation algorithm
+	LightMode  bool // Running in light client mode
+	LightServ  int  // Maximum percentage of time allowed for serving LES requests
+	LightPeers int  // Maximum number of LES client peers
+	MaxPeers   int  // Maximum number of global peers
+
+	SkipBcVersionCheck  bool // e.g. blockchain export
+	DatabaseCache       int
+	DatabaseHandles     int
+	DatabaseFreezer     string                // Directory of the ancient store, defaults to within the chain database
+	DatabaseCompression core.ChainCompression // Compression of block bodies and receipts in the chain database
+
+	NoPruning         bool   // Whether to disable trie pruning and write every state to disk (archive mode)
+	TrieCache         int    // Megabytes of memory allowed for recent state tries before flushing them to disk (0 = 256)
+	TrieFlushInterval uint64 // Number of blocks after which recent state tries are flushed to disk (0 = 1024)
+	SnapshotCache     int    // Megabytes of memory allowed for the flat state snapshot read cache (0 disables snapshots)
+	TxLookupLimit     uint64 // Number of recent blocks to keep transaction lookups for (0 = entire chain)
+
+	TxPool core.TxPoolConfig // Limits of the transaction pool
 
 	DocRoot   string
 	AutoDAG   bool
@@ -85,9 +99,10 @@ type Config struct {
 	PowShared bool
 	ExtraData []byte
 
-	Daxxcoinbase    common.Address
+	Daxxcoinbase common.Address
 	GasPrice     *big.Int
 	MinerThreads int
+	Stratum      miner.StratumConfig // Stratum mining server settings (empty address = disabled)
 	SolcPath     string
 
 	GpoMinGasPrice          *big.Int
@@ -113,8 +128,9 @@ type LesServer interface {
 type Daxxcoin struct {
 	chainConfig *params.ChainConfig
 	// Channel for shutting down the service
-	shutdownChan  chan bool // Channel for shutting down the daxxcoin
-	stopDbUpgrade func()    // stop chain db sequential key upgrade
+	shutdownChan       chan bool // Channel for shutting down the daxxcoin
+	stopDbUpgrade      func()    // stop chain db sequential key upgrade
+	stopReceiptUpgrade func()    // stop chain db slim receipt upgrade
 	// Handlers
 	txPool          *core.TxPool
 	txMu            sync.Mutex
@@ -125,17 +141,19 @@ type Daxxcoin struct {
 	chainDb ethdb.Database // Block chain database
 
 	eventMux       *event.TypeMux
-	pow            pow.PoW
+	engine         consensus.Engine
 	accountManager *accounts.Manager
 
 	ApiBackend *EthApiBackend
 
 	miner        *miner.Miner
+	remoteAgent  *miner.RemoteAgent   // Work distributor for external miners
+	stratum      *miner.StratumServer // Stratum server for external miners, if enabled
 	Mining       bool
 	MinerThreads int
 	AutoDAG      bool
 	autodagquit  chan bool
-	daxxcoinbase    common.Address
+	daxxcoinbase common.Address
 	solcPath     string
 
 	netVersionId  int
@@ -155,26 +173,29 @@ func New(ctx *node.ServiceContext, config *Config) (*Daxxcoin, error) {
 		return nil, err
 	}
 	stopDbUpgrade := upgradeSequentialKeys(chainDb)
-	if err := SetupGenesisBlock(&chainDb, config); err != nil {
-		return nil, err
+	stopReceiptUpgrade := upgradeSlimReceipts(chainDb)
+	chainConfig, genesisErr := SetupGenesisBlock(&chainDb, config)
+	if _, ok := genesisErr.(*params.ConfigCompatError); genesisErr != nil && !ok {
+		return nil, genesisErr
 	}
-	pow, err := CreatePoW(config)
+	engine, err := CreateConsensusEngine(config, chainConfig, chainDb)
 	if err != nil {
 		return nil, err
 	}
 
 	eth := &Daxxcoin{
-		chainDb:        chainDb,
-		eventMux:       ctx.EventMux,
-		accountManager: ctx.AccountManager,
-		pow:            pow,
-		shutdownChan:   make(chan bool),
-		stopDbUpgrade:  stopDbUpgrade,
-		netVersionId:   config.NetworkId,
-		daxxcoinbase:      config.Daxxcoinbase,
-		MinerThreads:   config.MinerThreads,
-		AutoDAG:        config.AutoDAG,
-		solcPath:       config.SolcPath,
+		chainDb:            chainDb,
+		eventMux:           ctx.EventMux,
+		accountManager:     ctx.AccountManager,
+		engine:             engine,
+		shutdownChan:       make(chan bool),
+		stopDbUpgrade:      stopDbUpgrade,
+		stopReceiptUpgrade: stopReceiptUpgrade,
+		netVersionId:       config.NetworkId,
+		daxxcoinbase:       config.Daxxcoinbase,
+		MinerThreads:       config.MinerThreads,
+		AutoDAG:            config.AutoDAG,
+		solcPath:           config.SolcPath,
 	}
 
 	if err := upgradeChainDatabase(chainDb); err != nil {
@@ -194,34 +215,45 @@ func New(ctx *node.ServiceContext, config *Config) (*Daxxcoin, error) {
 		core.WriteBlockChainVersion(chainDb, core.BlockChainVersion)
 	}
 
-	// load the genesis block or write a new one if no genesis
-	// block is prenent in the database.
-	genesis := core.GetBlock(chainDb, core.GetCanonicalHash(chainDb, 0), 0)
-	if genesis == nil {
-		genesis, err = core.WriteDefaultGenesisBlock(chainDb)
-		if err != nil {
-			return nil, err
-		}
-		glog.V(logger.Info).Infoln("WARNING: Wrote default daxxcoin genesis block")
-	}
-
-	if config.ChainConfig == nil {
-		return nil, errors.New("missing chain config")
-	}
-	core.WriteChainConfig(chainDb, genesis.Hash(), config.ChainConfig)
-
-	eth.chainConfig = config.ChainConfig
+	eth.chainConfig = chainConfig
 
 	glog.V(logger.Info).Infoln("Chain config:", eth.chainConfig)
 
-	eth.blockchain, err = core.NewBlockChain(chainDb, eth.chainConfig, eth.pow, eth.EventMux(), vm.Config{EnablePreimageRecording: config.EnablePreimageRecording})
+	trieCache, trieFlushInterval := config.TrieCache, config.TrieFlushInterval
+	if trieCache <= 0 {
+		trieCache = defaultTrieCache
+	}
+	if trieFlushInterval == 0 {
+		trieFlushInterval = defaultTrieFlushInterval
+	}
+	cacheConfig := &core.CacheConfig{
+		Disabled:          config.NoPruning,
+		TrieNodeLimit:     common.StorageSize(trieCache) * 1024 * 1024,
+		TrieFlushInterval: trieFlushInterval,
+		SnapshotLimit:     config.SnapshotCache,
+		TxLookupLimit:     config.TxLookupLimit,
+	}
+	eth.blockchain, err = core.NewBlockChain(chainDb, cacheConfig, eth.chainConfig, eth.engine, eth.EventMux(), vm.Config{EnablePreimageRecording: config.EnablePreimageRecording})
 	if err != nil {
 		if err == core.ErrNoGenesis {
 			return nil, fmt.Errorf(`No chain found. Please initialise a new chain using the "init" subcommand.`)
 		}
 		return nil, err
 	}
-	newPool := core.NewTxPool(eth.chainConfig, eth.EventMux(), eth.blockchain.State, eth.blockchain.GasLimit)
+	// Rewind the chain in case of an incompatible config upgrade
+	if compat, ok := genesisErr.(*params.ConfigCompatError); ok {
+		glog.V(logger.Warn).Infof("Rewinding chain to #%d to upgrade configuration: %v", compat.RewindTo, compat)
+		if err := eth.blockchain.SetHead(compat.RewindTo); err != nil {
+			return nil, err
+		}
+		if err := core.WriteChainConfig(chainDb, eth.blockchain.Genesis().Hash(), chainConfig); err != nil {
+			return nil, err
+		}
+	}
+	if config.TxPool.Journal != "" {
+		config.TxPool.Journal = ctx.ResolvePath(config.TxPool.Journal)
+	}
+	newPool := core.NewTxPool(config.TxPool, eth.chainConfig, chainDb, eth.EventMux(), eth.blockchain.State, eth.blockchain.GasLimit)
 	eth.txPool = newPool
 
 	maxPeers := config.MaxPeers
@@ -235,13 +267,19 @@ func New(ctx *node.ServiceContext, config *Config) (*Daxxcoin, error) {
 		}
 	}
 
-	if eth.protocolManager, err = NewProtocolManager(eth.chainConfig, config.FastSync, config.NetworkId, maxPeers, eth.eventMux, eth.txPool, eth.pow, eth.blockchain, chainDb); err != nil {
+	if eth.protocolManager, err = NewProtocolManager(eth.chainConfig, config.FastSync, config.NetworkId, maxPeers, eth.eventMux, eth.txPool, eth.engine, eth.blockchain, chainDb); err != nil {
 		return nil, err
 	}
-	eth.miner = miner.New(eth, eth.chainConfig, eth.EventMux(), eth.pow)
+	eth.miner = miner.New(eth, eth.chainConfig, eth.EventMux(), eth.engine)
 	eth.miner.SetGasPrice(config.GasPrice)
 	eth.miner.SetExtra(config.ExtraData)
 
+	eth.remoteAgent = miner.NewRemoteAgent(eth.BlockChain(), eth.Engine())
+	eth.miner.Register(eth.remoteAgent)
+	if config.Stratum.Addr != "" {
+		eth.stratum = miner.NewStratumServer(eth.remoteAgent, config.Stratum)
+	}
+
 	gpoParams := &gasprice.GpoParams{
 		GpoMinGasPrice:          config.GpoMinGasPrice,
 		GpoMaxGasPrice:          config.GpoMaxGasPrice,
@@ -258,23 +296,30 @@ func New(ctx *node.ServiceContext, config *Config) (*Daxxcoin, error) {
 
 // CreateDB creates the chain database.
 func CreateDB(ctx *node.ServiceContext, config *Config, name string) (ethdb.Database, error) {
-	db, err := ctx.OpenDatabase(name, config.DatabaseCache, config.DatabaseHandles)
+	var (
+		db  ethdb.Database
+		err error
+	)
+	// Only the full chain database keeps immutable chain data in an ancient store
+	if name == "chaindata" {
+		db, err = ctx.OpenDatabaseWithFreezer(name, config.DatabaseCache, config.DatabaseHandles, config.DatabaseFreezer)
+	} else {
+		db, err = ctx.OpenDatabase(name, config.DatabaseCache, config.DatabaseHandles)
+	}
+	if err != nil {
+		return nil, err
+	}
 	if db, ok := db.(*ethdb.LDBDatabase); ok {
 		db.Meter("eth/db/chaindata/")
 	}
-	return db, err
+	return ethdb.NewCompressedDatabase(db, core.ChainCompressionRules(config.DatabaseCompression)), nil
 }
 
-// SetupGenesisBlock initializes the genesis block for an Daxxcoin service
-func SetupGenesisBlock(chainDb *ethdb.Database, config *Config) error {
-	// Load up any custom genesis block if requested
-	if len(config.Genesis) > 0 {
-		block, err := core.WriteGenesisBlock(*chainDb, strings.NewReader(config.Genesis))
-		if err != nil {
-			return err
-		}
-		glog.V(logger.Info).Infof("Successfully wrote custom genesis block: %x", block.Hash())
-	}
+// SetupGenesisBlock initializes the genesis block for an Daxxcoin service and
+// returns the chain configuration of the network it belongs to. A returned
+// *params.ConfigCompatError signals that the chain must be rewound before the
+// configuration can be applied, the configuration is valid in that case too.
+func SetupGenesisBlock(chainDb *ethdb.Database, config *Config) (*params.ChainConfig, error) {
 	// Load up a test setup if directly injected
 	if config.TestGenesisState != nil {
 		*chainDb = config.TestGenesisState
@@ -285,30 +330,48 @@ func SetupGenesisBlock(chainDb *ethdb.Database, config *Config) error {
 		core.WriteCanonicalHash(*chainDb, config.TestGenesisBlock.Hash(), config.TestGenesisBlock.NumberU64())
 		core.WriteHeadBlockHash(*chainDb, config.TestGenesisBlock.Hash())
 	}
-	return nil
+	// Write or verify the genesis block, retrieving the chain configuration
+	chainConfig, genesisHash, err := core.SetupGenesisBlock(*chainDb, config.Genesis)
+	if _, ok := err.(*params.ConfigCompatError); err != nil && !ok {
+		return nil, err
+	}
+	glog.V(logger.Info).Infof("Using genesis block %x", genesisHash[:8])
+	return chainConfig, err
 }
 
-// CreatePoW creates the required type of PoW instance for an Daxxcoin service
-func CreatePoW(config *Config) (pow.PoW, error) {
+// CreateConsensusEngine creates the required type of consensus engine instance for an Daxxcoin service
+func CreateConsensusEngine(config *Config, chainConfig *params.ChainConfig, db ethdb.Database) (consensus.Engine, error) {
+	// If proof-of-authority is requested, set it up
+	if chainConfig.Clique != nil {
+		glog.V(logger.Info).Infof("clique used as consensus engine")
+		return clique.New(chainConfig.Clique, db), nil
+	}
+	// Otherwise assume proof-of-work
 	switch {
 	case config.PowFake:
-		glog.V(logger.Info).Infof("ethash used in fake mode")
-		return pow.PoW(core.FakePow{}), nil
+		glog.V(logger.Info).Infof("daxxhash used in fake mode")
+		return daxxhash.NewFaker(), nil
 	case config.PowTest:
-		glog.V(logger.Info).Infof("ethash used in test mode")
-		return ethash.NewForTesting()
+		glog.V(logger.Info).Infof("daxxhash used in test mode")
+		return daxxhash.NewTester()
 	case config.PowShared:
-		glog.V(logger.Info).Infof("ethash used in shared mode")
-		return ethash.NewShared(), nil
+		glog.V(logger.Info).Infof("daxxhash used in shared mode")
+		return daxxhash.NewShared(), nil
 	default:
-		return ethash.New(), nil
+		return daxxhash.New(), nil
 	}
 }
 
 // APIs returns the collection of RPC services the daxxcoin package offers.
 // NOTE, some of these services probably need to be moved to somewhere else.
 func (s *Daxxcoin) APIs() []rpc.API {
-	return append(ethapi.GetAPIs(s.ApiBackend, s.solcPath), []rpc.API{
+	apis := ethapi.GetAPIs(s.ApiBackend, s.solcPath)
+
+	// Append any APIs exposed explicitly by the consensus engine
+	apis = append(apis, s.engine.APIs(s.BlockChain())...)
+
+	// Append all the local APIs and return
+	return append(apis, []rpc.API{
 		{
 			Namespace: "eth",
 			Version:   "1.0",
@@ -385,6 +448,15 @@ func (s *Daxxcoin) StartMining(threads int) error {
 		glog.V(logger.Error).Infoln(err)
 		return err
 	}
+	if clique, ok := s.engine.(*clique.Clique); ok {
+		wallet, err := s.accountManager.Find(accounts.Account{Address: eb})
+		if wallet == nil || err != nil {
+			err = fmt.Errorf("Signer missing: %v", err)
+			glog.V(logger.Error).Infoln(err)
+			return err
+		}
+		clique.Authorize(eb, wallet.SignHash)
+	}
 	go s.miner.Start(eb, threads)
 	return nil
 }
@@ -397,7 +469,7 @@ func (s *Daxxcoin) AccountManager() *accounts.Manager  { return s.accountManager
 func (s *Daxxcoin) BlockChain() *core.BlockChain       { return s.blockchain }
 func (s *Daxxcoin) TxPool() *core.TxPool               { return s.txPool }
 func (s *Daxxcoin) EventMux() *event.TypeMux           { return s.eventMux }
-func (s *Daxxcoin) Pow() pow.PoW                       { return s.pow }
+func (s *Daxxcoin) Engine() consensus.Engine           { return s.engine }
 func (s *Daxxcoin) ChainDb() ethdb.Database            { return s.chainDb }
 func (s *Daxxcoin) IsListening() bool                  { return true } // Always listening
 func (s *Daxxcoin) EthVersion() int                    { return int(s.protocolManager.SubProtocols[0].Version) }
@@ -425,6 +497,11 @@ func (s *Daxxcoin) Start(srvr *p2p.Server) error {
 	if s.lesServer != nil {
 		s.lesServer.Start(srvr)
 	}
+	if s.stratum != nil {
+		if err := s.stratum.Start(); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -434,12 +511,18 @@ func (s *Daxxcoin) Stop() error {
 	if s.stopDbUpgrade != nil {
 		s.stopDbUpgrade()
 	}
+	if s.stopReceiptUpgrade != nil {
+		s.stopReceiptUpgrade()
+	}
 	s.blockchain.Stop()
 	s.protocolManager.Stop()
 	if s.lesServer != nil {
 		s.lesServer.Stop()
 	}
 	s.txPool.Stop()
+	if s.stratum != nil {
+		s.stratum.Stop()
+	}
 	s.miner.Stop()
 	s.eventMux.Stop()
 
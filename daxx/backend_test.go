@@ -17,7 +17,12 @@
 package eth
 
 import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
 	"math/big"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/daxxcoin/daxxcore/common"
@@ -25,23 +30,24 @@ import (
 	"github.com/daxxcoin/daxxcore/core/types"
 	"github.com/daxxcoin/daxxcore/daxxdb"
 	"github.com/daxxcoin/daxxcore/params"
+	"github.com/daxxcoin/daxxcore/rlp"
 )
 
 func TestMipmapUpgrade(t *testing.T) {
 	db, _ := ethdb.NewMemDatabase()
 	addr := common.BytesToAddress([]byte("jeff"))
-	genesis := core.WriteGenesisBlockForTesting(db)
+	genesis := new(core.Genesis).MustCommit(db)
 
 	chain, receipts := core.GenerateChain(params.TestChainConfig, genesis, db, 10, func(i int, gen *core.BlockGen) {
 		var receipts types.Receipts
 		switch i {
 		case 1:
-			receipt := types.NewReceipt(nil, new(big.Int))
+			receipt := types.NewReceipt(nil, false, new(big.Int))
 			receipt.Logs = []*types.Log{{Address: addr}}
 			gen.AddUncheckedReceipt(receipt)
 			receipts = types.Receipts{receipt}
 		case 2:
-			receipt := types.NewReceipt(nil, new(big.Int))
+			receipt := types.NewReceipt(nil, false, new(big.Int))
 			receipt.Logs = []*types.Log{{Address: addr}}
 			gen.AddUncheckedReceipt(receipt)
 			receipts = types.Receipts{receipt}
@@ -81,3 +87,82 @@ func TestMipmapUpgrade(t *testing.T) {
 		t.Error("setting-mipmap-version not written to database")
 	}
 }
+
+func TestSlimReceiptsUpgrade(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	db.Put([]byte("LastHeader"), common.Hash{0x01}.Bytes())
+
+	// Store a few block receipts in the legacy format, and a per tx receipt
+	receipt := types.NewReceipt(nil, false, big.NewInt(21000))
+	receipt.Logs = []*types.Log{{Address: common.Address{0x11}}}
+	receipt.Bloom = types.CreateBloom(types.Receipts{receipt})
+	receipt.TxHash = common.Hash{0x22}
+	receipt.GasUsed = big.NewInt(21000)
+
+	legacy, _ := rlp.EncodeToBytes([]*types.FullReceiptForStorage{(*types.FullReceiptForStorage)(receipt)})
+	for i := uint64(0); i < 3; i++ {
+		db.Put(append(append([]byte("r"), make([]byte, 8)...), common.Hash{byte(i)}.Bytes()...), legacy)
+	}
+	core.WriteReceipt(db, receipt)
+
+	// Interrupt the conversion after the first entry, then resume it
+	if err, stopped := upgradeSlimBlockReceipts(db, func() bool { return true }); err != nil || !stopped {
+		t.Fatalf("interrupted conversion mismatch: err %v, stopped %v", err, stopped)
+	}
+	if data, _ := db.Get(slimReceiptsProgress); len(data) == 0 {
+		t.Fatalf("conversion progress not saved")
+	}
+	if err, stopped := upgradeSlimBlockReceipts(db, func() bool { return false }); err != nil || stopped {
+		t.Fatalf("resumed conversion mismatch: err %v, stopped %v", err, stopped)
+	}
+	slim, _ := rlp.EncodeToBytes([]*types.ReceiptForStorage{(*types.ReceiptForStorage)(receipt)})
+	for i := uint64(0); i < 3; i++ {
+		if blob, _ := db.Get(append(append([]byte("r"), make([]byte, 8)...), common.Hash{byte(i)}.Bytes()...)); !bytes.Equal(blob, slim) {
+			t.Errorf("block receipts %d not converted: have %x, want %x", i, blob, slim)
+		}
+	}
+	if r := core.GetReceipt(db, receipt.TxHash); r == nil || r.GasUsed.Cmp(receipt.GasUsed) != 0 {
+		t.Errorf("per transaction receipt modified: %v", r)
+	}
+}
+
+// Tests that the slim receipt conversion doesn't write the receipts of blocks
+// already moved into the ancient store back into the database.
+func TestSlimReceiptsUpgradeSkipsAncients(t *testing.T) {
+	dir, err := ioutil.TempDir("", "slim-receipts-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := ethdb.NewLDBDatabaseWithFreezer(filepath.Join(dir, "chaindata"), 0, 0, filepath.Join(dir, "ancient"))
+	if err != nil {
+		t.Fatalf("failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	receipt := types.NewReceipt(nil, false, big.NewInt(21000))
+	receipt.GasUsed = big.NewInt(21000)
+	legacy, _ := rlp.EncodeToBytes([]*types.FullReceiptForStorage{(*types.FullReceiptForStorage)(receipt)})
+
+	keys := make([][]byte, 2)
+	for i := range keys {
+		keys[i] = append(append([]byte("r"), make([]byte, 8)...), common.Hash{byte(i)}.Bytes()...)
+		binary.BigEndian.PutUint64(keys[i][1:], uint64(i))
+		db.Put(keys[i], legacy)
+	}
+	// Freeze the first block, leaving its stale receipts behind in the database
+	if err := db.Freezer().AppendAncient(0, common.Hash{0}.Bytes(), nil, nil, legacy, nil); err != nil {
+		t.Fatalf("failed to freeze block: %v", err)
+	}
+	if err, stopped := upgradeSlimBlockReceipts(db, func() bool { return false }); err != nil || stopped {
+		t.Fatalf("conversion mismatch: err %v, stopped %v", err, stopped)
+	}
+	if blob, _ := db.Get(keys[0]); !bytes.Equal(blob, legacy) {
+		t.Errorf("frozen block receipts rewritten: have %x, want %x", blob, legacy)
+	}
+	slim, _ := rlp.EncodeToBytes([]*types.ReceiptForStorage{(*types.ReceiptForStorage)(receipt)})
+	if blob, _ := db.Get(keys[1]); !bytes.Equal(blob, slim) {
+		t.Errorf("block receipts not converted: have %x, want %x", blob, slim)
+	}
+}
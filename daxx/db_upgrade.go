@@ -33,7 +33,14 @@ import (
 	"github.com/daxxcoin/daxxcore/rlp"
 )
 
-var useSequentialKeys = []byte("dbUpgrade_20160530sequentialKeys")
+var (
+	useSequentialKeys = []byte("dbUpgrade_20160530sequentialKeys")
+	useSlimReceipts   = []byte("dbUpgrade_20171020slimReceipts")
+
+	// slimReceiptsProgress is the key of the last block receipts entry converted
+	// to the slim format, to resume an interrupted conversion from.
+	slimReceiptsProgress = []byte("dbUpgrade_20171020slimReceiptsProgress")
+)
 
 // upgradeSequentialKeys checks the chain database version and
 // starts a background process to make upgrades if necessary.
@@ -92,22 +99,15 @@ func upgradeSequentialKeys(db ethdb.Database) (stopFn func()) {
 // the database, writes them in new format and deletes the old ones if successful.
 func upgradeSequentialCanonicalNumbers(db ethdb.Database, stopFn func() bool) (error, bool) {
 	prefix := []byte("block-num-")
-	it := db.(*ethdb.LDBDatabase).NewIterator()
+	it := db.NewIterator(prefix, nil)
 	defer func() {
 		it.Release()
 	}()
-	it.Seek(prefix)
 	cnt := 0
-	for bytes.HasPrefix(it.Key(), prefix) {
+	for it.Next() {
 		keyPtr := it.Key()
 		if len(keyPtr) < 20 {
 			cnt++
-			if cnt%100000 == 0 {
-				it.Release()
-				it = db.(*ethdb.LDBDatabase).NewIterator()
-				it.Seek(keyPtr)
-				glog.V(logger.Info).Infof("converting %d canonical numbers...", cnt)
-			}
 			number := big.NewInt(0).SetBytes(keyPtr[10:]).Uint64()
 			newKey := []byte("h12345678n")
 			binary.BigEndian.PutUint64(newKey[1:9], number)
@@ -117,12 +117,17 @@ func upgradeSequentialCanonicalNumbers(db ethdb.Database, stopFn func() bool) (e
 			if err := db.Delete(keyPtr); err != nil {
 				return err, false
 			}
+			if cnt%100000 == 0 {
+				start := common.CopyBytes(keyPtr[len(prefix):])
+				it.Release()
+				it = db.NewIterator(prefix, start)
+				glog.V(logger.Info).Infof("converting %d canonical numbers...", cnt)
+			}
 		}
 
 		if stopFn() {
 			return nil, true
 		}
-		it.Next()
 	}
 	if cnt > 0 {
 		glog.V(logger.Info).Infof("converted %d canonical numbers...", cnt)
@@ -135,41 +140,39 @@ func upgradeSequentialCanonicalNumbers(db ethdb.Database, stopFn func() bool) (e
 // if successful.
 func upgradeSequentialBlocks(db ethdb.Database, stopFn func() bool) (error, bool) {
 	prefix := []byte("block-")
-	it := db.(*ethdb.LDBDatabase).NewIterator()
+	it := db.NewIterator(prefix, nil)
 	defer func() {
 		it.Release()
 	}()
-	it.Seek(prefix)
+	var keyPrefix []byte // prefix of the entries belonging to the block being converted
+
 	cnt := 0
-	for bytes.HasPrefix(it.Key(), prefix) {
+	for it.Next() {
 		keyPtr := it.Key()
 		if len(keyPtr) >= 38 {
-			cnt++
-			if cnt%10000 == 0 {
-				it.Release()
-				it = db.(*ethdb.LDBDatabase).NewIterator()
-				it.Seek(keyPtr)
-				glog.V(logger.Info).Infof("converting %d blocks...", cnt)
-			}
-			// convert header, body, td and block receipts
-			var keyPrefix [38]byte
-			copy(keyPrefix[:], keyPtr[0:38])
-			hash := keyPrefix[6:38]
-			if err := upgradeSequentialBlockData(db, hash); err != nil {
-				return err, false
-			}
-			// delete old db entries belonging to this hash
-			for bytes.HasPrefix(it.Key(), keyPrefix[:]) {
-				if err := db.Delete(it.Key()); err != nil {
+			if keyPrefix == nil || !bytes.HasPrefix(keyPtr, keyPrefix) {
+				cnt++
+				keyPrefix = common.CopyBytes(keyPtr[0:38])
+				if cnt%10000 == 0 {
+					it.Release()
+					it = db.NewIterator(prefix, keyPrefix[len(prefix):])
+					it.Next()
+					keyPtr = it.Key()
+					glog.V(logger.Info).Infof("converting %d blocks...", cnt)
+				}
+				// convert header, body, td and block receipts
+				hash := keyPrefix[6:38]
+				if err := upgradeSequentialBlockData(db, hash); err != nil {
+					return err, false
+				}
+				if err := db.Delete(append([]byte("receipts-block-"), hash...)); err != nil {
 					return err, false
 				}
-				it.Next()
 			}
-			if err := db.Delete(append([]byte("receipts-block-"), hash...)); err != nil {
+			// delete old db entries belonging to this hash
+			if err := db.Delete(keyPtr); err != nil {
 				return err, false
 			}
-		} else {
-			it.Next()
 		}
 
 		if stopFn() {
@@ -186,11 +189,10 @@ func upgradeSequentialBlocks(db ethdb.Database, stopFn func() bool) (error, bool
 // database that did not have a corresponding block
 func upgradeSequentialOrphanedReceipts(db ethdb.Database, stopFn func() bool) (error, bool) {
 	prefix := []byte("receipts-block-")
-	it := db.(*ethdb.LDBDatabase).NewIterator()
+	it := db.NewIterator(prefix, nil)
 	defer it.Release()
-	it.Seek(prefix)
 	cnt := 0
-	for bytes.HasPrefix(it.Key(), prefix) {
+	for it.Next() {
 		// phase 2 already converted receipts belonging to existing
 		// blocks, just remove if there's anything left
 		cnt++
@@ -201,7 +203,6 @@ func upgradeSequentialOrphanedReceipts(db ethdb.Database, stopFn func() bool) (e
 		if stopFn() {
 			return nil, true
 		}
-		it.Next()
 	}
 	if cnt > 0 {
 		glog.V(logger.Info).Infof("removed %d orphaned block receipts...", cnt)
@@ -253,6 +254,152 @@ func upgradeSequentialBlockData(db ethdb.Database, hash []byte) error {
 	return nil
 }
 
+// upgradeSlimReceipts checks whether the block receipts in the chain database
+// are stored in the slim format and starts a background process to convert the
+// legacy ones if necessary. The conversion saves its progress along the way, so
+// an interrupted run continues where it left off on the next start. Returns a
+// stop function that blocks until the process has been safely stopped.
+func upgradeSlimReceipts(db ethdb.Database) (stopFn func()) {
+	data, _ := db.Get(useSlimReceipts)
+	if len(data) > 0 && data[0] == 42 {
+		return nil // already converted
+	}
+
+	if data, _ := db.Get([]byte("LastHeader")); len(data) == 0 {
+		db.Put(useSlimReceipts, []byte{42})
+		return nil // empty database, nothing to do
+	}
+
+	glog.V(logger.Info).Infof("Upgrading chain database to store slim receipts")
+
+	stopChn := make(chan struct{})
+	stoppedChn := make(chan struct{})
+
+	go func() {
+		stopFn := func() bool {
+			select {
+			case <-time.After(time.Microsecond * 100): // make sure other processes don't get starved
+			case <-stopChn:
+				return true
+			}
+			return false
+		}
+
+		err, stopped := upgradeSlimBlockReceipts(db, stopFn)
+		if err == nil && !stopped {
+			glog.V(logger.Info).Infof("Receipt conversion successful")
+			db.Put(useSlimReceipts, []byte{42})
+			db.Delete(slimReceiptsProgress)
+		}
+		if err != nil {
+			glog.V(logger.Error).Infof("Receipt conversion failed: %v", err)
+		}
+		close(stoppedChn)
+	}()
+
+	return func() {
+		close(stopChn)
+		<-stoppedChn
+	}
+}
+
+// upgradeSlimBlockReceipts reads all block receipts from the database, starting
+// at the saved progress marker, and rewrites the ones still in the legacy format
+// in the slim storage format. Receipts of blocks already moved into the ancient
+// store are left alone, they must not be written back into the database.
+func upgradeSlimBlockReceipts(db ethdb.Database, stopFn func() bool) (error, bool) {
+	prefix := []byte("r")
+	start, _ := db.Get(slimReceiptsProgress)
+	it := db.NewIterator(prefix, start)
+	defer it.Release()
+
+	freezer := ethdb.AncientStore(db)
+	frozen := func(key []byte) bool {
+		return freezer != nil && binary.BigEndian.Uint64(key[len(prefix):]) < freezer.Ancients()
+	}
+	var (
+		batch   = db.NewBatch()
+		pending [][]byte // Keys of the converted receipts in the batch
+	)
+	flush := func(key []byte) error {
+		// Save the progress together with the converted receipts
+		if err := batch.Put(slimReceiptsProgress, common.CopyBytes(key[len(prefix):])); err != nil {
+			return err
+		}
+		if err := batch.Write(); err != nil {
+			return err
+		}
+		batch.Reset()
+
+		// The freezer might have moved some of the blocks concurrently, drop any
+		// receipts resurrected by the batch
+		for _, key := range pending {
+			if frozen(key) {
+				if err := db.Delete(key); err != nil {
+					return err
+				}
+			}
+		}
+		pending = pending[:0]
+		return nil
+	}
+	var (
+		cnt, converted int
+		lastKey        []byte
+	)
+	for it.Next() {
+		// Skip anything but block receipts (prefix + num + hash), most notably the
+		// per transaction receipts sharing the prefix
+		key := it.Key()
+		if len(key) != len(prefix)+8+common.HashLength || bytes.HasPrefix(key, []byte("receipts-")) {
+			continue
+		}
+		if frozen(key) {
+			continue
+		}
+		cnt++
+		lastKey = common.CopyBytes(key)
+
+		data := it.Value()
+		var receipts []*types.ReceiptForStorage
+		if err := rlp.DecodeBytes(data, &receipts); err != nil {
+			return fmt.Errorf("invalid block receipts %x: %v", key, err), false
+		}
+		slim, err := rlp.EncodeToBytes(receipts)
+		if err != nil {
+			return err, false
+		}
+		if !bytes.Equal(slim, data) {
+			if err := batch.Put(lastKey, slim); err != nil {
+				return err, false
+			}
+			pending = append(pending, lastKey)
+			converted++
+		}
+		if batch.ValueSize() >= ethdb.IdealBatchSize {
+			if err := flush(lastKey); err != nil {
+				return err, false
+			}
+			glog.V(logger.Info).Infof("converting %d block receipts (%d checked)...", converted, cnt)
+		}
+		if stopFn() {
+			return flush(lastKey), true
+		}
+	}
+	if err := it.Error(); err != nil {
+		return err, false
+	}
+	if lastKey != nil {
+		if err := flush(lastKey); err != nil {
+			return err, false
+		}
+	}
+	if converted > 0 {
+		glog.V(logger.Info).Infof("converted %d block receipts...", converted)
+	}
+	return nil, false
+}
+
 // upgradeChainDatabase ensures that the chain database stores block split into
 // separate header and body entries.
 func upgradeChainDatabase(db ethdb.Database) error {
@@ -269,46 +416,44 @@ func upgradeChainDatabase(db ethdb.Database) error {
 	// At least some of the database is still the old format, upgrade (skip the head block!)
 	glog.V(logger.Info).Info("Old database detected, upgrading...")
 
-	if db, ok := db.(*ethdb.LDBDatabase); ok {
-		blockPrefix := []byte("block-hash-")
-		for it := db.NewIterator(); it.Next(); {
-			// Skip anything other than a combined block
-			if !bytes.HasPrefix(it.Key(), blockPrefix) {
-				continue
-			}
-			// Skip the head block (merge last to signal upgrade completion)
-			if bytes.HasSuffix(it.Key(), head.Bytes()) {
-				continue
-			}
-			// Load the block, split and serialize (order!)
-			block := core.GetBlockByHashOld(db, common.BytesToHash(bytes.TrimPrefix(it.Key(), blockPrefix)))
+	blockPrefix := []byte("block-hash-")
 
-			if err := core.WriteTd(db, block.Hash(), block.NumberU64(), block.DeprecatedTd()); err != nil {
-				return err
-			}
-			if err := core.WriteBody(db, block.Hash(), block.NumberU64(), block.Body()); err != nil {
-				return err
-			}
-			if err := core.WriteHeader(db, block.Header()); err != nil {
-				return err
-			}
-			if err := db.Delete(it.Key()); err != nil {
-				return err
-			}
+	it := db.NewIterator(blockPrefix, nil)
+	defer it.Release()
+
+	for it.Next() {
+		// Skip the head block (merge last to signal upgrade completion)
+		if bytes.HasSuffix(it.Key(), head.Bytes()) {
+			continue
 		}
-		// Lastly, upgrade the head block, disabling the upgrade mechanism
-		current := core.GetBlockByHashOld(db, head)
+		// Load the block, split and serialize (order!)
+		block := core.GetBlockByHashOld(db, common.BytesToHash(bytes.TrimPrefix(it.Key(), blockPrefix)))
 
-		if err := core.WriteTd(db, current.Hash(), current.NumberU64(), current.DeprecatedTd()); err != nil {
+		if err := core.WriteTd(db, block.Hash(), block.NumberU64(), block.DeprecatedTd()); err != nil {
 			return err
 		}
-		if err := core.WriteBody(db, current.Hash(), current.NumberU64(), current.Body()); err != nil {
+		if err := core.WriteBody(db, block.Hash(), block.NumberU64(), block.Body()); err != nil {
 			return err
 		}
-		if err := core.WriteHeader(db, current.Header()); err != nil {
+		if err := core.WriteHeader(db, block.Header()); err != nil {
+			return err
+		}
+		if err := db.Delete(it.Key()); err != nil {
 			return err
 		}
 	}
+	// Lastly, upgrade the head block, disabling the upgrade mechanism
+	current := core.GetBlockByHashOld(db, head)
+
+	if err := core.WriteTd(db, current.Hash(), current.NumberU64(), current.DeprecatedTd()); err != nil {
+		return err
+	}
+	if err := core.WriteBody(db, current.Hash(), current.NumberU64(), current.Body()); err != nil {
+		return err
+	}
+	if err := core.WriteHeader(db, current.Header()); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -349,7 +494,7 @@ func addMipmapBloomBins(db ethdb.Database) (err error) {
 		if (hash == common.Hash{}) {
 			return fmt.Errorf("chain db corrupted. Could not find block %d.", i)
 		}
-		core.WriteMipmapBloom(db, i, core.GetBlockReceipts(db, hash, i))
+		core.WriteMipmapBloom(db, i, core.GetRawBlockReceipts(db, hash, i))
 	}
 	glog.V(logger.Info).Infoln("upgrade completed in", time.Since(tstart))
 	return nil
@@ -24,7 +24,7 @@ import (
 	"time"
 
 	"github.com/daxxcoin/daxxcore/common"
-	"github.com/daxxcoin/daxxcore/core"
+	"github.com/daxxcoin/daxxcore/consensus"
 	"github.com/daxxcoin/daxxcore/core/types"
 	"github.com/daxxcoin/daxxcore/logger"
 	"github.com/daxxcoin/daxxcore/logger/glog"
@@ -671,7 +671,7 @@ func (f *Fetcher) insert(peer string, block *types.Block) {
 			propBroadcastOutTimer.UpdateSince(block.ReceivedAt)
 			go f.broadcastBlock(block, true)
 
-		case core.BlockFutureErr:
+		case consensus.ErrFutureBlock:
 			// Weird future block, don't fail, but neither propagate
 
 		default:
@@ -61,7 +61,7 @@ func (b *testBackend) HeaderByNumber(ctx context.Context, blockNr rpc.BlockNumbe
 
 func (b *testBackend) GetReceipts(ctx context.Context, blockHash common.Hash) (types.Receipts, error) {
 	num := core.GetBlockNumber(b.db, blockHash)
-	return core.GetBlockReceipts(b.db, blockHash, num), nil
+	return core.GetRawBlockReceipts(b.db, blockHash, num), nil
 }
 
 // TestBlockSubscription tests if a block subscription returns block hashes for posted chain events.
@@ -78,7 +78,7 @@ func TestBlockSubscription(t *testing.T) {
 		backend = &testBackend{mux, db}
 		api     = NewPublicFilterAPI(backend, false)
 
-		genesis     = core.WriteGenesisBlockForTesting(db)
+		genesis     = new(core.Genesis).MustCommit(db)
 		chain, _    = core.GenerateChain(params.TestChainConfig, genesis, db, 10, func(i int, gen *core.BlockGen) {})
 		chainEvents = []core.ChainEvent{}
 	)
@@ -26,8 +26,10 @@ import (
 	"github.com/daxxcoin/daxxcore/common"
 	"github.com/daxxcoin/daxxcore/common/hexutil"
 	"github.com/daxxcoin/daxxcore/core/types"
+	"github.com/daxxcoin/daxxcore/crypto"
 	"github.com/daxxcoin/daxxcore/rlp"
 	"github.com/daxxcoin/daxxcore/rpc"
+	"github.com/daxxcoin/daxxcore/trie"
 	"golang.org/x/net/context"
 )
 
@@ -200,12 +202,8 @@ func (ec *Client) TransactionInBlock(ctx context.Context, blockHash common.Hash,
 func (ec *Client) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
 	var r *types.Receipt
 	err := ec.c.CallContext(ctx, &r, "eth_getTransactionReceipt", txHash)
-	if err == nil {
-		if r == nil {
-			return nil, daxxcoin.NotFound
-		} else if len(r.PostState) == 0 {
-			return nil, fmt.Errorf("server returned receipt without post state")
-		}
+	if err == nil && r == nil {
+		return nil, daxxcoin.NotFound
 	}
 	return r, err
 }
@@ -290,6 +288,129 @@ func (ec *Client) NonceAt(ctx context.Context, account common.Address, blockNumb
 	return uint64(result), err
 }
 
+// AccountResult is the Merkle proof of an account and some of its storage slots.
+type AccountResult struct {
+	Address      common.Address
+	AccountProof []rlp.RawValue
+	Balance      *big.Int
+	CodeHash     common.Hash
+	Nonce        uint64
+	StorageHash  common.Hash
+	StorageProof []StorageResult
+}
+
+// StorageResult is the Merkle proof of a single storage slot of an account.
+type StorageResult struct {
+	Key   common.Hash
+	Value *big.Int
+	Proof []rlp.RawValue
+}
+
+type rpcAccountResult struct {
+	Address      common.Address     `json:"address"`
+	AccountProof []hexutil.Bytes    `json:"accountProof"`
+	Balance      *hexutil.Big       `json:"balance"`
+	CodeHash     common.Hash        `json:"codeHash"`
+	Nonce        hexutil.Uint64     `json:"nonce"`
+	StorageHash  common.Hash        `json:"storageHash"`
+	StorageProof []rpcStorageResult `json:"storageProof"`
+}
+
+type rpcStorageResult struct {
+	Key   common.Hash     `json:"key"`
+	Value *hexutil.Big    `json:"value"`
+	Proof []hexutil.Bytes `json:"proof"`
+}
+
+// GetProof returns the Merkle proof of the given account and storage keys.
+// The block number can be nil, in which case the proof is taken from the latest known block.
+func (ec *Client) GetProof(ctx context.Context, account common.Address, keys []common.Hash, blockNumber *big.Int) (*AccountResult, error) {
+	var res rpcAccountResult
+	if err := ec.c.CallContext(ctx, &res, "eth_getProof", account, keys, toBlockNumArg(blockNumber)); err != nil {
+		return nil, err
+	}
+	if res.Balance == nil {
+		return nil, daxxcoin.NotFound
+	}
+	result := &AccountResult{
+		Address:      res.Address,
+		AccountProof: fromHexSlice(res.AccountProof),
+		Balance:      (*big.Int)(res.Balance),
+		CodeHash:     res.CodeHash,
+		Nonce:        uint64(res.Nonce),
+		StorageHash:  res.StorageHash,
+		StorageProof: make([]StorageResult, len(res.StorageProof)),
+	}
+	for i, slot := range res.StorageProof {
+		result.StorageProof[i] = StorageResult{
+			Key:   slot.Key,
+			Value: (*big.Int)(slot.Value),
+			Proof: fromHexSlice(slot.Proof),
+		}
+	}
+	return result, nil
+}
+
+// Verify checks the account proof against the given state root and all the
+// storage proofs against the storage root of the account, returning an error if
+// any of the proven values differ from the ones reported.
+func (r *AccountResult) Verify(root common.Hash) error {
+	enc, err := trie.VerifyProof(root, crypto.Keccak256(r.Address[:]), r.AccountProof)
+	if err != nil {
+		return fmt.Errorf("invalid account proof: %v", err)
+	}
+	if len(enc) == 0 {
+		// Nonexistent accounts must be reported empty
+		if r.Nonce != 0 || r.Balance.Sign() != 0 || r.StorageHash != types.EmptyRootHash {
+			return fmt.Errorf("account proven nonexistent but reported with content")
+		}
+	} else {
+		var account struct {
+			Nonce    uint64
+			Balance  *big.Int
+			Root     common.Hash
+			CodeHash []byte
+		}
+		if err := rlp.DecodeBytes(enc, &account); err != nil {
+			return fmt.Errorf("invalid proven account: %v", err)
+		}
+		if account.Nonce != r.Nonce || account.Balance.Cmp(r.Balance) != 0 || account.Root != r.StorageHash || common.BytesToHash(account.CodeHash) != r.CodeHash {
+			return fmt.Errorf("proven account doesn't match reported fields")
+		}
+	}
+	for _, slot := range r.StorageProof {
+		// Slots of an empty storage trie have nothing to prove
+		if r.StorageHash == types.EmptyRootHash && len(slot.Proof) == 0 {
+			if slot.Value.Sign() != 0 {
+				return fmt.Errorf("storage key %x reported non-zero in empty storage", slot.Key)
+			}
+			continue
+		}
+		enc, err := trie.VerifyProof(r.StorageHash, crypto.Keccak256(slot.Key[:]), slot.Proof)
+		if err != nil {
+			return fmt.Errorf("invalid storage proof for key %x: %v", slot.Key, err)
+		}
+		var value []byte
+		if len(enc) > 0 {
+			if err := rlp.DecodeBytes(enc, &value); err != nil {
+				return fmt.Errorf("invalid proven storage value for key %x: %v", slot.Key, err)
+			}
+		}
+		if new(big.Int).SetBytes(value).Cmp(slot.Value) != 0 {
+			return fmt.Errorf("proven storage value for key %x doesn't match reported one", slot.Key)
+		}
+	}
+	return nil
+}
+
+func fromHexSlice(blobs []hexutil.Bytes) []rlp.RawValue {
+	nodes := make([]rlp.RawValue, len(blobs))
+	for i, blob := range blobs {
+		nodes[i] = rlp.RawValue(blob)
+	}
+	return nodes
+}
+
 // Filters
 
 // FilterLogs executes a filter query.
@@ -0,0 +1,365 @@
+// Copyright 2017 The daxxcoreAuthors
+// This file is part of the daxxcore library.
+//
+// The daxxcore library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The daxxcore library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the daxxcore library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethdb
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/daxxcoin/daxxcore/common"
+	"github.com/daxxcoin/daxxcore/compression/rle"
+	"github.com/golang/snappy"
+)
+
+// Compression is an algorithm the compressing database wrapper can store values
+// with.
+type Compression byte
+
+const (
+	NoCompression     Compression = iota // Values are stored as is
+	SnappyCompression                    // Values are compressed with snappy
+	RLECompression                       // Values are compressed with the zero run-length encoding
+)
+
+// compressionMarker is the first byte of every value stored compressed, followed
+// by the algorithm used. The compressed tables hold RLP data, and an RLP encoding
+// starting with a zero byte is always a single byte long, so the marker tells
+// compressed values apart from the ones stored before compression.
+const compressionMarker = 0x00
+
+// String implements fmt.Stringer.
+func (c Compression) String() string {
+	switch c {
+	case NoCompression:
+		return "none"
+	case SnappyCompression:
+		return "snappy"
+	case RLECompression:
+		return "rle"
+	default:
+		return fmt.Sprintf("unknown(%d)", byte(c))
+	}
+}
+
+// ParseCompression returns the compression algorithm with the given name, as
+// returned by String.
+func ParseCompression(name string) (Compression, error) {
+	for _, c := range []Compression{NoCompression, SnappyCompression, RLECompression} {
+		if c.String() == name {
+			return c, nil
+		}
+	}
+	return NoCompression, fmt.Errorf("unknown compression algorithm %q (want none, snappy or rle)", name)
+}
+
+// CompressionRule selects the algorithm used to store the values of a database
+// table, the entries with a given key prefix and optionally an exact key length.
+type CompressionRule struct {
+	Prefix    []byte      // Key prefix of the table the rule applies to
+	KeyLength int         // Exact length of the keys in the table, 0 for any
+	Algorithm Compression // Algorithm to store new values with
+}
+
+// matches returns whether the rule applies to the given key.
+func (r *CompressionRule) matches(key []byte) bool {
+	if r.KeyLength != 0 && len(key) != r.KeyLength {
+		return false
+	}
+	return bytes.HasPrefix(key, r.Prefix)
+}
+
+// CompressedDatabase is a wrapper around a database, transparently compressing
+// the values of the tables selected by its rules. Values in those tables are
+// decompressed on retrieval regardless of the algorithm they were stored with,
+// so a table can mix values from before and after its rule changed. A rule with
+// NoCompression thus keeps existing compressed values readable.
+type CompressedDatabase struct {
+	db    Database
+	rules []CompressionRule
+}
+
+// NewCompressedDatabase wraps a database, compressing the values of the tables
+// selected by the given rules. For keys matching multiple rules, the first one
+// wins.
+func NewCompressedDatabase(db Database, rules []CompressionRule) *CompressedDatabase {
+	return &CompressedDatabase{
+		db:    db,
+		rules: rules,
+	}
+}
+
+// rule returns the compression rule applying to a key, or nil if the key is not
+// in a compressed table.
+func (db *CompressedDatabase) rule(key []byte) *CompressionRule {
+	for i := range db.rules {
+		if db.rules[i].matches(key) {
+			return &db.rules[i]
+		}
+	}
+	return nil
+}
+
+// encode converts a value to its stored format according to the rule of the key.
+func (db *CompressedDatabase) encode(key []byte, value []byte) []byte {
+	rule := db.rule(key)
+	if rule == nil {
+		return value
+	}
+	return compressValue(rule.Algorithm, value)
+}
+
+// decode converts a stored value back to its original format if the key is in
+// a compressed table.
+func (db *CompressedDatabase) decode(key []byte, blob []byte) ([]byte, error) {
+	if db.rule(key) == nil {
+		return blob, nil
+	}
+	return decompressValue(blob)
+}
+
+// Put compresses the value according to the rule of the key and inserts it into
+// the database.
+func (db *CompressedDatabase) Put(key []byte, value []byte) error {
+	return db.db.Put(key, db.encode(key, value))
+}
+
+// Get retrieves the value of a key, decompressing it if needed.
+func (db *CompressedDatabase) Get(key []byte) ([]byte, error) {
+	blob, err := db.db.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	return db.decode(key, blob)
+}
+
+// Has retrieves whether a key is present in the database.
+func (db *CompressedDatabase) Has(key []byte) (bool, error) {
+	return db.db.Has(key)
+}
+
+// Delete removes a key from the database.
+func (db *CompressedDatabase) Delete(key []byte) error {
+	return db.db.Delete(key)
+}
+
+// NewIterator creates an iterator over a subset of the database content,
+// decompressing the iterated values if needed.
+func (db *CompressedDatabase) NewIterator(prefix []byte, start []byte) Iterator {
+	return &compressedIterator{Iterator: db.db.NewIterator(prefix, start), db: db}
+}
+
+// Stat returns a particular internal stat of the wrapped database.
+func (db *CompressedDatabase) Stat(property string) (string, error) {
+	return db.db.Stat(property)
+}
+
+// Compact flattens the wrapped database for the given key range.
+func (db *CompressedDatabase) Compact(start []byte, limit []byte) error {
+	return db.db.Compact(start, limit)
+}
+
+// Close closes the wrapped database.
+func (db *CompressedDatabase) Close() {
+	db.db.Close()
+}
+
+// NewBatch creates a batch compressing the inserted values.
+func (db *CompressedDatabase) NewBatch() Batch {
+	return &compressedBatch{Batch: db.db.NewBatch(), db: db}
+}
+
+// Unwrap returns the wrapped database, which holds the values as stored on disk.
+func (db *CompressedDatabase) Unwrap() Database {
+	return db.db
+}
+
+// RawDatabase returns the database holding the values of the given one as they
+// are stored on disk, unwrapping any compression layers.
+func RawDatabase(db Database) Database {
+	for {
+		wrapper, ok := db.(interface {
+			Unwrap() Database
+		})
+		if !ok {
+			return db
+		}
+		db = wrapper.Unwrap()
+	}
+}
+
+// Freezer returns the ancient store backing the wrapped database, if any.
+func (db *CompressedDatabase) Freezer() *Freezer {
+	return AncientStore(db.db)
+}
+
+// Path returns the directory of the wrapped database, or an empty string if it
+// isn't stored on disk.
+func (db *CompressedDatabase) Path() string {
+	if db, ok := db.db.(interface {
+		Path() string
+	}); ok {
+		return db.Path()
+	}
+	return ""
+}
+
+// Recompress converts all the values of the compressed tables to the current
+// algorithm of their rules, leaving values already in the right format alone.
+// The number of converted values is returned.
+func (db *CompressedDatabase) Recompress() (int, error) {
+	var (
+		batch = db.db.NewBatch()
+		count int
+	)
+	for i := range db.rules {
+		rule := &db.rules[i]
+
+		it := db.db.NewIterator(rule.Prefix, nil)
+		for it.Next() {
+			key, blob := it.Key(), it.Value()
+			if db.rule(key) != rule {
+				continue // Not in this table, or shadowed by an earlier rule
+			}
+			if valueCompression(blob) == rule.Algorithm {
+				continue
+			}
+			value, err := decompressValue(blob)
+			if err != nil {
+				it.Release()
+				return count, fmt.Errorf("key %x: %v", key, err)
+			}
+			if err := batch.Put(common.CopyBytes(key), compressValue(rule.Algorithm, value)); err != nil {
+				it.Release()
+				return count, err
+			}
+			count++
+
+			if batch.ValueSize() >= IdealBatchSize {
+				if err := batch.Write(); err != nil {
+					it.Release()
+					return count, err
+				}
+				batch.Reset()
+			}
+		}
+		err := it.Error()
+		it.Release()
+		if err != nil {
+			return count, err
+		}
+	}
+	return count, batch.Write()
+}
+
+// compressedIterator is a wrapper around a database iterator, decompressing the
+// values of the compressed tables.
+type compressedIterator struct {
+	Iterator
+	db  *CompressedDatabase
+	err error
+}
+
+// Value returns the decompressed value of the current key/value pair.
+func (it *compressedIterator) Value() []byte {
+	value, err := it.db.decode(it.Key(), it.Iterator.Value())
+	if err != nil && it.err == nil {
+		it.err = err
+	}
+	return value
+}
+
+// Error returns any accumulated iteration or decompression error.
+func (it *compressedIterator) Error() error {
+	if it.err != nil {
+		return it.err
+	}
+	return it.Iterator.Error()
+}
+
+// compressedBatch is a wrapper around a database batch, compressing the values
+// of the compressed tables.
+type compressedBatch struct {
+	Batch
+	db *CompressedDatabase
+}
+
+// Put compresses the value according to the rule of the key and queues it up
+// for writing.
+func (b *compressedBatch) Put(key, value []byte) error {
+	return b.Batch.Put(key, b.db.encode(key, value))
+}
+
+// Replay replays the batch contents into the given writer, decompressing the
+// values of the compressed tables.
+func (b *compressedBatch) Replay(w KeyValueWriter) error {
+	return b.Batch.Replay(&compressedReplayer{w: w, db: b.db})
+}
+
+// compressedReplayer is a wrapper around a batch replayer, decompressing the
+// values of the compressed tables.
+type compressedReplayer struct {
+	w  KeyValueWriter
+	db *CompressedDatabase
+}
+
+func (r *compressedReplayer) Put(key []byte, value []byte) error {
+	value, err := r.db.decode(key, value)
+	if err != nil {
+		return err
+	}
+	return r.w.Put(key, value)
+}
+
+func (r *compressedReplayer) Delete(key []byte) error {
+	return r.w.Delete(key)
+}
+
+// valueCompression returns the algorithm a stored value was compressed with.
+func valueCompression(blob []byte) Compression {
+	if len(blob) < 2 || blob[0] != compressionMarker {
+		return NoCompression
+	}
+	return Compression(blob[1])
+}
+
+// compressValue compresses a value with the given algorithm, prefixing it with
+// the format marker.
+func compressValue(algo Compression, value []byte) []byte {
+	switch algo {
+	case SnappyCompression:
+		return append([]byte{compressionMarker, byte(algo)}, snappy.Encode(nil, value)...)
+	case RLECompression:
+		return append([]byte{compressionMarker, byte(algo)}, rle.Compress(value)...)
+	default:
+		return value
+	}
+}
+
+// decompressValue restores a stored value to its original format based on its
+// format marker.
+func decompressValue(blob []byte) ([]byte, error) {
+	switch algo := valueCompression(blob); algo {
+	case NoCompression:
+		return blob, nil
+	case SnappyCompression:
+		return snappy.Decode(nil, blob[2:])
+	case RLECompression:
+		return rle.Decompress(blob[2:])
+	default:
+		return nil, fmt.Errorf("unknown compression algorithm: %v", algo)
+	}
+}
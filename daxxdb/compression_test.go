@@ -0,0 +1,108 @@
+// Copyright 2017 The daxxcoreAuthors
+// This file is part of the daxxcore library.
+//
+// The daxxcore library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The daxxcore library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the daxxcore library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethdb
+
+import (
+	"bytes"
+	"testing"
+)
+
+// Tests that the compressing wrapper round trips values through all algorithms,
+// leaves tables without a rule alone and reads values stored before compression.
+func TestCompressedDatabase(t *testing.T) {
+	// An RLP list with a long run of zeros, compressible by all algorithms
+	value := append([]byte{0xf9, 0x01, 0x00}, make([]byte, 256)...)
+
+	for _, algo := range []Compression{NoCompression, SnappyCompression, RLECompression} {
+		raw, _ := NewMemDatabase()
+		raw.Put([]byte("a-old"), value)
+
+		db := NewCompressedDatabase(raw, []CompressionRule{{Prefix: []byte("a-"), Algorithm: algo}})
+		db.Put([]byte("a-new"), value)
+		db.Put([]byte("b-new"), value)
+
+		for _, key := range []string{"a-old", "a-new", "b-new"} {
+			if blob, err := db.Get([]byte(key)); err != nil || !bytes.Equal(blob, value) {
+				t.Errorf("%v: %s: value mismatch: have %x, %v, want %x", algo, key, blob, err, value)
+			}
+		}
+		if blob, _ := raw.Get([]byte("b-new")); !bytes.Equal(blob, value) {
+			t.Errorf("%v: value outside compressed tables modified: %x", algo, blob)
+		}
+		if blob, _ := raw.Get([]byte("a-new")); valueCompression(blob) != algo {
+			t.Errorf("%v: stored format mismatch: have %v", algo, valueCompression(blob))
+		} else if algo != NoCompression && len(blob) >= len(value) {
+			t.Errorf("%v: value not compressed: %d bytes, original %d", algo, len(blob), len(value))
+		}
+		// Ensure iteration and batches go through the compression too
+		batch := db.NewBatch()
+		batch.Put([]byte("a-batch"), value)
+		if err := batch.Write(); err != nil {
+			t.Fatalf("%v: failed to write batch: %v", algo, err)
+		}
+		it := db.NewIterator([]byte("a-"), nil)
+		for it.Next() {
+			if !bytes.Equal(it.Value(), value) {
+				t.Errorf("%v: %s: iterated value mismatch: have %x, want %x", algo, it.Key(), it.Value(), value)
+			}
+		}
+		if err := it.Error(); err != nil {
+			t.Errorf("%v: iteration failed: %v", algo, err)
+		}
+		it.Release()
+	}
+}
+
+// Tests that recompression converts every value in the compressed tables to the
+// format of their rules, in both directions.
+func TestRecompress(t *testing.T) {
+	value := append([]byte{0xf9, 0x01, 0x00}, make([]byte, 256)...)
+
+	raw, _ := NewMemDatabase()
+	raw.Put([]byte("a-1"), value)
+	raw.Put([]byte("b-1"), value)
+	raw.Put([]byte("c-1"), value)
+
+	// Compress the values of two of the tables
+	rules := []CompressionRule{
+		{Prefix: []byte("a-"), Algorithm: SnappyCompression},
+		{Prefix: []byte("b-"), Algorithm: RLECompression},
+	}
+	db := NewCompressedDatabase(raw, rules)
+	if count, err := db.Recompress(); err != nil || count != 2 {
+		t.Fatalf("recompression mismatch: have %d, %v, want %d", count, err, 2)
+	}
+	for key, want := range map[string]Compression{"a-1": SnappyCompression, "b-1": RLECompression, "c-1": NoCompression} {
+		if blob, _ := raw.Get([]byte(key)); valueCompression(blob) != want {
+			t.Errorf("%s: stored format mismatch: have %v, want %v", key, valueCompression(blob), want)
+		}
+	}
+	// Recompress again and ensure nothing changes
+	if count, err := db.Recompress(); err != nil || count != 0 {
+		t.Fatalf("repeated recompression mismatch: have %d, %v, want %d", count, err, 0)
+	}
+	// Disable compression and ensure the values are restored
+	rules[0].Algorithm, rules[1].Algorithm = NoCompression, NoCompression
+	if count, err := NewCompressedDatabase(raw, rules).Recompress(); err != nil || count != 2 {
+		t.Fatalf("decompression mismatch: have %d, %v, want %d", count, err, 2)
+	}
+	for _, key := range []string{"a-1", "b-1", "c-1"} {
+		if blob, _ := raw.Get([]byte(key)); !bytes.Equal(blob, value) {
+			t.Errorf("%s: value not restored: have %x, want %x", key, blob, value)
+		}
+	}
+}
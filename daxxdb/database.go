@@ -29,8 +29,8 @@ import (
 	"github.com/syndtr/goleveldb/leveldb"
 	"github.com/syndtr/goleveldb/leveldb/errors"
 	"github.com/syndtr/goleveldb/leveldb/filter"
-	"github.com/syndtr/goleveldb/leveldb/iterator"
 	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/util"
 
 	gometrics "github.com/rcrowley/go-metrics"
 )
@@ -55,6 +55,8 @@ type LDBDatabase struct {
 	fn string      // filename for reporting
 	db *leveldb.DB // LevelDB instance
 
+	freezer *Freezer // Ancient store for immutable chain data, nil if not configured
+
 	getTimer       gometrics.Timer // Timer for measuring the database get request counts and latencies
 	putTimer       gometrics.Timer // Timer for measuring the database put request counts and latencies
 	delTimer       gometrics.Timer // Timer for measuring the database delete request counts and latencies
@@ -102,6 +104,20 @@ func NewLDBDatabase(file string, cache int, handles int) (*LDBDatabase, error) {
 	}, nil
 }
 
+// NewLDBDatabaseWithFreezer returns a LevelDB wrapped object, backed by an
+// append-only ancient store in the given directory for immutable chain data.
+func NewLDBDatabaseWithFreezer(file string, cache int, handles int, freezer string) (*LDBDatabase, error) {
+	db, err := NewLDBDatabase(file, cache, handles)
+	if err != nil {
+		return nil, err
+	}
+	if db.freezer, err = NewFreezer(freezer); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
 // Path returns the path to the database directory.
 func (db *LDBDatabase) Path() string {
 	return db.fn
@@ -154,8 +170,34 @@ func (self *LDBDatabase) Delete(key []byte) error {
 	return self.db.Delete(key, nil)
 }
 
-func (self *LDBDatabase) NewIterator() iterator.Iterator {
-	return self.db.NewIterator(nil, nil)
+// Has retrieves whether a key is present in the database.
+func (self *LDBDatabase) Has(key []byte) (bool, error) {
+	return self.db.Has(key, nil)
+}
+
+// NewIterator creates a binary-alphabetical iterator over a subset of database
+// content with a particular key prefix, starting at a particular initial key.
+func (self *LDBDatabase) NewIterator(prefix []byte, start []byte) Iterator {
+	return self.db.NewIterator(bytesPrefixRange(prefix, start), nil)
+}
+
+// Stat returns a particular internal stat of the database.
+func (self *LDBDatabase) Stat(property string) (string, error) {
+	return self.db.GetProperty(property)
+}
+
+// Compact flattens the underlying data store for the given key range. A nil
+// start or limit is treated as a key before or after all keys respectively.
+func (self *LDBDatabase) Compact(start []byte, limit []byte) error {
+	return self.db.CompactRange(util.Range{Start: start, Limit: limit})
+}
+
+// bytesPrefixRange returns the key range that satisfies the given prefix and
+// starts at the given key relative to it.
+func bytesPrefixRange(prefix, start []byte) *util.Range {
+	r := util.BytesPrefix(prefix)
+	r.Start = append(r.Start, start...)
+	return r
 }
 
 func (self *LDBDatabase) Close() {
@@ -170,6 +212,11 @@ func (self *LDBDatabase) Close() {
 			glog.V(logger.Error).Infof("metrics failure in '%s': %v\n", self.fn, err)
 		}
 	}
+	if self.freezer != nil {
+		if err := self.freezer.Close(); err != nil {
+			glog.V(logger.Error).Infof("error closing ancient store %s: %v", self.freezer.Path(), err)
+		}
+	}
 	err := self.db.Close()
 	if glog.V(logger.Error) {
 		if err == nil {
@@ -184,6 +231,12 @@ func (self *LDBDatabase) LDB() *leveldb.DB {
 	return self.db
 }
 
+// Freezer returns the ancient store backing the database, or nil if none was
+// configured.
+func (self *LDBDatabase) Freezer() *Freezer {
+	return self.freezer
+}
+
 // Meter configures the database metrics collectors and
 func (self *LDBDatabase) Meter(prefix string) {
 	// Short circuit metering if the metrics system is disabled
@@ -292,20 +345,71 @@ func (db *LDBDatabase) NewBatch() Batch {
 	return &ldbBatch{db: db.db, b: new(leveldb.Batch)}
 }
 
+// ldbBatch is a write-only leveldb batch that commits changes to its host
+// database when Write is called.
 type ldbBatch struct {
-	db *leveldb.DB
-	b  *leveldb.Batch
+	db   *leveldb.DB
+	b    *leveldb.Batch
+	size int
 }
 
 func (b *ldbBatch) Put(key, value []byte) error {
 	b.b.Put(key, value)
+	b.size += len(value)
+	return nil
+}
+
+func (b *ldbBatch) Delete(key []byte) error {
+	b.b.Delete(key)
+	b.size++
 	return nil
 }
 
+func (b *ldbBatch) ValueSize() int {
+	return b.size
+}
+
 func (b *ldbBatch) Write() error {
 	return b.db.Write(b.b, nil)
 }
 
+func (b *ldbBatch) Reset() {
+	b.b.Reset()
+	b.size = 0
+}
+
+func (b *ldbBatch) Replay(w KeyValueWriter) error {
+	r := &replayer{writer: w}
+	if err := b.b.Replay(r); err != nil {
+		return err
+	}
+	return r.failure
+}
+
+// replayer is a small wrapper to implement the correct replay methods.
+type replayer struct {
+	writer  KeyValueWriter
+	failure error
+}
+
+// Put inserts the given value into the key-value data store.
+func (r *replayer) Put(key, value []byte) {
+	// If the replay already failed, stop executing ops
+	if r.failure != nil {
+		return
+	}
+	r.failure = r.writer.Put(key, value)
+}
+
+// Delete removes the key from the key-value data store.
+func (r *replayer) Delete(key []byte) {
+	// If the replay already failed, stop executing ops
+	if r.failure != nil {
+		return
+	}
+	r.failure = r.writer.Delete(key)
+}
+
 type table struct {
 	db     Database
 	prefix string
@@ -328,14 +432,64 @@ func (dt *table) Get(key []byte) ([]byte, error) {
 	return dt.db.Get(append([]byte(dt.prefix), key...))
 }
 
+func (dt *table) Has(key []byte) (bool, error) {
+	return dt.db.Has(append([]byte(dt.prefix), key...))
+}
+
 func (dt *table) Delete(key []byte) error {
 	return dt.db.Delete(append([]byte(dt.prefix), key...))
 }
 
+// NewIterator creates an iterator over the table content with a particular key
+// prefix, starting at a particular initial key. The table prefix is stripped
+// from the iterated keys.
+func (dt *table) NewIterator(prefix []byte, start []byte) Iterator {
+	return &tableIterator{
+		iter:   dt.db.NewIterator(append([]byte(dt.prefix), prefix...), start),
+		prefix: dt.prefix,
+	}
+}
+
+func (dt *table) Stat(property string) (string, error) {
+	return dt.db.Stat(property)
+}
+
+// Compact flattens the table content in the given key range. A nil start or
+// limit is treated as the first or last key of the table respectively.
+func (dt *table) Compact(start []byte, limit []byte) error {
+	start = append([]byte(dt.prefix), start...)
+	if limit == nil {
+		limit = util.BytesPrefix([]byte(dt.prefix)).Limit
+	} else {
+		limit = append([]byte(dt.prefix), limit...)
+	}
+	return dt.db.Compact(start, limit)
+}
+
 func (dt *table) Close() {
 	// Do nothing; don't close the underlying DB.
 }
 
+// tableIterator is a wrapper around a database iterator that strips the table
+// prefix from the returned keys.
+type tableIterator struct {
+	iter   Iterator
+	prefix string
+}
+
+func (it *tableIterator) Next() bool    { return it.iter.Next() }
+func (it *tableIterator) Error() error  { return it.iter.Error() }
+func (it *tableIterator) Value() []byte { return it.iter.Value() }
+func (it *tableIterator) Release()      { it.iter.Release() }
+
+func (it *tableIterator) Key() []byte {
+	key := it.iter.Key()
+	if key == nil {
+		return nil
+	}
+	return key[len(it.prefix):]
+}
+
 type tableBatch struct {
 	batch  Batch
 	prefix string
@@ -354,6 +508,39 @@ func (tb *tableBatch) Put(key, value []byte) error {
 	return tb.batch.Put(append([]byte(tb.prefix), key...), value)
 }
 
+func (tb *tableBatch) Delete(key []byte) error {
+	return tb.batch.Delete(append([]byte(tb.prefix), key...))
+}
+
+func (tb *tableBatch) ValueSize() int {
+	return tb.batch.ValueSize()
+}
+
 func (tb *tableBatch) Write() error {
 	return tb.batch.Write()
 }
+
+func (tb *tableBatch) Reset() {
+	tb.batch.Reset()
+}
+
+// Replay replays the batch contents into the given writer, stripping the table
+// prefix from the keys.
+func (tb *tableBatch) Replay(w KeyValueWriter) error {
+	return tb.batch.Replay(&tableReplayer{w: w, prefix: tb.prefix})
+}
+
+// tableReplayer is a wrapper around a batch replayer which strips the table
+// prefix from the keys being replayed.
+type tableReplayer struct {
+	w      KeyValueWriter
+	prefix string
+}
+
+func (r *tableReplayer) Put(key []byte, value []byte) error {
+	return r.w.Put(key[len(r.prefix):], value)
+}
+
+func (r *tableReplayer) Delete(key []byte) error {
+	return r.w.Delete(key[len(r.prefix):])
+}
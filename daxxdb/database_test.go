@@ -17,8 +17,12 @@
 package ethdb
 
 import (
+	"bytes"
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"reflect"
+	"testing"
 
 	"github.com/daxxcoin/daxxcore/common"
 )
@@ -32,3 +36,118 @@ func newDb() *LDBDatabase {
 
 	return db
 }
+
+// testDatabases runs a test against both the LevelDB and the in-memory database
+// backends.
+func testDatabases(t *testing.T, test func(t *testing.T, db Database)) {
+	dir, err := ioutil.TempDir("", "ethdb-test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ldb, err := NewLDBDatabase(dir, 0, 0)
+	if err != nil {
+		t.Fatalf("failed to create leveldb database: %v", err)
+	}
+	defer ldb.Close()
+	test(t, ldb)
+
+	mdb, _ := NewMemDatabase()
+	test(t, mdb)
+}
+
+// Tests that prefix iterators return exactly the requested range of entries, in
+// ascending key order.
+func TestIterator(t *testing.T) { testDatabases(t, testIterator) }
+
+func testIterator(t *testing.T, db Database) {
+	keys := []string{"a", "aa", "ab", "b", "ba", "bb", "bc", "c"}
+	for _, key := range keys {
+		if err := db.Put([]byte(key), []byte("v"+key)); err != nil {
+			t.Fatalf("failed to insert %q: %v", key, err)
+		}
+	}
+	tests := []struct {
+		prefix, start string
+		want          []string
+	}{
+		{"", "", keys},
+		{"a", "", []string{"a", "aa", "ab"}},
+		{"b", "a", []string{"ba", "bb", "bc"}},
+		{"b", "b", []string{"bb", "bc"}},
+		{"b", "d", nil},
+		{"", "bb", []string{"bb", "bc", "c"}},
+		{"d", "", nil},
+	}
+	for i, tt := range tests {
+		it := db.NewIterator([]byte(tt.prefix), []byte(tt.start))
+
+		var have []string
+		for it.Next() {
+			if value := string(it.Value()); value != "v"+string(it.Key()) {
+				t.Errorf("test %d: value mismatch for %q: have %q", i, it.Key(), value)
+			}
+			have = append(have, string(it.Key()))
+		}
+		if err := it.Error(); err != nil {
+			t.Errorf("test %d: iteration failed: %v", i, err)
+		}
+		it.Release()
+
+		if !reflect.DeepEqual(have, tt.want) {
+			t.Errorf("test %d: key mismatch: have %v, want %v", i, have, tt.want)
+		}
+	}
+}
+
+// Tests that batches track their size, can be replayed into another writer and
+// reused after a reset.
+func TestBatch(t *testing.T) { testDatabases(t, testBatch) }
+
+func testBatch(t *testing.T, db Database) {
+	if err := db.Put([]byte("deleted"), []byte{0x01}); err != nil {
+		t.Fatalf("failed to insert entry: %v", err)
+	}
+	batch := db.NewBatch()
+	batch.Put([]byte("a"), []byte{0x01, 0x02})
+	batch.Put([]byte("b"), []byte{0x03})
+	batch.Delete([]byte("deleted"))
+
+	if size := batch.ValueSize(); size != 4 {
+		t.Errorf("batch size mismatch: have %d, want %d", size, 4)
+	}
+	// Replay the batch into a fresh database and check the operations arrived
+	replica, _ := NewMemDatabase()
+	replica.Put([]byte("deleted"), []byte{0x01})
+	if err := batch.Replay(replica); err != nil {
+		t.Fatalf("failed to replay batch: %v", err)
+	}
+	if ok, _ := replica.Has([]byte("deleted")); ok {
+		t.Errorf("deletion not replayed")
+	}
+	if blob, _ := replica.Get([]byte("a")); !bytes.Equal(blob, []byte{0x01, 0x02}) {
+		t.Errorf("insertion not replayed: have %x, want %x", blob, []byte{0x01, 0x02})
+	}
+	// Write the batch out, reset and ensure nothing is written on reuse
+	if err := batch.Write(); err != nil {
+		t.Fatalf("failed to write batch: %v", err)
+	}
+	if ok, _ := db.Has([]byte("b")); !ok {
+		t.Errorf("batched entry missing from database")
+	}
+	if ok, _ := db.Has([]byte("deleted")); ok {
+		t.Errorf("batched deletion not applied to database")
+	}
+	batch.Reset()
+	if size := batch.ValueSize(); size != 0 {
+		t.Errorf("reset batch size mismatch: have %d, want 0", size)
+	}
+	db.Delete([]byte("a"))
+	if err := batch.Write(); err != nil {
+		t.Fatalf("failed to write reset batch: %v", err)
+	}
+	if ok, _ := db.Has([]byte("a")); ok {
+		t.Errorf("reset batch rewrote stale entry")
+	}
+}
@@ -0,0 +1,250 @@
+// Copyright 2017 The daxxcoreAuthors
+// This file is part of the daxxcore library.
+//
+// The daxxcore library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The daxxcore library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the daxxcore library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethdb
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/daxxcoin/daxxcore/logger"
+	"github.com/daxxcoin/daxxcore/logger/glog"
+)
+
+const (
+	// FreezerHeaderTable indicates the name of the freezer header table.
+	FreezerHeaderTable = "headers"
+
+	// FreezerHashTable indicates the name of the freezer canonical hash table.
+	FreezerHashTable = "hashes"
+
+	// FreezerBodiesTable indicates the name of the freezer block body table.
+	FreezerBodiesTable = "bodies"
+
+	// FreezerReceiptTable indicates the name of the freezer receipts table.
+	FreezerReceiptTable = "receipts"
+
+	// FreezerDifficultyTable indicates the name of the freezer total difficulty table.
+	FreezerDifficultyTable = "diffs"
+)
+
+// FreezerTables lists all the tables maintained by the freezer, in the order
+// they are written.
+var FreezerTables = []string{
+	FreezerHashTable,
+	FreezerHeaderTable,
+	FreezerBodiesTable,
+	FreezerReceiptTable,
+	FreezerDifficultyTable,
+}
+
+var (
+	// errUnknownTable is returned if the user attempts to read from a table that
+	// is not tracked by the freezer.
+	errUnknownTable = errors.New("unknown table")
+
+	// ErrAncientNotFound is returned if the requested item is not contained
+	// within the ancient store.
+	ErrAncientNotFound = errors.New("ancient item not found")
+)
+
+// Freezer is an append-only store for immutable chain data (headers, bodies,
+// receipts, etc) that is old enough to never be reorganised away. Each kind of
+// data lives in its own flat file table indexed by block number, which is a lot
+// cheaper to maintain than keeping the same data in a LevelDB instance.
+type Freezer struct {
+	frozen uint64 // Number of blocks already frozen (atomic)
+
+	path   string
+	tables map[string]*freezerTable // Data tables for storing everything
+	lock   sync.Mutex               // Lock serializing the writers
+}
+
+// NewFreezer creates a chain freezer that moves ancient chain data into
+// append-only flat file containers, located in the given directory.
+func NewFreezer(datadir string) (*Freezer, error) {
+	freezer := &Freezer{
+		path:   datadir,
+		tables: make(map[string]*freezerTable),
+	}
+	for _, name := range FreezerTables {
+		table, err := newFreezerTable(datadir, name)
+		if err != nil {
+			for _, table := range freezer.tables {
+				table.Close()
+			}
+			return nil, err
+		}
+		freezer.tables[name] = table
+	}
+	if err := freezer.repair(); err != nil {
+		freezer.Close()
+		return nil, err
+	}
+	glog.V(logger.Info).Infof("Opened ancient database %s with %d frozen blocks", datadir, freezer.frozen)
+	return freezer, nil
+}
+
+// repair truncates all data tables to the same length, dropping any block that
+// was only partially frozen before a crash.
+func (f *Freezer) repair() error {
+	min := ^uint64(0)
+	for _, table := range f.tables {
+		if items := table.Items(); items < min {
+			min = items
+		}
+	}
+	for _, table := range f.tables {
+		if err := table.truncate(min); err != nil {
+			return err
+		}
+	}
+	atomic.StoreUint64(&f.frozen, min)
+	return nil
+}
+
+// Path returns the directory the freezer stores its tables in.
+func (f *Freezer) Path() string {
+	return f.path
+}
+
+// Close terminates the chain freezer, closing all the data files.
+func (f *Freezer) Close() error {
+	var errs []error
+	for _, table := range f.tables {
+		if err := table.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if errs != nil {
+		return fmt.Errorf("%v", errs)
+	}
+	return nil
+}
+
+// HasAncient returns an indicator whether the specified ancient data exists
+// in the freezer.
+func (f *Freezer) HasAncient(kind string, number uint64) bool {
+	if table := f.tables[kind]; table != nil {
+		return table.Items() > number
+	}
+	return false
+}
+
+// Ancient retrieves an ancient binary blob from the append-only immutable files.
+func (f *Freezer) Ancient(kind string, number uint64) ([]byte, error) {
+	table := f.tables[kind]
+	if table == nil {
+		return nil, errUnknownTable
+	}
+	if number >= atomic.LoadUint64(&f.frozen) {
+		return nil, ErrAncientNotFound
+	}
+	return table.Retrieve(number)
+}
+
+// Ancients returns the number of blocks frozen into the ancient store.
+func (f *Freezer) Ancients() uint64 {
+	return atomic.LoadUint64(&f.frozen)
+}
+
+// AncientSize returns the data size of the specified table.
+func (f *Freezer) AncientSize(kind string) (uint64, error) {
+	if table := f.tables[kind]; table != nil {
+		return table.Size(), nil
+	}
+	return 0, errUnknownTable
+}
+
+// AppendAncient injects all binary blobs belonging to a block at the end of the
+// append-only immutable table files. The block number must be the next one in
+// line, otherwise the append is rejected. If any of the tables fails to accept
+// its blob, all of them are rolled back so the store stays consistent.
+func (f *Freezer) AppendAncient(number uint64, hash, header, body, receipts, td []byte) (err error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if frozen := atomic.LoadUint64(&f.frozen); number != frozen {
+		return fmt.Errorf("%v: have block %d, want %d", errOutOrderInsertion, number, frozen)
+	}
+	defer func() {
+		if err != nil {
+			for _, table := range f.tables {
+				if rerr := table.truncate(number); rerr != nil {
+					glog.V(logger.Error).Infof("Failed to rollback ancient table %s to %d: %v", table.name, number, rerr)
+				}
+			}
+		}
+	}()
+	blobs := map[string][]byte{
+		FreezerHashTable:       hash,
+		FreezerHeaderTable:     header,
+		FreezerBodiesTable:     body,
+		FreezerReceiptTable:    receipts,
+		FreezerDifficultyTable: td,
+	}
+	for _, name := range FreezerTables {
+		if err := f.tables[name].Append(number, blobs[name]); err != nil {
+			return fmt.Errorf("failed to append block %d to %s: %v", number, name, err)
+		}
+	}
+	atomic.AddUint64(&f.frozen, 1)
+	return nil
+}
+
+// TruncateAncients discards any recent data above the provided threshold number.
+func (f *Freezer) TruncateAncients(items uint64) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if atomic.LoadUint64(&f.frozen) <= items {
+		return nil
+	}
+	for _, table := range f.tables {
+		if err := table.truncate(items); err != nil {
+			return err
+		}
+	}
+	atomic.StoreUint64(&f.frozen, items)
+	return nil
+}
+
+// Sync flushes all data tables to disk.
+func (f *Freezer) Sync() error {
+	var errs []error
+	for _, table := range f.tables {
+		if err := table.Sync(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if errs != nil {
+		return fmt.Errorf("%v", errs)
+	}
+	return nil
+}
+
+// AncientStore returns the ancient store backing the given database, or nil if
+// the database does not have one.
+func AncientStore(db Database) *Freezer {
+	if db, ok := db.(interface {
+		Freezer() *Freezer
+	}); ok {
+		return db.Freezer()
+	}
+	return nil
+}
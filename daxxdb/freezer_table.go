@@ -0,0 +1,285 @@
+// Copyright 2017 The daxxcoreAuthors
+// This file is part of the daxxcore library.
+//
+// The daxxcore library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The daxxcore library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the daxxcore library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethdb
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+
+	"github.com/daxxcoin/daxxcore/logger"
+	"github.com/daxxcoin/daxxcore/logger/glog"
+)
+
+var (
+	// errOutOfBounds is returned if the item requested is not contained within
+	// the freezer table.
+	errOutOfBounds = errors.New("out of bounds")
+
+	// errOutOrderInsertion is returned if the user attempts to inject out-of-order
+	// binary blobs into the freezer.
+	errOutOrderInsertion = errors.New("the append operation is out-order")
+
+	// errClosed is returned if an operation attempts to read from or write to the
+	// freezer table after it has already been closed.
+	errClosed = errors.New("closed")
+)
+
+// indexEntrySize is the size of a single index entry: the big endian offset in
+// the data file at which the item ends.
+const indexEntrySize = 8
+
+// freezerTable represents a single chained data table within the freezer (e.g.
+// blocks). It consists of a data file holding the concatenated items and an
+// index file holding the end offset of each item within the data file. Items
+// can only be appended to the end, or truncated from the end.
+type freezerTable struct {
+	items uint64 // Number of items stored in the table (atomic)
+
+	name  string
+	index *os.File // File descriptor for the indexEntry file of the table
+	data  *os.File // File descriptor for the data file of the table
+	size  uint64   // Number of bytes stored in the data file
+
+	lock sync.RWMutex // Mutex protecting the data file descriptors
+}
+
+// newFreezerTable opens the given path as a freezer table, repairing any data
+// inconsistencies left behind by an unclean shutdown.
+func newFreezerTable(path, name string) (*freezerTable, error) {
+	if err := os.MkdirAll(path, 0755); err != nil {
+		return nil, err
+	}
+	index, err := os.OpenFile(filepath.Join(path, name+".idx"), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.OpenFile(filepath.Join(path, name+".dat"), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		index.Close()
+		return nil, err
+	}
+	tab := &freezerTable{
+		name:  name,
+		index: index,
+		data:  data,
+	}
+	if err := tab.repair(); err != nil {
+		tab.Close()
+		return nil, err
+	}
+	return tab, nil
+}
+
+// repair cross checks the index and data files, truncating both to the last item
+// that was fully written to disk.
+func (t *freezerTable) repair() error {
+	istat, err := t.index.Stat()
+	if err != nil {
+		return err
+	}
+	dstat, err := t.data.Stat()
+	if err != nil {
+		return err
+	}
+	// Drop any partially written index entry, then any index entries pointing
+	// past the end of the data file
+	items := uint64(istat.Size()) / indexEntrySize
+	size := uint64(dstat.Size())
+
+	var end uint64
+	for items > 0 {
+		if end, err = t.offset(items); err != nil {
+			return err
+		}
+		if end <= size {
+			break
+		}
+		items--
+	}
+	if items == 0 {
+		end = 0
+	}
+	if uint64(istat.Size()) != items*indexEntrySize {
+		glog.V(logger.Warn).Infof("Truncating dangling freezer %s index: %d -> %d items", t.name, uint64(istat.Size())/indexEntrySize, items)
+		if err := t.index.Truncate(int64(items * indexEntrySize)); err != nil {
+			return err
+		}
+	}
+	if size != end {
+		glog.V(logger.Warn).Infof("Truncating dangling freezer %s data: %d -> %d bytes", t.name, size, end)
+		if err := t.data.Truncate(int64(end)); err != nil {
+			return err
+		}
+	}
+	t.items, t.size = items, end
+	return nil
+}
+
+// offset retrieves the end offset of the n-th item (1 based) in the data file,
+// the zeroth item ending at the start of the file.
+func (t *freezerTable) offset(n uint64) (uint64, error) {
+	if n == 0 {
+		return 0, nil
+	}
+	buf := make([]byte, indexEntrySize)
+	if _, err := t.index.ReadAt(buf, int64((n-1)*indexEntrySize)); err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint64(buf), nil
+}
+
+// Items returns the number of items stored in the table.
+func (t *freezerTable) Items() uint64 {
+	return atomic.LoadUint64(&t.items)
+}
+
+// Size returns the total data size of the items stored in the table.
+func (t *freezerTable) Size() uint64 {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	return t.size
+}
+
+// Append injects a binary blob at the end of the freezer table. The item number
+// is a precautionary parameter to ensure data correctness, but the table will
+// reject already existing data.
+func (t *freezerTable) Append(item uint64, blob []byte) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.index == nil || t.data == nil {
+		return errClosed
+	}
+	if item != atomic.LoadUint64(&t.items) {
+		return errOutOrderInsertion
+	}
+	// Write the data first, so the index never points to missing content
+	if _, err := t.data.WriteAt(blob, int64(t.size)); err != nil {
+		return err
+	}
+	end := t.size + uint64(len(blob))
+
+	buf := make([]byte, indexEntrySize)
+	binary.BigEndian.PutUint64(buf, end)
+	if _, err := t.index.WriteAt(buf, int64(item*indexEntrySize)); err != nil {
+		return err
+	}
+	t.size = end
+	atomic.AddUint64(&t.items, 1)
+	return nil
+}
+
+// Retrieve looks up the data offset of an item with the given number and
+// retrieves the raw binary blob from the data file.
+func (t *freezerTable) Retrieve(item uint64) ([]byte, error) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	if t.index == nil || t.data == nil {
+		return nil, errClosed
+	}
+	if item >= atomic.LoadUint64(&t.items) {
+		return nil, errOutOfBounds
+	}
+	start, err := t.offset(item)
+	if err != nil {
+		return nil, err
+	}
+	end, err := t.offset(item + 1)
+	if err != nil {
+		return nil, err
+	}
+	if end < start {
+		return nil, fmt.Errorf("corrupted index of item %d: end %d before start %d", item, end, start)
+	}
+	blob := make([]byte, end-start)
+	if _, err := t.data.ReadAt(blob, int64(start)); err != nil {
+		return nil, err
+	}
+	return blob, nil
+}
+
+// truncate discards any recent data above the provided threshold number.
+func (t *freezerTable) truncate(items uint64) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.index == nil || t.data == nil {
+		return errClosed
+	}
+	if atomic.LoadUint64(&t.items) <= items {
+		return nil
+	}
+	end, err := t.offset(items)
+	if err != nil {
+		return err
+	}
+	if err := t.index.Truncate(int64(items * indexEntrySize)); err != nil {
+		return err
+	}
+	if err := t.data.Truncate(int64(end)); err != nil {
+		return err
+	}
+	t.size = end
+	atomic.StoreUint64(&t.items, items)
+	return nil
+}
+
+// Sync pushes any pending data from memory out to disk. This is an expensive
+// operation, so use it with care.
+func (t *freezerTable) Sync() error {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	if t.index == nil || t.data == nil {
+		return errClosed
+	}
+	if err := t.data.Sync(); err != nil {
+		return err
+	}
+	return t.index.Sync()
+}
+
+// Close closes all opened files.
+func (t *freezerTable) Close() error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	var errs []error
+	if t.index != nil {
+		if err := t.index.Close(); err != nil {
+			errs = append(errs, err)
+		}
+		t.index = nil
+	}
+	if t.data != nil {
+		if err := t.data.Close(); err != nil {
+			errs = append(errs, err)
+		}
+		t.data = nil
+	}
+	if errs != nil {
+		return fmt.Errorf("%v", errs)
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+// Copyright 2017 The daxxcoreAuthors
+// This file is part of the daxxcore library.
+//
+// The daxxcore library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The daxxcore library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the daxxcore library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethdb
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// appendTestBlocks appends n blocks with distinct content to the freezer.
+func appendTestBlocks(t *testing.T, f *Freezer, n int) {
+	for i := 0; i < n; i++ {
+		number := f.Ancients()
+		blob := bytes.Repeat([]byte{byte(number)}, int(number)+1)
+		if err := f.AppendAncient(number, blob, blob, blob, blob, blob); err != nil {
+			t.Fatalf("failed to append block %d: %v", number, err)
+		}
+	}
+}
+
+// Tests basic freezer operations: appending, retrieving, truncating and reopening.
+func TestFreezerBasics(t *testing.T) {
+	dir, err := ioutil.TempDir("", "freezer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	f, err := NewFreezer(dir)
+	if err != nil {
+		t.Fatalf("failed to create freezer: %v", err)
+	}
+	appendTestBlocks(t, f, 10)
+
+	if err := f.AppendAncient(5, nil, nil, nil, nil, nil); err == nil {
+		t.Errorf("out of order append succeeded")
+	}
+	for i := uint64(0); i < 10; i++ {
+		for _, table := range FreezerTables {
+			blob, err := f.Ancient(table, i)
+			if err != nil {
+				t.Fatalf("item %d/%s: failed to retrieve: %v", i, table, err)
+			}
+			if want := bytes.Repeat([]byte{byte(i)}, int(i)+1); !bytes.Equal(blob, want) {
+				t.Errorf("item %d/%s: content mismatch: have %x, want %x", i, table, blob, want)
+			}
+		}
+	}
+	if _, err := f.Ancient(FreezerHeaderTable, 10); err != ErrAncientNotFound {
+		t.Errorf("out of bounds retrieval error mismatch: have %v, want %v", err, ErrAncientNotFound)
+	}
+	if err := f.TruncateAncients(4); err != nil {
+		t.Fatalf("failed to truncate freezer: %v", err)
+	}
+	if f.HasAncient(FreezerBodiesTable, 4) {
+		t.Errorf("truncated item still present")
+	}
+	if size, _ := f.AncientSize(FreezerBodiesTable); size != 1+2+3+4 {
+		t.Errorf("table size mismatch: have %d, want %d", size, 10)
+	}
+	appendTestBlocks(t, f, 2)
+	f.Close()
+
+	// Reopen the freezer and check that everything's still there
+	if f, err = NewFreezer(dir); err != nil {
+		t.Fatalf("failed to reopen freezer: %v", err)
+	}
+	defer f.Close()
+
+	if frozen := f.Ancients(); frozen != 6 {
+		t.Fatalf("frozen item count mismatch: have %d, want %d", frozen, 6)
+	}
+	if blob, _ := f.Ancient(FreezerReceiptTable, 5); !bytes.Equal(blob, bytes.Repeat([]byte{5}, 6)) {
+		t.Errorf("reopened content mismatch: have %x", blob)
+	}
+}
+
+// Tests that a freezer left behind in an inconsistent state (e.g. crash during
+// an append) is repaired when opened.
+func TestFreezerRepair(t *testing.T) {
+	dir, err := ioutil.TempDir("", "freezer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	f, err := NewFreezer(dir)
+	if err != nil {
+		t.Fatalf("failed to create freezer: %v", err)
+	}
+	appendTestBlocks(t, f, 5)
+	f.Close()
+
+	// Simulate a crash: half an index entry on one table, missing data on another
+	index, _ := os.OpenFile(filepath.Join(dir, FreezerHeaderTable+".idx"), os.O_RDWR|os.O_APPEND, 0644)
+	index.Write([]byte{0x00, 0x00, 0x01})
+	index.Close()
+
+	stat, _ := os.Stat(filepath.Join(dir, FreezerBodiesTable+".dat"))
+	os.Truncate(filepath.Join(dir, FreezerBodiesTable+".dat"), stat.Size()-1)
+
+	if f, err = NewFreezer(dir); err != nil {
+		t.Fatalf("failed to reopen freezer: %v", err)
+	}
+	defer f.Close()
+
+	if frozen := f.Ancients(); frozen != 4 {
+		t.Fatalf("frozen item count mismatch: have %d, want %d", frozen, 4)
+	}
+	for _, table := range FreezerTables {
+		if f.HasAncient(table, 4) {
+			t.Errorf("%s: partially written item not dropped", table)
+		}
+	}
+	appendTestBlocks(t, f, 1)
+	if blob, _ := f.Ancient(FreezerBodiesTable, 4); !bytes.Equal(blob, bytes.Repeat([]byte{4}, 5)) {
+		t.Errorf("re-appended content mismatch: have %x", blob)
+	}
+}
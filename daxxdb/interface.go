@@ -16,15 +16,91 @@
 
 package ethdb
 
-type Database interface {
+// IdealBatchSize is the amount of data a batch should accumulate before being
+// flushed to disk, balancing memory use against write amplification.
+const IdealBatchSize = 100 * 1024
+
+// Putter wraps the database write operation supported by both batches and regular databases.
+type Putter interface {
 	Put(key []byte, value []byte) error
-	Get(key []byte) ([]byte, error)
+}
+
+// Deleter wraps the database delete operation supported by both batches and regular databases.
+type Deleter interface {
 	Delete(key []byte) error
+}
+
+// KeyValueWriter wraps the modification operations a batch can be replayed into.
+type KeyValueWriter interface {
+	Putter
+	Deleter
+}
+
+// Database wraps all database operations. All methods are safe for concurrent use.
+type Database interface {
+	Putter
+	Deleter
+	Get(key []byte) ([]byte, error)
+	Has(key []byte) (bool, error)
 	Close()
 	NewBatch() Batch
+
+	// NewIterator creates a binary-alphabetical iterator over the subset of the
+	// database content with a particular key prefix, starting at a particular
+	// initial key (or after, if it does not exist). The start key is relative
+	// to the prefix, i.e. it must not contain it.
+	NewIterator(prefix []byte, start []byte) Iterator
+
+	// Stat returns a particular internal stat of the database.
+	Stat(property string) (string, error)
+
+	// Compact flattens the underlying data store for the given key range. A nil
+	// start is treated as a key before all keys in the data store; a nil limit
+	// is treated as a key after all keys in the data store.
+	Compact(start []byte, limit []byte) error
 }
 
+// Batch is a write-only database that commits changes to its host database
+// when Write is called. Batches cannot be used concurrently.
 type Batch interface {
-	Put(key, value []byte) error
+	Putter
+	Deleter
+
+	// ValueSize retrieves the amount of data queued up for writing.
+	ValueSize() int
+
+	// Write flushes any accumulated data to disk.
 	Write() error
+
+	// Reset resets the batch for reuse.
+	Reset()
+
+	// Replay replays the batch contents into the given writer.
+	Replay(w KeyValueWriter) error
+}
+
+// Iterator iterates over a database's key/value pairs in ascending key order.
+// It must be released after use.
+type Iterator interface {
+	// Next moves the iterator to the next key/value pair, returning whether the
+	// iterator is exhausted.
+	Next() bool
+
+	// Error returns any accumulated error. Exhausting all the key/value pairs
+	// is not considered to be an error.
+	Error() error
+
+	// Key returns the key of the current key/value pair, or nil if done. The
+	// caller should not modify the contents of the returned slice, and its
+	// contents may change on the next call to Next.
+	Key() []byte
+
+	// Value returns the value of the current key/value pair, or nil if done.
+	// The caller should not modify the contents of the returned slice, and its
+	// contents may change on the next call to Next.
+	Value() []byte
+
+	// Release releases associated resources. Release should always succeed and
+	// can be called multiple times without causing error.
+	Release()
 }
@@ -130,6 +130,110 @@ func (s *PublicTxPoolAPI) Content() map[string]map[string]map[string]*RPCTransac
 	return content
 }
 
+// ContentFrom returns the transactions contained within the transaction pool
+// that were sent from the given account, keyed by nonce.
+func (s *PublicTxPoolAPI) ContentFrom(addr common.Address) map[string]map[string]*RPCTransaction {
+	pending, queue := s.b.TxPoolContentFrom(addr)
+
+	content := map[string]map[string]*RPCTransaction{
+		"pending": make(map[string]*RPCTransaction, len(pending)),
+		"queued":  make(map[string]*RPCTransaction, len(queue)),
+	}
+	for _, tx := range pending {
+		content["pending"][fmt.Sprintf("%d", tx.Nonce())] = newRPCPendingTransaction(tx)
+	}
+	for _, tx := range queue {
+		content["queued"][fmt.Sprintf("%d", tx.Nonce())] = newRPCPendingTransaction(tx)
+	}
+	return content
+}
+
+// RPCTxPoolEvent represents a transaction pool lifecycle event that will
+// serialize to the RPC representation of the event.
+type RPCTxPoolEvent struct {
+	Type       string         `json:"type"`
+	Hash       common.Hash    `json:"hash"`
+	From       common.Address `json:"from"`
+	Nonce      hexutil.Uint64 `json:"nonce"`
+	Local      bool           `json:"local,omitempty"`
+	ReplacedBy *common.Hash   `json:"replacedBy,omitempty"`
+	Reason     string         `json:"reason,omitempty"`
+}
+
+// newRPCTxPoolEvent converts a transaction pool event into its RPC representation,
+// returning nil for events not related to the transaction lifecycle.
+func newRPCTxPoolEvent(ev interface{}) *RPCTxPoolEvent {
+	var (
+		tx  *types.Transaction
+		res = new(RPCTxPoolEvent)
+	)
+	switch ev := ev.(type) {
+	case core.TxAddedEvent:
+		tx, res.Type, res.Local = ev.Tx, "added", ev.Local
+	case core.TxPromotedEvent:
+		tx, res.Type = ev.Tx, "promoted"
+	case core.TxReplacedEvent:
+		hash := ev.New.Hash()
+		tx, res.Type, res.ReplacedBy = ev.Old, "replaced", &hash
+	case core.TxDroppedEvent:
+		tx, res.Type, res.Reason = ev.Tx, "dropped", string(ev.Reason)
+	case core.TxMinedEvent:
+		tx, res.Type = ev.Tx, "mined"
+	default:
+		return nil
+	}
+	var signer types.Signer = types.FrontierSigner{}
+	if tx.Protected() {
+		signer = types.NewEIP155Signer(tx.ChainId())
+	}
+	res.From, _ = types.Sender(signer, tx)
+	res.Hash, res.Nonce = tx.Hash(), hexutil.Uint64(tx.Nonce())
+
+	return res
+}
+
+// txEventsBuffer is the number of transaction pool events buffered for a single
+// subscriber. Subscribers falling further behind are dropped.
+const txEventsBuffer = 1024
+
+// Transactions creates a subscription that is triggered each time a transaction
+// is added to, promoted within, replaced in, dropped from or mined out of the
+// transaction pool. Subscriptions not keeping up with the pool are terminated.
+func (s *PublicTxPoolAPI) Transactions(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		events := make(chan interface{}, txEventsBuffer)
+		sub := s.b.SubscribeTxEvents(events)
+		defer sub.Unsubscribe()
+
+		for {
+			select {
+			case ev := <-events:
+				if res := newRPCTxPoolEvent(ev); res != nil {
+					notifier.Notify(rpcSub.ID, res)
+				}
+			case err := <-sub.Err():
+				if err != nil {
+					glog.V(logger.Debug).Infof("Dropping transaction pool subscription %s: %v", rpcSub.ID, err)
+				}
+				return
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
 // Status returns the number of pending and queued transaction in the pool.
 func (s *PublicTxPoolAPI) Status() map[string]hexutil.Uint {
 	pending, queue := s.b.Stats()
@@ -544,6 +648,96 @@ func (s *PublicBlockChainAPI) GetStorageAt(ctx context.Context, address common.A
 	return res.Hex(), nil
 }
 
+// AccountResult is the Merkle proof of an account and some of its storage slots,
+// as returned by eth_getProof.
+type AccountResult struct {
+	Address      common.Address  `json:"address"`
+	AccountProof []hexutil.Bytes `json:"accountProof"`
+	Balance      *hexutil.Big    `json:"balance"`
+	CodeHash     common.Hash     `json:"codeHash"`
+	Nonce        hexutil.Uint64  `json:"nonce"`
+	StorageHash  common.Hash     `json:"storageHash"`
+	StorageProof []StorageResult `json:"storageProof"`
+}
+
+// StorageResult is the Merkle proof of a single storage slot of an account.
+type StorageResult struct {
+	Key   string          `json:"key"`
+	Value *hexutil.Big    `json:"value"`
+	Proof []hexutil.Bytes `json:"proof"`
+}
+
+// GetProof returns the Merkle proof of the given account and storage keys in the
+// state of the given block number, allowing the values to be verified against
+// the state root in the block header. The rpc.LatestBlockNumber and
+// rpc.PendingBlockNumber meta block numbers are also allowed.
+func (s *PublicBlockChainAPI) GetProof(ctx context.Context, address common.Address, storageKeys []string, blockNr rpc.BlockNumber) (*AccountResult, error) {
+	state, _, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	// Gather the account fields, defaulting the hashes of nonexistent accounts
+	balance, err := state.GetBalance(ctx, address)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := state.GetNonce(ctx, address)
+	if err != nil {
+		return nil, err
+	}
+	codeHash, err := state.GetCodeHash(ctx, address)
+	if err != nil {
+		return nil, err
+	}
+	if (codeHash == common.Hash{}) {
+		codeHash = crypto.Keccak256Hash(nil)
+	}
+	storageHash, err := state.GetStorageRoot(ctx, address)
+	if err != nil {
+		return nil, err
+	}
+	// Create the proofs of the account and the requested storage slots
+	accountProof, err := state.GetProof(ctx, address)
+	if err != nil {
+		return nil, err
+	}
+	storageProof := make([]StorageResult, len(storageKeys))
+	for i, key := range storageKeys {
+		hash := common.HexToHash(key)
+		value, err := state.GetState(ctx, address, hash)
+		if err != nil {
+			return nil, err
+		}
+		proof, err := state.GetStorageProof(ctx, address, hash)
+		if err != nil {
+			return nil, err
+		}
+		storageProof[i] = StorageResult{
+			Key:   key,
+			Value: (*hexutil.Big)(value.Big()),
+			Proof: toHexSlice(proof),
+		}
+	}
+	return &AccountResult{
+		Address:      address,
+		AccountProof: toHexSlice(accountProof),
+		Balance:      (*hexutil.Big)(balance),
+		CodeHash:     codeHash,
+		Nonce:        hexutil.Uint64(nonce),
+		StorageHash:  storageHash,
+		StorageProof: storageProof,
+	}, nil
+}
+
+// toHexSlice converts a list of RLP encoded trie nodes into hex encodable blobs.
+func toHexSlice(nodes []rlp.RawValue) []hexutil.Bytes {
+	blobs := make([]hexutil.Bytes, len(nodes))
+	for i, node := range nodes {
+		blobs[i] = hexutil.Bytes(node)
+	}
+	return blobs
+}
+
 // callmsg is the message type used for call transitions.
 type callmsg struct {
 	addr          common.Address
@@ -574,12 +768,12 @@ type CallArgs struct {
 	Data     hexutil.Bytes   `json:"data"`
 }
 
-func (s *PublicBlockChainAPI) doCall(ctx context.Context, args CallArgs, blockNr rpc.BlockNumber) (string, *big.Int, error) {
+func (s *PublicBlockChainAPI) doCall(ctx context.Context, args CallArgs, blockNr rpc.BlockNumber) (string, *big.Int, bool, error) {
 	defer func(start time.Time) { glog.V(logger.Debug).Infof("call took %v", time.Since(start)) }(time.Now())
 
 	state, header, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
 	if state == nil || err != nil {
-		return "0x", common.Big0, err
+		return "0x", common.Big0, false, err
 	}
 	// Set sender address or use a default if none specified
 	addr := args.From
@@ -605,23 +799,23 @@ func (s *PublicBlockChainAPI) doCall(ctx context.Context, args CallArgs, blockNr
 	// Execute the call and return
 	vmenv, vmError, err := s.b.GetVMEnv(ctx, msg, state, header)
 	if err != nil {
-		return "0x", common.Big0, err
+		return "0x", common.Big0, false, err
 	}
 	gp := new(core.GasPool).AddGas(common.MaxBig)
-	res, gas, err := core.ApplyMessage(vmenv, msg, gp)
+	res, gas, failed, err := core.ApplyMessage(vmenv, msg, gp)
 	if err := vmError(); err != nil {
-		return "0x", common.Big0, err
+		return "0x", common.Big0, false, err
 	}
 	if len(res) == 0 { // backwards compatibility
-		return "0x", gas, err
+		return "0x", gas, failed, err
 	}
-	return common.ToHex(res), gas, err
+	return common.ToHex(res), gas, failed, err
 }
 
 // Call executes the given transaction on the state for the given block number.
 // It doesn't make and changes in the state/blockchain and is useful to execute and retrieve values.
 func (s *PublicBlockChainAPI) Call(ctx context.Context, args CallArgs, blockNr rpc.BlockNumber) (string, error) {
-	result, _, err := s.doCall(ctx, args, blockNr)
+	result, _, _, err := s.doCall(ctx, args, blockNr)
 	return result, err
 }
 
@@ -644,10 +838,11 @@ func (s *PublicBlockChainAPI) EstimateGas(ctx context.Context, args CallArgs) (*
 		mid := (hi + lo) / 2
 		(*big.Int)(&args.Gas).SetUint64(mid)
 
-		_, gas, err := s.doCall(ctx, args, rpc.PendingBlockNumber)
+		_, _, failed, err := s.doCall(ctx, args, rpc.PendingBlockNumber)
 
-		// If the transaction became invalid or used all the gas (failed), raise the gas limit
-		if err != nil || gas.Cmp((*big.Int)(&args.Gas)) == 0 {
+		// If the transaction became invalid or its execution failed (ran out of
+		// gas or reverted), raise the gas limit
+		if err != nil || failed {
 			lo = mid
 			continue
 		}
@@ -662,6 +857,7 @@ func (s *PublicBlockChainAPI) EstimateGas(ctx context.Context, args CallArgs) (*
 // gas used and the return value
 type ExecutionResult struct {
 	Gas         *big.Int       `json:"gas"`
+	Failed      bool           `json:"failed"`
 	ReturnValue string         `json:"returnValue"`
 	StructLogs  []StructLogRes `json:"structLogs"`
 }
@@ -871,6 +1067,24 @@ func NewPublicTransactionPoolAPI(b Backend) *PublicTransactionPoolAPI {
 	return &PublicTransactionPoolAPI{b}
 }
 
+// errTxIndexing is returned for transactions not found while older transaction
+// lookups are still being indexed.
+var errTxIndexing = errors.New("transaction indexing is in progress")
+
+// txLookupError returns the error to report for a transaction found neither in
+// the database nor in the pool, or nil if it's known not to exist. Transactions
+// included before the lookup history limit can't be found.
+func txLookupError(b Backend) error {
+	tail, indexing := b.TxIndexProgress()
+	switch {
+	case indexing:
+		return errTxIndexing
+	case tail > 0:
+		return fmt.Errorf("transaction not found, transactions before block #%d are not indexed", tail)
+	}
+	return nil
+}
+
 func getTransaction(chainDb ethdb.Database, b Backend, txHash common.Hash) (*types.Transaction, bool, error) {
 	txData, err := chainDb.Get(txHash.Bytes())
 	isPending := false
@@ -984,7 +1198,7 @@ func (s *PublicTransactionPoolAPI) GetTransactionByHash(ctx context.Context, txH
 		glog.V(logger.Debug).Infof("%v\n", err)
 		return nil, nil
 	} else if tx == nil {
-		return nil, nil
+		return nil, txLookupError(s.b)
 	}
 
 	if isPending {
@@ -1013,7 +1227,7 @@ func (s *PublicTransactionPoolAPI) GetRawTransactionByHash(ctx context.Context,
 		glog.V(logger.Debug).Infof("%v\n", err)
 		return nil, nil
 	} else if tx == nil {
-		return nil, nil
+		return nil, txLookupError(s.b)
 	}
 
 	return rlp.EncodeToBytes(tx)
@@ -1024,7 +1238,10 @@ func (s *PublicTransactionPoolAPI) GetTransactionReceipt(txHash common.Hash) (ma
 	receipt := core.GetReceipt(s.b.ChainDb(), txHash)
 	if receipt == nil {
 		glog.V(logger.Debug).Infof("receipt not found for transaction %s", txHash.Hex())
-		return nil, nil
+		if s.b.GetPoolTransaction(txHash) != nil {
+			return nil, nil // pending transactions have no receipt yet
+		}
+		return nil, txLookupError(s.b)
 	}
 
 	tx, _, err := getTransaction(s.b.ChainDb(), s.b, txHash)
@@ -1046,7 +1263,6 @@ func (s *PublicTransactionPoolAPI) GetTransactionReceipt(txHash common.Hash) (ma
 	from, _ := types.Sender(signer, tx)
 
 	fields := map[string]interface{}{
-		"root":              hexutil.Bytes(receipt.PostState),
 		"blockHash":         txBlock,
 		"blockNumber":       hexutil.Uint64(blockIndex),
 		"transactionHash":   txHash,
@@ -1059,6 +1275,12 @@ func (s *PublicTransactionPoolAPI) GetTransactionReceipt(txHash common.Hash) (ma
 		"logs":              receipt.Logs,
 		"logsBloom":         receipt.Bloom,
 	}
+	// Assign the receipt status (post-Byzantium) or the intermediate state root
+	if len(receipt.PostState) > 0 {
+		fields["root"] = hexutil.Bytes(receipt.PostState)
+	} else {
+		fields["status"] = hexutil.Uint(receipt.Status)
+	}
 	if receipt.Logs == nil {
 		fields["logs"] = [][]*types.Log{}
 	}
@@ -1410,8 +1632,8 @@ func (api *PrivateDebugAPI) ChaindbCompact() error {
 }
 
 // SetHead rewinds the head of the blockchain to a previous block.
-func (api *PrivateDebugAPI) SetHead(number hexutil.Uint64) {
-	api.b.SetHead(uint64(number))
+func (api *PrivateDebugAPI) SetHead(number hexutil.Uint64) error {
+	return api.b.SetHead(uint64(number))
 }
 
 // PublicNetAPI offers network related RPC methods
@@ -29,6 +29,7 @@ import (
 	"github.com/daxxcoin/daxxcore/daxxdb"
 	"github.com/daxxcoin/daxxcore/event"
 	"github.com/daxxcoin/daxxcore/params"
+	"github.com/daxxcoin/daxxcore/rlp"
 	"github.com/daxxcoin/daxxcore/rpc"
 	"golang.org/x/net/context"
 )
@@ -44,7 +45,7 @@ type Backend interface {
 	EventMux() *event.TypeMux
 	AccountManager() *accounts.Manager
 	// BlockChain API
-	SetHead(number uint64)
+	SetHead(number uint64) error
 	HeaderByNumber(ctx context.Context, blockNr rpc.BlockNumber) (*types.Header, error)
 	BlockByNumber(ctx context.Context, blockNr rpc.BlockNumber) (*types.Block, error)
 	StateAndHeaderByNumber(ctx context.Context, blockNr rpc.BlockNumber) (State, *types.Header, error)
@@ -52,6 +53,7 @@ type Backend interface {
 	GetReceipts(ctx context.Context, blockHash common.Hash) (types.Receipts, error)
 	GetTd(blockHash common.Hash) *big.Int
 	GetVMEnv(ctx context.Context, msg core.Message, state State, header *types.Header) (*vm.EVM, func() error, error)
+	TxIndexProgress() (tail uint64, indexing bool)
 	// TxPool API
 	SendTx(ctx context.Context, signedTx *types.Transaction) error
 	RemoveTx(txHash common.Hash)
@@ -60,6 +62,8 @@ type Backend interface {
 	GetPoolNonce(ctx context.Context, addr common.Address) (uint64, error)
 	Stats() (pending int, queued int)
 	TxPoolContent() (map[common.Address]types.Transactions, map[common.Address]types.Transactions)
+	TxPoolContentFrom(addr common.Address) (types.Transactions, types.Transactions)
+	SubscribeTxEvents(ch chan<- interface{}) event.Subscription
 
 	ChainConfig() *params.ChainConfig
 	CurrentBlock() *types.Block
@@ -70,6 +74,10 @@ type State interface {
 	GetCode(ctx context.Context, addr common.Address) ([]byte, error)
 	GetState(ctx context.Context, a common.Address, b common.Hash) (common.Hash, error)
 	GetNonce(ctx context.Context, addr common.Address) (uint64, error)
+	GetCodeHash(ctx context.Context, addr common.Address) (common.Hash, error)
+	GetStorageRoot(ctx context.Context, addr common.Address) (common.Hash, error)
+	GetProof(ctx context.Context, addr common.Address) ([]rlp.RawValue, error)
+	GetStorageProof(ctx context.Context, addr common.Address, key common.Hash) ([]rlp.RawValue, error)
 }
 
 func GetAPIs(apiBackend Backend, solcPath string) []rpc.API {
@@ -18,7 +18,6 @@
 package les
 
 import (
-	"errors"
 	"fmt"
 	"time"
 
@@ -26,6 +25,7 @@ import (
 	"github.com/daxxcoin/daxxcore/common"
 	"github.com/daxxcoin/daxxcore/common/compiler"
 	"github.com/daxxcoin/daxxcore/common/hexutil"
+	"github.com/daxxcoin/daxxcore/consensus"
 	"github.com/daxxcoin/daxxcore/core"
 	"github.com/daxxcoin/daxxcore/core/types"
 	"github.com/daxxcoin/daxxcore/daxx"
@@ -41,7 +41,6 @@ import (
 	"github.com/daxxcoin/daxxcore/node"
 	"github.com/daxxcoin/daxxcore/p2p"
 	"github.com/daxxcoin/daxxcore/params"
-	"github.com/daxxcoin/daxxcore/pow"
 	rpc "github.com/daxxcoin/daxxcore/rpc"
 )
 
@@ -61,7 +60,7 @@ type LightDaxxcoin struct {
 	ApiBackend *LesApiBackend
 
 	eventMux       *event.TypeMux
-	pow            pow.PoW
+	engine         consensus.Engine
 	accountManager *accounts.Manager
 	solcPath       string
 	solc           *compiler.Solidity
@@ -75,10 +74,11 @@ func New(ctx *node.ServiceContext, config *eth.Config) (*LightDaxxcoin, error) {
 	if err != nil {
 		return nil, err
 	}
-	if err := eth.SetupGenesisBlock(&chainDb, config); err != nil {
-		return nil, err
+	chainConfig, genesisErr := eth.SetupGenesisBlock(&chainDb, config)
+	if _, ok := genesisErr.(*params.ConfigCompatError); genesisErr != nil && !ok {
+		return nil, genesisErr
 	}
-	pow, err := eth.CreatePoW(config)
+	engine, err := eth.CreateConsensusEngine(config, chainConfig, chainDb)
 	if err != nil {
 		return nil, err
 	}
@@ -91,17 +91,14 @@ func New(ctx *node.ServiceContext, config *eth.Config) (*LightDaxxcoin, error) {
 		chainDb:        chainDb,
 		eventMux:       ctx.EventMux,
 		accountManager: ctx.AccountManager,
-		pow:            pow,
+		engine:         engine,
 		shutdownChan:   make(chan bool),
 		netVersionId:   config.NetworkId,
 		solcPath:       config.SolcPath,
 	}
 
-	if config.ChainConfig == nil {
-		return nil, errors.New("missing chain config")
-	}
-	eth.chainConfig = config.ChainConfig
-	eth.blockchain, err = light.NewLightChain(odr, eth.chainConfig, eth.pow, eth.eventMux)
+	eth.chainConfig = chainConfig
+	eth.blockchain, err = light.NewLightChain(odr, eth.chainConfig, eth.engine, eth.eventMux)
 	if err != nil {
 		if err == core.ErrNoGenesis {
 			return nil, fmt.Errorf(`Genesis block not found. Please supply a genesis block with the "--genesis /path/to/file" argument`)
@@ -109,8 +106,17 @@ func New(ctx *node.ServiceContext, config *eth.Config) (*LightDaxxcoin, error) {
 		return nil, err
 	}
 
+	// Rewind the chain in case of an incompatible config upgrade
+	if compat, ok := genesisErr.(*params.ConfigCompatError); ok {
+		glog.V(logger.Warn).Infof("Rewinding chain to #%d to upgrade configuration: %v", compat.RewindTo, compat)
+		eth.blockchain.SetHead(compat.RewindTo)
+		if err := core.WriteChainConfig(chainDb, eth.blockchain.Genesis().Hash(), chainConfig); err != nil {
+			return nil, err
+		}
+	}
+
 	eth.txPool = light.NewTxPool(eth.chainConfig, eth.eventMux, eth.blockchain, eth.relay)
-	if eth.protocolManager, err = NewProtocolManager(eth.chainConfig, config.LightMode, config.NetworkId, eth.eventMux, eth.pow, eth.blockchain, nil, chainDb, odr, relay); err != nil {
+	if eth.protocolManager, err = NewProtocolManager(eth.chainConfig, config.LightMode, config.NetworkId, eth.eventMux, eth.engine, eth.blockchain, nil, chainDb, odr, relay); err != nil {
 		return nil, err
 	}
 
@@ -144,7 +150,13 @@ func (s *LightDummyAPI) Mining() bool {
 // APIs returns the collection of RPC services the daxxcoin package offers.
 // NOTE, some of these services probably need to be moved to somewhere else.
 func (s *LightDaxxcoin) APIs() []rpc.API {
-	return append(ethapi.GetAPIs(s.ApiBackend, s.solcPath), []rpc.API{
+	apis := ethapi.GetAPIs(s.ApiBackend, s.solcPath)
+
+	// Append any APIs exposed explicitly by the consensus engine
+	apis = append(apis, s.engine.APIs(s.BlockChain().HeaderChain())...)
+
+	// Append all the local APIs and return
+	return append(apis, []rpc.API{
 		{
 			Namespace: "eth",
 			Version:   "1.0",
@@ -178,6 +190,7 @@ func (s *LightDaxxcoin) TxPool() *light.TxPool              { return s.txPool }
 func (s *LightDaxxcoin) LesVersion() int                    { return int(s.protocolManager.SubProtocols[0].Version) }
 func (s *LightDaxxcoin) Downloader() *downloader.Downloader { return s.protocolManager.downloader }
 func (s *LightDaxxcoin) EventMux() *event.TypeMux           { return s.eventMux }
+func (s *LightDaxxcoin) Engine() consensus.Engine           { return s.engine }
 
 // Protocols implements node.Service, returning all the currently configured
 // network protocols to start.
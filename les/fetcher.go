@@ -24,6 +24,7 @@ import (
 
 	"github.com/daxxcoin/daxxcore/common"
 	"github.com/daxxcoin/daxxcore/common/mclock"
+	"github.com/daxxcoin/daxxcore/consensus"
 	"github.com/daxxcoin/daxxcore/core"
 	"github.com/daxxcoin/daxxcore/core/types"
 	"github.com/daxxcoin/daxxcore/light"
@@ -465,7 +466,7 @@ func (f *lightFetcher) processResponse(req fetchRequest, resp fetchResponse) boo
 		headers[int(req.amount)-1-i] = header
 	}
 	if _, err := f.chain.InsertHeaderChain(headers, 1); err != nil {
-		if err == core.BlockFutureErr {
+		if err == consensus.ErrFutureBlock {
 			return true
 		}
 		glog.V(logger.Debug).Infof("InsertHeaderChain error: %v", err)
@@ -22,14 +22,13 @@ import (
 	"runtime"
 	"testing"
 
-	"github.com/daxxnucleus/daxxhash"
 	"github.com/daxxcoin/daxxcore/common"
+	"github.com/daxxcoin/daxxcore/consensus/daxxhash"
 	"github.com/daxxcoin/daxxcore/core"
 	"github.com/daxxcoin/daxxcore/core/types"
 	"github.com/daxxcoin/daxxcore/daxxdb"
 	"github.com/daxxcoin/daxxcore/event"
 	"github.com/daxxcoin/daxxcore/params"
-	"github.com/daxxcoin/daxxcore/pow"
 	"github.com/hashicorp/golang-lru"
 	"golang.org/x/net/context"
 )
@@ -65,9 +64,9 @@ func newCanonical(n int) (ethdb.Database, *LightChain, error) {
 	evmux := &event.TypeMux{}
 
 	// Initialize a fresh chain with only a genesis block
-	genesis, _ := core.WriteTestNetGenesisBlock(db)
+	genesis := core.DefaultTestnetGenesisBlock().MustCommit(db)
 
-	blockchain, _ := NewLightChain(&dummyOdr{db: db}, testChainConfig(), core.FakePow{}, evmux)
+	blockchain, _ := NewLightChain(&dummyOdr{db: db}, testChainConfig(), daxxhash.NewFaker(), evmux)
 	// Create and inject the requested chain
 	if n == 0 {
 		return db, blockchain, nil
@@ -82,15 +81,11 @@ func init() {
 	runtime.GOMAXPROCS(runtime.NumCPU())
 }
 
-func thePow() pow.PoW {
-	pow, _ := ethash.NewForTesting()
-	return pow
-}
-
 func theLightChain(db ethdb.Database, t *testing.T) *LightChain {
 	var eventMux event.TypeMux
-	core.WriteTestNetGenesisBlock(db)
-	LightChain, err := NewLightChain(&dummyOdr{db: db}, testChainConfig(), thePow(), &eventMux)
+	core.DefaultTestnetGenesisBlock().MustCommit(db)
+	engine, _ := daxxhash.NewTester()
+	LightChain, err := NewLightChain(&dummyOdr{db: db}, testChainConfig(), engine, &eventMux)
 	if err != nil {
 		t.Error("failed creating LightChain:", err)
 		t.FailNow()
@@ -146,7 +141,7 @@ func printChain(bc *LightChain) {
 func testHeaderChainImport(chain []*types.Header, LightChain *LightChain) error {
 	for _, header := range chain {
 		// Try and validate the header
-		if err := LightChain.Validator().ValidateHeader(header, LightChain.GetHeaderByHash(header.ParentHash), false); err != nil {
+		if err := LightChain.engine.VerifyHeader(LightChain.hc, header, false); err != nil {
 			return err
 		}
 		// Manually insert the header into the database, but don't reorganize (allows subsequent testing)
@@ -270,10 +265,6 @@ func TestBrokenHeaderChain(t *testing.T) {
 	}
 }
 
-type bproc struct{}
-
-func (bproc) ValidateHeader(*types.Header, *types.Header, bool) error { return nil }
-
 func makeHeaderChainWithDiff(genesis *types.Block, d []int, seed byte) []*types.Header {
 	var chain []*types.Header
 	for i, difficulty := range d {
@@ -311,12 +302,11 @@ func (odr *dummyOdr) Retrieve(ctx context.Context, req OdrRequest) error {
 func chm(genesis *types.Block, db ethdb.Database) *LightChain {
 	odr := &dummyOdr{db: db}
 	var eventMux event.TypeMux
-	bc := &LightChain{odr: odr, chainDb: db, genesisBlock: genesis, eventMux: &eventMux, pow: core.FakePow{}}
-	bc.hc, _ = core.NewHeaderChain(db, testChainConfig(), bc.Validator, bc.getProcInterrupt)
+	bc := &LightChain{odr: odr, chainDb: db, genesisBlock: genesis, eventMux: &eventMux, engine: daxxhash.NewFullFaker()}
+	bc.hc, _ = core.NewHeaderChain(db, testChainConfig(), bc.engine, bc.getProcInterrupt)
 	bc.bodyCache, _ = lru.New(100)
 	bc.bodyRLPCache, _ = lru.New(100)
 	bc.blockCache, _ = lru.New(100)
-	bc.SetValidator(bproc{})
 	bc.ResetWithGenesisBlock(genesis)
 
 	return bc
@@ -337,7 +327,7 @@ func TestReorgShortHeaders(t *testing.T) {
 func testReorg(t *testing.T, first, second []int, td int64) {
 	// Create a pristine block chain
 	db, _ := ethdb.NewMemDatabase()
-	genesis, _ := core.WriteTestNetGenesisBlock(db)
+	genesis := core.DefaultTestnetGenesisBlock().MustCommit(db)
 	bc := chm(genesis, db)
 
 	// Insert an easy and a difficult chain afterwards
@@ -361,7 +351,7 @@ func testReorg(t *testing.T, first, second []int, td int64) {
 func TestBadHeaderHashes(t *testing.T) {
 	// Create a pristine block chain
 	db, _ := ethdb.NewMemDatabase()
-	genesis, _ := core.WriteTestNetGenesisBlock(db)
+	genesis := core.DefaultTestnetGenesisBlock().MustCommit(db)
 	bc := chm(genesis, db)
 
 	// Create a chain, ban a hash and try to import
@@ -379,7 +369,7 @@ func TestBadHeaderHashes(t *testing.T) {
 func TestReorgBadHeaderHashes(t *testing.T) {
 	// Create a pristine block chain
 	db, _ := ethdb.NewMemDatabase()
-	genesis, _ := core.WriteTestNetGenesisBlock(db)
+	genesis := core.DefaultTestnetGenesisBlock().MustCommit(db)
 	bc := chm(genesis, db)
 
 	// Create a chain, import and ban aferwards
@@ -394,7 +384,7 @@ func TestReorgBadHeaderHashes(t *testing.T) {
 	core.BadHashes[headers[3].Hash()] = true
 	defer func() { delete(core.BadHashes, headers[3].Hash()) }()
 	// Create a new chain manager and check it rolled back the state
-	ncm, err := NewLightChain(&dummyOdr{db: db}, testChainConfig(), core.FakePow{}, new(event.TypeMux))
+	ncm, err := NewLightChain(&dummyOdr{db: db}, testChainConfig(), daxxhash.NewFaker(), new(event.TypeMux))
 	if err != nil {
 		t.Fatalf("failed to create new chain manager: %v", err)
 	}
@@ -174,7 +174,7 @@ func GetBlock(ctx context.Context, odr OdrBackend, hash common.Hash, number uint
 // GetBlockReceipts retrieves the receipts generated by the transactions included
 // in a block given by its hash.
 func GetBlockReceipts(ctx context.Context, odr OdrBackend, hash common.Hash, number uint64) (types.Receipts, error) {
-	receipts := core.GetBlockReceipts(odr.Database(), hash, number)
+	receipts := core.GetRawBlockReceipts(odr.Database(), hash, number)
 	if receipts != nil {
 		return receipts, nil
 	}
@@ -20,9 +20,11 @@ import (
 	"math/big"
 
 	"github.com/daxxcoin/daxxcore/common"
+	"github.com/daxxcoin/daxxcore/core/types"
 	"github.com/daxxcoin/daxxcore/crypto"
 	"github.com/daxxcoin/daxxcore/logger"
 	"github.com/daxxcoin/daxxcore/logger/glog"
+	"github.com/daxxcoin/daxxcore/rlp"
 	"golang.org/x/net/context"
 )
 
@@ -118,6 +120,42 @@ func (self *LightState) GetState(ctx context.Context, a common.Address, b common
 	return common.Hash{}, err
 }
 
+// GetCodeHash returns the code hash of the account at the given address or the
+// zero hash if the account does not exist
+func (self *LightState) GetCodeHash(ctx context.Context, addr common.Address) (common.Hash, error) {
+	stateObject, err := self.GetStateObject(ctx, addr)
+	if err == nil && stateObject != nil {
+		return common.BytesToHash(stateObject.codeHash), nil
+	}
+	return common.Hash{}, err
+}
+
+// GetStorageRoot returns the root hash of the storage trie of the account at the
+// given address or the empty root if the account does not exist
+func (self *LightState) GetStorageRoot(ctx context.Context, addr common.Address) (common.Hash, error) {
+	stateObject, err := self.GetStateObject(ctx, addr)
+	if err == nil && stateObject != nil {
+		return stateObject.trie.id.Root, nil
+	}
+	return types.EmptyRootHash, err
+}
+
+// GetProof returns the Merkle proof of the given account in the state trie,
+// retrieving the trie nodes on the path from the ODR backend
+func (self *LightState) GetProof(ctx context.Context, addr common.Address) ([]rlp.RawValue, error) {
+	return self.trie.Prove(ctx, addr[:])
+}
+
+// GetStorageProof returns the Merkle proof of storage slot b in the storage trie
+// of contract address a or nil if the account does not exist
+func (self *LightState) GetStorageProof(ctx context.Context, a common.Address, b common.Hash) ([]rlp.RawValue, error) {
+	stateObject, err := self.GetStateObject(ctx, a)
+	if err == nil && stateObject != nil {
+		return stateObject.trie.Prove(ctx, b[:])
+	}
+	return nil, err
+}
+
 // HasSuicided returns true if the given account has been marked for deletion
 // or false if the account does not exist
 func (self *LightState) HasSuicided(ctx context.Context, addr common.Address) (bool, error) {
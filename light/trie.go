@@ -18,6 +18,7 @@ package light
 
 import (
 	"github.com/daxxcoin/daxxcore/daxxdb"
+	"github.com/daxxcoin/daxxcore/rlp"
 	"github.com/daxxcoin/daxxcore/trie"
 	"golang.org/x/net/context"
 )
@@ -120,3 +121,21 @@ func (t *LightTrie) Delete(ctx context.Context, key []byte) (err error) {
 	})
 	return
 }
+
+// Prove constructs a merkle proof for key, retrieving all the trie nodes on the
+// path to it from the ODR backend first.
+func (t *LightTrie) Prove(ctx context.Context, key []byte) (proof []rlp.RawValue, err error) {
+	err = t.do(ctx, key, func() (err error) {
+		if t.trie == nil {
+			t.trie, err = trie.NewSecure(t.id.Root, t.db, 0)
+		}
+		if err == nil {
+			_, err = t.trie.TryGet(key)
+		}
+		return
+	})
+	if err != nil {
+		return nil, err
+	}
+	return t.trie.Prove(key), nil
+}
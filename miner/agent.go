@@ -21,11 +21,9 @@ import (
 
 	"sync/atomic"
 
-	"github.com/daxxcoin/daxxcore/common"
-	"github.com/daxxcoin/daxxcore/core/types"
+	"github.com/daxxcoin/daxxcore/consensus"
 	"github.com/daxxcoin/daxxcore/logger"
 	"github.com/daxxcoin/daxxcore/logger/glog"
-	"github.com/daxxcoin/daxxcore/pow"
 )
 
 type CpuAgent struct {
@@ -36,16 +34,16 @@ type CpuAgent struct {
 	quitCurrentOp chan struct{}
 	returnCh      chan<- *Result
 
-	index int
-	pow   pow.PoW
+	chain  consensus.ChainReader
+	engine consensus.Engine
 
 	isMining int32 // isMining indicates whether the agent is currently mining
 }
 
-func NewCpuAgent(index int, pow pow.PoW) *CpuAgent {
+func NewCpuAgent(chain consensus.ChainReader, engine consensus.Engine) *CpuAgent {
 	miner := &CpuAgent{
-		pow:    pow,
-		index:  index,
+		chain:  chain,
+		engine: engine,
 		quit:   make(chan struct{}),
 		workCh: make(chan *Work, 1),
 	}
@@ -54,7 +52,6 @@ func NewCpuAgent(index int, pow pow.PoW) *CpuAgent {
 }
 
 func (self *CpuAgent) Work() chan<- *Work            { return self.workCh }
-func (self *CpuAgent) Pow() pow.PoW                  { return self.pow }
 func (self *CpuAgent) SetReturnCh(ch chan<- *Result) { self.returnCh = ch }
 
 func (self *CpuAgent) Stop() {
@@ -108,18 +105,20 @@ done:
 }
 
 func (self *CpuAgent) mine(work *Work, stop <-chan struct{}) {
-	glog.V(logger.Debug).Infof("(re)started agent[%d]. mining...\n", self.index)
-
-	// Mine
-	nonce, mixDigest := self.pow.Search(work.Block, stop, self.index)
-	if nonce != 0 {
-		block := work.Block.WithMiningResult(types.EncodeNonce(nonce), common.BytesToHash(mixDigest))
-		self.returnCh <- &Result{work, block}
+	if result, err := self.engine.Seal(self.chain, work.Block, stop); result != nil {
+		glog.V(logger.Info).Infof("Successfully sealed new block #%d [%x…]", result.Number(), result.Hash().Bytes()[:4])
+		self.returnCh <- &Result{work, result}
 	} else {
+		if err != nil {
+			glog.V(logger.Warn).Infof("Block sealing failed: %v", err)
+		}
 		self.returnCh <- nil
 	}
 }
 
 func (self *CpuAgent) GetHashRate() int64 {
-	return self.pow.GetHashrate()
+	if pow, ok := self.engine.(consensus.PoW); ok {
+		return int64(pow.Hashrate())
+	}
+	return 0
 }
@@ -25,10 +25,11 @@ import (
 
 	"github.com/daxxnucleus/daxxhash"
 	"github.com/daxxcoin/daxxcore/common"
+	"github.com/daxxcoin/daxxcore/consensus"
 	"github.com/daxxcoin/daxxcore/core/types"
+	"github.com/daxxcoin/daxxcore/event"
 	"github.com/daxxcoin/daxxcore/logger"
 	"github.com/daxxcoin/daxxcore/logger/glog"
-	"github.com/daxxcoin/daxxcore/pow"
 )
 
 type hashrate struct {
@@ -43,9 +44,12 @@ type RemoteAgent struct {
 	workCh   chan *Work
 	returnCh chan<- *Result
 
-	pow         pow.PoW
+	chain       consensus.ChainReader
+	engine      consensus.Engine
 	currentWork *Work
 	work        map[common.Hash]*Work
+	workFeed    event.Feed // Feed of new work packages for push based miners
+	workSubs    int32      // Number of work feed subscriptions. Call atomically
 
 	hashrateMu sync.RWMutex
 	hashrate   map[common.Hash]hashrate
@@ -53,9 +57,10 @@ type RemoteAgent struct {
 	running int32 // running indicates whether the agent is active. Call atomically
 }
 
-func NewRemoteAgent(pow pow.PoW) *RemoteAgent {
+func NewRemoteAgent(chain consensus.ChainReader, engine consensus.Engine) *RemoteAgent {
 	return &RemoteAgent{
-		pow:      pow,
+		chain:    chain,
+		engine:   engine,
 		work:     make(map[common.Hash]*Work),
 		hashrate: make(map[common.Hash]hashrate),
 	}
@@ -109,25 +114,112 @@ func (a *RemoteAgent) GetWork() ([3]string, error) {
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
-	var res [3]string
-
 	if a.currentWork != nil {
-		block := a.currentWork.Block
-
-		res[0] = block.HashNoNonce().Hex()
-		seedHash, _ := ethash.GetSeedHash(block.NumberU64())
-		res[1] = common.BytesToHash(seedHash).Hex()
-		// Calculate the "target" to be returned to the external miner
-		n := big.NewInt(1)
-		n.Lsh(n, 255)
-		n.Div(n, block.Difficulty())
-		n.Lsh(n, 1)
-		res[2] = common.BytesToHash(n.Bytes()).Hex()
-
-		a.work[block.HashNoNonce()] = a.currentWork
-		return res, nil
+		a.work[a.currentWork.Block.HashNoNonce()] = a.currentWork
+		return workPackage(a.currentWork.Block), nil
+	}
+	return [3]string{}, errors.New("No work available yet, don't panic.")
+}
+
+// SubscribeWork registers a subscription for the work packages (in the same
+// format as returned by GetWork) of all new work arriving into the agent. The
+// packages are registered for submission without a GetWork call.
+func (a *RemoteAgent) SubscribeWork(ch chan<- [3]string) event.Subscription {
+	atomic.AddInt32(&a.workSubs, 1)
+	sub := a.workFeed.Subscribe(ch)
+
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		<-quit
+		sub.Unsubscribe()
+		atomic.AddInt32(&a.workSubs, -1)
+		return nil
+	})
+}
+
+// shareVerifier is implemented by consensus engines able to check seals against
+// a lower difficulty than the block's own one.
+type shareVerifier interface {
+	VerifyShare(header *types.Header, difficulty *big.Int) error
+}
+
+// mixDigester is implemented by consensus engines able to recompute the mix
+// digest of a seal from its nonce.
+type mixDigester interface {
+	MixDigest(header *types.Header) (common.Hash, error)
+}
+
+// MixDigest recomputes the mix digest of a nonce found for a pending work package,
+// for miners submitting their solutions without it.
+func (a *RemoteAgent) MixDigest(nonce types.BlockNonce, hash common.Hash) (common.Hash, error) {
+	digester, ok := a.engine.(mixDigester)
+	if !ok {
+		return common.Hash{}, errors.New("mix digest computation not supported")
+	}
+	a.mu.Lock()
+	work := a.work[hash]
+	a.mu.Unlock()
+
+	if work == nil {
+		return common.Hash{}, errors.New("no pending work found")
+	}
+	header := work.Block.Header()
+	header.Nonce = nonce
+
+	return digester.MixDigest(header)
+}
+
+// SubmitShare checks a PoW solution of a pending work package against the given
+// share difficulty, returning whether it's a valid share. Shares satisfying the
+// block difficulty too are submitted as a solution, as with SubmitWork.
+func (a *RemoteAgent) SubmitShare(nonce types.BlockNonce, mixDigest, hash common.Hash, difficulty *big.Int) (share bool, sealed bool) {
+	verifier, ok := a.engine.(shareVerifier)
+	if !ok {
+		sealed := a.SubmitWork(nonce, mixDigest, hash)
+		return sealed, sealed
+	}
+	a.mu.Lock()
+	work := a.work[hash]
+	a.mu.Unlock()
+
+	if work == nil {
+		glog.V(logger.Debug).Infof("Share was submitted for %x but no pending work found", hash)
+		return false, false
+	}
+	result := work.Block.Header()
+	result.Nonce = nonce
+	result.MixDigest = mixDigest
+
+	// Submit the share if it seals the block, otherwise check the share difficulty
+	if verifier.VerifyShare(result, result.Difficulty) == nil {
+		sealed := a.SubmitWork(nonce, mixDigest, hash)
+		return sealed, sealed
+	}
+	if difficulty.Cmp(result.Difficulty) >= 0 {
+		return false, false
+	}
+	if err := verifier.VerifyShare(result, difficulty); err != nil {
+		glog.V(logger.Debug).Infof("Invalid share submitted for %x: %v", hash, err)
+		return false, false
 	}
-	return res, errors.New("No work available yet, don't panic.")
+	return true, false
+}
+
+// workPackage assembles the work package of a block for external miners:
+// the header pow-hash, the seed hash of the DAG and the boundary condition.
+func workPackage(block *types.Block) [3]string {
+	var res [3]string
+
+	res[0] = block.HashNoNonce().Hex()
+	seedHash, _ := ethash.GetSeedHash(block.NumberU64())
+	res[1] = common.BytesToHash(seedHash).Hex()
+	// Calculate the "target" to be returned to the external miner
+	n := big.NewInt(1)
+	n.Lsh(n, 255)
+	n.Div(n, block.Difficulty())
+	n.Lsh(n, 1)
+	res[2] = common.BytesToHash(n.Bytes()).Hex()
+
+	return res
 }
 
 // SubmitWork tries to inject a PoW solution tinto the remote agent, returning
@@ -144,11 +236,16 @@ func (a *RemoteAgent) SubmitWork(nonce types.BlockNonce, mixDigest, hash common.
 		return false
 	}
 	// Make sure the PoW solutions is indeed valid
-	block := work.Block.WithMiningResult(nonce, mixDigest)
-	if !a.pow.Verify(block) {
-		glog.V(logger.Warn).Infof("Invalid PoW submitted for %x", hash)
+	result := work.Block.Header()
+	result.Nonce = nonce
+	result.MixDigest = mixDigest
+
+	if err := a.engine.VerifySeal(a.chain, result); err != nil {
+		glog.V(logger.Warn).Infof("Invalid PoW submitted for %x: %v", hash, err)
 		return false
 	}
+	block := work.Block.WithSeal(result)
+
 	// Solutions seems to be valid, return to the miner and notify acceptance
 	a.returnCh <- &Result{work, block}
 	delete(a.work, hash)
@@ -169,10 +266,20 @@ func (a *RemoteAgent) loop(workCh chan *Work, quitCh chan struct{}) {
 		select {
 		case <-quitCh:
 			return
-		case work := <-workCh:
+		case work, ok := <-workCh:
+			if !ok {
+				return // Agent stopped, work channel closed
+			}
+			// Register the work for any subscribed miners before pushing it so
+			// early submissions find it, but don't hold the lock while sending
 			a.mu.Lock()
 			a.currentWork = work
+			if atomic.LoadInt32(&a.workSubs) > 0 {
+				a.work[work.Block.HashNoNonce()] = work
+			}
 			a.mu.Unlock()
+
+			a.workFeed.Send(workPackage(work.Block))
 		case <-ticker:
 			// cleanup
 			a.mu.Lock()
@@ -0,0 +1,916 @@
+// Copyright 2017 The daxxcoreAuthors
+// This file is part of the daxxcore library.
+//
+// The daxxcore library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The daxxcore library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the daxxcore library. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/daxxcoin/daxxcore/common"
+	"github.com/daxxcoin/daxxcore/common/hexutil"
+	"github.com/daxxcoin/daxxcore/core/types"
+	"github.com/daxxcoin/daxxcore/crypto"
+	"github.com/daxxcoin/daxxcore/logger"
+	"github.com/daxxcoin/daxxcore/logger/glog"
+)
+
+const (
+	ethereumStratumVersion = "EthereumStratum/1.0.0" // Protocol version of the EthereumStratum dialect
+
+	stratumMaxLineSize   = 4096             // Maximum size of a single stratum request
+	stratumMaxWorkerName = 64               // Maximum length of a worker login name
+	stratumMaxWorkers    = 1024             // Maximum number of workers to keep statistics of
+	stratumMaxJobs       = 8                // Number of recent jobs to accept shares for
+	stratumQueueSize     = 64               // Maximum number of messages queued up for a miner
+	stratumIdleTimeout   = 10 * time.Minute // Time allowed for a miner to stay silent
+	stratumWriteTimeout  = 10 * time.Second // Time allowed to push a message to a miner
+)
+
+var (
+	errStratumUnauthorized   = errors.New("unauthorized worker")
+	errStratumInvalidShare   = errors.New("invalid share")
+	errStratumStaleShare     = errors.New("stale share")
+	errStratumDuplicate      = errors.New("duplicate share")
+	errStratumOverflow       = errors.New("message queue overflow")
+	errStratumClosed         = errors.New("session closed")
+	errStratumTooManyWorkers = errors.New("too many workers")
+)
+
+// maxUint256 is the largest share target, used to convert difficulties to targets.
+var maxUint256 = new(big.Int).Sub(new(big.Int).Lsh(common.Big1, 256), common.Big1)
+
+// StratumConfig are the configuration parameters of the stratum server.
+type StratumConfig struct {
+	Addr        string   // Listening address of the server (empty = disabled)
+	Password    string   // Password required from the miners to log in (empty = unauthenticated)
+	Difficulty  *big.Int // Difficulty of the shares accepted from the miners
+	MaxSessions int      // Maximum number of concurrent miner connections
+}
+
+// DefaultStratumConfig contains the default configurations for the stratum
+// server.
+var DefaultStratumConfig = StratumConfig{
+	Difficulty:  big.NewInt(4000000000),
+	MaxSessions: 256,
+}
+
+// sanitize checks the provided user configurations and changes anything that's
+// unreasonable or unworkable.
+func (config *StratumConfig) sanitize() StratumConfig {
+	conf := *config
+	if conf.Difficulty == nil || conf.Difficulty.Sign() <= 0 {
+		glog.V(logger.Warn).Infof("Sanitizing invalid stratum share difficulty: provided %v, updated %v", conf.Difficulty, DefaultStratumConfig.Difficulty)
+		conf.Difficulty = DefaultStratumConfig.Difficulty
+	}
+	if conf.MaxSessions < 1 {
+		glog.V(logger.Warn).Infof("Sanitizing invalid stratum session limit: provided %d, updated %d", conf.MaxSessions, DefaultStratumConfig.MaxSessions)
+		conf.MaxSessions = DefaultStratumConfig.MaxSessions
+	}
+	return conf
+}
+
+// stratumRequest is a single request received from a stratum miner.
+type stratumRequest struct {
+	Id     *json.RawMessage  `json:"id"`
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+}
+
+// stratumResponse is the reply to a stratum request.
+type stratumResponse struct {
+	Id      *json.RawMessage `json:"id"`
+	Version string           `json:"jsonrpc"`
+	Result  interface{}      `json:"result"`
+	Error   *stratumError    `json:"error"`
+}
+
+// stratumNotification is a message pushed to a stratum miner unrequested.
+type stratumNotification struct {
+	Id      *json.RawMessage `json:"id"`
+	Version string           `json:"jsonrpc"`
+	Method  string           `json:"method"`
+	Params  []interface{}    `json:"params"`
+}
+
+// stratumError is the error reported to a stratum miner for a failed request.
+type stratumError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// StratumWorkerStats contains the mining statistics of a single stratum worker.
+type StratumWorkerStats struct {
+	Sessions       int            `json:"sessions"`       // Number of currently open connections
+	AcceptedShares uint64         `json:"acceptedShares"` // Number of valid shares submitted
+	RejectedShares uint64         `json:"rejectedShares"` // Number of invalid, stale or duplicate shares submitted
+	Blocks         uint64         `json:"blocks"`         // Number of shares which sealed a block
+	Hashrate       hexutil.Uint64 `json:"hashrate"`       // Last hashrate reported by the worker
+	LastShare      time.Time      `json:"lastShare"`      // Time of the last submitted share
+	LastSeen       time.Time      `json:"lastSeen"`       // Time of the last request from the worker
+}
+
+// StratumStats contains the statistics of the stratum server.
+type StratumStats struct {
+	Address    string                         `json:"address"`
+	Job        string                         `json:"job"`
+	Difficulty *hexutil.Big                   `json:"difficulty"`
+	Workers    map[string]*StratumWorkerStats `json:"workers"`
+}
+
+// StratumServer is a TCP server speaking the stratum protocol to external
+// miners, pushing them the work packages of a RemoteAgent and submitting their
+// solutions back to it.
+//
+// Two dialects of the protocol are spoken. By default it's the stratum dialect
+// supported by Ethash miners as "stratum+tcp" (also known as the stratum proxy
+// protocol), where submitted shares carry the header and mix digest too:
+//
+//	mining.subscribe     [agent]                            -> true
+//	mining.authorize     [worker, password]                 -> true
+//	mining.submit        [worker, job, nonce, header, mix]  -> accepted
+//	eth_submitHashrate   [rate, id]                         -> true
+//	mining.notify        [job, header, seed, target, clean] <- pushed on new work
+//
+// The eth-proxy flavour (eth_submitLogin, eth_getWork and eth_submitWork) of the
+// same requests is accepted as well.
+//
+// Miners subscribing with EthereumStratum/1.0.0 are served that dialect instead.
+// Each session is handed a unique extranonce the nonces of the miner have to
+// start with, and shares carry only the rest of the nonce, the mix digest being
+// recomputed by the node:
+//
+//	mining.subscribe        [agent, "EthereumStratum/1.0.0"] -> [["mining.notify", id, "EthereumStratum/1.0.0"], extranonce]
+//	mining.authorize        [worker, password]               -> true
+//	mining.submit           [worker, job, nonce]             -> accepted
+//	mining.set_difficulty   [difficulty]                     <- pushed on share difficulty changes
+//	mining.notify           [job, seed, header, clean]       <- pushed on new work
+//
+// Miners are given a share target derived from the configured share difficulty,
+// capped at the block target. Shares are counted per worker, and the ones which
+// satisfy the block difficulty too are submitted as the seal of the block.
+//
+// Unless a password is configured, any miner may log in as any worker, so the
+// server should only be reachable from trusted networks.
+type StratumServer struct {
+	agent  *RemoteAgent
+	config StratumConfig
+	target *big.Int // Share target derived from the share difficulty
+
+	listener net.Listener
+	sessions map[*stratumSession]struct{}
+	workers  map[string]*StratumWorkerStats
+	work     [3]string                                     // Work package currently pushed to the miners
+	jobs     []common.Hash                                 // Header hashes of the recent jobs, oldest first
+	shares   map[common.Hash]map[types.BlockNonce]struct{} // Nonces submitted for the recent jobs
+	nonces   uint16                                        // Last extranonce handed out to an EthereumStratum session
+	mu       sync.RWMutex
+
+	wg   sync.WaitGroup
+	quit chan struct{}
+}
+
+// NewStratumServer creates a stratum server listening on the configured address
+// once started, distributing the work of the given remote agent.
+func NewStratumServer(agent *RemoteAgent, config StratumConfig) *StratumServer {
+	// Sanitize the input to ensure no vulnerable share difficulty is set
+	config = (&config).sanitize()
+
+	return &StratumServer{
+		agent:    agent,
+		config:   config,
+		target:   new(big.Int).Div(maxUint256, config.Difficulty),
+		sessions: make(map[*stratumSession]struct{}),
+		workers:  make(map[string]*StratumWorkerStats),
+		shares:   make(map[common.Hash]map[types.BlockNonce]struct{}),
+	}
+}
+
+// Start opens the listener of the stratum server and starts accepting miners.
+func (s *StratumServer) Start() error {
+	listener, err := net.Listen("tcp", s.config.Addr)
+	if err != nil {
+		return err
+	}
+	s.listener = listener
+	s.quit = make(chan struct{})
+
+	s.wg.Add(2)
+	go s.acceptLoop()
+	go s.workLoop()
+
+	glog.V(logger.Info).Infof("Stratum server started on %s", listener.Addr())
+	if addr, ok := listener.Addr().(*net.TCPAddr); ok && !addr.IP.IsLoopback() && s.config.Password == "" {
+		glog.V(logger.Warn).Infof("Stratum server on %s accepts any miner, consider setting a password", listener.Addr())
+	}
+	return nil
+}
+
+// Stop closes the listener and all miner connections of the stratum server.
+func (s *StratumServer) Stop() {
+	if s.listener == nil {
+		return
+	}
+	close(s.quit)
+	s.listener.Close()
+
+	s.mu.Lock()
+	for session := range s.sessions {
+		session.conn.Close()
+	}
+	s.mu.Unlock()
+
+	s.wg.Wait()
+	glog.V(logger.Info).Infoln("Stratum server stopped")
+}
+
+// Addr returns the network address the stratum server is listening on.
+func (s *StratumServer) Addr() net.Addr {
+	return s.listener.Addr()
+}
+
+// Stats retrieves a snapshot of the statistics of the stratum server.
+func (s *StratumServer) Stats() *StratumStats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stats := &StratumStats{
+		Address:    s.config.Addr,
+		Job:        s.work[0],
+		Difficulty: (*hexutil.Big)(s.config.Difficulty),
+		Workers:    make(map[string]*StratumWorkerStats, len(s.workers)),
+	}
+	if s.listener != nil {
+		stats.Address = s.listener.Addr().String()
+	}
+	for name, worker := range s.workers {
+		copy := *worker
+		stats.Workers[name] = &copy
+	}
+	return stats
+}
+
+// acceptLoop accepts new miner connections until the server is stopped.
+func (s *StratumServer) acceptLoop() {
+	defer s.wg.Done()
+
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			select {
+			case <-s.quit:
+				return
+			default:
+			}
+			glog.V(logger.Debug).Infof("Stratum accept failed: %v", err)
+			if ne, ok := err.(net.Error); ok && ne.Temporary() {
+				time.Sleep(time.Second)
+				continue
+			}
+			return
+		}
+		// Register the session unless the server is stopping or full
+		s.mu.Lock()
+		select {
+		case <-s.quit:
+			s.mu.Unlock()
+			conn.Close()
+			return
+		default:
+		}
+		if len(s.sessions) >= s.config.MaxSessions {
+			s.mu.Unlock()
+			glog.V(logger.Debug).Infof("Stratum rejected %v: too many sessions", conn.RemoteAddr())
+			conn.Close()
+			continue
+		}
+		session := &stratumSession{
+			server: s,
+			conn:   conn,
+			queue:  make(chan []byte, stratumQueueSize),
+			done:   make(chan struct{}),
+		}
+		s.sessions[session] = struct{}{}
+		s.wg.Add(2)
+		s.mu.Unlock()
+
+		go session.handle()
+		go session.writeLoop()
+	}
+}
+
+// workLoop waits for new work packages from the remote agent and pushes them to
+// all the authorized miners.
+func (s *StratumServer) workLoop() {
+	defer s.wg.Done()
+
+	workCh := make(chan [3]string)
+	sub := s.agent.SubscribeWork(workCh)
+	defer sub.Unsubscribe()
+
+	for {
+		select {
+		case work := <-workCh:
+			work = s.shareWork(work)
+
+			s.mu.Lock()
+			s.work = work
+			s.addJob(common.HexToHash(work[0]))
+			sessions := make([]*stratumSession, 0, len(s.sessions))
+			for session := range s.sessions {
+				sessions = append(sessions, session)
+			}
+			s.mu.Unlock()
+
+			// Queue up the work for the miners, never waiting for any of them
+			for _, session := range sessions {
+				if session.authorized() {
+					session.pushWork(work)
+				}
+			}
+		case <-s.quit:
+			return
+		}
+	}
+}
+
+// shareWork replaces the block target of a work package with the share target,
+// unless the block target is the easier one.
+func (s *StratumServer) shareWork(work [3]string) [3]string {
+	if common.HexToHash(work[2]).Big().Cmp(s.target) < 0 {
+		work[2] = common.BigToHash(s.target).Hex()
+	}
+	return work
+}
+
+// jobHeader returns the header hash of a recent job, identified by its job id.
+//
+// Note, this method assumes the server lock is held!
+func (s *StratumServer) jobHeader(job string) (common.Hash, bool) {
+	for _, hash := range s.jobs {
+		if jobID(hash.Hex()) == job {
+			return hash, true
+		}
+	}
+	return common.Hash{}, false
+}
+
+// addJob starts accepting the shares of a new job, forgetting the oldest one if
+// too many are tracked.
+//
+// Note, this method assumes the server lock is held!
+func (s *StratumServer) addJob(hash common.Hash) {
+	if _, ok := s.shares[hash]; ok {
+		return
+	}
+	s.shares[hash] = make(map[types.BlockNonce]struct{})
+	s.jobs = append(s.jobs, hash)
+
+	if len(s.jobs) > stratumMaxJobs {
+		delete(s.shares, s.jobs[0])
+		s.jobs = s.jobs[1:]
+	}
+}
+
+// checkPassword returns whether the password supplied by a miner is accepted.
+func (s *StratumServer) checkPassword(password string) bool {
+	if s.config.Password == "" {
+		return true
+	}
+	return subtle.ConstantTimeCompare([]byte(password), []byte(s.config.Password)) == 1
+}
+
+// worker retrieves the statistics of a worker, creating it if needed.
+//
+// Note, this method assumes the server lock is held!
+func (s *StratumServer) worker(name string) *StratumWorkerStats {
+	worker, ok := s.workers[name]
+	if !ok {
+		worker = new(StratumWorkerStats)
+		s.workers[name] = worker
+	}
+	return worker
+}
+
+// evictWorker drops the statistics of the least recently seen worker without any
+// open sessions, returning whether there was such a worker.
+//
+// Note, this method assumes the server lock is held!
+func (s *StratumServer) evictWorker() bool {
+	var (
+		oldest string
+		seen   time.Time
+		found  bool
+	)
+	for name, worker := range s.workers {
+		if worker.Sessions == 0 && (!found || worker.LastSeen.Before(seen)) {
+			oldest, seen, found = name, worker.LastSeen, true
+		}
+	}
+	if found {
+		delete(s.workers, oldest)
+	}
+	return found
+}
+
+// stratumSession is a single connection of a stratum miner.
+type stratumSession struct {
+	server *StratumServer
+	conn   net.Conn
+	queue  chan []byte   // Messages waiting to be written to the miner
+	done   chan struct{} // Closed when the session is torn down
+
+	name            string     // Worker name the session was authorized with
+	ethereumStratum bool       // Whether the miner speaks EthereumStratum/1.0
+	extranonce      string     // Nonce prefix assigned to an EthereumStratum miner
+	target          string     // Share target last announced to an EthereumStratum miner
+	mu              sync.Mutex // Protects the fields above
+}
+
+// handle reads and serves the requests of a stratum miner until the connection
+// is closed or the miner stays silent for too long.
+func (sn *stratumSession) handle() {
+	defer sn.server.wg.Done()
+	defer sn.close()
+
+	scanner := bufio.NewScanner(sn.conn)
+	scanner.Buffer(make([]byte, stratumMaxLineSize), stratumMaxLineSize)
+
+	for {
+		sn.conn.SetReadDeadline(time.Now().Add(stratumIdleTimeout))
+		if !scanner.Scan() {
+			if err := scanner.Err(); err != nil {
+				glog.V(logger.Debug).Infof("Stratum read from %v failed: %v", sn.conn.RemoteAddr(), err)
+			}
+			return
+		}
+		var req stratumRequest
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			glog.V(logger.Debug).Infof("Stratum malformed request from %v: %v", sn.conn.RemoteAddr(), err)
+			return
+		}
+		result, err := sn.serve(&req)
+
+		res := &stratumResponse{Id: req.Id, Version: "2.0", Result: result}
+		if err != nil {
+			res.Error = &stratumError{Code: -1, Message: err.Error()}
+		}
+		if err := sn.send(res); err != nil {
+			return
+		}
+		// Push the current work to freshly authorized miners
+		if err == nil && (req.Method == "mining.authorize" || req.Method == "eth_submitLogin") {
+			sn.server.mu.RLock()
+			work := sn.server.work
+			sn.server.mu.RUnlock()
+
+			if work[0] != "" {
+				if err := sn.pushWork(work); err != nil {
+					return
+				}
+			}
+		}
+	}
+}
+
+// serve executes a single stratum request.
+func (sn *stratumSession) serve(req *stratumRequest) (interface{}, error) {
+	switch req.Method {
+	case "mining.subscribe":
+		var protocol string
+		if len(req.Params) > 1 {
+			json.Unmarshal(req.Params[1], &protocol)
+		}
+		if !strings.HasPrefix(protocol, "EthereumStratum/") {
+			return true, nil
+		}
+		if protocol != ethereumStratumVersion {
+			return nil, fmt.Errorf("protocol %s not supported", protocol)
+		}
+		return sn.subscribe(), nil
+
+	case "mining.extranonce.subscribe":
+		// The extranonce of a session never changes, nothing to subscribe to
+		return true, nil
+
+	case "mining.authorize", "eth_submitLogin":
+		var name, password string
+		if len(req.Params) < 1 || json.Unmarshal(req.Params[0], &name) != nil {
+			return false, errors.New("missing worker name")
+		}
+		if name = strings.TrimSpace(name); name == "" || len(name) > stratumMaxWorkerName {
+			return false, fmt.Errorf("invalid worker name")
+		}
+		if len(req.Params) > 1 {
+			json.Unmarshal(req.Params[1], &password)
+		}
+		if !sn.server.checkPassword(password) {
+			glog.V(logger.Debug).Infof("Stratum worker %s failed to log in from %v", name, sn.conn.RemoteAddr())
+			return false, errStratumUnauthorized
+		}
+		if err := sn.authorize(name); err != nil {
+			return false, err
+		}
+		return true, nil
+
+	case "eth_getWork":
+		if sn.worker() == "" {
+			return nil, errStratumUnauthorized
+		}
+		sn.server.mu.RLock()
+		work := sn.server.work
+		sn.server.mu.RUnlock()
+
+		if work[0] == "" {
+			return nil, errors.New("no work available yet")
+		}
+		return work, nil
+
+	case "mining.submit", "eth_submitWork":
+		name := sn.worker()
+		if name == "" {
+			return false, errStratumUnauthorized
+		}
+		var params []string
+		for _, param := range req.Params {
+			var str string
+			if err := json.Unmarshal(param, &str); err != nil {
+				return false, err
+			}
+			params = append(params, str)
+		}
+		var (
+			nonce  types.BlockNonce
+			header common.Hash
+			mix    *common.Hash
+			err    error
+		)
+		switch {
+		case req.Method == "mining.submit" && sn.speaksEthereumStratum():
+			// EthereumStratum sends the job and the nonce without the extranonce
+			if len(params) < 3 {
+				return false, errors.New("expected worker, job and nonce")
+			}
+			nonce, header, err = sn.decodeJobNonce(params[1], params[2])
+
+		default:
+			// Stratum prefixes the share with the worker and job, eth-proxy doesn't
+			if req.Method == "mining.submit" {
+				if len(params) < 2 {
+					return false, errors.New("missing worker and job")
+				}
+				params = params[2:]
+			}
+			if len(params) < 3 {
+				return false, errors.New("expected nonce, header and mix digest")
+			}
+			mix = new(common.Hash)
+			nonce, header, *mix, err = decodeShare(params[0], params[1], params[2])
+		}
+		// Malformed shares are refused, stale ones are accounted for as rejected
+		var accepted, sealed bool
+		if err == nil {
+			accepted, sealed, err = sn.submit(nonce, header, mix)
+		} else if err != errStratumStaleShare {
+			return false, err
+		}
+
+		sn.server.mu.Lock()
+		worker := sn.server.worker(name)
+		if accepted {
+			worker.AcceptedShares++
+		} else {
+			worker.RejectedShares++
+		}
+		if sealed {
+			worker.Blocks++
+		}
+		worker.LastShare = time.Now()
+		sn.server.mu.Unlock()
+
+		return accepted, err
+
+	case "eth_submitHashrate":
+		name := sn.worker()
+		if name == "" {
+			return false, errStratumUnauthorized
+		}
+		// Miners zero pad the hashrate to 32 bytes, so strict decoding can't be used
+		var str string
+		if len(req.Params) < 1 || json.Unmarshal(req.Params[0], &str) != nil {
+			return false, errors.New("missing hashrate")
+		}
+		rate, ok := new(big.Int).SetString(strings.TrimPrefix(str, "0x"), 16)
+		if !ok || rate.Sign() < 0 || rate.BitLen() > 64 {
+			return false, fmt.Errorf("invalid hashrate: %s", str)
+		}
+		// Use the miner supplied identifier, or derive one from the worker name
+		id := crypto.Keccak256Hash([]byte(name))
+		if len(req.Params) > 1 {
+			if err := json.Unmarshal(req.Params[1], &id); err != nil {
+				return false, err
+			}
+		}
+		sn.server.agent.SubmitHashrate(id, rate.Uint64())
+
+		sn.server.mu.Lock()
+		sn.server.worker(name).Hashrate = hexutil.Uint64(rate.Uint64())
+		sn.server.mu.Unlock()
+
+		return true, nil
+
+	default:
+		return nil, fmt.Errorf("method %s not supported", req.Method)
+	}
+}
+
+// authorize marks the session as belonging to the given worker. It fails if the
+// statistics of too many workers with open sessions are tracked already.
+func (sn *stratumSession) authorize(name string) error {
+	sn.server.mu.Lock()
+	defer sn.server.mu.Unlock()
+
+	if _, ok := sn.server.workers[name]; !ok && len(sn.server.workers) >= stratumMaxWorkers && !sn.server.evictWorker() {
+		glog.V(logger.Debug).Infof("Stratum worker %s refused from %v: too many workers", name, sn.conn.RemoteAddr())
+		return errStratumTooManyWorkers
+	}
+	sn.mu.Lock()
+	prev := sn.name
+	sn.name = name
+	sn.mu.Unlock()
+
+	if prev != "" {
+		sn.server.worker(prev).Sessions--
+	}
+	worker := sn.server.worker(name)
+	worker.Sessions++
+	worker.LastSeen = time.Now()
+
+	glog.V(logger.Debug).Infof("Stratum worker %s logged in from %v", name, sn.conn.RemoteAddr())
+	return nil
+}
+
+// subscribe switches the session to EthereumStratum/1.0, assigning it an extranonce.
+func (sn *stratumSession) subscribe() interface{} {
+	sn.server.mu.Lock()
+	sn.server.nonces++
+	extranonce := fmt.Sprintf("%04x", sn.server.nonces)
+	sn.server.mu.Unlock()
+
+	sn.mu.Lock()
+	sn.ethereumStratum = true
+	sn.extranonce = extranonce
+	sn.mu.Unlock()
+
+	id := make([]byte, 16)
+	rand.Read(id)
+
+	return []interface{}{[]interface{}{"mining.notify", hex.EncodeToString(id), ethereumStratumVersion}, extranonce}
+}
+
+// speaksEthereumStratum returns whether the miner subscribed with EthereumStratum/1.0.
+func (sn *stratumSession) speaksEthereumStratum() bool {
+	sn.mu.Lock()
+	defer sn.mu.Unlock()
+
+	return sn.ethereumStratum
+}
+
+// decodeJobNonce decodes an EthereumStratum share, prefixing the nonce with the
+// extranonce of the session and looking up the header of the job.
+func (sn *stratumSession) decodeJobNonce(job, nonceHex string) (types.BlockNonce, common.Hash, error) {
+	sn.mu.Lock()
+	extranonce := sn.extranonce
+	sn.mu.Unlock()
+
+	// Miners are supposed to omit the extranonce, but tolerate a full nonce with it
+	nonceHex = strings.TrimPrefix(nonceHex, "0x")
+	if len(nonceHex) != 2*len(types.BlockNonce{}) || !strings.HasPrefix(nonceHex, extranonce) {
+		nonceHex = extranonce + nonceHex
+	}
+	var nonce types.BlockNonce
+	if err := decodeStratumHex(nonceHex, nonce[:]); err != nil {
+		return nonce, common.Hash{}, fmt.Errorf("invalid nonce: %v", err)
+	}
+	sn.server.mu.RLock()
+	header, ok := sn.server.jobHeader(job)
+	sn.server.mu.RUnlock()
+
+	if !ok {
+		return nonce, common.Hash{}, errStratumStaleShare
+	}
+	return nonce, header, nil
+}
+
+// decodeShare decodes a stratum share carrying the header and mix digest.
+func decodeShare(nonceHex, headerHex, mixHex string) (nonce types.BlockNonce, header, mix common.Hash, err error) {
+	if err = decodeStratumHex(nonceHex, nonce[:]); err != nil {
+		return nonce, header, mix, fmt.Errorf("invalid nonce: %v", err)
+	}
+	if err = decodeStratumHex(headerHex, header[:]); err != nil {
+		return nonce, header, mix, fmt.Errorf("invalid header hash: %v", err)
+	}
+	if err = decodeStratumHex(mixHex, mix[:]); err != nil {
+		return nonce, header, mix, fmt.Errorf("invalid mix digest: %v", err)
+	}
+	return nonce, header, mix, nil
+}
+
+// submit checks that a share belongs to a recent job and wasn't submitted before,
+// and submits it to the remote agent. If no mix digest is given, it's recomputed
+// from the nonce. It returns whether the share was accepted and whether it sealed
+// a block.
+func (sn *stratumSession) submit(nonce types.BlockNonce, header common.Hash, mix *common.Hash) (bool, bool, error) {
+	// Record the share before verifying, rejecting stale and duplicate ones
+	sn.server.mu.Lock()
+	shares, ok := sn.server.shares[header]
+	if !ok {
+		sn.server.mu.Unlock()
+		return false, false, errStratumStaleShare
+	}
+	if _, dup := shares[nonce]; dup {
+		sn.server.mu.Unlock()
+		return false, false, errStratumDuplicate
+	}
+	shares[nonce] = struct{}{}
+	sn.server.mu.Unlock()
+
+	if mix == nil {
+		digest, err := sn.server.agent.MixDigest(nonce, header)
+		if err != nil {
+			return false, false, err
+		}
+		mix = &digest
+	}
+	share, sealed := sn.server.agent.SubmitShare(nonce, *mix, header, sn.server.config.Difficulty)
+	if !share {
+		return false, false, errStratumInvalidShare
+	}
+	return true, sealed, nil
+}
+
+// pushWork queues up a work package for the miner in its dialect, announcing the
+// share difficulty first to EthereumStratum miners if it changed.
+func (sn *stratumSession) pushWork(work [3]string) error {
+	sn.mu.Lock()
+	ethereumStratum := sn.ethereumStratum
+	retarget := ethereumStratum && sn.target != work[2]
+	if retarget {
+		sn.target = work[2]
+	}
+	sn.mu.Unlock()
+
+	if !ethereumStratum {
+		return sn.send(&stratumNotification{
+			Version: "2.0",
+			Method:  "mining.notify",
+			Params:  []interface{}{jobID(work[0]), work[0], work[1], work[2], true},
+		})
+	}
+	if retarget {
+		err := sn.send(&stratumNotification{
+			Version: "2.0",
+			Method:  "mining.set_difficulty",
+			Params:  []interface{}{ethereumStratumDifficulty(work[2])},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return sn.send(&stratumNotification{
+		Version: "2.0",
+		Method:  "mining.notify",
+		Params:  []interface{}{jobID(work[0]), work[1][2:], work[0][2:], true},
+	})
+}
+
+// jobID derives the identifier of a job from its header hash.
+func jobID(header string) string {
+	return header[2:18]
+}
+
+// ethereumStratumDifficulty converts a share target to the difficulty announced
+// to EthereumStratum miners, where difficulty 1 means 2^32 hashes per share.
+func ethereumStratumDifficulty(target string) float64 {
+	difficulty, _ := new(big.Float).SetInt(new(big.Int).Div(maxUint256, common.HexToHash(target).Big())).Float64()
+	return difficulty / (1 << 32)
+}
+
+// send queues up a single message for the miner without blocking. If the miner
+// doesn't keep up with its messages, the connection is dropped.
+func (sn *stratumSession) send(msg interface{}) error {
+	blob, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	select {
+	case sn.queue <- append(blob, '\n'):
+		return nil
+	case <-sn.done:
+		return errStratumClosed
+	default:
+		glog.V(logger.Debug).Infof("Stratum queue to %v overflowed, dropping", sn.conn.RemoteAddr())
+		sn.conn.Close()
+		return errStratumOverflow
+	}
+}
+
+// writeLoop writes the queued up messages to the miner until the session is
+// torn down.
+func (sn *stratumSession) writeLoop() {
+	defer sn.server.wg.Done()
+
+	for {
+		select {
+		case blob := <-sn.queue:
+			sn.conn.SetWriteDeadline(time.Now().Add(stratumWriteTimeout))
+			if _, err := sn.conn.Write(blob); err != nil {
+				glog.V(logger.Debug).Infof("Stratum write to %v failed: %v", sn.conn.RemoteAddr(), err)
+				sn.conn.Close()
+				return
+			}
+		case <-sn.done:
+			return
+		}
+	}
+}
+
+// worker returns the name the session was authorized with, updating the time
+// the worker was last seen.
+func (sn *stratumSession) worker() string {
+	sn.mu.Lock()
+	name := sn.name
+	sn.mu.Unlock()
+
+	if name != "" {
+		sn.server.mu.Lock()
+		sn.server.worker(name).LastSeen = time.Now()
+		sn.server.mu.Unlock()
+	}
+	return name
+}
+
+// authorized returns whether the session has logged in as a worker.
+func (sn *stratumSession) authorized() bool {
+	sn.mu.Lock()
+	defer sn.mu.Unlock()
+
+	return sn.name != ""
+}
+
+// close tears down the session and unregisters it from the server.
+func (sn *stratumSession) close() {
+	sn.conn.Close()
+	close(sn.done)
+
+	sn.mu.Lock()
+	name := sn.name
+	sn.mu.Unlock()
+
+	sn.server.mu.Lock()
+	delete(sn.server.sessions, sn)
+	if name != "" {
+		sn.server.worker(name).Sessions--
+	}
+	sn.server.mu.Unlock()
+}
+
+// decodeStratumHex decodes a fixed size hex value submitted by a miner into the
+// given buffer. Miners don't always prefix the values with 0x, so it's optional.
+func decodeStratumHex(input string, out []byte) error {
+	if !strings.HasPrefix(input, "0x") && !strings.HasPrefix(input, "0X") {
+		input = "0x" + input
+	}
+	blob, err := hexutil.Decode(input)
+	if err != nil {
+		return err
+	}
+	if len(blob) != len(out) {
+		return fmt.Errorf("invalid length %d, want %d", len(blob), len(out))
+	}
+	copy(out, blob)
+	return nil
+}
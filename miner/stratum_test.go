@@ -0,0 +1,337 @@
+// Copyright 2017 The daxxcoreAuthors
+// This file is part of the daxxcore library.
+//
+// The daxxcore library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The daxxcore library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the daxxcore library. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/daxxcoin/daxxcore/common"
+	"github.com/daxxcoin/daxxcore/consensus/daxxhash"
+	"github.com/daxxcoin/daxxcore/core/types"
+	"github.com/daxxnucleus/daxxhash"
+)
+
+// stratumTestMessage is a response or notification received from the server.
+type stratumTestMessage struct {
+	Id     *json.RawMessage
+	Result interface{}
+	Error  *stratumError
+	Method string
+	Params []interface{}
+}
+
+// Tests that the stratum server authorizes workers, pushes them new work with the
+// share target and accounts for the shares and hashrate they submit.
+func TestStratumServer(t *testing.T) {
+	results := make(chan *Result, 1)
+
+	agent := NewRemoteAgent(nil, daxxhash.NewFaker())
+	agent.SetReturnCh(results)
+	agent.Start()
+	defer agent.Stop()
+
+	server := NewStratumServer(agent, StratumConfig{Addr: "127.0.0.1:0", Password: "secret", Difficulty: big.NewInt(100)})
+	if err := server.Start(); err != nil {
+		t.Fatalf("failed to start stratum server: %v", err)
+	}
+	defer server.Stop()
+
+	conn, err := net.Dial("tcp", server.Addr().String())
+	if err != nil {
+		t.Fatalf("failed to connect to stratum server: %v", err)
+	}
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+
+	id := 0
+	call := func(method string, params ...interface{}) *stratumTestMessage {
+		id++
+		blob, _ := json.Marshal(map[string]interface{}{"id": id, "method": method, "params": params})
+		if _, err := conn.Write(append(blob, '\n')); err != nil {
+			t.Fatalf("%s: failed to send request: %v", method, err)
+		}
+		return read(t, conn, reader)
+	}
+	// Ensure unknown protocols are refused and shares are only accepted from authorized workers
+	if res := call("mining.subscribe", "tester", "EthereumStratum/2.0.0"); res.Error == nil {
+		t.Fatalf("unsupported protocol accepted: %+v", res)
+	}
+	if res := call("mining.subscribe", "tester"); res.Error != nil || res.Result != true {
+		t.Fatalf("subscription failed: %+v", res)
+	}
+	if res := call("mining.submit", "rig", "", "0x00", "0x00", "0x00"); res.Error == nil {
+		t.Fatalf("unauthorized share accepted: %+v", res)
+	}
+	if res := call("mining.authorize", "rig", "x"); res.Error == nil {
+		t.Fatalf("wrong password accepted: %+v", res)
+	}
+	if res := call("mining.authorize", "rig", "secret"); res.Error != nil || res.Result != true {
+		t.Fatalf("authorization failed: %+v", res)
+	}
+	// Feed a new work package and ensure it's pushed to the worker
+	block := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(1), Difficulty: big.NewInt(1000)})
+	agent.Work() <- &Work{Block: block, createdAt: time.Now()}
+
+	notify := read(t, conn, reader)
+	if notify.Method != "mining.notify" || len(notify.Params) < 4 || notify.Params[1] != block.HashNoNonce().Hex() {
+		t.Fatalf("work notification mismatch: %+v", notify)
+	}
+	if target := common.BigToHash(new(big.Int).Div(maxUint256, big.NewInt(100))).Hex(); notify.Params[3] != target {
+		t.Fatalf("share target mismatch: have %v, want %v", notify.Params[3], target)
+	}
+	// Submit a share for the pushed work, and the same one again (already sealed)
+	share := []interface{}{"rig", notify.Params[0], "0x0000000000000001", block.HashNoNonce().Hex(), block.HashNoNonce().Hex()}
+	if res := call("mining.submit", share...); res.Error != nil || res.Result != true {
+		t.Fatalf("valid share rejected: %+v", res)
+	}
+	select {
+	case result := <-results:
+		if result.Block.Nonce() != 1 {
+			t.Fatalf("sealed nonce mismatch: have %d, want %d", result.Block.Nonce(), 1)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("sealed block not returned to the miner")
+	}
+	if res := call("mining.submit", share...); res.Error == nil {
+		t.Fatalf("duplicate share accepted: %+v", res)
+	}
+	stale := []interface{}{"rig", notify.Params[0], "0x0000000000000002", common.Hash{1}.Hex(), block.HashNoNonce().Hex()}
+	if res := call("mining.submit", stale...); res.Error == nil {
+		t.Fatalf("stale share accepted: %+v", res)
+	}
+	// Report the hashrate of the worker and check the stats
+	if res := call("eth_submitHashrate", fmt.Sprintf("0x%064x", 1500), fmt.Sprintf("0x%064x", 1)); res.Error != nil || res.Result != true {
+		t.Fatalf("hashrate submission failed: %+v", res)
+	}
+	if rate := agent.GetHashRate(); rate != 1500 {
+		t.Fatalf("agent hashrate mismatch: have %d, want %d", rate, 1500)
+	}
+	stats := server.Stats().Workers["rig"]
+	if stats == nil {
+		t.Fatalf("worker stats missing")
+	}
+	if stats.Sessions != 1 || stats.AcceptedShares != 1 || stats.RejectedShares != 2 || stats.Blocks != 1 || stats.Hashrate != 1500 {
+		t.Fatalf("worker stats mismatch: have %+v", stats)
+	}
+}
+
+// Tests that the stratum server speaks EthereumStratum/1.0 to miners subscribing
+// with it: handing out an extranonce and the share difficulty, pushing jobs and
+// accepting shares without a mix digest.
+func TestEthereumStratumServer(t *testing.T) {
+	results := make(chan *Result, 1)
+
+	agent := NewRemoteAgent(nil, daxxhash.NewFaker())
+	agent.SetReturnCh(results)
+	agent.Start()
+	defer agent.Stop()
+
+	server := NewStratumServer(agent, StratumConfig{Addr: "127.0.0.1:0", Difficulty: big.NewInt(100)})
+	if err := server.Start(); err != nil {
+		t.Fatalf("failed to start stratum server: %v", err)
+	}
+	defer server.Stop()
+
+	conn, err := net.Dial("tcp", server.Addr().String())
+	if err != nil {
+		t.Fatalf("failed to connect to stratum server: %v", err)
+	}
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+
+	id := 0
+	call := func(method string, params ...interface{}) *stratumTestMessage {
+		id++
+		blob, _ := json.Marshal(map[string]interface{}{"id": id, "method": method, "params": params})
+		if _, err := conn.Write(append(blob, '\n')); err != nil {
+			t.Fatalf("%s: failed to send request: %v", method, err)
+		}
+		return read(t, conn, reader)
+	}
+	// Subscribe and retrieve the extranonce
+	res := call("mining.subscribe", "tester", "EthereumStratum/1.0.0")
+	if res.Error != nil {
+		t.Fatalf("subscription failed: %+v", res.Error)
+	}
+	result, ok := res.Result.([]interface{})
+	if !ok || len(result) != 2 {
+		t.Fatalf("subscription result mismatch: %+v", res.Result)
+	}
+	if notify, ok := result[0].([]interface{}); !ok || len(notify) != 3 || notify[0] != "mining.notify" || notify[2] != "EthereumStratum/1.0.0" {
+		t.Fatalf("subscription details mismatch: %+v", result[0])
+	}
+	extranonce, ok := result[1].(string)
+	if !ok || len(extranonce) != 4 {
+		t.Fatalf("extranonce mismatch: %+v", result[1])
+	}
+	if res := call("mining.authorize", "rig", "x"); res.Error != nil || res.Result != true {
+		t.Fatalf("authorization failed: %+v", res)
+	}
+	// Feed a new work package and ensure the difficulty and the job are pushed
+	block := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(1), Difficulty: big.NewInt(1000)})
+	agent.Work() <- &Work{Block: block, createdAt: time.Now()}
+
+	difficulty := read(t, conn, reader)
+	if difficulty.Method != "mining.set_difficulty" || len(difficulty.Params) != 1 {
+		t.Fatalf("difficulty notification mismatch: %+v", difficulty)
+	}
+	if diff, want := difficulty.Params[0].(float64), 100.0/(1<<32); diff < want*0.999999 || diff > want*1.000001 {
+		t.Fatalf("share difficulty mismatch: have %v, want %v", diff, want)
+	}
+	seed, _ := ethash.GetSeedHash(1)
+	notify := read(t, conn, reader)
+	if notify.Method != "mining.notify" || len(notify.Params) != 4 {
+		t.Fatalf("work notification mismatch: %+v", notify)
+	}
+	if notify.Params[1] != common.Bytes2Hex(seed) || notify.Params[2] != block.HashNoNonce().Hex()[2:] || notify.Params[3] != true {
+		t.Fatalf("work notification mismatch: %+v", notify)
+	}
+	// Submit a share with only the miner's part of the nonce, and a stale one
+	if res := call("mining.submit", "rig", notify.Params[0], "000000000001"); res.Error != nil || res.Result != true {
+		t.Fatalf("valid share rejected: %+v", res)
+	}
+	select {
+	case result := <-results:
+		want, _ := strconv.ParseUint(extranonce+"000000000001", 16, 64)
+		if result.Block.Nonce() != want {
+			t.Fatalf("sealed nonce mismatch: have %x, want %x", result.Block.Nonce(), want)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("sealed block not returned to the miner")
+	}
+	if res := call("mining.submit", "rig", "0000000000000000", "000000000002"); res.Error == nil {
+		t.Fatalf("share for unknown job accepted: %+v", res)
+	}
+	stats := server.Stats().Workers["rig"]
+	if stats == nil || stats.AcceptedShares != 1 || stats.RejectedShares != 1 || stats.Blocks != 1 {
+		t.Fatalf("worker stats mismatch: have %+v", stats)
+	}
+}
+
+// Tests that the stratum server refuses connections above the session limit.
+func TestStratumServerSessionLimit(t *testing.T) {
+	agent := NewRemoteAgent(nil, daxxhash.NewFaker())
+	agent.Start()
+	defer agent.Stop()
+
+	server := NewStratumServer(agent, StratumConfig{Addr: "127.0.0.1:0", Difficulty: big.NewInt(100), MaxSessions: 1})
+	if err := server.Start(); err != nil {
+		t.Fatalf("failed to start stratum server: %v", err)
+	}
+	defer server.Stop()
+
+	conns := make([]net.Conn, 2)
+	for i := range conns {
+		conn, err := net.Dial("tcp", server.Addr().String())
+		if err != nil {
+			t.Fatalf("conn %d: failed to connect to stratum server: %v", i, err)
+		}
+		defer conn.Close()
+
+		if _, err := conn.Write([]byte(`{"id":1,"method":"mining.subscribe","params":[]}` + "\n")); err != nil {
+			t.Fatalf("conn %d: failed to send request: %v", i, err)
+		}
+		conns[i] = conn
+	}
+	if res := read(t, conns[0], bufio.NewReader(conns[0])); res.Error != nil || res.Result != true {
+		t.Fatalf("subscription failed: %+v", res)
+	}
+	conns[1].SetReadDeadline(time.Now().Add(time.Second))
+	if _, err := bufio.NewReader(conns[1]).ReadBytes('\n'); err == nil {
+		t.Fatalf("connection above the session limit served")
+	}
+}
+
+// Tests that the statistics of idle workers are dropped to admit new ones, but
+// logins are refused if all tracked workers have open sessions.
+func TestStratumWorkerLimit(t *testing.T) {
+	server := NewStratumServer(NewRemoteAgent(nil, daxxhash.NewFaker()), StratumConfig{})
+	for i := 0; i < stratumMaxWorkers; i++ {
+		server.workers[fmt.Sprintf("busy-%d", i)] = &StratumWorkerStats{Sessions: 1}
+	}
+	conn, _ := net.Pipe()
+	defer conn.Close()
+	session := &stratumSession{server: server, conn: conn}
+
+	if err := session.authorize("new"); err != errStratumTooManyWorkers {
+		t.Fatalf("login with all workers busy: have %v, want %v", err, errStratumTooManyWorkers)
+	}
+	if len(server.workers) != stratumMaxWorkers {
+		t.Fatalf("tracked worker count mismatch: have %d, want %d", len(server.workers), stratumMaxWorkers)
+	}
+	server.workers["busy-0"].Sessions = 0
+	if err := session.authorize("new"); err != nil {
+		t.Fatalf("login with an idle worker refused: %v", err)
+	}
+	if _, ok := server.workers["busy-0"]; ok {
+		t.Fatalf("idle worker not evicted")
+	}
+	if stats := server.workers["new"]; stats == nil || stats.Sessions != 1 {
+		t.Fatalf("new worker stats mismatch: have %+v", stats)
+	}
+}
+
+// Tests that subscribers of the work feed not receiving the work don't block the
+// remote agent from serving other requests.
+func TestRemoteAgentBlockedSubscriber(t *testing.T) {
+	agent := NewRemoteAgent(nil, daxxhash.NewFaker())
+	agent.Start()
+	defer agent.Stop()
+
+	sub := agent.SubscribeWork(make(chan [3]string))
+	defer sub.Unsubscribe()
+
+	block := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(1), Difficulty: big.NewInt(1000)})
+	agent.Work() <- &Work{Block: block, createdAt: time.Now()}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			if work, err := agent.GetWork(); err == nil && work[0] == block.HashNoNonce().Hex() {
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("remote agent blocked by work subscriber")
+	}
+}
+
+// read reads the next message sent by the stratum server.
+func read(t *testing.T, conn net.Conn, reader *bufio.Reader) *stratumTestMessage {
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	line, err := reader.ReadBytes('\n')
+	if err != nil {
+		t.Fatalf("failed to read message: %v", err)
+	}
+	msg := new(stratumTestMessage)
+	if err := json.Unmarshal(line, msg); err != nil {
+		t.Fatalf("failed to decode message %s: %v", line, err)
+	}
+	return msg
+}
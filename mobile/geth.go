@@ -20,11 +20,13 @@
 package geth
 
 import (
+	"encoding/json"
 	"fmt"
 	"math/big"
 	"path/filepath"
 
 	"github.com/daxxcoin/daxxcore/common"
+	"github.com/daxxcoin/daxxcore/core"
 	"github.com/daxxcoin/daxxcore/daxx"
 	"github.com/daxxcoin/daxxcore/daxxclient"
 	"github.com/daxxcoin/daxxcore/daxxstats"
@@ -130,18 +132,28 @@ func NewNode(datadir string, config *NodeConfig) (stack *Node, _ error) {
 	}
 	// Register the Daxxcoin protocol if requested
 	if config.DaxxcoinEnabled {
+		// Use the main net genesis unless a custom one was requested
+		var genesis *core.Genesis
+		if config.DaxxcoinGenesis != "" {
+			genesis = new(core.Genesis)
+			if err := json.Unmarshal([]byte(config.DaxxcoinGenesis), genesis); err != nil {
+				return nil, fmt.Errorf("invalid genesis spec: %v", err)
+			}
+			if chain := config.DaxxcoinChainConfig; chain != nil {
+				genesis.Config = &params.ChainConfig{
+					ChainId:        big.NewInt(chain.ChainID),
+					HomesteadBlock: big.NewInt(chain.HomesteadBlock),
+					DAOForkBlock:   big.NewInt(chain.DAOForkBlock),
+					DAOForkSupport: chain.DAOForkSupport,
+					EIP150Block:    big.NewInt(chain.EIP150Block),
+					EIP150Hash:     chain.EIP150Hash.hash,
+					EIP155Block:    big.NewInt(chain.EIP155Block),
+					EIP158Block:    big.NewInt(chain.EIP158Block),
+				}
+			}
+		}
 		ethConf := &eth.Config{
-			ChainConfig: &params.ChainConfig{
-				ChainId:        big.NewInt(config.DaxxcoinChainConfig.ChainID),
-				HomesteadBlock: big.NewInt(config.DaxxcoinChainConfig.HomesteadBlock),
-				DAOForkBlock:   big.NewInt(config.DaxxcoinChainConfig.DAOForkBlock),
-				DAOForkSupport: config.DaxxcoinChainConfig.DAOForkSupport,
-				EIP150Block:    big.NewInt(config.DaxxcoinChainConfig.EIP150Block),
-				EIP150Hash:     config.DaxxcoinChainConfig.EIP150Hash.hash,
-				EIP155Block:    big.NewInt(config.DaxxcoinChainConfig.EIP155Block),
-				EIP158Block:    big.NewInt(config.DaxxcoinChainConfig.EIP158Block),
-			},
-			Genesis:                 config.DaxxcoinGenesis,
+			Genesis:                 genesis,
 			LightMode:               true,
 			DatabaseCache:           config.DaxxcoinDatabaseCache,
 			NetworkId:               config.DaxxcoinNetworkID,
@@ -186,6 +186,7 @@ type Receipt struct {
 }
 
 func (r *Receipt) GetPostState() []byte          { return r.receipt.PostState }
+func (r *Receipt) GetStatus() int                { return int(r.receipt.Status) }
 func (r *Receipt) GetCumulativeGasUsed() *BigInt { return &BigInt{r.receipt.CumulativeGasUsed} }
 func (r *Receipt) GetBloom() *Bloom              { return &Bloom{r.receipt.Bloom} }
 func (r *Receipt) GetLogs() *Logs                { return &Logs{r.receipt.Logs} }
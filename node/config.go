@@ -31,6 +31,7 @@ import (
 	"github.com/daxxcoin/daxxcore/accounts/usbwallet"
 	"github.com/daxxcoin/daxxcore/common"
 	"github.com/daxxcoin/daxxcore/crypto"
+	"github.com/daxxcoin/daxxcore/daxxdb"
 	"github.com/daxxcoin/daxxcore/logger"
 	"github.com/daxxcoin/daxxcore/logger/glog"
 	"github.com/daxxcoin/daxxcore/p2p/discover"
@@ -315,6 +316,23 @@ func (c *Config) resolvePath(path string) string {
 	return filepath.Join(c.instanceDir(), path)
 }
 
+// openDatabaseWithFreezer opens a LevelDB database with an attached ancient store.
+// An empty freezer path defaults to the "ancient" folder within the database,
+// relative paths are resolved against the instance directory.
+func (c *Config) openDatabaseWithFreezer(name string, cache, handles int, freezer string) (ethdb.Database, error) {
+	if c.DataDir == "" {
+		return ethdb.NewMemDatabase()
+	}
+	root := c.resolvePath(name)
+	switch {
+	case freezer == "":
+		freezer = filepath.Join(root, "ancient")
+	case !filepath.IsAbs(freezer):
+		freezer = c.resolvePath(freezer)
+	}
+	return ethdb.NewLDBDatabaseWithFreezer(root, cache, handles, freezer)
+}
+
 func (c *Config) instanceDir() string {
 	if c.DataDir == "" {
 		return ""
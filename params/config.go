@@ -19,6 +19,7 @@ package params
 import (
 	"fmt"
 	"math/big"
+	"sort"
 
 	"github.com/daxxcoin/daxxcore/common"
 )
@@ -65,11 +66,67 @@ type ChainConfig struct {
 
 	EIP155Block *big.Int `json:"eip155Block"` // EIP155 HF block
 	EIP158Block *big.Int `json:"eip158Block"` // EIP158 HF block
+
+	ByzantiumBlock      *big.Int `json:"byzantiumBlock,omitempty"`      // Byzantium switch block (nil = no fork, 0 = already on byzantium)
+	ConstantinopleBlock *big.Int `json:"constantinopleBlock,omitempty"` // Constantinople switch block (nil = no fork, 0 = already activated)
+
+	Rewards []*RewardConfig `json:"rewards,omitempty"` // Block reward schedules by activation block (nil = protocol defaults)
+
+	// Various consensus engines
+	Clique *CliqueConfig `json:"clique,omitempty"`
+}
+
+// RewardConfig is the monetary policy of a proof-of-work chain from its activation
+// block onwards: the base block reward, the uncle reward fractions and an optional
+// era based reduction of the reward. Any field left unset falls back to the
+// protocol default, as do all blocks before the first scheduled activation.
+type RewardConfig struct {
+	Block *big.Int `json:"block"` // Block number the schedule is activated at (nil = 0)
+
+	BlockReward   *big.Int `json:"blockReward"`             // Base reward in wei for mining a block
+	UncleDivisor  *big.Int `json:"uncleDivisor,omitempty"`  // Uncles earn (uncle + divisor - number) / divisor of the block reward
+	NephewDivisor *big.Int `json:"nephewDivisor,omitempty"` // Miners earn 1 / divisor of the block reward per included uncle
+
+	EraLength      *big.Int `json:"eraLength,omitempty"`      // Number of blocks after activation between reward reductions (nil = never)
+	EraNumerator   *big.Int `json:"eraNumerator,omitempty"`   // Reward is scaled by numerator / denominator each era (default 1)
+	EraDenominator *big.Int `json:"eraDenominator,omitempty"` // Reward is scaled by numerator / denominator each era (default 2, halving)
+}
+
+// String implements the stringer interface, returning the reward schedule details.
+func (c *RewardConfig) String() string {
+	return fmt.Sprintf("{Block: %v Reward: %v UncleDivisor: %v NephewDivisor: %v EraLength: %v Era: %v/%v}",
+		c.block(),
+		c.BlockReward,
+		c.UncleDivisor,
+		c.NephewDivisor,
+		c.EraLength,
+		c.EraNumerator,
+		c.EraDenominator,
+	)
+}
+
+// block returns the activation block of the reward schedule.
+func (c *RewardConfig) block() *big.Int {
+	if c.Block == nil {
+		return common.Big0
+	}
+	return c.Block
+}
+
+// CliqueConfig is the consensus engine configs for proof-of-authority based sealing.
+type CliqueConfig struct {
+	Period uint64 `json:"period"` // Number of seconds between blocks to enforce
+	Epoch  uint64 `json:"epoch"`  // Epoch length to reset votes and checkpoint
+}
+
+// String implements the stringer interface, returning the consensus engine details.
+func (c *CliqueConfig) String() string {
+	return "clique"
 }
 
 // String implements the Stringer interface.
 func (c *ChainConfig) String() string {
-	return fmt.Sprintf("{ChainID: %v Homestead: %v DAO: %v DAOSupport: %v EIP150: %v EIP155: %v EIP158: %v}",
+	return fmt.Sprintf("{ChainID: %v Homestead: %v DAO: %v DAOSupport: %v EIP150: %v EIP155: %v EIP158: %v Byzantium: %v Constantinople: %v Rewards: %v Engine: %v}",
 		c.ChainId,
 		c.HomesteadBlock,
 		c.DAOForkBlock,
@@ -77,11 +134,29 @@ func (c *ChainConfig) String() string {
 		c.EIP150Block,
 		c.EIP155Block,
 		c.EIP158Block,
+		c.ByzantiumBlock,
+		c.ConstantinopleBlock,
+		c.Rewards,
+		c.engine(),
 	)
 }
 
 var (
-	TestChainConfig = &ChainConfig{big.NewInt(1), new(big.Int), new(big.Int), true, new(big.Int), common.Hash{}, new(big.Int), new(big.Int)}
+	// AllDaxxhashProtocolChanges contains every protocol change (EIPs) introduced
+	// and accepted by the Daxxcoin core developers into the Daxxhash consensus.
+	//
+	// This configuration is intentionally not using keyed fields to force anyone
+	// adding flags to the config to also have to set these fields.
+	AllDaxxhashProtocolChanges = &ChainConfig{big.NewInt(1337), new(big.Int), nil, false, new(big.Int), common.Hash{}, new(big.Int), new(big.Int), new(big.Int), new(big.Int), nil, nil}
+
+	// AllCliqueProtocolChanges contains every protocol change (EIPs) introduced
+	// and accepted by the Daxxcoin core developers into the Clique consensus.
+	//
+	// This configuration is intentionally not using keyed fields to force anyone
+	// adding flags to the config to also have to set these fields.
+	AllCliqueProtocolChanges = &ChainConfig{big.NewInt(1337), new(big.Int), nil, false, new(big.Int), common.Hash{}, new(big.Int), new(big.Int), new(big.Int), new(big.Int), nil, &CliqueConfig{Period: 0, Epoch: 30000}}
+
+	TestChainConfig = &ChainConfig{big.NewInt(1), new(big.Int), new(big.Int), true, new(big.Int), common.Hash{}, new(big.Int), new(big.Int), new(big.Int), new(big.Int), nil, nil}
 	TestRules       = TestChainConfig.Rules(new(big.Int))
 )
 
@@ -102,6 +177,8 @@ func (c *ChainConfig) GasTable(num *big.Int) GasTable {
 	}
 
 	switch {
+	case c.IsConstantinople(num):
+		return GasTableConstantinople
 	case c.EIP158Block != nil && num.Cmp(c.EIP158Block) >= 0:
 		return GasTableEIP158
 	case c.EIP150Block != nil && num.Cmp(c.EIP150Block) >= 0:
@@ -135,6 +212,351 @@ func (c *ChainConfig) IsEIP158(num *big.Int) bool {
 
 }
 
+// IsByzantium returns whether num is either equal to the Byzantium fork block or greater.
+func (c *ChainConfig) IsByzantium(num *big.Int) bool {
+	if c.ByzantiumBlock == nil || num == nil {
+		return false
+	}
+	return num.Cmp(c.ByzantiumBlock) >= 0
+}
+
+// IsConstantinople returns whether num is either equal to the Constantinople fork block or greater.
+func (c *ChainConfig) IsConstantinople(num *big.Int) bool {
+	if c.ConstantinopleBlock == nil || num == nil {
+		return false
+	}
+	return num.Cmp(c.ConstantinopleBlock) >= 0
+}
+
+// rewardsAt returns the reward schedule active at the given block number, or nil
+// if the block is still subject to the protocol defaults.
+func (c *ChainConfig) rewardsAt(num *big.Int) *RewardConfig {
+	var active *RewardConfig
+	for _, rewards := range c.Rewards {
+		if num == nil || rewards.block().Cmp(num) > 0 {
+			continue
+		}
+		if active == nil || rewards.block().Cmp(active.block()) >= 0 {
+			active = rewards
+		}
+	}
+	return active
+}
+
+// BlockReward returns the reward in wei for mining the block with the given
+// number, after applying any era based reductions of the active reward schedule.
+func (c *ChainConfig) BlockReward(num *big.Int) *big.Int {
+	reward := new(big.Int).Set(BlockReward)
+
+	rewards := c.rewardsAt(num)
+	if rewards == nil {
+		return reward
+	}
+	if rewards.BlockReward != nil {
+		reward.Set(rewards.BlockReward)
+	}
+	// Reduce the reward once for every era passed since the schedule activated
+	if rewards.EraLength == nil || rewards.EraLength.Sign() <= 0 {
+		return reward
+	}
+	eras := new(big.Int).Sub(num, rewards.block())
+	if eras.Div(eras, rewards.EraLength); eras.Sign() == 0 || reward.Sign() == 0 {
+		return reward
+	}
+	numerator, denominator := rewards.eraRatio()
+	if numerator.Sign() <= 0 {
+		return new(big.Int)
+	}
+	if numerator.Cmp(denominator) >= 0 {
+		return reward // Increasing schedules are refused by CheckConfig
+	}
+	for era := new(big.Int); era.Cmp(eras) < 0 && reward.Sign() > 0; era.Add(era, common.Big1) {
+		reward.Mul(reward, numerator)
+		reward.Div(reward, denominator)
+	}
+	return reward
+}
+
+// eraRatio returns the numerator and denominator the reward is scaled by every
+// era of the schedule, defaulting to halving.
+func (r *RewardConfig) eraRatio() (*big.Int, *big.Int) {
+	numerator, denominator := big.NewInt(1), big.NewInt(2)
+	if r.EraNumerator != nil {
+		numerator = r.EraNumerator
+	}
+	if r.EraDenominator != nil && r.EraDenominator.Sign() > 0 {
+		denominator = r.EraDenominator
+	}
+	return numerator, denominator
+}
+
+// UncleRewardDivisor returns the divisor of the depth scaled uncle reward paid
+// for uncles included in the block with the given number.
+func (c *ChainConfig) UncleRewardDivisor(num *big.Int) *big.Int {
+	rewards := c.rewardsAt(num)
+	if rewards == nil || rewards.UncleDivisor == nil || rewards.UncleDivisor.Sign() <= 0 {
+		return UncleRewardDivisor
+	}
+	return rewards.UncleDivisor
+}
+
+// NephewRewardDivisor returns the divisor of the block reward paid to the miner
+// of the block with the given number for every uncle it includes.
+func (c *ChainConfig) NephewRewardDivisor(num *big.Int) *big.Int {
+	rewards := c.rewardsAt(num)
+	if rewards == nil || rewards.NephewDivisor == nil || rewards.NephewDivisor.Sign() <= 0 {
+		return NephewRewardDivisor
+	}
+	return rewards.NephewDivisor
+}
+
+// CheckConfig checks the chain configuration for parameters which are invalid
+// on their own, regardless of any stored configuration.
+func (c *ChainConfig) CheckConfig() error {
+	for _, rewards := range c.Rewards {
+		if rewards.EraLength == nil || rewards.EraLength.Sign() <= 0 {
+			continue
+		}
+		numerator, denominator := rewards.eraRatio()
+		if numerator.Sign() < 0 {
+			return fmt.Errorf("negative era numerator %v in reward schedule of block %v", numerator, rewards.block())
+		}
+		if numerator.Cmp(denominator) > 0 {
+			return fmt.Errorf("reward schedule of block %v increases the reward every era (%v/%v)", rewards.block(), numerator, denominator)
+		}
+	}
+	return nil
+}
+
+// CheckCompatible checks whether scheduled fork transitions have been imported
+// with a mismatching chain configuration. A rescheduled fork or reward schedule
+// is reported as a *ConfigCompatError, which can be resolved by rewinding the
+// local chain to the block preceding the fork. Changes to consensus parameters
+// not bound to a fork block can't be resolved that way and are reported as plain
+// errors.
+func (c *ChainConfig) CheckCompatible(newcfg *ChainConfig, height uint64) error {
+	if height > 0 {
+		if err := c.checkConsensusParams(newcfg); err != nil {
+			return err
+		}
+	}
+	bhead := new(big.Int).SetUint64(height)
+
+	// Iterate checkCompatible to find the lowest conflict.
+	var lasterr *ConfigCompatError
+	for {
+		err := c.checkCompatible(newcfg, bhead)
+		if err == nil || (lasterr != nil && err.RewindTo == lasterr.RewindTo) {
+			break
+		}
+		lasterr = err
+		bhead.SetUint64(err.RewindTo)
+	}
+	if lasterr == nil {
+		return nil
+	}
+	return lasterr
+}
+
+func (c *ChainConfig) checkCompatible(newcfg *ChainConfig, head *big.Int) *ConfigCompatError {
+	if isForkIncompatible(c.HomesteadBlock, newcfg.HomesteadBlock, head) {
+		return newCompatError("Homestead fork block", c.HomesteadBlock, newcfg.HomesteadBlock)
+	}
+	if isForkIncompatible(c.DAOForkBlock, newcfg.DAOForkBlock, head) {
+		return newCompatError("DAO fork block", c.DAOForkBlock, newcfg.DAOForkBlock)
+	}
+	if isForked(c.DAOForkBlock, head) && c.DAOForkSupport != newcfg.DAOForkSupport {
+		return newCompatError("DAO fork support flag", c.DAOForkBlock, newcfg.DAOForkBlock)
+	}
+	if isForkIncompatible(c.EIP150Block, newcfg.EIP150Block, head) {
+		return newCompatError("EIP150 fork block", c.EIP150Block, newcfg.EIP150Block)
+	}
+	if isForked(c.EIP150Block, head) && c.EIP150Hash != newcfg.EIP150Hash {
+		return newCompatError("EIP150 fork hash", c.EIP150Block, newcfg.EIP150Block)
+	}
+	if isForkIncompatible(c.EIP155Block, newcfg.EIP155Block, head) {
+		return newCompatError("EIP155 fork block", c.EIP155Block, newcfg.EIP155Block)
+	}
+	if isForked(c.EIP155Block, head) && !configNumEqual(c.ChainId, newcfg.ChainId) {
+		return newCompatError("EIP155 chain ID", c.EIP155Block, newcfg.EIP155Block)
+	}
+	if isForkIncompatible(c.EIP158Block, newcfg.EIP158Block, head) {
+		return newCompatError("EIP158 fork block", c.EIP158Block, newcfg.EIP158Block)
+	}
+	if isForkIncompatible(c.ByzantiumBlock, newcfg.ByzantiumBlock, head) {
+		return newCompatError("Byzantium fork block", c.ByzantiumBlock, newcfg.ByzantiumBlock)
+	}
+	if isForkIncompatible(c.ConstantinopleBlock, newcfg.ConstantinopleBlock, head) {
+		return newCompatError("Constantinople fork block", c.ConstantinopleBlock, newcfg.ConstantinopleBlock)
+	}
+	if s1, s2, changed := rewardsChange(c.Rewards, newcfg.Rewards); changed && (isForked(s1, head) || isForked(s2, head)) {
+		return newCompatError("block reward schedule", s1, s2)
+	}
+	return nil
+}
+
+// checkConsensusParams ensures that the consensus engine, which applies to every
+// block of the chain, was not changed.
+func (c *ChainConfig) checkConsensusParams(newcfg *ChainConfig) error {
+	switch {
+	case (c.Clique == nil) != (newcfg.Clique == nil):
+		return fmt.Errorf("incompatible consensus engine in database (have %v, want %v)", c.engine(), newcfg.engine())
+	case c.Clique != nil && *c.Clique != *newcfg.Clique:
+		return fmt.Errorf("incompatible clique parameters in database (have period %d epoch %d, want period %d epoch %d)",
+			c.Clique.Period, c.Clique.Epoch, newcfg.Clique.Period, newcfg.Clique.Epoch)
+	}
+	return nil
+}
+
+// rewardsChange finds the first reward schedule activation that differs between
+// two schedule lists, returning the stored and new activation blocks of it (nil
+// if the respective list has no such schedule). Both lists are normalised first,
+// so unset fields and a nil list compare equal to the protocol defaults.
+func rewardsChange(stored, new []*RewardConfig) (*big.Int, *big.Int, bool) {
+	stored, new = normalizeRewards(stored), normalizeRewards(new)
+	for i := 0; i < len(stored) || i < len(new); i++ {
+		switch {
+		case i >= len(stored):
+			return nil, new[i].Block, true
+		case i >= len(new):
+			return stored[i].Block, nil, true
+		case !stored[i].equal(new[i]):
+			return stored[i].Block, new[i].Block, true
+		}
+	}
+	return nil, nil, false
+}
+
+// normalizeRewards returns a copy of the reward schedules sorted by activation
+// block, with the implicit protocol defaults made explicit.
+func normalizeRewards(rewards []*RewardConfig) []*RewardConfig {
+	normal := make([]*RewardConfig, 0, len(rewards)+1)
+	for _, r := range rewards {
+		n := &RewardConfig{
+			Block:          r.block(),
+			BlockReward:    r.BlockReward,
+			UncleDivisor:   r.UncleDivisor,
+			NephewDivisor:  r.NephewDivisor,
+			EraLength:      r.EraLength,
+			EraNumerator:   r.EraNumerator,
+			EraDenominator: r.EraDenominator,
+		}
+		if n.BlockReward == nil {
+			n.BlockReward = BlockReward
+		}
+		if n.UncleDivisor == nil || n.UncleDivisor.Sign() <= 0 {
+			n.UncleDivisor = UncleRewardDivisor
+		}
+		if n.NephewDivisor == nil || n.NephewDivisor.Sign() <= 0 {
+			n.NephewDivisor = NephewRewardDivisor
+		}
+		if n.EraLength == nil || n.EraLength.Sign() <= 0 {
+			n.EraLength, n.EraNumerator, n.EraDenominator = nil, nil, nil
+		} else {
+			if n.EraNumerator == nil {
+				n.EraNumerator = big.NewInt(1)
+			}
+			if n.EraDenominator == nil || n.EraDenominator.Sign() <= 0 {
+				n.EraDenominator = big.NewInt(2)
+			}
+		}
+		normal = append(normal, n)
+	}
+	sort.Stable(rewardsByBlock(normal))
+
+	// Blocks before the first activation run with the protocol defaults
+	if len(normal) == 0 || normal[0].Block.Sign() > 0 {
+		defaults := &RewardConfig{Block: common.Big0, BlockReward: BlockReward, UncleDivisor: UncleRewardDivisor, NephewDivisor: NephewRewardDivisor}
+		normal = append([]*RewardConfig{defaults}, normal...)
+	}
+	return normal
+}
+
+// rewardsByBlock implements sort.Interface to order reward schedules by their
+// activation block.
+type rewardsByBlock []*RewardConfig
+
+func (s rewardsByBlock) Len() int           { return len(s) }
+func (s rewardsByBlock) Less(i, j int) bool { return s[i].block().Cmp(s[j].block()) < 0 }
+func (s rewardsByBlock) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+// equal reports whether two reward schedules are identical.
+func (c *RewardConfig) equal(other *RewardConfig) bool {
+	if c == nil || other == nil {
+		return c == other
+	}
+	return configNumEqual(c.block(), other.block()) &&
+		configNumEqual(c.BlockReward, other.BlockReward) &&
+		configNumEqual(c.UncleDivisor, other.UncleDivisor) &&
+		configNumEqual(c.NephewDivisor, other.NephewDivisor) &&
+		configNumEqual(c.EraLength, other.EraLength) &&
+		configNumEqual(c.EraNumerator, other.EraNumerator) &&
+		configNumEqual(c.EraDenominator, other.EraDenominator)
+}
+
+// engine returns the name of the consensus engine the config runs with.
+func (c *ChainConfig) engine() string {
+	if c.Clique != nil {
+		return c.Clique.String()
+	}
+	return "daxxhash"
+}
+
+// isForkIncompatible returns true if a fork scheduled at s1 cannot be rescheduled to
+// block s2 because head is already past the fork.
+func isForkIncompatible(s1, s2, head *big.Int) bool {
+	return (isForked(s1, head) || isForked(s2, head)) && !configNumEqual(s1, s2)
+}
+
+// isForked returns whether a fork scheduled at block s is active at the given head block.
+func isForked(s, head *big.Int) bool {
+	if s == nil || head == nil {
+		return false
+	}
+	return s.Cmp(head) <= 0
+}
+
+func configNumEqual(x, y *big.Int) bool {
+	if x == nil {
+		return y == nil
+	}
+	if y == nil {
+		return x == nil
+	}
+	return x.Cmp(y) == 0
+}
+
+// ConfigCompatError is raised if the locally-stored blockchain is initialised with a
+// ChainConfig that would alter the past.
+type ConfigCompatError struct {
+	What string
+	// block numbers of the stored and new configurations
+	StoredConfig, NewConfig *big.Int
+	// the block number to which the local chain must be rewound to correct the error
+	RewindTo uint64
+}
+
+func newCompatError(what string, storedblock, newblock *big.Int) *ConfigCompatError {
+	var rew *big.Int
+	switch {
+	case storedblock == nil:
+		rew = newblock
+	case newblock == nil || storedblock.Cmp(newblock) < 0:
+		rew = storedblock
+	default:
+		rew = newblock
+	}
+	err := &ConfigCompatError{what, storedblock, newblock, 0}
+	if rew != nil && rew.Sign() > 0 {
+		err.RewindTo = rew.Uint64() - 1
+	}
+	return err
+}
+
+func (err *ConfigCompatError) Error() string {
+	return fmt.Sprintf("mismatching %s in database (have %d, want %d, rewindto %d)", err.What, err.StoredConfig, err.NewConfig, err.RewindTo)
+}
+
 // Rules wraps ChainConfig and is merely syntatic sugar or can be used for functions
 // that do not have or require information about the block.
 //
@@ -143,8 +565,9 @@ func (c *ChainConfig) IsEIP158(num *big.Int) bool {
 type Rules struct {
 	ChainId                                   *big.Int
 	IsHomestead, IsEIP150, IsEIP155, IsEIP158 bool
+	IsByzantium, IsConstantinople             bool
 }
 
 func (c *ChainConfig) Rules(num *big.Int) Rules {
-	return Rules{ChainId: new(big.Int).Set(c.ChainId), IsHomestead: c.IsHomestead(num), IsEIP150: c.IsEIP150(num), IsEIP155: c.IsEIP155(num), IsEIP158: c.IsEIP158(num)}
+	return Rules{ChainId: new(big.Int).Set(c.ChainId), IsHomestead: c.IsHomestead(num), IsEIP150: c.IsEIP150(num), IsEIP155: c.IsEIP155(num), IsEIP158: c.IsEIP158(num), IsByzantium: c.IsByzantium(num), IsConstantinople: c.IsConstantinople(num)}
 }
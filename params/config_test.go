@@ -0,0 +1,247 @@
+// Copyright 2016 The daxxcoreAuthors
+// This file is part of the daxxcore library.
+//
+// The daxxcore library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The daxxcore library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the daxxcore library. If not, see <http://www.gnu.org/licenses/>.
+
+package params
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+func TestCheckCompatible(t *testing.T) {
+	type test struct {
+		stored, new *ChainConfig
+		head        uint64
+		wantErr     *ConfigCompatError
+	}
+	tests := []test{
+		{stored: AllDaxxhashProtocolChanges, new: AllDaxxhashProtocolChanges, head: 0, wantErr: nil},
+		{stored: AllDaxxhashProtocolChanges, new: AllDaxxhashProtocolChanges, head: 100, wantErr: nil},
+		{
+			stored:  &ChainConfig{EIP150Block: big.NewInt(10)},
+			new:     &ChainConfig{EIP150Block: big.NewInt(20)},
+			head:    9,
+			wantErr: nil,
+		},
+		{
+			stored: AllDaxxhashProtocolChanges,
+			new:    &ChainConfig{HomesteadBlock: nil},
+			head:   3,
+			wantErr: &ConfigCompatError{
+				What:         "Homestead fork block",
+				StoredConfig: big.NewInt(0),
+				NewConfig:    nil,
+				RewindTo:     0,
+			},
+		},
+		{
+			stored: AllDaxxhashProtocolChanges,
+			new:    &ChainConfig{HomesteadBlock: big.NewInt(1)},
+			head:   3,
+			wantErr: &ConfigCompatError{
+				What:         "Homestead fork block",
+				StoredConfig: big.NewInt(0),
+				NewConfig:    big.NewInt(1),
+				RewindTo:     0,
+			},
+		},
+		{
+			stored: &ChainConfig{HomesteadBlock: big.NewInt(30), EIP150Block: big.NewInt(10)},
+			new:    &ChainConfig{HomesteadBlock: big.NewInt(25), EIP150Block: big.NewInt(20)},
+			head:   25,
+			wantErr: &ConfigCompatError{
+				What:         "EIP150 fork block",
+				StoredConfig: big.NewInt(10),
+				NewConfig:    big.NewInt(20),
+				RewindTo:     9,
+			},
+		},
+		{
+			stored: &ChainConfig{DAOForkBlock: big.NewInt(5), DAOForkSupport: true},
+			new:    &ChainConfig{DAOForkBlock: big.NewInt(5), DAOForkSupport: false},
+			head:   10,
+			wantErr: &ConfigCompatError{
+				What:         "DAO fork support flag",
+				StoredConfig: big.NewInt(5),
+				NewConfig:    big.NewInt(5),
+				RewindTo:     4,
+			},
+		},
+		{
+			stored: &ChainConfig{ChainId: big.NewInt(1), EIP155Block: big.NewInt(10)},
+			new:    &ChainConfig{ChainId: big.NewInt(2), EIP155Block: big.NewInt(10)},
+			head:   10,
+			wantErr: &ConfigCompatError{
+				What:         "EIP155 chain ID",
+				StoredConfig: big.NewInt(10),
+				NewConfig:    big.NewInt(10),
+				RewindTo:     9,
+			},
+		},
+		{
+			stored:  &ChainConfig{},
+			new:     &ChainConfig{Rewards: []*RewardConfig{{BlockReward: BlockReward, UncleDivisor: UncleRewardDivisor}}},
+			head:    100,
+			wantErr: nil,
+		},
+		{
+			stored:  &ChainConfig{},
+			new:     &ChainConfig{Rewards: []*RewardConfig{{Block: big.NewInt(200), BlockReward: big.NewInt(1)}}},
+			head:    100,
+			wantErr: nil,
+		},
+		{
+			stored: &ChainConfig{Rewards: []*RewardConfig{{Block: big.NewInt(50), BlockReward: big.NewInt(1)}}},
+			new:    &ChainConfig{Rewards: []*RewardConfig{{Block: big.NewInt(70), BlockReward: big.NewInt(1)}}},
+			head:   100,
+			wantErr: &ConfigCompatError{
+				What:         "block reward schedule",
+				StoredConfig: big.NewInt(50),
+				NewConfig:    big.NewInt(70),
+				RewindTo:     49,
+			},
+		},
+		{
+			stored: &ChainConfig{Rewards: []*RewardConfig{{Block: big.NewInt(50), BlockReward: big.NewInt(1)}}},
+			new:    &ChainConfig{Rewards: []*RewardConfig{{Block: big.NewInt(50), BlockReward: big.NewInt(2)}}},
+			head:   100,
+			wantErr: &ConfigCompatError{
+				What:         "block reward schedule",
+				StoredConfig: big.NewInt(50),
+				NewConfig:    big.NewInt(50),
+				RewindTo:     49,
+			},
+		},
+		{
+			stored: &ChainConfig{},
+			new:    &ChainConfig{Rewards: []*RewardConfig{{Block: big.NewInt(80), BlockReward: big.NewInt(1)}}},
+			head:   100,
+			wantErr: &ConfigCompatError{
+				What:         "block reward schedule",
+				StoredConfig: nil,
+				NewConfig:    big.NewInt(80),
+				RewindTo:     79,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		err := test.stored.CheckCompatible(test.new, test.head)
+		if test.wantErr == nil {
+			if err != nil {
+				t.Errorf("error mismatch:\nstored: %v\nnew: %v\nhead: %v\nerr: %v\nwant: nil", test.stored, test.new, test.head, err)
+			}
+			continue
+		}
+		if !reflect.DeepEqual(err, test.wantErr) {
+			t.Errorf("error mismatch:\nstored: %v\nnew: %v\nhead: %v\nerr: %v\nwant: %v", test.stored, test.new, test.head, err, test.wantErr)
+		}
+	}
+}
+
+// Tests that changes to consensus parameters which apply to the entire chain are
+// refused outright instead of being resolved by a rewind.
+func TestCheckCompatibleConsensus(t *testing.T) {
+	var (
+		daxxhash = &ChainConfig{HomesteadBlock: big.NewInt(0)}
+		clique   = &ChainConfig{HomesteadBlock: big.NewInt(0), Clique: &CliqueConfig{Period: 15, Epoch: 30000}}
+		reclique = &ChainConfig{HomesteadBlock: big.NewInt(0), Clique: &CliqueConfig{Period: 5, Epoch: 30000}}
+	)
+	tests := []struct {
+		stored, new *ChainConfig
+	}{
+		{daxxhash, clique},
+		{clique, daxxhash},
+		{clique, reclique},
+	}
+	for i, test := range tests {
+		err := test.stored.CheckCompatible(test.new, 10)
+		if err == nil {
+			t.Errorf("test %d: incompatible change accepted", i)
+			continue
+		}
+		if _, ok := err.(*ConfigCompatError); ok {
+			t.Errorf("test %d: consensus change reported as reschedulable: %v", i, err)
+		}
+		// Without any imported blocks, the parameters may still be changed
+		if err := test.stored.CheckCompatible(test.new, 0); err != nil {
+			t.Errorf("test %d: change refused on empty chain: %v", i, err)
+		}
+	}
+}
+
+// Tests that era based reward reductions are applied once per era, including for
+// ratios barely below one, which take many eras to drop the reward to zero.
+func TestBlockRewardEras(t *testing.T) {
+	config := &ChainConfig{Rewards: []*RewardConfig{{
+		Block:          big.NewInt(100),
+		BlockReward:    big.NewInt(1000000),
+		EraLength:      big.NewInt(10),
+		EraNumerator:   big.NewInt(999),
+		EraDenominator: big.NewInt(1000),
+	}}}
+	tests := []struct {
+		block  int64
+		reward *big.Int
+	}{
+		{99, BlockReward},
+		{100, big.NewInt(1000000)},
+		{109, big.NewInt(1000000)},
+		{110, big.NewInt(999000)},
+		{120, big.NewInt(998001)},
+		{100 + 10*1000, big.NewInt(367375)},
+		{100 + 10*5000, big.NewInt(6230)},
+		{100 + 10*7481, big.NewInt(1)},
+		{100 + 10*7482, big.NewInt(0)},
+		{100 + 10*1000000, big.NewInt(0)},
+	}
+	for i, test := range tests {
+		if reward := config.BlockReward(big.NewInt(test.block)); reward.Cmp(test.reward) != 0 {
+			t.Errorf("test %d: block %d reward mismatch: have %v, want %v", i, test.block, reward, test.reward)
+		}
+	}
+	// A ratio of one never reduces the reward
+	config.Rewards[0].EraNumerator = big.NewInt(1000)
+	if reward := config.BlockReward(big.NewInt(1000000)); reward.Cmp(big.NewInt(1000000)) != 0 {
+		t.Errorf("constant schedule reward mismatch: have %v, want %d", reward, 1000000)
+	}
+}
+
+// Tests that reward schedules increasing the reward every era are refused.
+func TestCheckConfigRewards(t *testing.T) {
+	tests := []struct {
+		rewards *RewardConfig
+		valid   bool
+	}{
+		{&RewardConfig{EraLength: big.NewInt(10)}, true},
+		{&RewardConfig{EraLength: big.NewInt(10), EraNumerator: big.NewInt(999999), EraDenominator: big.NewInt(1000000)}, true},
+		{&RewardConfig{EraLength: big.NewInt(10), EraNumerator: big.NewInt(2), EraDenominator: big.NewInt(2)}, true},
+		{&RewardConfig{EraLength: big.NewInt(10), EraNumerator: big.NewInt(3)}, false},
+		{&RewardConfig{EraLength: big.NewInt(10), EraNumerator: big.NewInt(1000001), EraDenominator: big.NewInt(1000000)}, false},
+		{&RewardConfig{EraLength: big.NewInt(10), EraNumerator: big.NewInt(-1)}, false},
+		{&RewardConfig{EraNumerator: big.NewInt(3)}, true}, // no eras, ratio unused
+	}
+	for i, test := range tests {
+		err := (&ChainConfig{Rewards: []*RewardConfig{test.rewards}}).CheckConfig()
+		if test.valid && err != nil {
+			t.Errorf("test %d: valid schedule refused: %v", i, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("test %d: invalid schedule accepted", i)
+		}
+	}
+}
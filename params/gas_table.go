@@ -21,6 +21,7 @@ import "math/big"
 type GasTable struct {
 	ExtcodeSize *big.Int
 	ExtcodeCopy *big.Int
+	ExtcodeHash *big.Int
 	Balance     *big.Int
 	SLoad       *big.Int
 	Calls       *big.Int
@@ -80,4 +81,19 @@ var (
 
 		CreateBySuicide: big.NewInt(25000),
 	}
+
+	// GasTableConstantinople contain the gas prices for
+	// the constantinople phase.
+	GasTableConstantinople = GasTable{
+		ExtcodeSize: big.NewInt(700),
+		ExtcodeCopy: big.NewInt(700),
+		ExtcodeHash: big.NewInt(400),
+		Balance:     big.NewInt(400),
+		SLoad:       big.NewInt(200),
+		Calls:       big.NewInt(700),
+		Suicide:     big.NewInt(5000),
+		ExpByte:     big.NewInt(50),
+
+		CreateBySuicide: big.NewInt(25000),
+	}
 )
@@ -37,6 +37,10 @@ var (
 	EcrecoverGas           = big.NewInt(3000)   //
 	Sha256WordGas          = big.NewInt(12)     //
 
+	BlockReward         = new(big.Int).Mul(big.NewInt(30), big.NewInt(1e18)) // Default reward in wei for successfully mining a block
+	UncleRewardDivisor  = big.NewInt(8)                                      // Default divisor of the depth scaled uncle reward
+	NephewRewardDivisor = big.NewInt(32)                                     // Default divisor of the reward paid per included uncle
+
 	MinGasLimit     = big.NewInt(5000)                  // Minimum the gas limit may ever be.
 	GenesisGasLimit = big.NewInt(4712388)               // Gas limit of the Genesis block.
 	TargetGasLimit  = new(big.Int).Set(GenesisGasLimit) // The artificial target
@@ -65,9 +69,16 @@ var (
 	TierStepGas          = big.NewInt(0)      // Once per operation, for a selection of them.
 	LogTopicGas          = big.NewInt(375)    // Multiplied by the * of the LOG*, per LOG transaction. e.g. LOG0 incurs 0 * c_txLogTopicGas, LOG4 incurs 4 * c_txLogTopicGas.
 	CreateGas            = big.NewInt(32000)  // Once per CREATE operation & contract-creation transaction.
+	Create2Gas           = big.NewInt(32000)  // Once per CREATE2 operation
 	SuicideRefundGas     = big.NewInt(24000)  // Refunded following a suicide operation.
 	MemoryGas            = big.NewInt(3)      // Times the address of the (highest referenced byte in memory + 1). NOTE: referencing happens on read, write and in instructions such as RETURN and CALL.
 	TxDataNonZeroGas     = big.NewInt(68)     // Per byte of data attached to a transaction that is not equal to zero. NOTE: Not payable on data of calls between transactions.
 
+	ModExpQuadCoeffDiv      = big.NewInt(20)     // Divisor for the quadratic particle of the big int modular exponentiation
+	Bn256AddGas             = big.NewInt(500)    // Gas needed for an elliptic curve addition
+	Bn256ScalarMulGas       = big.NewInt(40000)  // Gas needed for an elliptic curve scalar multiplication
+	Bn256PairingBaseGas     = big.NewInt(100000) // Base price for an elliptic curve pairing check
+	Bn256PairingPerPointGas = big.NewInt(80000)  // Per-point price for an elliptic curve pairing check
+
 	MaxCodeSize = 24576
 )
@@ -256,6 +256,79 @@ func TestClientSubscribe(t *testing.T) {
 	}
 }
 
+// Tests that subscriptions can be made on namespaces other than eth.
+func TestClientSubscribeNamespace(t *testing.T) {
+	server := newTestServer("nftest", new(NotificationTestService))
+	defer server.Stop()
+	client := DialInProc(server)
+	defer client.Close()
+
+	nc := make(chan int)
+	count := 10
+	sub, err := client.Subscribe(context.Background(), "nftest", nc, "someSubscription", count, 0)
+	if err != nil {
+		t.Fatal("can't subscribe:", err)
+	}
+	for i := 0; i < count; i++ {
+		if val := <-nc; val != i {
+			t.Fatalf("value mismatch: got %d, want %d", val, i)
+		}
+	}
+	sub.Unsubscribe()
+	if _, err := client.EthSubscribe(context.Background(), nc, "someSubscription", count, 0); err == nil {
+		t.Fatal("subscribed on the eth namespace of a non-eth service")
+	}
+}
+
+// Tests that subscriptions can only be cancelled on the namespace they were made on.
+func TestClientUnsubscribeNamespace(t *testing.T) {
+	server := newTestServer("nftest", new(NotificationTestService))
+	if err := server.RegisterName("other", new(NotificationTestService)); err != nil {
+		t.Fatal(err)
+	}
+	defer server.Stop()
+	client := DialInProc(server)
+	defer client.Close()
+
+	nc := make(chan int, 1)
+	sub, err := client.Subscribe(context.Background(), "nftest", nc, "someSubscription", 1, 0)
+	if err != nil {
+		t.Fatal("can't subscribe:", err)
+	}
+	<-nc
+
+	var result bool
+	if err := client.Call(&result, "other_unsubscribe", sub.subid); err == nil {
+		t.Fatal("unsubscribed on a different namespace")
+	}
+	if err := client.Call(&result, "nftest_unsubscribe", sub.subid); err != nil {
+		t.Fatal("can't unsubscribe:", err)
+	}
+}
+
+type PlainSubscribeService struct{}
+
+func (s *PlainSubscribeService) Subscribe(name string) string {
+	return "subscribed " + name
+}
+
+// Tests that subscribe methods of services without subscriptions are called as
+// regular methods.
+func TestClientPlainSubscribeMethod(t *testing.T) {
+	server := newTestServer("plain", new(PlainSubscribeService))
+	defer server.Stop()
+	client := DialInProc(server)
+	defer client.Close()
+
+	var result string
+	if err := client.Call(&result, "plain_subscribe", "foo"); err != nil {
+		t.Fatal(err)
+	}
+	if result != "subscribed foo" {
+		t.Fatalf("result mismatch: got %q, want %q", result, "subscribed foo")
+	}
+}
+
 // In this test, the connection drops while EthSubscribe is
 // waiting for a response.
 func TestClientSubscribeClose(t *testing.T) {
@@ -31,11 +31,13 @@ import (
 )
 
 const (
-	jsonrpcVersion         = "2.0"
-	serviceMethodSeparator = "_"
-	subscribeMethod        = "eth_subscribe"
-	unsubscribeMethod      = "eth_unsubscribe"
-	notificationMethod     = "eth_subscription"
+	jsonrpcVersion           = "2.0"
+	serviceMethodSeparator   = "_"
+	subscribeMethod          = "subscribe"
+	unsubscribeMethod        = "unsubscribe"
+	subscribeMethodSuffix    = serviceMethodSeparator + subscribeMethod
+	unsubscribeMethodSuffix  = serviceMethodSeparator + unsubscribeMethod
+	notificationMethodSuffix = "_subscription"
 )
 
 type jsonRequest struct {
@@ -164,41 +166,19 @@ func parseRequest(incomingMsg json.RawMessage) ([]rpcRequest, bool, Error) {
 		return nil, false, &invalidMessageError{err.Error()}
 	}
 
-	// subscribe are special, they will always use `subscribeMethod` as first param in the payload
-	if in.Method == subscribeMethod {
-		reqs := []rpcRequest{{id: &in.Id, isPubSub: true}}
-		if len(in.Payload) > 0 {
-			// first param must be subscription name
-			var subscribeMethod [1]string
-			if err := json.Unmarshal(in.Payload, &subscribeMethod); err != nil {
-				glog.V(logger.Debug).Infof("Unable to parse subscription method: %v\n", err)
-				return nil, false, &invalidRequestError{"Unable to parse subscription request"}
-			}
-
-			// all subscriptions are made on the eth service
-			reqs[0].service, reqs[0].method = "eth", subscribeMethod[0]
-			reqs[0].params = in.Payload
-			return reqs, false, nil
-		}
-		return nil, false, &invalidRequestError{"Unable to parse subscription request"}
-	}
-
-	if in.Method == unsubscribeMethod {
-		return []rpcRequest{{id: &in.Id, isPubSub: true,
-			method: unsubscribeMethod, params: in.Payload}}, false, nil
-	}
-
 	elems := strings.Split(in.Method, serviceMethodSeparator)
 	if len(elems) != 2 {
 		return nil, false, &methodNotFoundError{in.Method, ""}
 	}
 
-	// regular RPC call
-	if len(in.Payload) == 0 {
-		return []rpcRequest{{service: elems[0], method: elems[1], id: &in.Id}}, false, nil
+	// regular RPC call, or a (un)subscribe request if the service supports subscriptions
+	req := rpcRequest{service: elems[0], method: elems[1], id: &in.Id}
+	if len(in.Payload) > 0 {
+		req.params = in.Payload
 	}
+	parsePubSub(&req, in.Payload)
 
-	return []rpcRequest{{service: elems[0], method: elems[1], id: &in.Id, params: in.Payload}}, false, nil
+	return []rpcRequest{req}, false, nil
 }
 
 // parseBatchRequest will parse a batch request into a collection of requests from the given RawMessage, an indication
@@ -217,31 +197,6 @@ func parseBatchRequest(incomingMsg json.RawMessage) ([]rpcRequest, bool, Error)
 
 		id := &in[i].Id
 
-		// subscribe are special, they will always use `subscribeMethod` as first param in the payload
-		if r.Method == subscribeMethod {
-			requests[i] = rpcRequest{id: id, isPubSub: true}
-			if len(r.Payload) > 0 {
-				// first param must be subscription name
-				var subscribeMethod [1]string
-				if err := json.Unmarshal(r.Payload, &subscribeMethod); err != nil {
-					glog.V(logger.Debug).Infof("Unable to parse subscription method: %v\n", err)
-					return nil, false, &invalidRequestError{"Unable to parse subscription request"}
-				}
-
-				// all subscriptions are made on the eth service
-				requests[i].service, requests[i].method = "eth", subscribeMethod[0]
-				requests[i].params = r.Payload
-				continue
-			}
-
-			return nil, true, &invalidRequestError{"Unable to parse (un)subscribe request arguments"}
-		}
-
-		if r.Method == unsubscribeMethod {
-			requests[i] = rpcRequest{id: id, isPubSub: true, method: unsubscribeMethod, params: r.Payload}
-			continue
-		}
-
 		if len(r.Payload) == 0 {
 			requests[i] = rpcRequest{id: id, params: nil}
 		} else {
@@ -249,6 +204,7 @@ func parseBatchRequest(incomingMsg json.RawMessage) ([]rpcRequest, bool, Error)
 		}
 		if elem := strings.Split(r.Method, serviceMethodSeparator); len(elem) == 2 {
 			requests[i].service, requests[i].method = elem[0], elem[1]
+			parsePubSub(&requests[i], r.Payload)
 		} else {
 			requests[i].err = &methodNotFoundError{r.Method, ""}
 		}
@@ -257,6 +213,27 @@ func parseBatchRequest(incomingMsg json.RawMessage) ([]rpcRequest, bool, Error)
 	return requests, true, nil
 }
 
+// parsePubSub marks <namespace>_subscribe and <namespace>_unsubscribe requests as
+// potential subscription requests. Subscribe requests always carry the name of the
+// subscription as their first param. Whether they are handled as subscriptions or
+// as regular calls is decided by the server, depending on the registered services.
+func parsePubSub(req *rpcRequest, payload json.RawMessage) {
+	switch req.method {
+	case subscribeMethod:
+		req.isPubSub = true
+
+		var subscription [1]string
+		if err := json.Unmarshal(payload, &subscription); err != nil {
+			glog.V(logger.Debug).Infof("Unable to parse subscription method: %v\n", err)
+			return
+		}
+		req.subscription = subscription[0]
+
+	case unsubscribeMethod:
+		req.isPubSub = true
+	}
+}
+
 // ParseRequestArguments tries to parse the given params (json.RawMessage) with the given
 // types. It returns the parsed values or an error when the parsing failed.
 func (c *jsonCodec) ParseRequestArguments(argTypes []reflect.Type, params interface{}) ([]reflect.Value, Error) {
@@ -326,13 +303,14 @@ func (c *jsonCodec) CreateErrorResponseWithInfo(id interface{}, err Error, info
 }
 
 // CreateNotification will create a JSON-RPC notification with the given subscription id and event as params.
-func (c *jsonCodec) CreateNotification(subid string, event interface{}) interface{} {
+// The notification is sent as <namespace>_subscription, namespace being the service the subscription was made on.
+func (c *jsonCodec) CreateNotification(subid, namespace string, event interface{}) interface{} {
 	if isHexNum(reflect.TypeOf(event)) {
-		return &jsonNotification{Version: jsonrpcVersion, Method: notificationMethod,
+		return &jsonNotification{Version: jsonrpcVersion, Method: namespace + notificationMethodSuffix,
 			Params: jsonSubscription{Subscription: subid, Result: fmt.Sprintf(`%#x`, event)}}
 	}
 
-	return &jsonNotification{Version: jsonrpcVersion, Method: notificationMethod,
+	return &jsonNotification{Version: jsonrpcVersion, Method: namespace + notificationMethodSuffix,
 		Params: jsonSubscription{Subscription: subid, Result: event}}
 }
 
@@ -274,7 +274,7 @@ func (s *Server) handle(ctx context.Context, codec ServerCodec, req *serverReque
 			}
 
 			subid := ID(req.args[0].String())
-			if err := notifier.unsubscribe(subid); err != nil {
+			if err := notifier.unsubscribe(subid, req.svcname); err != nil {
 				return codec.CreateErrorResponse(&req.id, &callbackError{err.Error()}), nil
 			}
 
@@ -292,7 +292,7 @@ func (s *Server) handle(ctx context.Context, codec ServerCodec, req *serverReque
 		// active the subscription after the sub id was successfully sent to the client
 		activateSub := func() {
 			notifier, _ := NotifierFromContext(ctx)
-			notifier.activate(subid)
+			notifier.activate(subid, req.svcname)
 		}
 
 		return codec.CreateResponse(req.id, subid), activateSub
@@ -399,24 +399,28 @@ func (s *Server) readRequest(codec ServerCodec) ([]*serverRequest, bool, Error)
 			continue
 		}
 
-		if r.isPubSub && r.method == unsubscribeMethod {
-			requests[i] = &serverRequest{id: r.id, isUnsubscribe: true}
-			argTypes := []reflect.Type{reflect.TypeOf("")} // expect subscription id as first arg
-			if args, err := codec.ParseRequestArguments(argTypes, r.params); err == nil {
-				requests[i].args = args
-			} else {
-				requests[i].err = &invalidParamsError{err.Error()}
-			}
-			continue
-		}
-
 		if svc, ok = s.services[r.service]; !ok { // rpc method isn't available
 			requests[i] = &serverRequest{id: r.id, err: &methodNotFoundError{r.service, r.method}}
 			continue
 		}
 
-		if r.isPubSub { // eth_subscribe, r.method contains the subscription method name
-			if callb, ok := svc.subscriptions[r.method]; ok {
+		// (un)subscribe requests are only special on services offering subscriptions,
+		// otherwise they are regular method calls
+		if r.isPubSub && len(svc.subscriptions) > 0 {
+			if r.method == unsubscribeMethod {
+				requests[i] = &serverRequest{id: r.id, svcname: svc.name, isUnsubscribe: true}
+				argTypes := []reflect.Type{reflect.TypeOf("")} // expect subscription id as first arg
+				if args, err := codec.ParseRequestArguments(argTypes, r.params); err == nil {
+					requests[i].args = args
+				} else {
+					requests[i].err = &invalidParamsError{err.Error()}
+				}
+				continue
+			}
+			// <service>_subscribe, r.subscription contains the subscription method name
+			if r.subscription == "" {
+				requests[i] = &serverRequest{id: r.id, err: &invalidRequestError{"Unable to parse subscription request"}}
+			} else if callb, ok := svc.subscriptions[r.subscription]; ok {
 				requests[i] = &serverRequest{id: r.id, svcname: svc.name, callb: callb}
 				if r.params != nil && len(callb.argTypes) > 0 {
 					argTypes := []reflect.Type{reflect.TypeOf("")}
@@ -428,7 +432,7 @@ func (s *Server) readRequest(codec ServerCodec) ([]*serverRequest, bool, Error)
 					}
 				}
 			} else {
-				requests[i] = &serverRequest{id: r.id, err: &methodNotFoundError{subscribeMethod, r.method}}
+				requests[i] = &serverRequest{id: r.id, err: &methodNotFoundError{r.service + subscribeMethodSuffix, r.subscription}}
 			}
 			continue
 		}
@@ -151,6 +151,9 @@ func TestNotifications(t *testing.T) {
 			t.Fatalf("%v", err)
 		}
 
+		if notification.Method != "eth_subscription" {
+			t.Fatalf("expected notification method %q, got %q", "eth_subscription", notification.Method)
+		}
 		if int(notification.Params.Result.(float64)) != val+i {
 			t.Fatalf("expected %d, got %d", val+i, notification.Params.Result)
 		}
@@ -83,12 +83,13 @@ type Server struct {
 
 // rpcRequest represents a raw incoming RPC request
 type rpcRequest struct {
-	service  string
-	method   string
-	id       interface{}
-	isPubSub bool
-	params   interface{}
-	err      Error // invalid batch element
+	service      string
+	method       string
+	id           interface{}
+	isPubSub     bool   // <service>_subscribe or <service>_unsubscribe request
+	subscription string // subscription name of a subscribe request
+	params       interface{}
+	err          Error // invalid batch element
 }
 
 // Error wraps RPC errors, which contain an error code in addition to the message.
@@ -112,7 +113,7 @@ type ServerCodec interface {
 	// Assemble error response with extra information about the error through info
 	CreateErrorResponseWithInfo(id interface{}, err Error, info interface{}) interface{}
 	// Create notification response
-	CreateNotification(string, interface{}) interface{}
+	CreateNotification(string, string, interface{}) interface{}
 	// Write msg to client.
 	Write(interface{}) error
 	// Close underlying data stream
@@ -212,7 +212,7 @@ func RunState(chainConfig *params.ChainConfig, statedb *state.StateDB, env, tx m
 
 	snapshot := statedb.Snapshot()
 
-	ret, gasUsed, err := core.ApplyMessage(environment, msg, gaspool)
+	ret, gasUsed, _, err := core.ApplyMessage(environment, msg, gaspool)
 	if core.IsNonceErr(err) || core.IsInvalidTxErr(err) || core.IsGasLimitErr(err) {
 		statedb.RevertToSnapshot(snapshot)
 	}
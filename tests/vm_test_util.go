@@ -226,7 +226,8 @@ func RunVm(statedb *state.StateDB, env, exec map[string]string) ([]byte, []*type
 		value = common.Big(exec["value"])
 	)
 	caller := statedb.GetOrNewStateObject(from)
-	vm.PrecompiledContracts = make(map[common.Address]vm.PrecompiledContract)
+	vm.PrecompiledContractsHomestead = make(map[common.Address]vm.PrecompiledContract)
+	vm.PrecompiledContractsByzantium = make(map[common.Address]vm.PrecompiledContract)
 
 	environment, _ := NewEVMEnvironment(true, chainConfig, statedb, env, exec)
 	ret, err := environment.Call(caller, to, data, gas, value)
@@ -0,0 +1,351 @@
+// Copyright 2017 The daxxcoreAuthors
+// This file is part of the daxxcore library.
+//
+// The daxxcore library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The daxxcore library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the daxxcore library. If not, see <http://www.gnu.org/licenses/>.
+
+package trie
+
+import (
+	"bytes"
+	"sync"
+	"time"
+
+	"github.com/daxxcoin/daxxcore/common"
+	"github.com/daxxcoin/daxxcore/daxxdb"
+	"github.com/daxxcoin/daxxcore/logger"
+	"github.com/daxxcoin/daxxcore/logger/glog"
+)
+
+// LeafCallback is a callback type invoked when a trie operation reaches a leaf
+// node. It's used by state tries to link account leaves to the storage tries
+// and contract code they reference.
+type LeafCallback func(leaf []byte, parent common.Hash) error
+
+// cachedNode is all the information we know about a single cached trie node in
+// the memory database write layer.
+type cachedNode struct {
+	blob     []byte              // Cached data block of the trie node
+	parents  int                 // Number of live nodes referencing this one
+	children map[common.Hash]int // Children referenced by this node
+}
+
+// NodeDatabase is an intermediate write layer between the trie data structures
+// and the disk database. Committed trie nodes are accumulated in memory with
+// reference counts instead of being written out immediately, so that nodes of
+// tries that go out of use can be dropped without ever hitting the disk.
+//
+// Tries are kept alive by referencing their root from the empty hash, which acts
+// as a meta root. Dereferencing a root recursively removes every node that is
+// no longer reachable from any other live node. Committing a root flushes it,
+// along with all the nodes it references, into the disk database.
+type NodeDatabase struct {
+	diskdb ethdb.Database // Persistent storage for matured trie nodes
+
+	nodes     map[common.Hash]*cachedNode // Data and references relationships of a node
+	preimages map[common.Hash][]byte      // Preimages of nodes from the secure trie
+
+	nodesSize     common.StorageSize // Storage size of the nodes cache
+	preimagesSize common.StorageSize // Storage size of the preimages cache
+
+	lock sync.RWMutex
+}
+
+// NewNodeDatabase creates a new trie node cache to store ephemeral trie content
+// before it's written out to disk or garbage collected.
+func NewNodeDatabase(diskdb ethdb.Database) *NodeDatabase {
+	return &NodeDatabase{
+		diskdb: diskdb,
+		nodes: map[common.Hash]*cachedNode{
+			{}: {children: make(map[common.Hash]int)},
+		},
+		preimages: make(map[common.Hash][]byte),
+	}
+}
+
+// DiskDB retrieves the persistent storage backing the trie node cache.
+func (db *NodeDatabase) DiskDB() ethdb.Database {
+	return db.diskdb
+}
+
+// Get retrieves the data blob stored under the given key, serving trie nodes,
+// contract code and secure key preimages from memory if they are cached and
+// falling back to the disk database otherwise.
+func (db *NodeDatabase) Get(key []byte) ([]byte, error) {
+	db.lock.RLock()
+	switch {
+	case len(key) == common.HashLength:
+		if node := db.nodes[common.BytesToHash(key)]; node != nil {
+			db.lock.RUnlock()
+			return node.blob, nil
+		}
+	case len(key) == secureKeyLength && bytes.HasPrefix(key, secureKeyPrefix):
+		if preimage := db.preimages[common.BytesToHash(key[len(secureKeyPrefix):])]; preimage != nil {
+			db.lock.RUnlock()
+			return preimage, nil
+		}
+	}
+	db.lock.RUnlock()
+
+	return db.diskdb.Get(key)
+}
+
+// Has retrieves whether a data blob is stored under the given key, either in
+// the memory cache or on disk.
+func (db *NodeDatabase) Has(key []byte) (bool, error) {
+	db.lock.RLock()
+	switch {
+	case len(key) == common.HashLength:
+		if node := db.nodes[common.BytesToHash(key)]; node != nil {
+			db.lock.RUnlock()
+			return true, nil
+		}
+	case len(key) == secureKeyLength && bytes.HasPrefix(key, secureKeyPrefix):
+		if preimage := db.preimages[common.BytesToHash(key[len(secureKeyPrefix):])]; preimage != nil {
+			db.lock.RUnlock()
+			return true, nil
+		}
+	}
+	db.lock.RUnlock()
+
+	return db.diskdb.Has(key)
+}
+
+// Put inserts a data blob into the memory cache. Secure key preimages are kept
+// until the next commit, whereas any other blob is tracked as a trie node without
+// children (e.g. contract code), which is dropped once nothing references it.
+func (db *NodeDatabase) Put(key, value []byte) error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	if len(key) == secureKeyLength && bytes.HasPrefix(key, secureKeyPrefix) {
+		hash := common.BytesToHash(key[len(secureKeyPrefix):])
+		if _, ok := db.preimages[hash]; !ok {
+			db.preimages[hash] = common.CopyBytes(value)
+			db.preimagesSize += common.StorageSize(common.HashLength + len(value))
+		}
+		return nil
+	}
+	db.insert(common.BytesToHash(key), value)
+	return nil
+}
+
+// insert inserts a collapsed trie node into the memory database. This method is
+// a more generic version of Put, tracking the references to the node's children
+// that are themselves cached.
+//
+// The caller must hold the write lock.
+func (db *NodeDatabase) insert(hash common.Hash, blob []byte, children ...common.Hash) {
+	if _, ok := db.nodes[hash]; !ok {
+		db.nodes[hash] = &cachedNode{
+			blob:     common.CopyBytes(blob),
+			children: make(map[common.Hash]int),
+		}
+		db.nodesSize += common.StorageSize(common.HashLength + len(blob))
+	}
+	for _, child := range children {
+		db.reference(child, hash)
+	}
+}
+
+// Reference adds a new reference from a parent node to a child node. Referencing
+// a trie root from the empty hash keeps the entire trie alive.
+func (db *NodeDatabase) Reference(child common.Hash, parent common.Hash) {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	db.reference(child, parent)
+}
+
+// reference is the private locked version of Reference.
+func (db *NodeDatabase) reference(child common.Hash, parent common.Hash) {
+	// If the node does not exist, it's a node pulled from disk, skip
+	node, ok := db.nodes[child]
+	if !ok {
+		return
+	}
+	owner, ok := db.nodes[parent]
+	if !ok {
+		return
+	}
+	// If the reference already exists, only duplicate for roots
+	if _, ok = owner.children[child]; ok && parent != (common.Hash{}) {
+		return
+	}
+	node.parents++
+	owner.children[child]++
+}
+
+// Dereference removes an existing reference from the meta root to a trie root,
+// deleting every cached node that becomes unreachable.
+func (db *NodeDatabase) Dereference(root common.Hash) {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	nodes, storage, start := len(db.nodes), db.nodesSize, time.Now()
+	db.dereference(root, common.Hash{})
+
+	glog.V(logger.Debug).Infof("Dereferenced trie %x from memory: removed %d nodes (%v) in %v, left %d nodes (%v)",
+		root[:4], nodes-len(db.nodes), storage-db.nodesSize, time.Since(start), len(db.nodes)-1, db.nodesSize)
+}
+
+// dereference is the private locked version of Dereference.
+func (db *NodeDatabase) dereference(child common.Hash, parent common.Hash) {
+	// Dereference the parent-child, unless the reference was never recorded
+	owner := db.nodes[parent]
+	if _, ok := owner.children[child]; !ok {
+		return
+	}
+	if owner.children[child]--; owner.children[child] <= 0 {
+		delete(owner.children, child)
+	}
+	// If the child does not exist, it's a previously committed node
+	node, ok := db.nodes[child]
+	if !ok {
+		return
+	}
+	// If there are no more references to the child, delete it and cascade
+	if node.parents--; node.parents == 0 {
+		for hash := range node.children {
+			db.dereference(hash, child)
+		}
+		delete(db.nodes, child)
+		db.nodesSize -= common.StorageSize(common.HashLength + len(node.blob))
+	}
+}
+
+// Commit iterates over all the children of a particular node, writes them out
+// to disk together with all pending secure key preimages and removes them from
+// the memory cache.
+func (db *NodeDatabase) Commit(root common.Hash) error {
+	// Create a database batch to flush persistent data out. It is important that
+	// outside code doesn't see an inconsistent state (referenced data removed from
+	// memory cache during commit but not yet in persistent storage). This is ensured
+	// by only uncaching existing data when the database write finalizes.
+	db.lock.RLock()
+
+	start := time.Now()
+	batch := newSizedBatch(db.diskdb)
+
+	for hash, preimage := range db.preimages {
+		key := append(common.CopyBytes(secureKeyPrefix), hash[:]...)
+		if err := batch.put(key, preimage); err != nil {
+			db.lock.RUnlock()
+			return err
+		}
+	}
+	nodes, storage := len(db.nodes), db.nodesSize
+	if err := db.commit(root, batch); err != nil {
+		db.lock.RUnlock()
+		return err
+	}
+	if err := batch.write(); err != nil {
+		db.lock.RUnlock()
+		return err
+	}
+	db.lock.RUnlock()
+
+	// Write successful, clear out the flushed data
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	db.preimages = make(map[common.Hash][]byte)
+	db.preimagesSize = 0
+
+	db.uncache(root)
+
+	glog.V(logger.Debug).Infof("Persisted trie %x from memory: flushed %d nodes (%v) in %v, left %d nodes (%v)",
+		root[:4], nodes-len(db.nodes), storage-db.nodesSize, time.Since(start), len(db.nodes)-1, db.nodesSize)
+	return nil
+}
+
+// commit is the private locked version of Commit.
+func (db *NodeDatabase) commit(hash common.Hash, batch *sizedBatch) error {
+	// If the node does not exist, it's a previously committed node
+	node, ok := db.nodes[hash]
+	if !ok {
+		return nil
+	}
+	for child := range node.children {
+		if err := db.commit(child, batch); err != nil {
+			return err
+		}
+	}
+	return batch.put(hash[:], node.blob)
+}
+
+// uncache is the post-processing step of a commit operation where the already
+// persisted trie is removed from the cache. The reason behind the two-phase
+// commit is to ensure consistent data availability while moving from memory
+// to disk.
+func (db *NodeDatabase) uncache(hash common.Hash) {
+	// If the node does not exist, we're done on this path
+	node, ok := db.nodes[hash]
+	if !ok || hash == (common.Hash{}) {
+		return
+	}
+	// Otherwise uncache the node's subtries and remove the node itself too
+	for child := range node.children {
+		db.uncache(child)
+	}
+	delete(db.nodes, hash)
+	db.nodesSize -= common.StorageSize(common.HashLength + len(node.blob))
+}
+
+// Size returns the current storage size of the memory cache in front of the
+// persistent database layer.
+func (db *NodeDatabase) Size() common.StorageSize {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	return db.nodesSize + db.preimagesSize
+}
+
+// Nodes returns the number of trie nodes held in the memory cache.
+func (db *NodeDatabase) Nodes() int {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	return len(db.nodes) - 1 // Don't count the meta root
+}
+
+// sizedBatch is a database batch which writes itself out whenever the amount of
+// accumulated data exceeds ethdb.IdealBatchSize.
+type sizedBatch struct {
+	ethdb.Batch
+}
+
+// newSizedBatch creates a self flushing batch on top of the given database.
+func newSizedBatch(db ethdb.Database) *sizedBatch {
+	return &sizedBatch{db.NewBatch()}
+}
+
+// put inserts the given value into the batch, flushing it if it grew too large.
+func (b *sizedBatch) put(key, value []byte) error {
+	if err := b.Put(key, value); err != nil {
+		return err
+	}
+	if b.ValueSize() >= ethdb.IdealBatchSize {
+		return b.write()
+	}
+	return nil
+}
+
+// write flushes any accumulated data to disk and resets the batch for reuse.
+func (b *sizedBatch) write() error {
+	if err := b.Write(); err != nil {
+		return err
+	}
+	b.Reset()
+	return nil
+}
@@ -0,0 +1,168 @@
+// Copyright 2017 The daxxcoreAuthors
+// This file is part of the daxxcore library.
+//
+// The daxxcore library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The daxxcore library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the daxxcore library. If not, see <http://www.gnu.org/licenses/>.
+
+package trie
+
+import (
+	"testing"
+
+	"github.com/daxxcoin/daxxcore/common"
+	"github.com/daxxcoin/daxxcore/daxxdb"
+)
+
+// makeCachedTrie creates a trie in the given node cache, filling it with a few
+// hundred entries modified by the given salt, and returns its committed root.
+func makeCachedTrie(t *testing.T, db *NodeDatabase, base common.Hash, salt byte) common.Hash {
+	trie, err := NewSecure(base, db, 0)
+	if err != nil {
+		t.Fatalf("failed to open trie %x: %v", base, err)
+	}
+	for i := byte(0); i < 100; i++ {
+		trie.Update(common.LeftPadBytes([]byte{i}, 32), []byte{salt, i})
+	}
+	root, err := trie.Commit()
+	if err != nil {
+		t.Fatalf("failed to commit trie: %v", err)
+	}
+	db.Reference(root, common.Hash{})
+	return root
+}
+
+// Tests that committed tries are kept in memory and not flushed to disk until
+// explicitly requested.
+func TestNodeDatabaseCaching(t *testing.T) {
+	diskdb, _ := ethdb.NewMemDatabase()
+	db := NewNodeDatabase(diskdb)
+
+	root := makeCachedTrie(t, db, common.Hash{}, 1)
+	if len(diskdb.Keys()) != 0 {
+		t.Fatalf("trie nodes leaked to disk before commit: %d entries", len(diskdb.Keys()))
+	}
+	if db.Nodes() == 0 || db.Size() == 0 {
+		t.Fatalf("trie nodes not cached: %d nodes, %v", db.Nodes(), db.Size())
+	}
+	if _, err := NewSecure(root, db, 0); err != nil {
+		t.Fatalf("failed to open cached trie: %v", err)
+	}
+	if err := db.Commit(root); err != nil {
+		t.Fatalf("failed to flush trie: %v", err)
+	}
+	if db.Nodes() != 0 || db.Size() != 0 {
+		t.Errorf("flushed nodes still cached: %d nodes, %v", db.Nodes(), db.Size())
+	}
+	if _, err := NewSecure(root, diskdb, 0); err != nil {
+		t.Fatalf("failed to open flushed trie from disk: %v", err)
+	}
+	// Ensure the secure key preimages got flushed too
+	trie, _ := NewSecure(root, diskdb, 0)
+	if key := common.LeftPadBytes([]byte{42}, 32); string(trie.GetKey(trie.hashKey(key))) != string(key) {
+		t.Errorf("secure key preimage not flushed")
+	}
+}
+
+// Tests that dereferencing a trie only removes the nodes not shared with other
+// live tries.
+func TestNodeDatabaseDereference(t *testing.T) {
+	diskdb, _ := ethdb.NewMemDatabase()
+	db := NewNodeDatabase(diskdb)
+
+	first := makeCachedTrie(t, db, common.Hash{}, 1)
+	nodes := db.Nodes()
+
+	// Create a trie modifying a few entries of the first one, sharing the rest
+	trie, _ := NewSecure(first, db, 0)
+	trie.Update(common.LeftPadBytes([]byte{1}, 32), []byte{2, 1})
+	second, _ := trie.Commit()
+	db.Reference(second, common.Hash{})
+
+	if db.Nodes() <= nodes {
+		t.Fatalf("modified trie didn't add nodes: have %d, had %d", db.Nodes(), nodes)
+	}
+	// Drop the first trie and ensure the second is still fully accessible
+	db.Dereference(first)
+	if _, err := db.Get(first[:]); err == nil {
+		t.Errorf("dereferenced root still available")
+	}
+	trie, err := NewSecure(second, db, 0)
+	if err != nil {
+		t.Fatalf("failed to open surviving trie: %v", err)
+	}
+	for i := byte(0); i < 100; i++ {
+		want := []byte{1, i}
+		if i == 1 {
+			want = []byte{2, 1}
+		}
+		if val, err := trie.TryGet(common.LeftPadBytes([]byte{i}, 32)); err != nil || string(val) != string(want) {
+			t.Errorf("item %d: value mismatch: have %x (err %v), want %x", i, val, err, want)
+		}
+	}
+	// Drop the second trie too and ensure everything is gone
+	db.Dereference(second)
+	if db.Nodes() != 0 {
+		t.Errorf("dangling nodes after dereferencing all tries: %d", db.Nodes())
+	}
+	if len(diskdb.Keys()) != 0 {
+		t.Errorf("garbage collected nodes leaked to disk: %d entries", len(diskdb.Keys()))
+	}
+}
+
+// Tests that a trie referenced multiple times from the meta root survives until
+// all references are dropped.
+func TestNodeDatabaseMultiReference(t *testing.T) {
+	diskdb, _ := ethdb.NewMemDatabase()
+	db := NewNodeDatabase(diskdb)
+
+	root := makeCachedTrie(t, db, common.Hash{}, 1)
+	db.Reference(root, common.Hash{})
+
+	db.Dereference(root)
+	if _, err := NewSecure(root, db, 0); err != nil {
+		t.Fatalf("trie dropped while still referenced: %v", err)
+	}
+	db.Dereference(root)
+	if db.Nodes() != 0 {
+		t.Errorf("dangling nodes after dereferencing all references: %d", db.Nodes())
+	}
+}
+
+// Tests that leaf callbacks can link external data to the trie nodes holding the
+// leaves, keeping it alive for as long as the trie is.
+func TestNodeDatabaseLeafReference(t *testing.T) {
+	diskdb, _ := ethdb.NewMemDatabase()
+	db := NewNodeDatabase(diskdb)
+
+	blob := []byte("some external data referenced from a leaf")
+	hash := common.BytesToHash(common.LeftPadBytes([]byte("external"), 32))
+	db.Put(hash[:], blob)
+
+	trie, _ := NewSecure(common.Hash{}, db, 0)
+	trie.Update([]byte("key"), append(hash[:], hash[:]...))
+	root, err := trie.CommitToWithCallback(db, func(leaf []byte, parent common.Hash) error {
+		db.Reference(hash, parent)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to commit trie: %v", err)
+	}
+	db.Reference(root, common.Hash{})
+
+	if err := db.Commit(root); err != nil {
+		t.Fatalf("failed to flush trie: %v", err)
+	}
+	if data, _ := diskdb.Get(hash[:]); string(data) != string(blob) {
+		t.Errorf("referenced blob not flushed with trie: have %q, want %q", data, blob)
+	}
+}
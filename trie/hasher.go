@@ -30,6 +30,8 @@ type hasher struct {
 	tmp                  *bytes.Buffer
 	sha                  hash.Hash
 	cachegen, cachelimit uint16
+	onleaf               LeafCallback
+	parallel             bool // Whether to hash the children of the top full node concurrently
 }
 
 // hashers live in a global pool.
@@ -39,9 +41,10 @@ var hasherPool = sync.Pool{
 	},
 }
 
-func newHasher(cachegen, cachelimit uint16) *hasher {
+func newHasher(cachegen, cachelimit uint16, onleaf LeafCallback) *hasher {
 	h := hasherPool.Get().(*hasher)
-	h.cachegen, h.cachelimit = cachegen, cachelimit
+	h.cachegen, h.cachelimit, h.onleaf = cachegen, cachelimit, onleaf
+	h.parallel = false
 	return h
 }
 
@@ -123,14 +126,20 @@ func (h *hasher) hashChildren(original node, db DatabaseWriter) (node, node, err
 		// Hash the full node's children, caching the newly hashed subtrees
 		collapsed, cached := n.copy(), n.copy()
 
-		for i := 0; i < 16; i++ {
-			if n.Children[i] != nil {
-				collapsed.Children[i], cached.Children[i], err = h.hash(n.Children[i], db, false)
-				if err != nil {
-					return original, original, err
+		if h.parallel {
+			if err := h.hashChildrenParallel(n, collapsed, cached, db); err != nil {
+				return original, original, err
+			}
+		} else {
+			for i := 0; i < 16; i++ {
+				if n.Children[i] != nil {
+					collapsed.Children[i], cached.Children[i], err = h.hash(n.Children[i], db, false)
+					if err != nil {
+						return original, original, err
+					}
+				} else {
+					collapsed.Children[i] = valueNode(nil) // Ensure that nil children are encoded as empty strings.
 				}
-			} else {
-				collapsed.Children[i] = valueNode(nil) // Ensure that nil children are encoded as empty strings.
 			}
 		}
 		cached.Children[16] = n.Children[16]
@@ -145,6 +154,40 @@ func (h *hasher) hashChildren(original node, db DatabaseWriter) (node, node, err
 	}
 }
 
+// hashChildrenParallel hashes the children of a full node concurrently, each on
+// its own hasher, filling in the collapsed and cached copies of the node. Only
+// the topmost full node is processed in parallel, the subtrees are hashed
+// sequentially.
+func (h *hasher) hashChildrenParallel(n, collapsed, cached *fullNode, db DatabaseWriter) error {
+	var (
+		wg   sync.WaitGroup
+		errs [16]error
+	)
+	for i := 0; i < 16; i++ {
+		if n.Children[i] == nil {
+			collapsed.Children[i] = valueNode(nil) // Ensure that nil children are encoded as empty strings.
+			continue
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			child := newHasher(h.cachegen, h.cachelimit, h.onleaf)
+			defer returnHasherToPool(child)
+
+			collapsed.Children[i], cached.Children[i], errs[i] = child.hash(n.Children[i], db, false)
+		}(i)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (h *hasher) store(n node, db DatabaseWriter, force bool) (node, error) {
 	// Don't store hashes or empty nodes.
 	if _, isHash := n.(hashNode); n == nil || isHash {
@@ -166,8 +209,47 @@ func (h *hasher) store(n node, db DatabaseWriter, force bool) (node, error) {
 		h.sha.Write(h.tmp.Bytes())
 		hash = hashNode(h.sha.Sum(nil))
 	}
-	if db != nil {
-		return hash, db.Put(hash, h.tmp.Bytes())
+	if db == nil {
+		return hash, nil
+	}
+	// Pool the node into the memory cache if there's one, tracking its references
+	// to any cached children. Otherwise write it straight to the database.
+	if cache, ok := db.(*NodeDatabase); ok {
+		var children []common.Hash
+		switch n := n.(type) {
+		case *shortNode:
+			if child, ok := n.Val.(hashNode); ok {
+				children = append(children, common.BytesToHash(child))
+			}
+		case *fullNode:
+			for i := 0; i < 16; i++ {
+				if child, ok := n.Children[i].(hashNode); ok {
+					children = append(children, common.BytesToHash(child))
+				}
+			}
+		}
+		cache.lock.Lock()
+		cache.insert(common.BytesToHash(hash), h.tmp.Bytes(), children...)
+		cache.lock.Unlock()
+	} else if err := db.Put(hash, h.tmp.Bytes()); err != nil {
+		return hash, err
+	}
+	// Notify the caller of any leaves embedded in the freshly stored node
+	if h.onleaf != nil {
+		switch n := n.(type) {
+		case *shortNode:
+			if child, ok := n.Val.(valueNode); ok {
+				if err := h.onleaf(child, common.BytesToHash(hash)); err != nil {
+					return hash, err
+				}
+			}
+		case *fullNode:
+			if child, ok := n.Children[16].(valueNode); ok && len(child) > 0 {
+				if err := h.onleaf(child, common.BytesToHash(hash)); err != nil {
+					return hash, err
+				}
+			}
+		}
 	}
 	return hash, nil
 }
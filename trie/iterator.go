@@ -16,7 +16,11 @@
 
 package trie
 
-import "github.com/daxxcoin/daxxcore/common"
+import (
+	"bytes"
+
+	"github.com/daxxcoin/daxxcore/common"
+)
 
 // Iterator is a key-value trie iterator that traverses a Trie.
 type Iterator struct {
@@ -38,6 +42,14 @@ func NewIterator(trie *Trie) *Iterator {
 	}
 }
 
+// NewIteratorFrom creates a new key-value iterator, skipping all the entries
+// with keys ordered before start.
+func NewIteratorFrom(trie *Trie, start []byte) *Iterator {
+	it := NewIterator(trie)
+	it.nodeIt = NewNodeIteratorFrom(trie, start)
+	return it
+}
+
 // Next moves the iterator forward one key-value entry.
 func (it *Iterator) Next() bool {
 	for it.nodeIt.Next() {
@@ -52,6 +64,13 @@ func (it *Iterator) Next() bool {
 	return false
 }
 
+// Error returns the failure that aborted the iteration, if any. A missing trie
+// node during iteration terminates it, so Next returning false only signals a
+// completed traversal if Error is nil.
+func (it *Iterator) Error() error {
+	return it.nodeIt.Error
+}
+
 func (it *Iterator) makeKey() []byte {
 	key := it.keyBuf[:0]
 	for _, se := range it.nodeIt.stack {
@@ -78,12 +97,14 @@ type nodeIteratorState struct {
 	node   node        // Trie node being iterated
 	parent common.Hash // Hash of the first full ancestor node (nil if current is the root)
 	child  int         // Child to be processed next
+	path   []byte      // Hex key path leading to the node (only tracked when seeking)
 }
 
 // NodeIterator is an iterator to traverse the trie post-order.
 type NodeIterator struct {
 	trie  *Trie                // Trie being iterated
 	stack []*nodeIteratorState // Hierarchy of trie nodes persisting the iteration state
+	start []byte               // Hex key before which subtries are skipped (nil if iterating all)
 
 	Hash     common.Hash // Hash of the current node being iterated (nil if not standalone)
 	Node     node        // Current node being iterated (internal representation)
@@ -102,6 +123,19 @@ func NewNodeIterator(trie *Trie) *NodeIterator {
 	return &NodeIterator{trie: trie}
 }
 
+// NewNodeIteratorFrom creates a post-order trie iterator which skips all the
+// subtries containing only keys ordered before start. Nodes on the path to the
+// start position are still visited.
+func NewNodeIteratorFrom(trie *Trie, start []byte) *NodeIterator {
+	it := NewNodeIterator(trie)
+	if len(start) > 0 {
+		// Drop the terminator, so shorter starts act as prefixes
+		it.start = compactHexDecode(start)
+		it.start = it.start[:len(it.start)-1]
+	}
+	return it
+}
+
 // Next moves the iterator to the next node, returning whether there are any
 // further nodes. In case of an internal error this method returns false and
 // sets the Error field to the encountered failure.
@@ -155,11 +189,16 @@ func (it *NodeIterator) step() error {
 			}
 			for parent.child++; parent.child < len(node.Children); parent.child++ {
 				if current := node.Children[parent.child]; current != nil {
+					path, skip := it.seekPath(parent, []byte{byte(parent.child)})
+					if skip {
+						continue
+					}
 					it.stack = append(it.stack, &nodeIteratorState{
 						hash:   common.BytesToHash(node.flags.hash),
 						node:   current,
 						parent: ancestor,
 						child:  -1,
+						path:   path,
 					})
 					break
 				}
@@ -170,11 +209,17 @@ func (it *NodeIterator) step() error {
 				break
 			}
 			parent.child++
+
+			path, skip := it.seekPath(parent, node.Key)
+			if skip {
+				break
+			}
 			it.stack = append(it.stack, &nodeIteratorState{
 				hash:   common.BytesToHash(node.flags.hash),
 				node:   node.Val,
 				parent: ancestor,
 				child:  -1,
+				path:   path,
 			})
 		} else if hash, ok := parent.node.(hashNode); ok {
 			// Hash node, resolve the hash child from the database, then the node itself
@@ -192,6 +237,7 @@ func (it *NodeIterator) step() error {
 				node:   node,
 				parent: ancestor,
 				child:  -1,
+				path:   parent.path,
 			})
 		} else {
 			break
@@ -200,6 +246,24 @@ func (it *NodeIterator) step() error {
 	return nil
 }
 
+// seekPath extends the path of the parent with the given key nibbles, and reports
+// whether the subtrie at the extended path only contains keys ordered before the
+// start position of the iterator. Paths are only tracked if the iterator seeks.
+func (it *NodeIterator) seekPath(parent *nodeIteratorState, key []byte) ([]byte, bool) {
+	if it.start == nil {
+		return nil, false
+	}
+	path := make([]byte, len(parent.path)+len(key))
+	copy(path, parent.path)
+	copy(path[len(parent.path):], key)
+
+	prefix := it.start
+	if len(prefix) > len(path) {
+		prefix = prefix[:len(path)]
+	}
+	return path, bytes.Compare(path, prefix) < 0
+}
+
 // retrieve pulls and caches the current trie node the iterator is traversing.
 // In case of a value node, the additional leaf blob is also populated with the
 // data contents for external interpretation.
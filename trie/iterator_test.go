@@ -17,6 +17,7 @@
 package trie
 
 import (
+	"bytes"
 	"testing"
 
 	"github.com/daxxcoin/daxxcore/common"
@@ -92,6 +93,45 @@ func TestIteratorLargeData(t *testing.T) {
 	}
 }
 
+// Tests that an iterator started from a given key skips exactly the entries
+// ordered before it, also when the trie nodes need to be resolved from disk.
+func TestIteratorFrom(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	trie, _ := New(common.Hash{}, db)
+
+	var keys [][]byte
+	for i := 0; i < 255; i++ {
+		key := common.LeftPadBytes([]byte{byte(i), byte(i * 7)}, 32)
+		trie.Update(key, []byte{byte(i)})
+		keys = append(keys, key)
+	}
+	root, _ := trie.Commit()
+	trie, _ = New(root, db)
+
+	for _, start := range [][]byte{nil, keys[0], keys[100], common.LeftPadBytes([]byte{100, 0}, 32), keys[254], common.LeftPadBytes([]byte{255}, 32), {0x00}, {0x00, 0x00}, {0x01}} {
+		var want int
+		for _, key := range keys {
+			if bytes.Compare(key, start) >= 0 {
+				want++
+			}
+		}
+		var have int
+		it := NewIteratorFrom(trie, start)
+		for it.Next() {
+			if bytes.Compare(it.Key, start) < 0 {
+				t.Errorf("start %x: iterated key %x before start", start, it.Key)
+			}
+			have++
+		}
+		if err := it.Error(); err != nil {
+			t.Fatalf("start %x: iteration failed: %v", start, err)
+		}
+		if have != want {
+			t.Errorf("start %x: iterated entries mismatch: have %d, want %d", start, have, want)
+		}
+	}
+}
+
 // Tests that the node iterator indeed walks over the entire database contents.
 func TestNodeIteratorCoverage(t *testing.T) {
 	// Create some arbitrary test trie to iterate
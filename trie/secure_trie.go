@@ -20,6 +20,7 @@ import (
 	"github.com/daxxcoin/daxxcore/common"
 	"github.com/daxxcoin/daxxcore/logger"
 	"github.com/daxxcoin/daxxcore/logger/glog"
+	"github.com/daxxcoin/daxxcore/rlp"
 )
 
 var secureKeyPrefix = []byte("secure-key-")
@@ -159,6 +160,18 @@ func (t *SecureTrie) Iterator() *Iterator {
 	return t.trie.Iterator()
 }
 
+// IteratorFrom returns an iterator over the mappings of the trie whose hashed
+// keys are ordered at or after start.
+func (t *SecureTrie) IteratorFrom(start []byte) *Iterator {
+	return NewIteratorFrom(&t.trie, start)
+}
+
+// Prove constructs a merkle proof for key, hashing it the same way as the trie
+// accessors do. See Trie.Prove for the format of the proof.
+func (t *SecureTrie) Prove(key []byte) []rlp.RawValue {
+	return t.trie.Prove(t.hashKey(key))
+}
+
 func (t *SecureTrie) NodeIterator() *NodeIterator {
 	return NewNodeIterator(&t.trie)
 }
@@ -170,6 +183,13 @@ func (t *SecureTrie) NodeIterator() *NodeIterator {
 // the trie's database. Calling code must ensure that the changes made to db are
 // written back to the trie's attached database before using the trie.
 func (t *SecureTrie) CommitTo(db DatabaseWriter) (root common.Hash, err error) {
+	return t.CommitToWithCallback(db, nil)
+}
+
+// CommitToWithCallback writes all nodes and the secure hash pre-images to the
+// given database the same way as CommitTo, invoking onleaf for every leaf value
+// contained in a newly written node along with the hash of that node.
+func (t *SecureTrie) CommitToWithCallback(db DatabaseWriter, onleaf LeafCallback) (root common.Hash, err error) {
 	if len(t.getSecKeyCache()) > 0 {
 		for hk, key := range t.secKeyCache {
 			if err := db.Put(t.secKey([]byte(hk)), key); err != nil {
@@ -178,7 +198,7 @@ func (t *SecureTrie) CommitTo(db DatabaseWriter) (root common.Hash, err error) {
 		}
 		t.secKeyCache = make(map[string][]byte)
 	}
-	return t.trie.CommitTo(db)
+	return t.trie.CommitToWithCallback(db, onleaf)
 }
 
 // secKey returns the database key for the preimage of key, as an ephemeral buffer.
@@ -194,7 +214,7 @@ func (t *SecureTrie) secKey(key []byte) []byte {
 // The caller must not hold onto the return value because it will become
 // invalid on the next call to hashKey or secKey.
 func (t *SecureTrie) hashKey(key []byte) []byte {
-	h := newHasher(0, 0)
+	h := newHasher(0, 0, nil)
 	h.sha.Reset()
 	h.sha.Write(key)
 	buf := h.sha.Sum(t.hashKeyBuf[:0])
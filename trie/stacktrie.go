@@ -0,0 +1,140 @@
+// Copyright 2017 The daxxcoreAuthors
+// This file is part of the daxxcore library.
+//
+// The daxxcore library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The daxxcore library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the daxxcore library. If not, see <http://www.gnu.org/licenses/>.
+
+package trie
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/daxxcoin/daxxcore/common"
+	"github.com/daxxcoin/daxxcore/logger"
+	"github.com/daxxcoin/daxxcore/logger/glog"
+)
+
+// ErrUnsortedKeys is returned when inserting a key into a stack trie that is not
+// strictly larger than the previously inserted one.
+var ErrUnsortedKeys = errors.New("stack trie keys not in ascending order")
+
+// StackTrie is a trie builder computing the root hash of a set of key/value pairs
+// inserted in ascending key order. Since no key can ever land left of the last
+// inserted one, every subtree left of the insertion path is final: it's hashed
+// right away (and written to the database, if there's one) and only its hash is
+// kept. Memory use is thus bounded by the depth of the trie instead of its size.
+//
+// StackTrie is not safe for concurrent use.
+type StackTrie struct {
+	trie Trie           // Trie holding the nodes of the insertion path
+	db   DatabaseWriter // Optional database to write the finalized nodes into
+	last []byte         // Last inserted key, to enforce the insertion order
+}
+
+// NewStackTrie creates an empty stack trie. If db is non-nil, the nodes of the
+// trie are written into it as they are finalized.
+func NewStackTrie(db DatabaseWriter) *StackTrie {
+	return &StackTrie{db: db}
+}
+
+// Update inserts a key/value pair into the trie, logging any error.
+func (st *StackTrie) Update(key, value []byte) {
+	if err := st.TryUpdate(key, value); err != nil && glog.V(logger.Error) {
+		glog.Errorf("Unhandled stack trie error: %v", err)
+	}
+}
+
+// TryUpdate inserts a key/value pair into the trie. The key must be larger than
+// all previously inserted ones and the value must not be empty, as deletions are
+// not supported.
+//
+// The value bytes must not be modified by the caller while they are stored in
+// the trie.
+func (st *StackTrie) TryUpdate(key, value []byte) error {
+	if len(value) == 0 {
+		return fmt.Errorf("empty value for stack trie key %x", key)
+	}
+	if st.last != nil && bytes.Compare(key, st.last) <= 0 {
+		return ErrUnsortedKeys
+	}
+	st.last = common.CopyBytes(key)
+
+	k := compactHexDecode(key)
+	_, root, err := st.trie.insert(st.trie.root, nil, k, valueNode(value))
+	if err != nil {
+		return err
+	}
+	st.trie.root = root
+	return st.finalize(root, k)
+}
+
+// finalize walks the path of the last inserted key, replacing the children left
+// of it with their hashes. Children small enough to be embedded into their parent
+// are kept as they are.
+func (st *StackTrie) finalize(n node, key []byte) error {
+	for {
+		switch nn := n.(type) {
+		case *shortNode:
+			if !bytes.HasPrefix(key, nn.Key) {
+				return nil
+			}
+			n, key = nn.Val, key[len(nn.Key):]
+
+		case *fullNode:
+			h := newHasher(0, 0, nil)
+			for i := 0; i < int(key[0]); i++ {
+				if child := nn.Children[i]; child != nil {
+					if _, ok := child.(hashNode); ok {
+						continue
+					}
+					hashed, _, err := h.hash(child, st.db, false)
+					if err != nil {
+						returnHasherToPool(h)
+						return err
+					}
+					if hash, ok := hashed.(hashNode); ok {
+						nn.Children[i] = hash
+					}
+				}
+			}
+			returnHasherToPool(h)
+			n, key = nn.Children[key[0]], key[1:]
+
+		default:
+			return nil
+		}
+	}
+}
+
+// Hash returns the root hash of the trie. Further keys can still be inserted
+// afterwards.
+func (st *StackTrie) Hash() common.Hash {
+	return st.trie.Hash()
+}
+
+// Commit writes the nodes of the insertion path into the database and returns
+// the root hash of the trie. No more keys can be inserted afterwards.
+func (st *StackTrie) Commit() (common.Hash, error) {
+	if st.db == nil {
+		return common.Hash{}, errors.New("stack trie commit without database")
+	}
+	return st.trie.CommitTo(st.db)
+}
+
+// Reset clears the trie, so it can be reused to build a new one.
+func (st *StackTrie) Reset() {
+	st.trie = Trie{}
+	st.last = nil
+}
@@ -0,0 +1,151 @@
+// Copyright 2017 The daxxcoreAuthors
+// This file is part of the daxxcore library.
+//
+// The daxxcore library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The daxxcore library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the daxxcore library. If not, see <http://www.gnu.org/licenses/>.
+
+package trie
+
+import (
+	"bytes"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/daxxcoin/daxxcore/common"
+	"github.com/daxxcoin/daxxcore/daxxdb"
+)
+
+// randomEntries generates n random key/value pairs sorted by key. Keys are of
+// varying length and may be prefixes of each other.
+func randomEntries(r *rand.Rand, n int) (keys, values [][]byte) {
+	seen := make(map[string]bool)
+	for len(keys) < n {
+		key := make([]byte, 1+r.Intn(8))
+		r.Read(key)
+		if seen[string(key)] {
+			continue
+		}
+		seen[string(key)] = true
+
+		// Sometimes add an extension of the key too, sharing it as a prefix
+		keys = append(keys, key)
+		if r.Intn(4) == 0 && len(keys) < n {
+			ext := append(common.CopyBytes(key), byte(r.Intn(256)))
+			if !seen[string(ext)] {
+				seen[string(ext)] = true
+				keys = append(keys, ext)
+			}
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return bytes.Compare(keys[i], keys[j]) < 0 })
+	for range keys {
+		value := make([]byte, 1+r.Intn(64))
+		r.Read(value)
+		values = append(values, value)
+	}
+	return keys, values
+}
+
+// Tests that the stack trie computes the same roots as the regular trie, and
+// that the nodes it commits form a complete trie.
+func TestStackTrie(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, n := range []int{0, 1, 2, 16, 100, 1000} {
+		keys, values := randomEntries(r, n)
+
+		trie := newEmpty()
+		db, _ := ethdb.NewMemDatabase()
+		st := NewStackTrie(db)
+		for i := range keys {
+			trie.Update(keys[i], values[i])
+			if err := st.TryUpdate(keys[i], values[i]); err != nil {
+				t.Fatalf("n=%d: failed to insert key %x: %v", n, keys[i], err)
+			}
+		}
+		want := trie.Hash()
+		if have := st.Hash(); have != want {
+			t.Fatalf("n=%d: root mismatch: have %x, want %x", n, have, want)
+		}
+		if n == 0 {
+			continue
+		}
+		root, err := st.Commit()
+		if err != nil || root != want {
+			t.Fatalf("n=%d: commit mismatch: have %x/%v, want %x", n, root, err, want)
+		}
+		committed, err := New(root, db)
+		if err != nil {
+			t.Fatalf("n=%d: failed to open committed trie: %v", n, err)
+		}
+		for i := range keys {
+			if have, err := committed.TryGet(keys[i]); err != nil || !bytes.Equal(have, values[i]) {
+				t.Fatalf("n=%d: key %x: value mismatch: have %x/%v, want %x", n, keys[i], have, err, values[i])
+			}
+		}
+	}
+}
+
+// Tests that the stack trie rejects keys inserted out of order.
+func TestStackTrieUnsorted(t *testing.T) {
+	st := NewStackTrie(nil)
+	if err := st.TryUpdate([]byte{0x02}, []byte{0x01}); err != nil {
+		t.Fatalf("failed to insert first key: %v", err)
+	}
+	for _, key := range [][]byte{{0x02}, {0x01}, {0x01, 0xff}} {
+		if err := st.TryUpdate(key, []byte{0x01}); err != ErrUnsortedKeys {
+			t.Errorf("key %x: error mismatch: have %v, want %v", key, err, ErrUnsortedKeys)
+		}
+	}
+}
+
+// Tests that hashing and committing large tries in parallel yields the same
+// results as doing it sequentially.
+func TestParallelHash(t *testing.T) {
+	keys, values := randomEntries(rand.New(rand.NewSource(2)), 2*parallelHashThreshold)
+
+	// Build one trie hashing after every update, the other in one go
+	sequential, parallel := newEmpty(), newEmpty()
+	for i := range keys {
+		sequential.Update(keys[i], values[i])
+		sequential.Hash()
+		parallel.Update(keys[i], values[i])
+	}
+	if parallel.unhashed < parallelHashThreshold {
+		t.Fatalf("parallel hashing not triggered: %d updates", parallel.unhashed)
+	}
+	want := sequential.Hash()
+	if have := parallel.Hash(); have != want {
+		t.Fatalf("root mismatch: have %x, want %x", have, want)
+	}
+	// Commit into a node cache in parallel and ensure the trie is complete
+	for i := range keys {
+		parallel.Update(keys[i], append(values[i], 0x01))
+	}
+	diskdb, _ := ethdb.NewMemDatabase()
+	cache := NewNodeDatabase(diskdb)
+
+	root, err := parallel.CommitTo(cache)
+	if err != nil {
+		t.Fatalf("failed to commit trie: %v", err)
+	}
+	committed, err := New(root, cache)
+	if err != nil {
+		t.Fatalf("failed to open committed trie: %v", err)
+	}
+	for i := range keys {
+		if have, err := committed.TryGet(keys[i]); err != nil || !bytes.Equal(have, append(values[i], 0x01)) {
+			t.Fatalf("key %x: value mismatch: have %x/%v", keys[i], have, err)
+		}
+	}
+}
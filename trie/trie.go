@@ -64,9 +64,13 @@ type Database interface {
 	DatabaseWriter
 }
 
-// DatabaseReader wraps the Get method of a backing store for the trie.
+// DatabaseReader wraps the Get and Has methods of a backing store for the trie.
 type DatabaseReader interface {
+	// Get retrieves the value associated with key form the database.
 	Get(key []byte) (value []byte, err error)
+
+	// Has retrieves whether a key is present in the database.
+	Has(key []byte) (bool, error)
 }
 
 // DatabaseWriter wraps the Put method of a backing store for the trie.
@@ -77,6 +81,10 @@ type DatabaseWriter interface {
 	Put(key, value []byte) error
 }
 
+// parallelHashThreshold is the number of updates after which a trie's top level
+// subtries are hashed concurrently.
+const parallelHashThreshold = 100
+
 // Trie is a Merkle Patricia Trie.
 // The zero value is an empty trie with no database.
 // Use New to create a trie that sits on top of a database.
@@ -92,6 +100,10 @@ type Trie struct {
 	// new nodes are tagged with the current generation and unloaded
 	// when their generation is older than than cachegen-cachelimit.
 	cachegen, cachelimit uint16
+
+	// unhashed is the number of updates since the last hashing, used to decide
+	// whether hashing is worth parallelising.
+	unhashed int
 }
 
 // SetCacheLimit sets the number of 'cache generations' to keep.
@@ -212,6 +224,7 @@ func (t *Trie) Update(key, value []byte) {
 //
 // If a node was not found in the database, a MissingNodeError is returned.
 func (t *Trie) TryUpdate(key, value []byte) error {
+	t.unhashed++
 	k := compactHexDecode(key)
 	if len(value) != 0 {
 		_, n, err := t.insert(t.root, nil, k, valueNode(value))
@@ -308,6 +321,7 @@ func (t *Trie) Delete(key []byte) {
 // TryDelete removes any existing value for key from the trie.
 // If a node was not found in the database, a MissingNodeError is returned.
 func (t *Trie) TryDelete(key []byte) error {
+	t.unhashed++
 	k := compactHexDecode(key)
 	_, n, err := t.delete(t.root, nil, k)
 	if err != nil {
@@ -467,7 +481,7 @@ func (t *Trie) Root() []byte { return t.Hash().Bytes() }
 // Hash returns the root hash of the trie. It does not write to the
 // database and can be used even if the trie doesn't have one.
 func (t *Trie) Hash() common.Hash {
-	hash, cached, _ := t.hashRoot(nil)
+	hash, cached, _ := t.hashRoot(nil, nil)
 	t.root = cached
 	return common.BytesToHash(hash.(hashNode))
 }
@@ -492,7 +506,14 @@ func (t *Trie) Commit() (root common.Hash, err error) {
 // the changes made to db are written back to the trie's attached
 // database before using the trie.
 func (t *Trie) CommitTo(db DatabaseWriter) (root common.Hash, err error) {
-	hash, cached, err := t.hashRoot(db)
+	return t.CommitToWithCallback(db, nil)
+}
+
+// CommitToWithCallback writes all nodes to the given database the same way as
+// CommitTo, invoking onleaf for every leaf value contained in a newly written
+// node along with the hash of that node.
+func (t *Trie) CommitToWithCallback(db DatabaseWriter, onleaf LeafCallback) (root common.Hash, err error) {
+	hash, cached, err := t.hashRoot(db, onleaf)
 	if err != nil {
 		return (common.Hash{}), err
 	}
@@ -501,11 +522,21 @@ func (t *Trie) CommitTo(db DatabaseWriter) (root common.Hash, err error) {
 	return common.BytesToHash(hash.(hashNode)), nil
 }
 
-func (t *Trie) hashRoot(db DatabaseWriter) (node, node, error) {
+func (t *Trie) hashRoot(db DatabaseWriter, onleaf LeafCallback) (node, node, error) {
 	if t.root == nil {
 		return hashNode(emptyRoot.Bytes()), nil, nil
 	}
-	h := newHasher(t.cachegen, t.cachelimit)
+	h := newHasher(t.cachegen, t.cachelimit, onleaf)
 	defer returnHasherToPool(h)
+
+	// Hash the subtries concurrently if enough of them changed. Nodes can only be
+	// stored concurrently into the memory cache, which does its own locking.
+	if t.unhashed >= parallelHashThreshold {
+		if _, ok := db.(*NodeDatabase); ok || db == nil {
+			h.parallel = true
+		}
+	}
+	t.unhashed = 0
+
 	return h.hash(t.root, db, true)
 }
@@ -21,6 +21,7 @@ import (
 	"encoding/binary"
 	"io/ioutil"
 	"math/rand"
+	"os"
 	"testing"
 	"time"
 
@@ -78,14 +79,15 @@ func TestMailServer(t *testing.T) {
 	const password = "password_for_this_test"
 	const dbPath = "whisper-server-test"
 
-	_, err := ioutil.TempDir("", dbPath)
+	dir, err := ioutil.TempDir("", dbPath)
 	if err != nil {
 		t.Fatal(err)
 	}
+	defer os.RemoveAll(dir)
 
 	var server WMailServer
 	shh = whisper.NewWhisper(&server)
-	server.Init(shh, dbPath, password, powRequirement)
+	server.Init(shh, dir, password, powRequirement)
 	defer server.Close()
 
 	err = shh.AddSymKey(keyName, []byte(password))